@@ -11,21 +11,40 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/mafzaidi/authorizer/internal/delivery/http/handler"
-	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
 	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
 	"github.com/mafzaidi/authorizer/internal/delivery/http/router"
 	"github.com/mafzaidi/authorizer/internal/domain/service"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/auth"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/fieldcrypt"
 	infraConfig "github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/hook"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/jobs"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/keystore"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/ldap"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/mail"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/oidc"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/persistence/postgres"
 	postgresRepo "github.com/mafzaidi/authorizer/internal/infrastructure/persistence/postgres/repository"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/persistence/redis"
 	redisRepo "github.com/mafzaidi/authorizer/internal/infrastructure/persistence/redis/repository"
+	accessRequestUsecase "github.com/mafzaidi/authorizer/internal/usecase/accessrequest"
+	accessTokenUsecase "github.com/mafzaidi/authorizer/internal/usecase/accesstoken"
 	appUsecase "github.com/mafzaidi/authorizer/internal/usecase/application"
 	authUsecase "github.com/mafzaidi/authorizer/internal/usecase/auth"
+	authorizeUsecase "github.com/mafzaidi/authorizer/internal/usecase/authorize"
+	federationUsecase "github.com/mafzaidi/authorizer/internal/usecase/federation"
+	groupUsecase "github.com/mafzaidi/authorizer/internal/usecase/group"
+	magicLinkUsecase "github.com/mafzaidi/authorizer/internal/usecase/magiclink"
+	oauthUsecase "github.com/mafzaidi/authorizer/internal/usecase/oauth"
+	organizationUsecase "github.com/mafzaidi/authorizer/internal/usecase/organization"
 	permUsecase "github.com/mafzaidi/authorizer/internal/usecase/permission"
+	relationUsecase "github.com/mafzaidi/authorizer/internal/usecase/relation"
 	roleUsecase "github.com/mafzaidi/authorizer/internal/usecase/role"
+	sealUsecase "github.com/mafzaidi/authorizer/internal/usecase/seal"
+	serviceAccountUsecase "github.com/mafzaidi/authorizer/internal/usecase/serviceaccount"
+	sodUsecase "github.com/mafzaidi/authorizer/internal/usecase/sod"
 	userUsecase "github.com/mafzaidi/authorizer/internal/usecase/user"
 )
 
@@ -55,16 +74,40 @@ func main() {
 	log.Info("Redis connection established", logger.Fields{})
 
 	// 5. Initialize repositories
+	// User PII is encrypted with keys derived from the master key of the
+	// encrypted key file; without one it is stored in cleartext
+	piiCipher := fieldcrypt.New(cfg.JWT, cfg.PII.KeyVersion)
+
 	// PostgreSQL repositories
-	userRepo := postgresRepo.NewUserRepositoryPGX(pool)
+	userRepo := postgresRepo.NewUserRepositoryPGX(pool, piiCipher)
+	userKeyRotationRepo := postgresRepo.NewUserKeyRotationRepositoryPGX(pool, piiCipher)
 	roleRepo := postgresRepo.NewRoleRepositoryPGX(pool)
 	permRepo := postgresRepo.NewPermRepositoryPGX(pool)
 	appRepo := postgresRepo.NewAppRepositoryPGX(pool)
 	userRoleRepo := postgresRepo.NewUserRoleRepositoryPGX(pool)
+	userRoleExpiryRepo := postgresRepo.NewUserRoleExpiryRepositoryPGX(pool)
 	rolePermRepo := postgresRepo.NewRolePermRepositoryPGX(pool)
+	tokenHookRepo := postgresRepo.NewTokenHookRepositoryPGX(pool)
+	patRepo := postgresRepo.NewPersonalAccessTokenRepositoryPGX(pool)
+	saRepo := postgresRepo.NewServiceAccountRepositoryPGX(pool)
+	saRoleRepo := postgresRepo.NewServiceAccountRoleRepositoryPGX(pool)
+	saKeyRepo := postgresRepo.NewServiceAccountKeyRepositoryPGX(pool)
+	userIdentityRepo := postgresRepo.NewUserIdentityRepositoryPGX(pool)
+	relationRepo := postgresRepo.NewRelationRepositoryPGX(pool)
+	orgRepo := postgresRepo.NewOrganizationRepositoryPGX(pool)
+	groupRepo := postgresRepo.NewGroupRepositoryPGX(pool)
+	accessRequestRepo := postgresRepo.NewAccessRequestRepositoryPGX(pool)
+	sodRepo := postgresRepo.NewSoDConstraintRepositoryPGX(pool)
+	appAdminRepo := postgresRepo.NewApplicationAdminRepositoryPGX(pool)
+	transactor := postgresRepo.NewTransactorPGX(pool)
 
 	// Redis repositories
 	authRepo := redisRepo.NewAuthRepository(redisClient)
+	permCacheRepo := redisRepo.NewPermissionCacheRepository(redisClient)
+	deviceAuthRepo := redisRepo.NewDeviceAuthorizationRepository(redisClient)
+	fedStateRepo := redisRepo.NewFederationStateRepository(redisClient)
+	magicLinkRepo := redisRepo.NewMagicLinkRepository(redisClient)
+	rateLimitRepo := redisRepo.NewRateLimitRepository(redisClient)
 
 	log.Info("All repositories initialized", logger.Fields{})
 
@@ -74,20 +117,57 @@ func main() {
 		roleRepo,
 		rolePermRepo,
 		appRepo,
+		orgRepo,
+		groupRepo,
+		appAdminRepo,
+	)
+	sodService := service.NewSoDService(sodRepo, log)
+	identityService := service.NewIdentityService(
+		userIdentityRepo,
+		userRepo,
+		appRepo,
+		roleRepo,
+		userRoleRepo,
+		permCacheRepo,
+		transactor,
+		sodService,
+		log,
 	)
 	log.Info("Domain services initialized", logger.Fields{})
 
 	// 7. Initialize infrastructure services
 	jwtService := auth.NewJWTService(log)
 	jwksService := auth.NewJWKSService()
+	hookInvoker := hook.NewTokenHookInvoker(log)
+	mailer := mail.NewMailer(cfg.Mail)
+
+	credentialVerifiers := []service.CredentialVerifier{
+		authUsecase.NewLocalCredentialVerifier(userRepo),
+	}
+	if cfg.LDAP.Enabled {
+		ldapVerifier, err := ldap.NewVerifier(cfg.LDAP)
+		if err != nil {
+			log.Error("Failed to configure LDAP", logger.Fields{
+				"error": err.Error(),
+			})
+			panic(fmt.Sprintf("Failed to configure LDAP: %v", err))
+		}
+		credentialVerifiers = append(credentialVerifiers, ldapVerifier)
+	}
 	log.Info("Infrastructure services initialized", logger.Fields{})
 
 	// 8. Initialize use cases
 	authUC := authUsecase.NewAuthUseCase(
 		authRepo,
 		userRepo,
+		appRepo,
+		tokenHookRepo,
+		permCacheRepo,
 		authService,
 		jwtService,
+		hookInvoker,
+		credentialVerifiers,
+		identityService,
 		log,
 	)
 
@@ -95,6 +175,9 @@ func main() {
 		userRepo,
 		roleRepo,
 		userRoleRepo,
+		permCacheRepo,
+		transactor,
+		sodService,
 		log,
 	)
 
@@ -103,6 +186,10 @@ func main() {
 		appRepo,
 		permRepo,
 		rolePermRepo,
+		userRoleRepo,
+		permCacheRepo,
+		transactor,
+		sodService,
 		log,
 	)
 
@@ -114,6 +201,131 @@ func main() {
 
 	appUC := appUsecase.NewAppUsecase(
 		appRepo,
+		tokenHookRepo,
+		appAdminRepo,
+		userRepo,
+		permRepo,
+		permCacheRepo,
+		log,
+	)
+
+	accessTokenUC := accessTokenUsecase.NewAccessTokenUsecase(
+		patRepo,
+		userRepo,
+		appRepo,
+		permRepo,
+		authService,
+		log,
+	)
+
+	serviceAccountUC := serviceAccountUsecase.NewServiceAccountUsecase(
+		saRepo,
+		saRoleRepo,
+		saKeyRepo,
+		appRepo,
+		roleRepo,
+		rolePermRepo,
+		transactor,
+		sodService,
+		jwtService,
+		log,
+	)
+
+	oauthUC := oauthUsecase.NewOAuthUsecase(
+		deviceAuthRepo,
+		appRepo,
+		authUC,
+		log,
+	)
+
+	var identityProviders []federationUsecase.IdentityProvider
+	for _, idp := range cfg.IdentityProviders {
+		identityProviders = append(identityProviders, oidc.NewProvider(idp, nil))
+	}
+
+	federationUC := federationUsecase.NewFederationUsecase(
+		identityProviders,
+		fedStateRepo,
+		identityService,
+		appRepo,
+		authUC,
+		log,
+	)
+
+	magicLinkUC := magicLinkUsecase.NewMagicLinkUsecase(
+		magicLinkRepo,
+		userRepo,
+		appRepo,
+		rateLimitRepo,
+		mailer,
+		authUC,
+		log,
+	)
+
+	var keyFile *keystore.File
+	if cfg.JWT.EncryptedKeyPath != "" {
+		keyFile, err = keystore.Load(cfg.JWT.EncryptedKeyPath)
+		if err != nil {
+			log.Error("Failed to load encrypted signing key", logger.Fields{
+				"error": err.Error(),
+			})
+			panic(fmt.Sprintf("Failed to load encrypted signing key: %v", err))
+		}
+	}
+	sealUC := sealUsecase.NewSealUsecase(keyFile, cfg.JWT, cfg.Seal.Enabled, log)
+
+	authorizeUC := authorizeUsecase.NewAuthorizeUsecase(
+		userRepo,
+		userRoleRepo,
+		permCacheRepo,
+		jwtService,
+		accessTokenUC,
+		cfg.JWT,
+		log,
+	)
+
+	organizationUC := organizationUsecase.NewOrganizationUsecase(
+		orgRepo,
+		userRepo,
+		roleRepo,
+		permCacheRepo,
+		transactor,
+		sodService,
+		log,
+	)
+
+	groupUC := groupUsecase.NewGroupUsecase(
+		groupRepo,
+		userRepo,
+		roleRepo,
+		permCacheRepo,
+		transactor,
+		sodService,
+		log,
+	)
+
+	accessRequestUC := accessRequestUsecase.NewAccessRequestUsecase(
+		accessRequestRepo,
+		appRepo,
+		roleRepo,
+		userRepo,
+		userRoleRepo,
+		permCacheRepo,
+		transactor,
+		sodService,
+		log,
+	)
+
+	sodUC := sodUsecase.NewSoDUsecase(
+		sodRepo,
+		appRepo,
+		roleRepo,
+		log,
+	)
+
+	relationUC := relationUsecase.NewRelationUsecase(
+		appRepo,
+		relationRepo,
 		log,
 	)
 
@@ -147,6 +359,70 @@ func main() {
 		log,
 	)
 
+	accessTokenHandler := handler.NewAccessTokenHandler(
+		accessTokenUC,
+		log,
+	)
+
+	serviceAccountHandler := handler.NewServiceAccountHandler(
+		serviceAccountUC,
+		cfg,
+		log,
+	)
+
+	oauthHandler := handler.NewOAuthHandler(
+		oauthUC,
+		cfg,
+		log,
+	)
+
+	federationHandler := handler.NewFederationHandler(
+		federationUC,
+		cfg,
+		log,
+	)
+
+	magicLinkHandler := handler.NewMagicLinkHandler(
+		magicLinkUC,
+		cfg,
+		log,
+	)
+
+	sealHandler := handler.NewSealHandler(
+		sealUC,
+		log,
+	)
+
+	authorizeHandler := handler.NewAuthorizeHandler(
+		authorizeUC,
+		log,
+	)
+
+	organizationHandler := handler.NewOrganizationHandler(
+		organizationUC,
+		log,
+	)
+
+	groupHandler := handler.NewGroupHandler(
+		groupUC,
+		log,
+	)
+
+	accessRequestHandler := handler.NewAccessRequestHandler(
+		accessRequestUC,
+		log,
+	)
+
+	sodHandler := handler.NewSoDHandler(
+		sodUC,
+		log,
+	)
+
+	relationHandler := handler.NewRelationHandler(
+		relationUC,
+		log,
+	)
+
 	healthHandler := handler.NewHealthHandler(log)
 
 	log.Info("All handlers initialized", logger.Fields{})
@@ -154,7 +430,9 @@ func main() {
 	// 10. Initialize middleware
 	// Note: Middleware uses new infrastructure config, but we need to convert from old config
 	// This will be cleaned up when handlers are fully migrated to new config
-	jwtMiddleware := middleware.JWTAuthMiddleware(jwtService, convertToInfraConfig(cfg), log)
+	jwtMiddleware := middleware.JWTAuthMiddleware(jwtService, accessTokenUC, convertToInfraConfig(cfg), log)
+	loginRateLimit := middleware.RateLimit(rateLimitRepo, "login", cfg.RateLimit.LoginAttempts, cfg.RateLimit.LoginWindow, log)
+	sealGuard := middleware.SealGuard(sealUC, "/authorizer/v1/health", "/authorizer/v1/sys/")
 	log.Info("Middleware initialized", logger.Fields{})
 
 	// 11. Create Echo instance
@@ -172,6 +450,23 @@ func main() {
 		HealthHandler: healthHandler,
 		JWTMiddleware: jwtMiddleware,
 		Logger:        log,
+
+		LoginRateLimit: loginRateLimit,
+		SealGuard:      sealGuard,
+		RoleLookup:     roleRepo,
+
+		AccessTokenHandler:    accessTokenHandler,
+		ServiceAccountHandler: serviceAccountHandler,
+		OAuthHandler:          oauthHandler,
+		FederationHandler:     federationHandler,
+		MagicLinkHandler:      magicLinkHandler,
+		SealHandler:           sealHandler,
+		AuthorizeHandler:      authorizeHandler,
+		RelationHandler:       relationHandler,
+		OrganizationHandler:   organizationHandler,
+		GroupHandler:          groupHandler,
+		AccessRequestHandler:  accessRequestHandler,
+		SoDHandler:            sodHandler,
 	})
 	if err != nil {
 		log.Error("Failed to setup router", logger.Fields{
@@ -181,7 +476,13 @@ func main() {
 	}
 	log.Info("Router configured successfully", logger.Fields{})
 
-	// 13. Start server with graceful shutdown
+	// 13. Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go jobs.NewUserRekey(userKeyRotationRepo, cfg.PII.RekeyInterval, cfg.PII.RekeyBatchSize, log).Run(jobsCtx)
+	go jobs.NewRoleExpiry(userRoleExpiryRepo, permCacheRepo, cfg.RoleExpiry.CleanupInterval, cfg.RoleExpiry.CleanupBatchSize, log).Run(jobsCtx)
+
+	// 14. Start server with graceful shutdown
 	startServer(e, cfg, log)
 }
 
@@ -252,14 +553,17 @@ func convertToInfraConfig(oldCfg *config.Config) *infraConfig.Config {
 			Password: oldCfg.Redis.Password,
 			DBName:   oldCfg.Redis.DBName,
 		},
-		JWT: &infraConfig.JWT{
-			PrivateKeyPath: oldCfg.JWT.PrivateKeyPath,
-			PublicKeyPath:  oldCfg.JWT.PublicKeyPath,
-			PrivateKey:     oldCfg.JWT.PrivateKey,
-			PublicKey:      oldCfg.JWT.PublicKey,
-			KeyID:          oldCfg.JWT.KeyID,
-			TokenExpiry:    oldCfg.JWT.TokenExpiry,
-			RefreshExpiry:  oldCfg.JWT.RefreshExpiry,
-		},
+		// Shared so sealing and unsealing apply to the middleware as well
+		JWT: oldCfg.JWT,
+		Session:   oldCfg.Session,
+		OAuth:     oldCfg.OAuth,
+		LDAP:      oldCfg.LDAP,
+		MagicLink: oldCfg.MagicLink,
+		Mail:      oldCfg.Mail,
+		RateLimit: oldCfg.RateLimit,
+		Seal:      oldCfg.Seal,
+		PII:       oldCfg.PII,
+
+		IdentityProviders: oldCfg.IdentityProviders,
 	}
 }
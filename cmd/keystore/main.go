@@ -0,0 +1,79 @@
+// Command keystore encrypts a PEM signing key into an envelope-encrypted
+// key file for jwt.encryptedKeyPath. The unseal password is read from the
+// MASTER_KEY_PASSWORD environment variable so it never appears in shell
+// history.
+//
+//	MASTER_KEY_PASSWORD=... go run ./cmd/keystore -in private.pem -out signing-key.enc.json
+//
+// With -shares and -threshold a random unseal password is generated and
+// split with Shamir secret sharing instead; the shares are printed once,
+// one per operator, and the password itself is never shown.
+//
+//	go run ./cmd/keystore -shares 5 -threshold 3
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mafzaidi/authorizer/internal/infrastructure/keystore"
+)
+
+func main() {
+	in := flag.String("in", "./private.pem", "plaintext PEM private key to encrypt")
+	out := flag.String("out", "./signing-key.enc.json", "encrypted key file to write")
+	shares := flag.Int("shares", 0, "split a generated unseal password into this many shares")
+	threshold := flag.Int("threshold", 0, "number of shares required to unseal")
+	flag.Parse()
+
+	if err := run(*in, *out, os.Getenv("MASTER_KEY_PASSWORD"), *shares, *threshold); err != nil {
+		fmt.Fprintln(os.Stderr, "keystore:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Encrypted signing key written to %s; %s can now be removed\n", *out, *in)
+}
+
+func run(in, out, password string, shares, threshold int) error {
+	var split [][]byte
+	if shares > 0 {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		password = base64.StdEncoding.EncodeToString(secret)
+
+		var err error
+		split, err = keystore.Split([]byte(password), shares, threshold)
+		if err != nil {
+			return err
+		}
+	} else if password == "" {
+		return fmt.Errorf("MASTER_KEY_PASSWORD is not set")
+	}
+
+	keyPEM, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+
+	f, err := keystore.Seal(keyPEM, password)
+	if err != nil {
+		return err
+	}
+	if split != nil {
+		f.Shares = shares
+		f.Threshold = threshold
+	}
+
+	if err := keystore.Save(out, f); err != nil {
+		return err
+	}
+
+	for i, share := range split {
+		fmt.Printf("Unseal share %d: %s\n", i+1, base64.StdEncoding.EncodeToString(share))
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/usecase/accessrequest"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	CreateAccessRequestRequest struct {
+		ApplicationID string     `json:"application_id" validate:"required"`
+		Role          string     `json:"role" validate:"required"`
+		Justification string     `json:"justification" validate:"required"`
+		ValidUntil    *time.Time `json:"valid_until"`
+	}
+
+	AccessRequestDecisionRequest struct {
+		Comment    *string    `json:"comment"`
+		ValidUntil *time.Time `json:"valid_until"`
+	}
+
+	AddAccessApproverRequest struct {
+		UserID string  `json:"user_id" validate:"required"`
+		Role   *string `json:"role"`
+	}
+
+	AccessRequestResponse struct {
+		ID              string     `json:"id"`
+		UserID          string     `json:"user_id"`
+		ApplicationID   string     `json:"application_id"`
+		Application     string     `json:"application"`
+		Role            string     `json:"role"`
+		Justification   string     `json:"justification"`
+		ValidUntil      *time.Time `json:"valid_until,omitempty"`
+		Status          string     `json:"status"`
+		DecidedBy       *string    `json:"decided_by,omitempty"`
+		DecidedAt       *time.Time `json:"decided_at,omitempty"`
+		DecisionComment *string    `json:"decision_comment,omitempty"`
+		CreatedAt       time.Time  `json:"created_at"`
+	}
+
+	AccessRequestEventResponse struct {
+		Action    string    `json:"action"`
+		ActorID   *string   `json:"actor_id,omitempty"`
+		Comment   *string   `json:"comment,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	AccessRequestDetailResponse struct {
+		*AccessRequestResponse
+		Events []*AccessRequestEventResponse `json:"events"`
+	}
+
+	AccessApproverResponse struct {
+		ID        string    `json:"id"`
+		UserID    string    `json:"user_id"`
+		RoleID    *string   `json:"role_id,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+)
+
+type AccessRequestHandler struct {
+	accessRequestUC accessrequest.Usecase
+	logger          service.Logger
+}
+
+func NewAccessRequestHandler(uc accessrequest.Usecase, logger service.Logger) *AccessRequestHandler {
+	return &AccessRequestHandler{
+		accessRequestUC: uc,
+		logger:          logger,
+	}
+}
+
+// Create files an access request for the caller. Only users can request
+// access; service accounts are assigned roles directly.
+func (h *AccessRequestHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+		if claims.PrincipalType != "" && claims.PrincipalType != entity.PrincipalTypeUser {
+			return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", "only users can request access")
+		}
+
+		req := &CreateAccessRequestRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		ar, err := h.accessRequestUC.Create(c.Request().Context(), claims.UserID, &accessrequest.CreateInput{
+			ApplicationID: req.ApplicationID,
+			Role:          req.Role,
+			Justification: req.Justification,
+			ValidUntil:    req.ValidUntil,
+		})
+		if err != nil {
+			return h.requestError(c, err)
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access request created successfully",
+			Data:    newAccessRequestResponse(ar),
+		})
+	}
+}
+
+func (h *AccessRequestHandler) ListMine() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		reqs, err := h.accessRequestUC.ListMine(c.Request().Context(), claims.UserID)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access requests retrieved successfully",
+			Data:    newAccessRequestResponses(reqs),
+		})
+	}
+}
+
+// ListPending lists the open requests the caller is an approver for
+func (h *AccessRequestHandler) ListPending() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		reqs, err := h.accessRequestUC.ListPending(c.Request().Context(), claims.UserID)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access requests retrieved successfully",
+			Data:    newAccessRequestResponses(reqs),
+		})
+	}
+}
+
+func (h *AccessRequestHandler) GetDetail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		detail, err := h.accessRequestUC.GetDetail(c.Request().Context(), claims.UserID, c.Param("id"))
+		if err != nil {
+			return h.requestError(c, err)
+		}
+
+		events := make([]*AccessRequestEventResponse, 0, len(detail.Events))
+		for _, e := range detail.Events {
+			events = append(events, &AccessRequestEventResponse{
+				Action:    e.Action,
+				ActorID:   e.ActorID,
+				Comment:   e.Comment,
+				CreatedAt: e.CreatedAt,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access request retrieved successfully",
+			Data: &AccessRequestDetailResponse{
+				AccessRequestResponse: newAccessRequestResponse(detail.Request),
+				Events:                events,
+			},
+		})
+	}
+}
+
+// Approve approves the request and assigns its role to the requester. A
+// valid_until in the body overrides the end the requester asked for.
+func (h *AccessRequestHandler) Approve() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		req := &AccessRequestDecisionRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		err := h.accessRequestUC.Approve(c.Request().Context(), claims.UserID, c.Param("id"), &accessrequest.DecisionInput{
+			Comment:    req.Comment,
+			ValidUntil: req.ValidUntil,
+		})
+		if err != nil {
+			h.logger.Warn("Failed to approve access request", service.Fields{
+				"request_id":  c.Param("id"),
+				"approver_id": claims.UserID,
+				"error":       err.Error(),
+			})
+			return h.requestError(c, err)
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access request approved successfully",
+		})
+	}
+}
+
+func (h *AccessRequestHandler) Deny() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		req := &AccessRequestDecisionRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		err := h.accessRequestUC.Deny(c.Request().Context(), claims.UserID, c.Param("id"), &accessrequest.DecisionInput{
+			Comment: req.Comment,
+		})
+		if err != nil {
+			h.logger.Warn("Failed to deny access request", service.Fields{
+				"request_id":  c.Param("id"),
+				"approver_id": claims.UserID,
+				"error":       err.Error(),
+			})
+			return h.requestError(c, err)
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access request denied successfully",
+		})
+	}
+}
+
+func (h *AccessRequestHandler) Cancel() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		if err := h.accessRequestUC.Cancel(c.Request().Context(), claims.UserID, c.Param("id")); err != nil {
+			return h.requestError(c, err)
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access request cancelled successfully",
+		})
+	}
+}
+
+func (h *AccessRequestHandler) ListApprovers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		approvers, err := h.accessRequestUC.ListApprovers(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		resp := make([]*AccessApproverResponse, 0, len(approvers))
+		for _, a := range approvers {
+			resp = append(resp, newAccessApproverResponse(a))
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "approvers retrieved successfully",
+			Data:    resp,
+		})
+	}
+}
+
+// AddApprover designates an approver for the application, or for one of
+// its roles when role is set
+func (h *AccessRequestHandler) AddApprover() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &AddAccessApproverRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		approver, err := h.accessRequestUC.AddApprover(c.Request().Context(), c.Param("id"), &accessrequest.AddApproverInput{
+			UserID: req.UserID,
+			Role:   req.Role,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "approver added successfully",
+			Data:    newAccessApproverResponse(approver),
+		})
+	}
+}
+
+func (h *AccessRequestHandler) RemoveApprover() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.accessRequestUC.RemoveApprover(c.Request().Context(), c.Param("id"), c.Param("approver_id")); err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "approver removed successfully",
+		})
+	}
+}
+
+// requestError maps access request errors to their status codes
+func (h *AccessRequestHandler) requestError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, accessrequest.ErrNotFound):
+		return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+	case errors.Is(err, accessrequest.ErrNotApprover), errors.Is(err, accessrequest.ErrSelfDecision):
+		return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", err.Error())
+	case errors.Is(err, accessrequest.ErrNotPending), errors.Is(err, service.ErrSoDViolation):
+		return response.ErrorHandler(c, http.StatusConflict, "Conflict", err.Error())
+	}
+	return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+}
+
+func newAccessRequestResponse(ar *entity.AccessRequest) *AccessRequestResponse {
+	return &AccessRequestResponse{
+		ID:              ar.ID,
+		UserID:          ar.UserID,
+		ApplicationID:   ar.ApplicationID,
+		Application:     ar.AppCode,
+		Role:            ar.RoleCode,
+		Justification:   ar.Justification,
+		ValidUntil:      ar.ValidUntil,
+		Status:          ar.Status,
+		DecidedBy:       ar.DecidedBy,
+		DecidedAt:       ar.DecidedAt,
+		DecisionComment: ar.DecisionComment,
+		CreatedAt:       ar.CreatedAt,
+	}
+}
+
+func newAccessRequestResponses(reqs []*entity.AccessRequest) []*AccessRequestResponse {
+	resp := make([]*AccessRequestResponse, 0, len(reqs))
+	for _, ar := range reqs {
+		resp = append(resp, newAccessRequestResponse(ar))
+	}
+	return resp
+}
+
+func newAccessApproverResponse(a *entity.AccessApprover) *AccessApproverResponse {
+	return &AccessApproverResponse{
+		ID:        a.ID,
+		UserID:    a.UserID,
+		RoleID:    a.RoleID,
+		CreatedAt: a.CreatedAt,
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/internal/usecase/accessrequest"
+)
+
+// MockAccessRequestUseCase is a mock implementation of accessrequest.Usecase
+type MockAccessRequestUseCase struct {
+	CreateFunc         func(ctx context.Context, userID string, input *accessrequest.CreateInput) (*entity.AccessRequest, error)
+	ListMineFunc       func(ctx context.Context, userID string) ([]*entity.AccessRequest, error)
+	ListPendingFunc    func(ctx context.Context, approverID string) ([]*entity.AccessRequest, error)
+	GetDetailFunc      func(ctx context.Context, callerID, id string) (*accessrequest.Detail, error)
+	ApproveFunc        func(ctx context.Context, approverID, id string, input *accessrequest.DecisionInput) error
+	DenyFunc           func(ctx context.Context, approverID, id string, input *accessrequest.DecisionInput) error
+	CancelFunc         func(ctx context.Context, userID, id string) error
+	AddApproverFunc    func(ctx context.Context, appID string, input *accessrequest.AddApproverInput) (*entity.AccessApprover, error)
+	RemoveApproverFunc func(ctx context.Context, appID, id string) error
+	ListApproversFunc  func(ctx context.Context, appID string) ([]*entity.AccessApprover, error)
+}
+
+func (m *MockAccessRequestUseCase) Create(ctx context.Context, userID string, input *accessrequest.CreateInput) (*entity.AccessRequest, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, userID, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) ListMine(ctx context.Context, userID string) ([]*entity.AccessRequest, error) {
+	if m.ListMineFunc != nil {
+		return m.ListMineFunc(ctx, userID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) ListPending(ctx context.Context, approverID string) ([]*entity.AccessRequest, error) {
+	if m.ListPendingFunc != nil {
+		return m.ListPendingFunc(ctx, approverID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) GetDetail(ctx context.Context, callerID, id string) (*accessrequest.Detail, error) {
+	if m.GetDetailFunc != nil {
+		return m.GetDetailFunc(ctx, callerID, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) Approve(ctx context.Context, approverID, id string, input *accessrequest.DecisionInput) error {
+	if m.ApproveFunc != nil {
+		return m.ApproveFunc(ctx, approverID, id, input)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) Deny(ctx context.Context, approverID, id string, input *accessrequest.DecisionInput) error {
+	if m.DenyFunc != nil {
+		return m.DenyFunc(ctx, approverID, id, input)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) Cancel(ctx context.Context, userID, id string) error {
+	if m.CancelFunc != nil {
+		return m.CancelFunc(ctx, userID, id)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) AddApprover(ctx context.Context, appID string, input *accessrequest.AddApproverInput) (*entity.AccessApprover, error) {
+	if m.AddApproverFunc != nil {
+		return m.AddApproverFunc(ctx, appID, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) RemoveApprover(ctx context.Context, appID, id string) error {
+	if m.RemoveApproverFunc != nil {
+		return m.RemoveApproverFunc(ctx, appID, id)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockAccessRequestUseCase) ListApprovers(ctx context.Context, appID string) ([]*entity.AccessApprover, error) {
+	if m.ListApproversFunc != nil {
+		return m.ListApproversFunc(ctx, appID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func serveAccessRequest(handlerFunc echo.HandlerFunc, claims *middleware.JWTClaims, method, body string, params ...string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_claims", claims)
+	var names, values []string
+	for i := 0; i+1 < len(params); i += 2 {
+		names = append(names, params[i])
+		values = append(values, params[i+1])
+	}
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	_ = handlerFunc(c)
+	return rec
+}
+
+func TestAccessRequestHandler_Create(t *testing.T) {
+	var gotUser string
+	var got *accessrequest.CreateInput
+	mockUC := &MockAccessRequestUseCase{
+		CreateFunc: func(ctx context.Context, userID string, input *accessrequest.CreateInput) (*entity.AccessRequest, error) {
+			gotUser, got = userID, input
+			return &entity.AccessRequest{ID: "ar-1", UserID: userID, Status: entity.AccessRequestPending}, nil
+		},
+	}
+	handler := NewAccessRequestHandler(mockUC, logger.New())
+
+	body := `{"application_id":"app-1","role":"editor","justification":"quarterly report","valid_until":"2099-01-01T00:00:00Z"}`
+	rec := serveAccessRequest(handler.Create(), &middleware.JWTClaims{UserID: "u-1"}, http.MethodPost, body)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if gotUser != "u-1" || got == nil || got.ApplicationID != "app-1" || got.Role != "editor" ||
+		got.Justification != "quarterly report" || got.ValidUntil == nil {
+		t.Errorf("Unexpected request input: %s %+v", gotUser, got)
+	}
+}
+
+func TestAccessRequestHandler_Create_ServiceAccount(t *testing.T) {
+	mockUC := &MockAccessRequestUseCase{
+		CreateFunc: func(ctx context.Context, userID string, input *accessrequest.CreateInput) (*entity.AccessRequest, error) {
+			t.Fatal("service accounts must not request access")
+			return nil, nil
+		},
+	}
+	handler := NewAccessRequestHandler(mockUC, logger.New())
+
+	claims := &middleware.JWTClaims{UserID: "sa-1", PrincipalType: entity.PrincipalTypeServiceAccount}
+	rec := serveAccessRequest(handler.Create(), claims, http.MethodPost, `{"application_id":"app-1","role":"editor","justification":"x"}`)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, rec.Code)
+	}
+}
+
+func TestAccessRequestHandler_Approve(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"success", nil, http.StatusOK},
+		{"not found", accessrequest.ErrNotFound, http.StatusNotFound},
+		{"not approver", accessrequest.ErrNotApprover, http.StatusForbidden},
+		{"own request", accessrequest.ErrSelfDecision, http.StatusForbidden},
+		{"already decided", accessrequest.ErrNotPending, http.StatusConflict},
+		{"separation of duties", fmt.Errorf("%w: constraint pay allows at most 1", service.ErrSoDViolation), http.StatusConflict},
+		{"invalid", errors.New("valid_until must be in the future"), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotApprover, gotID string
+			var got *accessrequest.DecisionInput
+			mockUC := &MockAccessRequestUseCase{
+				ApproveFunc: func(ctx context.Context, approverID, id string, input *accessrequest.DecisionInput) error {
+					gotApprover, gotID, got = approverID, id, input
+					return tt.err
+				},
+			}
+			handler := NewAccessRequestHandler(mockUC, logger.New())
+
+			rec := serveAccessRequest(handler.Approve(), &middleware.JWTClaims{UserID: "u-2"},
+				http.MethodPost, `{"comment":"ok","valid_until":"2099-01-01T00:00:00Z"}`, "id", "ar-1")
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if gotApprover != "u-2" || gotID != "ar-1" || got == nil || got.Comment == nil || got.ValidUntil == nil {
+				t.Errorf("Unexpected decision: %s %s %+v", gotApprover, gotID, got)
+			}
+		})
+	}
+}
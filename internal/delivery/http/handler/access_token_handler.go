@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/usecase/accesstoken"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	CreateAccessTokenRequest struct {
+		Name          string   `json:"name" validate:"required"`
+		Application   string   `json:"application" validate:"required"`
+		Permissions   []string `json:"permissions" validate:"required"`
+		ExpiresInDays int      `json:"expires_in_days" validate:"required"`
+	}
+
+	AccessTokenResponse struct {
+		ID          string     `json:"id"`
+		Name        string     `json:"name"`
+		Token       string     `json:"token,omitempty"`
+		Prefix      string     `json:"prefix,omitempty"`
+		Application string     `json:"application,omitempty"`
+		Permissions []string   `json:"permissions"`
+		ExpiresAt   time.Time  `json:"expires_at"`
+		LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+		RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+		CreatedAt   *time.Time `json:"created_at,omitempty"`
+	}
+)
+
+type AccessTokenHandler struct {
+	tokenUC accesstoken.Usecase
+	logger  service.Logger
+}
+
+func NewAccessTokenHandler(uc accesstoken.Usecase, logger service.Logger) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		tokenUC: uc,
+		logger:  logger,
+	}
+}
+
+func (h *AccessTokenHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		// An access token must not be able to mint further access tokens
+		if claims.AuthMethod == middleware.AuthMethodPAT {
+			return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", "access tokens cannot create access tokens")
+		}
+
+		req := &CreateAccessTokenRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			h.logger.Warn("Failed to decode create access token request", service.Fields{
+				"error": err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		in := &accesstoken.CreateInput{
+			UserID:      claims.UserID,
+			AppCode:     req.Application,
+			Name:        req.Name,
+			Permissions: req.Permissions,
+			ExpiresIn:   time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+		}
+
+		created, err := h.tokenUC.Create(c.Request().Context(), in)
+		if err != nil {
+			h.logger.Error("Failed to create access token", service.Fields{
+				"user_id": claims.UserID,
+				"error":   err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access token created successfully",
+			Data: &AccessTokenResponse{
+				ID:          created.ID,
+				Name:        created.Name,
+				Token:       created.Token,
+				Application: created.AppCode,
+				Permissions: created.Permissions,
+				ExpiresAt:   created.ExpiresAt,
+			},
+		})
+	}
+}
+
+func (h *AccessTokenHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		tokens, err := h.tokenUC.List(c.Request().Context(), claims.UserID)
+		if err != nil {
+			h.logger.Error("Failed to list access tokens", service.Fields{
+				"user_id": claims.UserID,
+				"error":   err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		resp := make([]*AccessTokenResponse, 0, len(tokens))
+		for _, t := range tokens {
+			createdAt := t.CreatedAt
+			resp = append(resp, &AccessTokenResponse{
+				ID:          t.ID,
+				Name:        t.Name,
+				Prefix:      t.Prefix,
+				Permissions: t.Permissions,
+				ExpiresAt:   t.ExpiresAt,
+				LastUsedAt:  t.LastUsedAt,
+				RevokedAt:   t.RevokedAt,
+				CreatedAt:   &createdAt,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *AccessTokenHandler) Revoke() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		tokenID := c.Param("id")
+
+		if err := h.tokenUC.Revoke(c.Request().Context(), claims.UserID, tokenID); err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "access token revoked successfully",
+		})
+	}
+}
@@ -2,18 +2,63 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
 	"github.com/mafzaidi/authorizer/internal/domain/service"
 	app "github.com/mafzaidi/authorizer/internal/usecase/application"
 	"github.com/mafzaidi/authorizer/pkg/response"
 )
 
 type CreateAppRequest struct {
-	Code        string `json:"code" validate:"required"`
-	Name        string `json:"name" validate:"required"`
-	Description string `json:"description"`
+	Code              string `json:"code" validate:"required"`
+	Name              string `json:"name" validate:"required"`
+	Description       string `json:"description"`
+	TokenMode         string `json:"token_mode"`
+	HookFailurePolicy string `json:"hook_failure_policy"`
+}
+
+type UpdateTokenModeRequest struct {
+	TokenMode string `json:"token_mode" validate:"required"`
+}
+
+type UpdateHookFailurePolicyRequest struct {
+	HookFailurePolicy string `json:"hook_failure_policy" validate:"required"`
+}
+
+type CreateHookRequest struct {
+	Name      string `json:"name" validate:"required"`
+	URL       string `json:"url" validate:"required"`
+	Secret    string `json:"secret"`
+	TimeoutMs int    `json:"timeout_ms"`
+}
+
+type HookResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	TimeoutMs int       `json:"timeout_ms"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SaveAppAdminRequest struct {
+	// Permissions are AUTHORIZER permission codes the user holds for this
+	// application only
+	Permissions []string `json:"permissions" validate:"required"`
+}
+
+type AppAdminResponse struct {
+	UserID      string    `json:"user_id"`
+	Permissions []string  `json:"permissions"`
+	CreatedBy   *string   `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type AppHandler struct {
@@ -40,9 +85,11 @@ func (h *AppHandler) Create() echo.HandlerFunc {
 		}
 
 		in := &app.CreateInput{
-			Code:        req.Code,
-			Name:        req.Name,
-			Description: req.Description,
+			Code:              req.Code,
+			Name:              req.Name,
+			Description:       req.Description,
+			TokenMode:         req.TokenMode,
+			HookFailurePolicy: req.HookFailurePolicy,
 		}
 
 		if err := h.appUC.Create(c.Request().Context(), in); err != nil {
@@ -63,3 +110,228 @@ func (h *AppHandler) Create() echo.HandlerFunc {
 		})
 	}
 }
+
+func (h *AppHandler) UpdateTokenMode() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID := c.Param("id")
+		req := &UpdateTokenModeRequest{}
+
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			h.logger.Warn("Failed to decode token mode request", service.Fields{
+				"app_id": appID,
+				"error":  err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.appUC.UpdateTokenMode(c.Request().Context(), appID, req.TokenMode); err != nil {
+			h.logger.Error("Failed to update token mode", service.Fields{
+				"app_id": appID,
+				"error":  err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "token mode updated successfully",
+		})
+	}
+}
+
+func (h *AppHandler) UpdateHookFailurePolicy() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID := c.Param("id")
+		req := &UpdateHookFailurePolicyRequest{}
+
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			h.logger.Warn("Failed to decode hook failure policy request", service.Fields{
+				"app_id": appID,
+				"error":  err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.appUC.UpdateHookFailurePolicy(c.Request().Context(), appID, req.HookFailurePolicy); err != nil {
+			h.logger.Error("Failed to update hook failure policy", service.Fields{
+				"app_id": appID,
+				"error":  err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "hook failure policy updated successfully",
+		})
+	}
+}
+
+func (h *AppHandler) CreateHook() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID := c.Param("id")
+		req := &CreateHookRequest{}
+
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			h.logger.Warn("Failed to decode hook creation request", service.Fields{
+				"app_id": appID,
+				"error":  err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		in := &app.CreateHookInput{
+			AppID:     appID,
+			Name:      req.Name,
+			URL:       req.URL,
+			Secret:    req.Secret,
+			TimeoutMs: req.TimeoutMs,
+		}
+
+		hook, err := h.appUC.CreateHook(c.Request().Context(), in)
+		if err != nil {
+			h.logger.Error("Failed to create token hook", service.Fields{
+				"app_id": appID,
+				"error":  err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		// The secret is only returned once, on creation
+		resp := toHookResponse(hook)
+		resp.Secret = hook.Secret
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "hook created successfully",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *AppHandler) ListHooks() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID := c.Param("id")
+
+		hooks, err := h.appUC.ListHooks(c.Request().Context(), appID)
+		if err != nil {
+			h.logger.Error("Failed to list token hooks", service.Fields{
+				"app_id": appID,
+				"error":  err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		resp := make([]*HookResponse, 0, len(hooks))
+		for _, hook := range hooks {
+			resp = append(resp, toHookResponse(hook))
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *AppHandler) DeleteHook() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID := c.Param("id")
+		hookID := c.Param("hook_id")
+
+		if err := h.appUC.DeleteHook(c.Request().Context(), appID, hookID); err != nil {
+			if errors.Is(err, app.ErrHookNotFound) {
+				return response.ErrorHandler(c, http.StatusNotFound, "NotFound", "hook not found")
+			}
+			h.logger.Error("Failed to delete token hook", service.Fields{
+				"app_id":  appID,
+				"hook_id": hookID,
+				"error":   err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", "failed to delete hook")
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "hook deleted successfully",
+		})
+	}
+}
+
+// SaveAdmin delegates administration of the application to a user, or
+// replaces the permissions of an existing admin
+func (h *AppHandler) SaveAdmin() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &SaveAppAdminRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		in := &app.SaveAdminInput{
+			AppID:       c.Param("id"),
+			UserID:      c.Param("user_id"),
+			Permissions: req.Permissions,
+		}
+		if claims := middleware.GetUserFromContext(c); claims != nil {
+			in.ActorID = claims.UserID
+		}
+
+		admin, err := h.appUC.SaveAdmin(c.Request().Context(), in)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "admin saved successfully",
+			Data:    toAppAdminResponse(admin),
+		})
+	}
+}
+
+func (h *AppHandler) ListAdmins() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		admins, err := h.appUC.ListAdmins(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		resp := make([]*AppAdminResponse, 0, len(admins))
+		for _, admin := range admins {
+			resp = append(resp, toAppAdminResponse(admin))
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *AppHandler) RemoveAdmin() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.appUC.RemoveAdmin(c.Request().Context(), c.Param("id"), c.Param("user_id")); err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "admin removed successfully",
+		})
+	}
+}
+
+func toAppAdminResponse(admin *entity.ApplicationAdmin) *AppAdminResponse {
+	return &AppAdminResponse{
+		UserID:      admin.UserID,
+		Permissions: admin.Permissions,
+		CreatedBy:   admin.CreatedBy,
+		CreatedAt:   admin.CreatedAt,
+		UpdatedAt:   admin.UpdatedAt,
+	}
+}
+
+func toHookResponse(hook *entity.TokenHook) *HookResponse {
+	return &HookResponse{
+		ID:        hook.ID,
+		Name:      hook.Name,
+		URL:       hook.URL,
+		TimeoutMs: hook.TimeoutMs,
+		IsActive:  hook.IsActive,
+		CreatedAt: hook.CreatedAt,
+	}
+}
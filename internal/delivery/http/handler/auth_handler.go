@@ -2,10 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/auth"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
@@ -16,20 +21,45 @@ import (
 type (
 	LoginRequest struct {
 		Application string `json:"application"`
-		Email       string `json:"email"`
-		Password    string `json:"password"`
+		// Organization selects the organization whose tenant roles the
+		// token carries
+		Organization string `json:"organization"`
+		Email        string `json:"email"`
+		Password     string `json:"password"`
+		// Mode selects how the token is delivered: "bearer" (default) returns
+		// it in the body, "cookie" sets it as an HttpOnly session cookie.
+		Mode string `json:"mode"`
+	}
+
+	// TokenExchangeRequest asks for a token for another organization or
+	// application. Mode is as for LoginRequest.
+	TokenExchangeRequest struct {
+		Application  string `json:"application"`
+		Organization string `json:"organization"`
+		Mode         string `json:"mode"`
 	}
 
 	LoginResponse struct {
-		Username    string `json:"username"`
-		Fullname    string `json:"full_name"`
-		AccessToken struct {
-			Type      string    `json:"type"`
-			Token     string    `json:"token"`
-			ExpiresAt time.Time `json:"expires_at"`
-		} `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
+		Username     string       `json:"username"`
+		Fullname     string       `json:"full_name"`
+		Organization string       `json:"organization,omitempty"`
+		AccessToken  *AccessToken `json:"access_token,omitempty"`
+		RefreshToken string       `json:"refresh_token,omitempty"`
+		CSRFToken    string       `json:"csrf_token,omitempty"`
+		ExpiresAt    time.Time    `json:"expires_at"`
+
+		Authorization []Authorization `json:"authorization"`
+	}
+
+	AccessToken struct {
+		Type      string    `json:"type"`
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
 
+	PermissionsResponse struct {
+		UserID        string          `json:"user_id"`
+		Version       int64           `json:"version"`
 		Authorization []Authorization `json:"authorization"`
 	}
 
@@ -38,12 +68,15 @@ type (
 	}
 
 	Authorization struct {
-		App         string   `json:"app"`
-		Roles       []string `json:"roles"`
-		Permissions []string `json:"permissions"`
+		App         string              `json:"app"`
+		Roles       []string            `json:"roles"`
+		Permissions []string            `json:"permissions"`
+		Conditions  map[string][]string `json:"conditions,omitempty"`
 	}
 )
 
+const loginModeCookie = "cookie"
+
 type AuthHandler struct {
 	authUC      authUsecase.Usecase
 	jwksService auth.JWKSService
@@ -75,12 +108,14 @@ func (h *AuthHandler) Login() echo.HandlerFunc {
 			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
 		}
 
+		session := sessionSettings(h.cfg)
+
 		var validToken string
-		if cookie, err := c.Cookie("jwt_user_token"); err == nil {
+		if cookie, err := c.Cookie(session.CookieName); err == nil {
 			validToken = cookie.Value
 		}
 
-		data, err := h.authUC.Login(c.Request().Context(), req.Application, req.Email, req.Password, validToken, h.cfg)
+		data, err := h.authUC.Login(c.Request().Context(), req.Application, req.Organization, req.Email, req.Password, validToken, h.cfg)
 		if err != nil {
 			h.logger.Warn("Login failed", logger.Fields{
 				"email": req.Email,
@@ -89,41 +124,12 @@ func (h *AuthHandler) Login() echo.HandlerFunc {
 			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
 		}
 
-		newCookie := new(http.Cookie)
-		newCookie.Name = "jwt_user_token"
-		newCookie.Value = data.Token
-		// newCookie.HttpOnly = true
-		newCookie.Secure = true
-		newCookie.SameSite = http.SameSiteNoneMode
-		newCookie.Expires = data.Claims.ExpiresAt.Time
-		newCookie.Path = "/"
-
-		c.SetCookie(newCookie)
-
-		var authorizations []Authorization
-
-		for _, a := range data.Claims.Authorization {
-			authorizations = append(authorizations, Authorization{
-				App:         a.App,
-				Roles:       a.Roles,
-				Permissions: a.Permissions,
+		resp, err := newLoginResponse(c, h.cfg, data, req.Mode)
+		if err != nil {
+			h.logger.Error("Failed to generate CSRF token", logger.Fields{
+				"error": err.Error(),
 			})
-		}
-
-		resp := &LoginResponse{
-			Username: data.Claims.Username,
-			Fullname: data.User.FullName,
-			AccessToken: struct {
-				Type      string    `json:"type"`
-				Token     string    `json:"token"`
-				ExpiresAt time.Time `json:"expires_at"`
-			}{
-				Type:      "Bearer",
-				Token:     data.Token,
-				ExpiresAt: data.Claims.ExpiresAt.Time,
-			},
-			RefreshToken:  data.RefreshToken,
-			Authorization: authorizations,
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", "failed to start session")
 		}
 
 		h.logger.Info("User logged in successfully", logger.Fields{
@@ -138,19 +144,62 @@ func (h *AuthHandler) Login() echo.HandlerFunc {
 	}
 }
 
+// ExchangeToken issues the caller a token for another organization or
+// application, e.g. when switching organizations. Personal access tokens
+// and service accounts cannot be exchanged.
+func (h *AuthHandler) ExchangeToken() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		if claims.AuthMethod == middleware.AuthMethodPAT ||
+			(claims.PrincipalType != "" && claims.PrincipalType != entity.PrincipalTypeUser) {
+			return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", "only user sessions can be exchanged")
+		}
+
+		req := &TokenExchangeRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		data, err := h.authUC.ExchangeToken(c.Request().Context(), claims.UserID, req.Application, req.Organization, h.cfg)
+		if err != nil {
+			h.logger.Warn("Token exchange failed", logger.Fields{
+				"user_id": claims.UserID,
+				"error":   err.Error(),
+			})
+			if errors.Is(err, service.ErrNotOrganizationMember) {
+				return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		resp, err := newLoginResponse(c, h.cfg, data, req.Mode)
+		if err != nil {
+			h.logger.Error("Failed to generate CSRF token", logger.Fields{
+				"error": err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", "failed to start session")
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "token exchanged successfully",
+			Data:    resp,
+		})
+	}
+}
+
 func (h *AuthHandler) Logout() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		expiredCookie := &http.Cookie{
-			Name:     "jwt_user_token",
-			Value:    "",
-			Path:     "/",
-			Expires:  time.Unix(0, 0),
-			MaxAge:   -1,
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		}
-		c.SetCookie(expiredCookie)
+		session := sessionSettings(h.cfg)
+
+		for _, name := range []string{session.CookieName, session.CSRFCookieName} {
+			expiredCookie := newSessionCookie(h.cfg, name, "", time.Unix(0, 0), true)
+			expiredCookie.MaxAge = -1
+			c.SetCookie(expiredCookie)
+		}
 
 		h.logger.Info("User logged out successfully", logger.Fields{})
 
@@ -160,9 +209,92 @@ func (h *AuthHandler) Logout() echo.HandlerFunc {
 	}
 }
 
+// GetPermissions resolves the caller's live permissions. Relying services
+// holding a thin token call it to learn what the token's subject may do.
+// The permissions version is returned as ETag so clients can revalidate.
+// A personal access token is answered with its own, restricted
+// permissions rather than its owner's; service accounts have no user
+// permissions to resolve.
+func (h *AuthHandler) GetPermissions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		if claims.PrincipalType != "" && claims.PrincipalType != entity.PrincipalTypeUser {
+			return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", "only users have resolvable permissions")
+		}
+
+		appCode := c.QueryParam("app")
+
+		if claims.AuthMethod == middleware.AuthMethodPAT {
+			authorizations := make([]Authorization, 0, len(claims.Authorization))
+			for _, a := range claims.Authorization {
+				if appCode != "" && a.App != appCode {
+					continue
+				}
+				authorizations = append(authorizations, Authorization{
+					App:         a.App,
+					Roles:       a.Roles,
+					Permissions: a.Permissions,
+					Conditions:  a.Conditions,
+				})
+			}
+
+			c.Response().Header().Set("Cache-Control", "private, no-store")
+			return response.SuccesHandler(c, &response.Response{
+				Message: "OK",
+				Data: &PermissionsResponse{
+					UserID:        claims.UserID,
+					Version:       claims.PermissionsVersion,
+					Authorization: authorizations,
+				},
+			})
+		}
+
+		resolved, err := h.authUC.ResolvePermissions(c.Request().Context(), claims.UserID, appCode, claims.Organization)
+		if err != nil {
+			h.logger.Error("Failed to resolve permissions", logger.Fields{
+				"user_id":  claims.UserID,
+				"app_code": appCode,
+				"error":    err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		etag := fmt.Sprintf(`"pv-%d"`, resolved.Version)
+		c.Response().Header().Set("ETag", etag)
+		c.Response().Header().Set("Cache-Control", "private, no-cache")
+		if c.Request().Header.Get("If-None-Match") == etag {
+			return c.NoContent(http.StatusNotModified)
+		}
+
+		authorizations := make([]Authorization, 0, len(resolved.Authorization))
+		for _, a := range resolved.Authorization {
+			authorizations = append(authorizations, Authorization{
+				App:         a.App,
+				Roles:       a.Roles,
+				Permissions: a.Permissions,
+				Conditions:  a.Conditions,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data: &PermissionsResponse{
+				UserID:        resolved.UserID,
+				Version:       resolved.Version,
+				Authorization: authorizations,
+			},
+		})
+	}
+}
+
 func (h *AuthHandler) GetJWKS() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		jwksResp, err := h.jwksService.GetJWKS(h.cfg.JWT.PublicKey, h.cfg.JWT.KeyID)
+		publicKey, keyID := h.cfg.JWT.VerificationKey()
+		jwksResp, err := h.jwksService.GetJWKS(publicKey, keyID)
 		if err != nil {
 			h.logger.Error("Failed to generate JWKS", logger.Fields{
 				"error": err.Error(),
@@ -177,3 +309,75 @@ func (h *AuthHandler) GetJWKS() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, resp)
 	}
 }
+
+// newLoginResponse builds the login response for an issued token. In
+// cookie mode the token is set as an HttpOnly session cookie together with
+// a CSRF cookie instead of being returned in the body.
+func newLoginResponse(c echo.Context, cfg *config.Config, data *authUsecase.UserToken, mode string) (*LoginResponse, error) {
+	var authorizations []Authorization
+
+	for _, a := range data.Claims.Authorization {
+		authorizations = append(authorizations, Authorization{
+			App:         a.App,
+			Roles:       a.Roles,
+			Permissions: a.Permissions,
+			Conditions:  a.Conditions,
+		})
+	}
+
+	expiresAt := data.Claims.ExpiresAt.Time
+	resp := &LoginResponse{
+		Username:      data.Claims.Username,
+		Fullname:      data.User.FullName,
+		Organization:  data.Claims.Organization,
+		ExpiresAt:     expiresAt,
+		Authorization: authorizations,
+	}
+
+	if mode == loginModeCookie {
+		// Browser session: the token never reaches JavaScript, the
+		// frontend only sees the CSRF token it must echo back
+		csrfToken, err := middleware.NewCSRFToken()
+		if err != nil {
+			return nil, err
+		}
+
+		session := sessionSettings(cfg)
+		c.SetCookie(newSessionCookie(cfg, session.CookieName, data.Token, expiresAt, true))
+		c.SetCookie(newSessionCookie(cfg, session.CSRFCookieName, csrfToken, expiresAt, false))
+		resp.CSRFToken = csrfToken
+	} else {
+		resp.AccessToken = &AccessToken{
+			Type:      "Bearer",
+			Token:     data.Token,
+			ExpiresAt: expiresAt,
+		}
+		resp.RefreshToken = data.RefreshToken
+	}
+
+	return resp, nil
+}
+
+// sessionSettings returns the configured session settings or the defaults
+func sessionSettings(cfg *config.Config) *config.Session {
+	if cfg != nil && cfg.Session != nil {
+		return cfg.Session
+	}
+	return config.DefaultSession()
+}
+
+// newSessionCookie builds a session-scoped cookie using the configured
+// SameSite, Secure and Domain attributes
+func newSessionCookie(cfg *config.Config, name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	session := sessionSettings(cfg)
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   session.Domain,
+		Expires:  expires,
+		Secure:   !session.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: session.SameSiteMode(),
+	}
+}
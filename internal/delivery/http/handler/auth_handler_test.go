@@ -14,22 +14,26 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
-	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/auth"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
 	authUsecase "github.com/mafzaidi/authorizer/internal/usecase/auth"
 )
 
 // MockAuthUseCase is a mock implementation of auth.Usecase
 type MockAuthUseCase struct {
-	LoginFunc        func(ctx context.Context, appCode, email, password, validToken string, cfg *config.Config) (*authUsecase.UserToken, error)
-	RefreshTokenFunc func(ctx context.Context, refreshToken string, cfg *config.Config) (string, string, error)
+	LoginFunc              func(ctx context.Context, appCode, orgCode, email, password, validToken string, cfg *config.Config) (*authUsecase.UserToken, error)
+	RefreshTokenFunc       func(ctx context.Context, refreshToken string, cfg *config.Config) (string, string, error)
+	IssueTokenFunc         func(ctx context.Context, userID, appCode string, cfg *config.Config) (*authUsecase.UserToken, error)
+	ExchangeTokenFunc      func(ctx context.Context, userID, appCode, orgCode string, cfg *config.Config) (*authUsecase.UserToken, error)
+	ResolvePermissionsFunc func(ctx context.Context, userID, appCode, orgCode string) (*authUsecase.ResolvedPermissions, error)
 }
 
-func (m *MockAuthUseCase) Login(ctx context.Context, appCode, email, password, validToken string, cfg *config.Config) (*authUsecase.UserToken, error) {
+func (m *MockAuthUseCase) Login(ctx context.Context, appCode, orgCode, email, password, validToken string, cfg *config.Config) (*authUsecase.UserToken, error) {
 	if m.LoginFunc != nil {
-		return m.LoginFunc(ctx, appCode, email, password, validToken, cfg)
+		return m.LoginFunc(ctx, appCode, orgCode, email, password, validToken, cfg)
 	}
 	return nil, errors.New("not implemented")
 }
@@ -41,6 +45,27 @@ func (m *MockAuthUseCase) RefreshToken(ctx context.Context, refreshToken string,
 	return "", "", errors.New("not implemented")
 }
 
+func (m *MockAuthUseCase) IssueToken(ctx context.Context, userID, appCode string, cfg *config.Config) (*authUsecase.UserToken, error) {
+	if m.IssueTokenFunc != nil {
+		return m.IssueTokenFunc(ctx, userID, appCode, cfg)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockAuthUseCase) ExchangeToken(ctx context.Context, userID, appCode, orgCode string, cfg *config.Config) (*authUsecase.UserToken, error) {
+	if m.ExchangeTokenFunc != nil {
+		return m.ExchangeTokenFunc(ctx, userID, appCode, orgCode, cfg)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockAuthUseCase) ResolvePermissions(ctx context.Context, userID, appCode, orgCode string) (*authUsecase.ResolvedPermissions, error) {
+	if m.ResolvePermissionsFunc != nil {
+		return m.ResolvePermissionsFunc(ctx, userID, appCode, orgCode)
+	}
+	return nil, errors.New("not implemented")
+}
+
 // MockJWKSService is a mock implementation of auth.JWKSService
 type MockJWKSService struct {
 	GetJWKSFunc func(publicKey *rsa.PublicKey, keyID string) (*auth.JWKSResponse, error)
@@ -77,7 +102,7 @@ func TestNewAuthHandler(t *testing.T) {
 func TestAuthHandler_Login_Success(t *testing.T) {
 	// Setup
 	mockAuthUC := &MockAuthUseCase{
-		LoginFunc: func(ctx context.Context, appCode, email, password, validToken string, cfg *config.Config) (*authUsecase.UserToken, error) {
+		LoginFunc: func(ctx context.Context, appCode, orgCode, email, password, validToken string, cfg *config.Config) (*authUsecase.UserToken, error) {
 			return &authUsecase.UserToken{
 				User: &entity.User{
 					ID:       "user-123",
@@ -142,6 +167,81 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_Login_CookieMode(t *testing.T) {
+	// Setup
+	mockAuthUC := &MockAuthUseCase{
+		LoginFunc: func(ctx context.Context, appCode, orgCode, email, password, validToken string, cfg *config.Config) (*authUsecase.UserToken, error) {
+			return &authUsecase.UserToken{
+				User:         &entity.User{ID: "user-123", FullName: "Test User"},
+				Token:        "test-token",
+				RefreshToken: "refresh-token",
+				Claims: &middleware.JWTClaims{
+					RegisteredClaims: jwt.RegisteredClaims{
+						Subject:   "user-123",
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+					},
+					Username: "testuser",
+				},
+			}, nil
+		},
+	}
+	handler := NewAuthHandler(mockAuthUC, &MockJWKSService{}, &config.Config{}, logger.New())
+
+	body, _ := json.Marshal(LoginRequest{
+		Application: "APP1",
+		Email:       "test@example.com",
+		Password:    "password123",
+		Mode:        "cookie",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	// Execute
+	if err := handler.Login()(c); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var sessionCookie, csrfCookie *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		switch cookie.Name {
+		case "jwt_user_token":
+			sessionCookie = cookie
+		case "csrf_token":
+			csrfCookie = cookie
+		}
+	}
+
+	if sessionCookie == nil || !sessionCookie.HttpOnly || !sessionCookie.Secure {
+		t.Fatal("Expected an HttpOnly, Secure session cookie")
+	}
+	if sessionCookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("Expected SameSite=Lax by default, got %v", sessionCookie.SameSite)
+	}
+	if csrfCookie == nil || csrfCookie.HttpOnly || csrfCookie.Value == "" {
+		t.Fatal("Expected a non-HttpOnly CSRF cookie")
+	}
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if _, ok := resp.Data["access_token"]; ok {
+		t.Error("Expected access token to be omitted from the body in cookie mode")
+	}
+	if resp.Data["csrf_token"] != csrfCookie.Value {
+		t.Error("Expected body CSRF token to match the CSRF cookie")
+	}
+}
+
 func TestAuthHandler_Login_InvalidRequest(t *testing.T) {
 	// Setup
 	mockAuthUC := &MockAuthUseCase{}
@@ -321,3 +421,183 @@ func TestAuthHandler_GetJWKS_ServiceError(t *testing.T) {
 		t.Errorf("Expected status code %d when JWKS service fails, got %d", http.StatusInternalServerError, rec.Code)
 	}
 }
+
+func TestAuthHandler_GetPermissions_NotModified(t *testing.T) {
+	// Setup
+	mockAuthUC := &MockAuthUseCase{
+		ResolvePermissionsFunc: func(ctx context.Context, userID, appCode, orgCode string) (*authUsecase.ResolvedPermissions, error) {
+			return &authUsecase.ResolvedPermissions{
+				UserID:  userID,
+				Version: 3,
+				Authorization: []entity.Authorization{
+					{App: appCode, Roles: []string{"viewer"}, Permissions: []string{"read"}},
+				},
+			}, nil
+		},
+	}
+	handler := NewAuthHandler(mockAuthUC, &MockJWKSService{}, &config.Config{}, logger.New())
+
+	e := echo.New()
+
+	// First request returns the permissions and the version as ETag
+	req := httptest.NewRequest(http.MethodGet, "/auth/permissions?app=APP1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_claims", &middleware.JWTClaims{UserID: "user-123"})
+
+	if err := handler.GetPermissions()(c); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != `"pv-3"` {
+		t.Errorf("Expected ETag %q, got %q", `"pv-3"`, etag)
+	}
+
+	// Revalidation with the same version is answered with 304
+	req = httptest.NewRequest(http.MethodGet, "/auth/permissions?app=APP1", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.Set("user_claims", &middleware.JWTClaims{UserID: "user-123"})
+
+	if err := handler.GetPermissions()(c); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotModified, rec.Code)
+	}
+}
+
+func TestAuthHandler_GetPermissions_PersonalAccessToken(t *testing.T) {
+	// The owner's live permissions must not be resolved for a token
+	mockAuthUC := &MockAuthUseCase{
+		ResolvePermissionsFunc: func(ctx context.Context, userID, appCode, orgCode string) (*authUsecase.ResolvedPermissions, error) {
+			t.Error("Expected the owner's permissions not to be resolved")
+			return nil, errors.New("unexpected call")
+		},
+	}
+	handler := NewAuthHandler(mockAuthUC, &MockJWKSService{}, &config.Config{}, logger.New())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/auth/permissions?app=APP1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_claims", &middleware.JWTClaims{
+		UserID:     "user-123",
+		AuthMethod: middleware.AuthMethodPAT,
+		Authorization: []middleware.Authorization{
+			{App: "APP1", Permissions: []string{"read"}},
+			{App: "APP2", Permissions: []string{"write"}},
+		},
+	})
+
+	if err := handler.GetPermissions()(c); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var body struct {
+		Data PermissionsResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Data.Authorization) != 1 || body.Data.Authorization[0].App != "APP1" ||
+		len(body.Data.Authorization[0].Permissions) != 1 || body.Data.Authorization[0].Permissions[0] != "read" {
+		t.Errorf("Expected only the token's APP1 permissions, got %+v", body.Data.Authorization)
+	}
+}
+
+func TestAuthHandler_GetPermissions_ServiceAccount(t *testing.T) {
+	handler := NewAuthHandler(&MockAuthUseCase{}, &MockJWKSService{}, &config.Config{}, logger.New())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/auth/permissions", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_claims", &middleware.JWTClaims{UserID: "sa-1", PrincipalType: entity.PrincipalTypeServiceAccount})
+
+	_ = handler.GetPermissions()(c)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, rec.Code)
+	}
+}
+
+func TestAuthHandler_ExchangeToken(t *testing.T) {
+	var gotOrg string
+	mockAuthUC := &MockAuthUseCase{
+		ExchangeTokenFunc: func(ctx context.Context, userID, appCode, orgCode string, cfg *config.Config) (*authUsecase.UserToken, error) {
+			gotOrg = orgCode
+			return &authUsecase.UserToken{
+				User:  &entity.User{ID: userID, Email: "test@example.com"},
+				Token: "org-token",
+				Claims: &middleware.JWTClaims{
+					RegisteredClaims: jwt.RegisteredClaims{
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+					},
+					Organization: orgCode,
+				},
+			}, nil
+		},
+	}
+	handler := NewAuthHandler(mockAuthUC, &MockJWKSService{}, &config.Config{}, logger.New())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/auth/token/exchange", bytes.NewBufferString(`{"application":"APP1","organization":"ACME"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_claims", &middleware.JWTClaims{UserID: "user-123"})
+
+	if err := handler.ExchangeToken()(c); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+	if gotOrg != "ACME" {
+		t.Errorf("Expected organization ACME, got %q", gotOrg)
+	}
+}
+
+func TestAuthHandler_ExchangeToken_Forbidden(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *middleware.JWTClaims
+		err    error
+	}{
+		{"personal access token", &middleware.JWTClaims{UserID: "user-123", AuthMethod: middleware.AuthMethodPAT}, nil},
+		{"service account", &middleware.JWTClaims{UserID: "sa-1", PrincipalType: entity.PrincipalTypeServiceAccount}, nil},
+		{"not a member", &middleware.JWTClaims{UserID: "user-123"}, service.ErrNotOrganizationMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthUC := &MockAuthUseCase{
+				ExchangeTokenFunc: func(ctx context.Context, userID, appCode, orgCode string, cfg *config.Config) (*authUsecase.UserToken, error) {
+					return nil, tt.err
+				},
+			}
+			handler := NewAuthHandler(mockAuthUC, &MockJWKSService{}, &config.Config{}, logger.New())
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/auth/token/exchange", bytes.NewBufferString(`{"application":"APP1","organization":"ACME"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("user_claims", tt.claims)
+
+			_ = handler.ExchangeToken()(c)
+
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("Expected status code %d, got %d", http.StatusForbidden, rec.Code)
+			}
+		})
+	}
+}
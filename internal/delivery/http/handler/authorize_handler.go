@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/usecase/authorize"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	// CheckRequest identifies the subject by user_id or by a token it
+	// was issued. Organization includes the subject's tenant roles in it.
+	// Context carries the request attributes conditional grants are
+	// evaluated against.
+	CheckRequest struct {
+		UserID       string         `json:"user_id"`
+		Token        string         `json:"token"`
+		Organization string         `json:"organization"`
+		Application  string         `json:"application"`
+		Permission   string         `json:"permission"`
+		Resource     string         `json:"resource"`
+		Context      map[string]any `json:"context"`
+	}
+
+	BatchCheckRequest struct {
+		UserID       string           `json:"user_id"`
+		Token        string           `json:"token"`
+		Organization string           `json:"organization"`
+		Checks       []BatchCheckItem `json:"checks"`
+		Context      map[string]any   `json:"context"`
+	}
+
+	BatchCheckItem struct {
+		Application string         `json:"application"`
+		Permission  string         `json:"permission"`
+		Resource    string         `json:"resource"`
+		Context     map[string]any `json:"context"`
+	}
+
+	BatchCheckResponse struct {
+		UserID       string           `json:"user_id"`
+		Organization string           `json:"organization,omitempty"`
+		Decisions    []*CheckResponse `json:"decisions"`
+	}
+
+	CheckResponse struct {
+		Allowed      bool   `json:"allowed"`
+		Reason       string `json:"reason"`
+		UserID       string `json:"user_id"`
+		Organization string `json:"organization,omitempty"`
+		Application  string `json:"application"`
+		Permission   string `json:"permission"`
+		Resource     string `json:"resource,omitempty"`
+	}
+)
+
+type AuthorizeHandler struct {
+	authorizeUC authorize.Usecase
+	logger      service.Logger
+}
+
+func NewAuthorizeHandler(uc authorize.Usecase, logger service.Logger) *AuthorizeHandler {
+	return &AuthorizeHandler{
+		authorizeUC: uc,
+		logger:      logger,
+	}
+}
+
+// Check answers whether a subject holds a permission. Allow and deny are
+// both successful responses; errors mean no decision could be made.
+func (h *AuthorizeHandler) Check() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &CheckRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		decision, err := h.authorizeUC.Check(c.Request().Context(), &authorize.CheckInput{
+			UserID:       req.UserID,
+			Token:        req.Token,
+			Organization: req.Organization,
+			AppCode:      req.Application,
+			Permission:   req.Permission,
+			Resource:     req.Resource,
+			Context:      req.Context,
+		})
+		if err != nil {
+			if errors.Is(err, authorize.ErrEvaluationFailed) {
+				return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "authorization checked",
+			Data:    newCheckResponse(decision),
+		})
+	}
+}
+
+// BatchCheck answers many checks for one subject, in request order
+func (h *AuthorizeHandler) BatchCheck() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &BatchCheckRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		checks := make([]authorize.CheckItem, 0, len(req.Checks))
+		for _, item := range req.Checks {
+			checks = append(checks, authorize.CheckItem{
+				AppCode:    item.Application,
+				Permission: item.Permission,
+				Resource:   item.Resource,
+				Context:    item.Context,
+			})
+		}
+
+		decisions, err := h.authorizeUC.BatchCheck(c.Request().Context(), &authorize.BatchCheckInput{
+			UserID:       req.UserID,
+			Token:        req.Token,
+			Organization: req.Organization,
+			Checks:       checks,
+			Context:      req.Context,
+		})
+		if err != nil {
+			if errors.Is(err, authorize.ErrEvaluationFailed) {
+				return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		resp := &BatchCheckResponse{
+			Decisions: make([]*CheckResponse, 0, len(decisions)),
+		}
+		for _, d := range decisions {
+			resp.UserID = d.UserID
+			resp.Organization = d.Organization
+			resp.Decisions = append(resp.Decisions, newCheckResponse(d))
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "authorization checked",
+			Data:    resp,
+		})
+	}
+}
+
+func newCheckResponse(d *authorize.Decision) *CheckResponse {
+	return &CheckResponse{
+		Allowed:      d.Allowed,
+		Reason:       d.Reason,
+		UserID:       d.UserID,
+		Organization: d.Organization,
+		Application:  d.AppCode,
+		Permission:   d.Permission,
+		Resource:     d.Resource,
+	}
+}
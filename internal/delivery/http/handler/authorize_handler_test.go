@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/internal/usecase/authorize"
+)
+
+// MockAuthorizeUseCase is a mock implementation of authorize.Usecase
+type MockAuthorizeUseCase struct {
+	CheckFunc      func(ctx context.Context, input *authorize.CheckInput) (*authorize.Decision, error)
+	BatchCheckFunc func(ctx context.Context, input *authorize.BatchCheckInput) ([]*authorize.Decision, error)
+}
+
+func (m *MockAuthorizeUseCase) Check(ctx context.Context, input *authorize.CheckInput) (*authorize.Decision, error) {
+	if m.CheckFunc != nil {
+		return m.CheckFunc(ctx, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockAuthorizeUseCase) BatchCheck(ctx context.Context, input *authorize.BatchCheckInput) ([]*authorize.Decision, error) {
+	if m.BatchCheckFunc != nil {
+		return m.BatchCheckFunc(ctx, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func TestAuthorizeHandler_Check_Deny(t *testing.T) {
+	var got *authorize.CheckInput
+	mockUC := &MockAuthorizeUseCase{
+		CheckFunc: func(ctx context.Context, input *authorize.CheckInput) (*authorize.Decision, error) {
+			got = input
+			return &authorize.Decision{
+				UserID:     input.UserID,
+				AppCode:    input.AppCode,
+				Permission: input.Permission,
+				Reason:     "no role grants user.read",
+			}, nil
+		},
+	}
+	handler := NewAuthorizeHandler(mockUC, logger.New())
+
+	rec := postJSON(handler.Check(), `{"user_id":"user-1","application":"APP1","permission":"user.read","resource":"doc:1"}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got == nil || got.UserID != "user-1" || got.AppCode != "APP1" || got.Permission != "user.read" || got.Resource != "doc:1" {
+		t.Errorf("Unexpected check input: %+v", got)
+	}
+
+	var body struct {
+		Data CheckResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Data.Allowed || body.Data.Reason != "no role grants user.read" {
+		t.Errorf("Unexpected decision: %+v", body.Data)
+	}
+}
+
+func TestAuthorizeHandler_Check_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"invalid input", errors.New("subject is required"), http.StatusBadRequest},
+		{"evaluation failure", authorize.ErrEvaluationFailed, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUC := &MockAuthorizeUseCase{
+				CheckFunc: func(ctx context.Context, input *authorize.CheckInput) (*authorize.Decision, error) {
+					return nil, tt.err
+				},
+			}
+			handler := NewAuthorizeHandler(mockUC, logger.New())
+
+			rec := postJSON(handler.Check(), `{"application":"APP1","permission":"user.read"}`)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAuthorizeHandler_BatchCheck(t *testing.T) {
+	var got *authorize.BatchCheckInput
+	mockUC := &MockAuthorizeUseCase{
+		BatchCheckFunc: func(ctx context.Context, input *authorize.BatchCheckInput) ([]*authorize.Decision, error) {
+			got = input
+			decisions := make([]*authorize.Decision, 0, len(input.Checks))
+			for _, c := range input.Checks {
+				decisions = append(decisions, &authorize.Decision{
+					UserID:     "user-1",
+					AppCode:    c.AppCode,
+					Permission: c.Permission,
+					Allowed:    c.Permission == "user.read",
+				})
+			}
+			return decisions, nil
+		},
+	}
+	handler := NewAuthorizeHandler(mockUC, logger.New())
+
+	rec := postJSON(handler.BatchCheck(), `{"token":"tok","checks":[
+		{"application":"APP1","permission":"user.read"},
+		{"application":"APP2","permission":"user.delete"}
+	]}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got == nil || got.Token != "tok" || len(got.Checks) != 2 || got.Checks[1].AppCode != "APP2" {
+		t.Fatalf("Unexpected batch input: %+v", got)
+	}
+
+	var body struct {
+		Data BatchCheckResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Data.UserID != "user-1" || len(body.Data.Decisions) != 2 {
+		t.Fatalf("Unexpected response: %+v", body.Data)
+	}
+	if !body.Data.Decisions[0].Allowed || body.Data.Decisions[1].Allowed {
+		t.Errorf("Decisions out of order: %+v, %+v", body.Data.Decisions[0], body.Data.Decisions[1])
+	}
+}
+
+func TestAuthorizeHandler_Check_Context(t *testing.T) {
+	var got *authorize.CheckInput
+	mockUC := &MockAuthorizeUseCase{
+		CheckFunc: func(ctx context.Context, input *authorize.CheckInput) (*authorize.Decision, error) {
+			got = input
+			return &authorize.Decision{UserID: input.UserID, Allowed: true}, nil
+		},
+	}
+	handler := NewAuthorizeHandler(mockUC, logger.New())
+
+	rec := postJSON(handler.Check(), `{"user_id":"user-1","application":"APP1","permission":"doc.update",
+		"context":{"request":{"ip":"10.0.0.1"},"resource":{"owner":"user-1"}}}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	request, _ := got.Context["request"].(map[string]any)
+	resource, _ := got.Context["resource"].(map[string]any)
+	if request["ip"] != "10.0.0.1" || resource["owner"] != "user-1" {
+		t.Errorf("Unexpected check context: %+v", got.Context)
+	}
+}
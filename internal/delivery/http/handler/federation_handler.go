@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/federation"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+const (
+	// federationStateCookie holds the binding of a federated login's
+	// state to the browser that started it
+	federationStateCookie = "authorizer_federation_state"
+
+	// federationStateTTL matches how long the login state is kept
+	// server-side
+	federationStateTTL = 10 * time.Minute
+)
+
+type FederationHandler struct {
+	fedUC  federation.Usecase
+	cfg    *config.Config
+	logger service.Logger
+}
+
+func NewFederationHandler(uc federation.Usecase, cfg *config.Config, logger service.Logger) *FederationHandler {
+	return &FederationHandler{
+		fedUC:  uc,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Login redirects the browser to the upstream identity provider. A
+// short-lived cookie binds the login state to this browser.
+func (h *FederationHandler) Login() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		provider := c.Param("provider")
+
+		start, err := h.fedUC.StartLogin(c.Request().Context(), &federation.StartInput{
+			Provider: provider,
+			AppCode:  c.QueryParam("application"),
+			Mode:     c.QueryParam("mode"),
+		})
+		if err != nil {
+			h.logger.Warn("Federated login start failed", service.Fields{
+				"provider": provider,
+				"error":    err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		c.SetCookie(h.stateCookie(start.Binding, time.Now().Add(federationStateTTL)))
+
+		return c.Redirect(http.StatusFound, start.RedirectURL)
+	}
+}
+
+// Callback completes the login when the identity provider redirects back
+func (h *FederationHandler) Callback() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		provider := c.Param("provider")
+
+		if errCode := c.QueryParam("error"); errCode != "" {
+			h.logger.Warn("Identity provider returned an error", service.Fields{
+				"provider": provider,
+				"error":    errCode,
+			})
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "identity provider error: "+errCode)
+		}
+
+		var binding string
+		if cookie, err := c.Cookie(federationStateCookie); err == nil {
+			binding = cookie.Value
+		}
+		expired := h.stateCookie("", time.Unix(0, 0))
+		expired.MaxAge = -1
+		c.SetCookie(expired)
+
+		result, err := h.fedUC.Callback(c.Request().Context(), &federation.CallbackInput{
+			Provider: provider,
+			State:    c.QueryParam("state"),
+			Code:     c.QueryParam("code"),
+			Binding:  binding,
+		}, h.cfg)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", err.Error())
+		}
+
+		resp, err := newLoginResponse(c, h.cfg, result.Token, result.Mode)
+		if err != nil {
+			h.logger.Error("Failed to generate CSRF token", service.Fields{
+				"error": err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", "failed to start session")
+		}
+
+		// Browser sessions go back to the frontend once cookies are set
+		if result.Mode == loginModeCookie {
+			if idp := h.provider(provider); idp != nil && idp.PostLoginRedirect != "" {
+				return c.Redirect(http.StatusFound, idp.PostLoginRedirect)
+			}
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "user login successfully",
+			Data:    resp,
+		})
+	}
+}
+
+// stateCookie builds the state binding cookie. It is Lax whatever the
+// session SameSite setting, as the provider redirects back with a
+// cross-site top-level navigation.
+func (h *FederationHandler) stateCookie(value string, expires time.Time) *http.Cookie {
+	cookie := newSessionCookie(h.cfg, federationStateCookie, value, expires, true)
+	cookie.SameSite = http.SameSiteLaxMode
+	return cookie
+}
+
+func (h *FederationHandler) provider(name string) *config.IdentityProvider {
+	if h.cfg == nil {
+		return nil
+	}
+	for _, idp := range h.cfg.IdentityProviders {
+		if idp.Name == name {
+			return idp
+		}
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/usecase/group"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	CreateGroupRequest struct {
+		Code        string  `json:"code" validate:"required"`
+		Name        string  `json:"name" validate:"required"`
+		Description *string `json:"description"`
+	}
+
+	AddGroupMemberRequest struct {
+		UserID string `json:"user_id" validate:"required"`
+	}
+
+	AddSubgroupRequest struct {
+		GroupID string `json:"group_id" validate:"required"`
+	}
+
+	AssignGroupRolesRequest struct {
+		Roles []string `json:"roles"`
+	}
+
+	GetGroupListQuery struct {
+		Page  int `query:"page"`
+		Limit int `query:"limit"`
+	}
+
+	GroupResponse struct {
+		ID          string    `json:"id"`
+		Code        string    `json:"code"`
+		Name        string    `json:"name"`
+		Description *string   `json:"description,omitempty"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	GroupMemberResponse struct {
+		UserID   string    `json:"user_id"`
+		JoinedAt time.Time `json:"joined_at"`
+	}
+
+	GroupRoleResponse struct {
+		ID            string  `json:"id"`
+		Code          string  `json:"code"`
+		ApplicationID *string `json:"application_id"`
+	}
+)
+
+type GroupHandler struct {
+	groupUC group.Usecase
+	logger  service.Logger
+}
+
+func NewGroupHandler(uc group.Usecase, logger service.Logger) *GroupHandler {
+	return &GroupHandler{
+		groupUC: uc,
+		logger:  logger,
+	}
+}
+
+func (h *GroupHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &CreateGroupRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		g, err := h.groupUC.Create(c.Request().Context(), &group.CreateInput{
+			Code:        req.Code,
+			Name:        req.Name,
+			Description: req.Description,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "group created successfully",
+			Data:    newGroupResponse(g),
+		})
+	}
+}
+
+func (h *GroupHandler) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.groupUC.Delete(c.Request().Context(), c.Param("id")); err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "group deleted successfully",
+		})
+	}
+}
+
+func (h *GroupHandler) GetList() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := GetGroupListQuery{}
+		if err := c.Bind(&query); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		page := query.Page
+		if page <= 0 {
+			page = 1
+		}
+		limit := query.Limit
+		if limit <= 0 {
+			limit = 50
+		}
+
+		groups, err := h.groupUC.GetList(c.Request().Context(), limit, (page-1)*limit)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "groups retrieved successfully",
+			Data:    newGroupResponses(groups),
+		})
+	}
+}
+
+func (h *GroupHandler) GetDetail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		g, err := h.groupUC.GetDetail(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "group retrieved successfully",
+			Data:    newGroupResponse(g),
+		})
+	}
+}
+
+func (h *GroupHandler) ListMembers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		members, err := h.groupUC.ListMembers(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		resp := make([]*GroupMemberResponse, 0, len(members))
+		for _, m := range members {
+			resp = append(resp, &GroupMemberResponse{
+				UserID:   m.UserID,
+				JoinedAt: m.CreatedAt,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "group members retrieved successfully",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *GroupHandler) AddMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &AddGroupMemberRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.groupUC.AddMember(c.Request().Context(), c.Param("id"), req.UserID); err != nil {
+			if errors.Is(err, service.ErrSoDViolation) {
+				return response.ErrorHandler(c, http.StatusConflict, "Conflict", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "group member added successfully",
+		})
+	}
+}
+
+func (h *GroupHandler) RemoveMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.groupUC.RemoveMember(c.Request().Context(), c.Param("id"), c.Param("user_id")); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "group member removed successfully",
+		})
+	}
+}
+
+func (h *GroupHandler) ListSubgroups() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		groups, err := h.groupUC.ListSubgroups(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "subgroups retrieved successfully",
+			Data:    newGroupResponses(groups),
+		})
+	}
+}
+
+// AddSubgroup nests a group. Nesting that would make a group contain
+// itself, or give its members roles a separation-of-duties constraint
+// forbids together, is rejected with 409.
+func (h *GroupHandler) AddSubgroup() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		groupID := c.Param("id")
+
+		req := &AddSubgroupRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.groupUC.AddSubgroup(c.Request().Context(), groupID, req.GroupID); err != nil {
+			h.logger.Warn("Failed to add subgroup", service.Fields{
+				"group_id":    groupID,
+				"subgroup_id": req.GroupID,
+				"error":       err.Error(),
+			})
+			if errors.Is(err, group.ErrNestingCycle) || errors.Is(err, service.ErrSoDViolation) {
+				return response.ErrorHandler(c, http.StatusConflict, "Conflict", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "subgroup added successfully",
+		})
+	}
+}
+
+func (h *GroupHandler) RemoveSubgroup() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.groupUC.RemoveSubgroup(c.Request().Context(), c.Param("id"), c.Param("subgroup_id")); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "subgroup removed successfully",
+		})
+	}
+}
+
+func (h *GroupHandler) GetRoles() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		roles, err := h.groupUC.GetRoles(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		resp := make([]*GroupRoleResponse, 0, len(roles))
+		for _, r := range roles {
+			resp = append(resp, &GroupRoleResponse{
+				ID:            r.ID,
+				Code:          r.Code,
+				ApplicationID: r.ApplicationID,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "group roles retrieved successfully",
+			Data:    resp,
+		})
+	}
+}
+
+// AssignRoles replaces the group's roles in one application
+func (h *GroupHandler) AssignRoles() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		groupID := c.Param("id")
+		appID := c.Param("app_id")
+
+		req := &AssignGroupRolesRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.groupUC.AssignRoles(c.Request().Context(), groupID, appID, req.Roles); err != nil {
+			h.logger.Warn("Failed to assign group roles", service.Fields{
+				"group_id": groupID,
+				"app_id":   appID,
+				"error":    err.Error(),
+			})
+			if errors.Is(err, service.ErrSoDViolation) {
+				return response.ErrorHandler(c, http.StatusConflict, "Conflict", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "roles assigned successfully",
+		})
+	}
+}
+
+func newGroupResponse(g *entity.Group) *GroupResponse {
+	return &GroupResponse{
+		ID:          g.ID,
+		Code:        g.Code,
+		Name:        g.Name,
+		Description: g.Description,
+		CreatedAt:   g.CreatedAt,
+	}
+}
+
+func newGroupResponses(groups []*entity.Group) []*GroupResponse {
+	resp := make([]*GroupResponse, 0, len(groups))
+	for _, g := range groups {
+		resp = append(resp, newGroupResponse(g))
+	}
+	return resp
+}
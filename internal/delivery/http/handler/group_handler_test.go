@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/internal/usecase/group"
+)
+
+// MockGroupUseCase is a mock implementation of group.Usecase
+type MockGroupUseCase struct {
+	CreateFunc         func(ctx context.Context, input *group.CreateInput) (*entity.Group, error)
+	DeleteFunc         func(ctx context.Context, id string) error
+	GetDetailFunc      func(ctx context.Context, id string) (*entity.Group, error)
+	GetListFunc        func(ctx context.Context, limit, offset int) ([]*entity.Group, error)
+	AddMemberFunc      func(ctx context.Context, groupID, userID string) error
+	RemoveMemberFunc   func(ctx context.Context, groupID, userID string) error
+	ListMembersFunc    func(ctx context.Context, groupID string) ([]*entity.GroupMember, error)
+	AddSubgroupFunc    func(ctx context.Context, groupID, subgroupID string) error
+	RemoveSubgroupFunc func(ctx context.Context, groupID, subgroupID string) error
+	ListSubgroupsFunc  func(ctx context.Context, groupID string) ([]*entity.Group, error)
+	AssignRolesFunc    func(ctx context.Context, groupID, appID string, roles []string) error
+	GetRolesFunc       func(ctx context.Context, groupID string) ([]*entity.Role, error)
+}
+
+func (m *MockGroupUseCase) Create(ctx context.Context, input *group.CreateInput) (*entity.Group, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) GetDetail(ctx context.Context, id string) (*entity.Group, error) {
+	if m.GetDetailFunc != nil {
+		return m.GetDetailFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) GetList(ctx context.Context, limit, offset int) ([]*entity.Group, error) {
+	if m.GetListFunc != nil {
+		return m.GetListFunc(ctx, limit, offset)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) AddMember(ctx context.Context, groupID, userID string) error {
+	if m.AddMemberFunc != nil {
+		return m.AddMemberFunc(ctx, groupID, userID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) RemoveMember(ctx context.Context, groupID, userID string) error {
+	if m.RemoveMemberFunc != nil {
+		return m.RemoveMemberFunc(ctx, groupID, userID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) ListMembers(ctx context.Context, groupID string) ([]*entity.GroupMember, error) {
+	if m.ListMembersFunc != nil {
+		return m.ListMembersFunc(ctx, groupID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) AddSubgroup(ctx context.Context, groupID, subgroupID string) error {
+	if m.AddSubgroupFunc != nil {
+		return m.AddSubgroupFunc(ctx, groupID, subgroupID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) RemoveSubgroup(ctx context.Context, groupID, subgroupID string) error {
+	if m.RemoveSubgroupFunc != nil {
+		return m.RemoveSubgroupFunc(ctx, groupID, subgroupID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) ListSubgroups(ctx context.Context, groupID string) ([]*entity.Group, error) {
+	if m.ListSubgroupsFunc != nil {
+		return m.ListSubgroupsFunc(ctx, groupID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) AssignRoles(ctx context.Context, groupID, appID string, roles []string) error {
+	if m.AssignRolesFunc != nil {
+		return m.AssignRolesFunc(ctx, groupID, appID, roles)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockGroupUseCase) GetRoles(ctx context.Context, groupID string) ([]*entity.Role, error) {
+	if m.GetRolesFunc != nil {
+		return m.GetRolesFunc(ctx, groupID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func serveGroup(handlerFunc echo.HandlerFunc, method, body string, params ...string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	var names, values []string
+	for i := 0; i+1 < len(params); i += 2 {
+		names = append(names, params[i])
+		values = append(values, params[i+1])
+	}
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	_ = handlerFunc(c)
+	return rec
+}
+
+func TestGroupHandler_AddSubgroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"success", nil, http.StatusOK},
+		{"cycle", group.ErrNestingCycle, http.StatusConflict},
+		{"separation of duties", fmt.Errorf("%w: constraint pay allows at most 1", service.ErrSoDViolation), http.StatusConflict},
+		{"unknown subgroup", errors.New("subgroup g-2 not found"), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotGroup, gotSubgroup string
+			mockUC := &MockGroupUseCase{
+				AddSubgroupFunc: func(ctx context.Context, groupID, subgroupID string) error {
+					gotGroup, gotSubgroup = groupID, subgroupID
+					return tt.err
+				},
+			}
+			handler := NewGroupHandler(mockUC, logger.New())
+
+			rec := serveGroup(handler.AddSubgroup(), http.MethodPost, `{"group_id":"g-2"}`, "id", "g-1")
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if gotGroup != "g-1" || gotSubgroup != "g-2" {
+				t.Errorf("Unexpected nesting: %s in %s", gotSubgroup, gotGroup)
+			}
+		})
+	}
+}
+
+func TestGroupHandler_AssignRoles(t *testing.T) {
+	var gotGroup, gotApp string
+	var gotRoles []string
+	mockUC := &MockGroupUseCase{
+		AssignRolesFunc: func(ctx context.Context, groupID, appID string, roles []string) error {
+			gotGroup, gotApp, gotRoles = groupID, appID, roles
+			return nil
+		},
+	}
+	handler := NewGroupHandler(mockUC, logger.New())
+
+	rec := serveGroup(handler.AssignRoles(), http.MethodPut, `{"roles":["editor"]}`, "id", "g-1", "app_id", "app-1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if gotGroup != "g-1" || gotApp != "app-1" || !reflect.DeepEqual(gotRoles, []string{"editor"}) {
+		t.Errorf("Unexpected assignment: %s %s %v", gotGroup, gotApp, gotRoles)
+	}
+}
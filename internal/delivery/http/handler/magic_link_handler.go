@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/magiclink"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	MagicLinkRequest struct {
+		Application string `json:"application"`
+		Email       string `json:"email"`
+	}
+
+	MagicLinkConsumeRequest struct {
+		Application string `json:"application"`
+		Token       string `json:"token"`
+		// Mode selects how the token is delivered, as for password login
+		Mode string `json:"mode"`
+	}
+)
+
+type MagicLinkHandler struct {
+	magicLinkUC magiclink.Usecase
+	cfg         *config.Config
+	logger      service.Logger
+}
+
+func NewMagicLinkHandler(uc magiclink.Usecase, cfg *config.Config, logger service.Logger) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkUC: uc,
+		cfg:         cfg,
+		logger:      logger,
+	}
+}
+
+// Request emails a login link. The response is the same whether or not
+// the email belongs to a user.
+func (h *MagicLinkHandler) Request() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &MagicLinkRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		err := h.magicLinkUC.RequestLink(c.Request().Context(), &magiclink.RequestInput{
+			Email:   req.Email,
+			AppCode: req.Application,
+		}, h.cfg)
+		if err != nil {
+			h.logger.Warn("Magic link request failed", service.Fields{
+				"error": err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "if the email is registered, a login link has been sent",
+		})
+	}
+}
+
+// Consume exchanges a login link token for access and refresh tokens
+func (h *MagicLinkHandler) Consume() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &MagicLinkConsumeRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		data, err := h.magicLinkUC.Consume(c.Request().Context(), &magiclink.ConsumeInput{
+			Token:   req.Token,
+			AppCode: req.Application,
+		}, h.cfg)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", err.Error())
+		}
+
+		resp, err := newLoginResponse(c, h.cfg, data, req.Mode)
+		if err != nil {
+			h.logger.Error("Failed to generate CSRF token", service.Fields{
+				"error": err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", "failed to start session")
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "user login successfully",
+			Data:    resp,
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	authUsecase "github.com/mafzaidi/authorizer/internal/usecase/auth"
+	"github.com/mafzaidi/authorizer/internal/usecase/magiclink"
+)
+
+// MockMagicLinkUseCase is a mock implementation of magiclink.Usecase
+type MockMagicLinkUseCase struct {
+	RequestLinkFunc func(ctx context.Context, input *magiclink.RequestInput, cfg *config.Config) error
+	ConsumeFunc     func(ctx context.Context, input *magiclink.ConsumeInput, cfg *config.Config) (*authUsecase.UserToken, error)
+}
+
+func (m *MockMagicLinkUseCase) RequestLink(ctx context.Context, input *magiclink.RequestInput, cfg *config.Config) error {
+	if m.RequestLinkFunc != nil {
+		return m.RequestLinkFunc(ctx, input, cfg)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockMagicLinkUseCase) Consume(ctx context.Context, input *magiclink.ConsumeInput, cfg *config.Config) (*authUsecase.UserToken, error) {
+	if m.ConsumeFunc != nil {
+		return m.ConsumeFunc(ctx, input, cfg)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func postJSON(handlerFunc echo.HandlerFunc, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	_ = handlerFunc(e.NewContext(req, rec))
+	return rec
+}
+
+func TestMagicLinkHandler_Request(t *testing.T) {
+	var got *magiclink.RequestInput
+	mockUC := &MockMagicLinkUseCase{
+		RequestLinkFunc: func(ctx context.Context, input *magiclink.RequestInput, cfg *config.Config) error {
+			got = input
+			return nil
+		},
+	}
+	handler := NewMagicLinkHandler(mockUC, &config.Config{}, logger.New())
+
+	rec := postJSON(handler.Request(), `{"application":"APP1","email":"user@example.com"}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got == nil || got.AppCode != "APP1" || got.Email != "user@example.com" {
+		t.Errorf("Unexpected request input: %+v", got)
+	}
+}
+
+func TestMagicLinkHandler_Consume_InvalidToken(t *testing.T) {
+	mockUC := &MockMagicLinkUseCase{
+		ConsumeFunc: func(ctx context.Context, input *magiclink.ConsumeInput, cfg *config.Config) (*authUsecase.UserToken, error) {
+			return nil, errors.New("invalid or expired login link")
+		},
+	}
+	handler := NewMagicLinkHandler(mockUC, &config.Config{}, logger.New())
+
+	rec := postJSON(handler.Consume(), `{"application":"APP1","token":"used"}`)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestMagicLinkHandler_Consume_Success(t *testing.T) {
+	mockUC := &MockMagicLinkUseCase{
+		ConsumeFunc: func(ctx context.Context, input *magiclink.ConsumeInput, cfg *config.Config) (*authUsecase.UserToken, error) {
+			if input.Token != "link-token" || input.AppCode != "APP1" {
+				t.Errorf("Unexpected consume input: %+v", input)
+			}
+			return &authUsecase.UserToken{
+				User:         &entity.User{FullName: "Test User"},
+				Token:        "access-token",
+				RefreshToken: "refresh-token",
+				Claims: &middleware.JWTClaims{
+					Username: "testuser",
+					RegisteredClaims: jwt.RegisteredClaims{
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+					},
+				},
+			}, nil
+		},
+	}
+	handler := NewMagicLinkHandler(mockUC, &config.Config{}, logger.New())
+
+	rec := postJSON(handler.Consume(), `{"application":"APP1","token":"link-token"}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "access-token") || !strings.Contains(rec.Body.String(), "refresh-token") {
+		t.Errorf("Expected tokens in response, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/oauth"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	// DeviceCodeRequest and TokenRequest accept both form-encoded bodies,
+	// as required by the OAuth specs, and JSON
+	DeviceCodeRequest struct {
+		ClientID string `form:"client_id" json:"client_id"`
+	}
+
+	TokenRequest struct {
+		GrantType  string `form:"grant_type" json:"grant_type"`
+		DeviceCode string `form:"device_code" json:"device_code"`
+		ClientID   string `form:"client_id" json:"client_id"`
+	}
+
+	DeviceCodeResponse struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+
+	TokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+	}
+
+	OAuthErrorResponse struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+	}
+
+	DeviceApprovalRequest struct {
+		UserCode string `json:"user_code" validate:"required"`
+		Approve  bool   `json:"approve"`
+	}
+
+	DeviceAuthorizationResponse struct {
+		UserCode    string    `json:"user_code"`
+		Application string    `json:"application"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}
+)
+
+type OAuthHandler struct {
+	oauthUC oauth.Usecase
+	cfg     *config.Config
+	logger  service.Logger
+}
+
+func NewOAuthHandler(uc oauth.Usecase, cfg *config.Config, logger service.Logger) *OAuthHandler {
+	return &OAuthHandler{
+		oauthUC: uc,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+// DeviceCode is the device authorization endpoint (RFC 8628 section 3.1)
+func (h *OAuthHandler) DeviceCode() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &DeviceCodeRequest{}
+		if err := c.Bind(req); err != nil {
+			return oauthError(c, &oauth.Error{Code: oauth.ErrCodeInvalidRequest, Description: err.Error()})
+		}
+
+		dc, err := h.oauthUC.RequestDeviceCode(c.Request().Context(), req.ClientID, h.cfg)
+		if err != nil {
+			return oauthError(c, err)
+		}
+
+		c.Response().Header().Set("Cache-Control", "no-store")
+		return c.JSON(http.StatusOK, &DeviceCodeResponse{
+			DeviceCode:              dc.DeviceCode,
+			UserCode:                dc.UserCode,
+			VerificationURI:         dc.VerificationURI,
+			VerificationURIComplete: dc.VerificationURIComplete,
+			ExpiresIn:               int(dc.ExpiresIn.Seconds()),
+			Interval:                int(dc.Interval.Seconds()),
+		})
+	}
+}
+
+// Token is the OAuth token endpoint. Only the device code grant is
+// supported.
+func (h *OAuthHandler) Token() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &TokenRequest{}
+		if err := c.Bind(req); err != nil {
+			return oauthError(c, &oauth.Error{Code: oauth.ErrCodeInvalidRequest, Description: err.Error()})
+		}
+
+		if req.GrantType != oauth.GrantTypeDeviceCode {
+			return oauthError(c, &oauth.Error{Code: oauth.ErrCodeUnsupportedGrantType})
+		}
+
+		token, err := h.oauthUC.PollDeviceToken(c.Request().Context(), req.DeviceCode, req.ClientID, h.cfg)
+		if err != nil {
+			return oauthError(c, err)
+		}
+
+		c.Response().Header().Set("Cache-Control", "no-store")
+		return c.JSON(http.StatusOK, &TokenResponse{
+			AccessToken:  token.Token,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(time.Until(token.Claims.ExpiresAt.Time).Seconds()),
+			RefreshToken: token.RefreshToken,
+		})
+	}
+}
+
+// GetDevice returns the application behind a user code so the
+// verification page can ask the logged-in user to confirm it
+func (h *OAuthHandler) GetDevice() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		da, err := h.oauthUC.GetDeviceAuthorization(c.Request().Context(), c.QueryParam("user_code"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data: &DeviceAuthorizationResponse{
+				UserCode:    c.QueryParam("user_code"),
+				Application: da.AppCode,
+				ExpiresAt:   da.ExpiresAt,
+			},
+		})
+	}
+}
+
+// ApproveDevice records the logged-in user's approval or denial. The
+// device receives a full user token, so personal access tokens and service
+// accounts cannot approve it.
+func (h *OAuthHandler) ApproveDevice() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		if claims.AuthMethod == middleware.AuthMethodPAT ||
+			(claims.PrincipalType != "" && claims.PrincipalType != entity.PrincipalTypeUser) {
+			return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", "only user sessions can approve devices")
+		}
+
+		req := &DeviceApprovalRequest{}
+		if err := c.Bind(req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.oauthUC.ApproveDevice(c.Request().Context(), req.UserCode, claims.UserID, req.Approve); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		message := "device denied"
+		if req.Approve {
+			message = "device approved"
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: message,
+		})
+	}
+}
+
+// oauthError writes an RFC 6749 error response
+func oauthError(c echo.Context, err error) error {
+	var oe *oauth.Error
+	if !errors.As(err, &oe) {
+		return c.JSON(http.StatusInternalServerError, &OAuthErrorResponse{
+			Error:            "server_error",
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	status := http.StatusBadRequest
+	if oe.Code == oauth.ErrCodeInvalidClient {
+		status = http.StatusUnauthorized
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(status, &OAuthErrorResponse{
+		Error:            oe.Code,
+		ErrorDescription: oe.Description,
+	})
+}
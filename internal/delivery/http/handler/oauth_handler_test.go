@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	authUsecase "github.com/mafzaidi/authorizer/internal/usecase/auth"
+	"github.com/mafzaidi/authorizer/internal/usecase/oauth"
+)
+
+// MockOAuthUseCase is a mock implementation of oauth.Usecase
+type MockOAuthUseCase struct {
+	ApproveDeviceFunc   func(ctx context.Context, userCode, userID string, approve bool) error
+	PollDeviceTokenFunc func(ctx context.Context, deviceCode, clientID string, cfg *config.Config) (*authUsecase.UserToken, error)
+}
+
+func (m *MockOAuthUseCase) RequestDeviceCode(ctx context.Context, clientID string, cfg *config.Config) (*oauth.DeviceCode, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOAuthUseCase) GetDeviceAuthorization(ctx context.Context, userCode string) (*entity.DeviceAuthorization, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOAuthUseCase) ApproveDevice(ctx context.Context, userCode, userID string, approve bool) error {
+	if m.ApproveDeviceFunc != nil {
+		return m.ApproveDeviceFunc(ctx, userCode, userID, approve)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockOAuthUseCase) PollDeviceToken(ctx context.Context, deviceCode, clientID string, cfg *config.Config) (*authUsecase.UserToken, error) {
+	if m.PollDeviceTokenFunc != nil {
+		return m.PollDeviceTokenFunc(ctx, deviceCode, clientID, cfg)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func postTokenForm(h *OAuthHandler, form url.Values) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	_ = h.Token()(e.NewContext(req, rec))
+	return rec
+}
+
+func TestOAuthHandler_Token_UnsupportedGrant(t *testing.T) {
+	handler := NewOAuthHandler(&MockOAuthUseCase{}, &config.Config{}, logger.New())
+
+	rec := postTokenForm(handler, url.Values{"grant_type": {"password"}})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	var body OAuthErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if body.Error != oauth.ErrCodeUnsupportedGrantType {
+		t.Errorf("Expected error %q, got %q", oauth.ErrCodeUnsupportedGrantType, body.Error)
+	}
+}
+
+func TestOAuthHandler_Token_PollingErrors(t *testing.T) {
+	for _, code := range []string{oauth.ErrCodeAuthorizationPending, oauth.ErrCodeSlowDown, oauth.ErrCodeExpiredToken} {
+		t.Run(code, func(t *testing.T) {
+			mockUC := &MockOAuthUseCase{
+				PollDeviceTokenFunc: func(ctx context.Context, deviceCode, clientID string, cfg *config.Config) (*authUsecase.UserToken, error) {
+					return nil, &oauth.Error{Code: code}
+				},
+			}
+			handler := NewOAuthHandler(mockUC, &config.Config{}, logger.New())
+
+			rec := postTokenForm(handler, url.Values{
+				"grant_type":  {oauth.GrantTypeDeviceCode},
+				"device_code": {"device-code"},
+				"client_id":   {"APP1"},
+			})
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+			}
+
+			var body OAuthErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if body.Error != code {
+				t.Errorf("Expected error %q, got %q", code, body.Error)
+			}
+		})
+	}
+}
+
+func TestOAuthHandler_Token_Success(t *testing.T) {
+	mockUC := &MockOAuthUseCase{
+		PollDeviceTokenFunc: func(ctx context.Context, deviceCode, clientID string, cfg *config.Config) (*authUsecase.UserToken, error) {
+			if deviceCode != "device-code" || clientID != "APP1" {
+				t.Errorf("Unexpected device code %q or client %q", deviceCode, clientID)
+			}
+			return &authUsecase.UserToken{
+				Token: "access-token",
+				Claims: &middleware.JWTClaims{
+					RegisteredClaims: jwt.RegisteredClaims{
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+					},
+				},
+			}, nil
+		},
+	}
+	handler := NewOAuthHandler(mockUC, &config.Config{}, logger.New())
+
+	rec := postTokenForm(handler, url.Values{
+		"grant_type":  {oauth.GrantTypeDeviceCode},
+		"device_code": {"device-code"},
+		"client_id":   {"APP1"},
+	})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var body TokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if body.AccessToken != "access-token" || body.TokenType != "Bearer" {
+		t.Errorf("Unexpected token response: %+v", body)
+	}
+	if body.ExpiresIn <= 0 {
+		t.Errorf("Expected positive expires_in, got %d", body.ExpiresIn)
+	}
+}
+
+func TestOAuthHandler_ApproveDevice(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   *middleware.JWTClaims
+		wantCode int
+	}{
+		{"user session", &middleware.JWTClaims{UserID: "user-123", AuthMethod: middleware.AuthMethodJWT}, http.StatusOK},
+		{"personal access token", &middleware.JWTClaims{UserID: "user-123", AuthMethod: middleware.AuthMethodPAT}, http.StatusForbidden},
+		{"service account", &middleware.JWTClaims{UserID: "sa-1", PrincipalType: entity.PrincipalTypeServiceAccount}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approved := false
+			mockUC := &MockOAuthUseCase{
+				ApproveDeviceFunc: func(ctx context.Context, userCode, userID string, approve bool) error {
+					approved = true
+					return nil
+				},
+			}
+			handler := NewOAuthHandler(mockUC, &config.Config{}, logger.New())
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/oauth/device", strings.NewReader(`{"user_code":"ABCD-EFGH","approve":true}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("user_claims", tt.claims)
+
+			_ = handler.ApproveDevice()(c)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if approved != (tt.wantCode == http.StatusOK) {
+				t.Errorf("Expected approval recorded to be %v", tt.wantCode == http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/usecase/organization"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	CreateOrganizationRequest struct {
+		Code string `json:"code" validate:"required"`
+		Name string `json:"name" validate:"required"`
+	}
+
+	AddOrganizationMemberRequest struct {
+		UserID string `json:"user_id" validate:"required"`
+	}
+
+	AssignOrganizationRolesRequest struct {
+		Roles []string `json:"roles"`
+	}
+
+	GetOrganizationListQuery struct {
+		Page  int `query:"page"`
+		Limit int `query:"limit"`
+	}
+
+	OrganizationResponse struct {
+		ID        string    `json:"id"`
+		Code      string    `json:"code"`
+		Name      string    `json:"name"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	OrganizationMemberResponse struct {
+		UserID   string    `json:"user_id"`
+		JoinedAt time.Time `json:"joined_at"`
+	}
+)
+
+type OrganizationHandler struct {
+	orgUC  organization.Usecase
+	logger service.Logger
+}
+
+func NewOrganizationHandler(uc organization.Usecase, logger service.Logger) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgUC:  uc,
+		logger: logger,
+	}
+}
+
+func (h *OrganizationHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &CreateOrganizationRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		org, err := h.orgUC.Create(c.Request().Context(), &organization.CreateInput{
+			Code: req.Code,
+			Name: req.Name,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "organization created successfully",
+			Data:    newOrganizationResponse(org),
+		})
+	}
+}
+
+func (h *OrganizationHandler) GetList() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := GetOrganizationListQuery{}
+		if err := c.Bind(&query); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		page := query.Page
+		if page <= 0 {
+			page = 1
+		}
+		limit := query.Limit
+		if limit <= 0 {
+			limit = 50
+		}
+
+		orgs, err := h.orgUC.GetList(c.Request().Context(), limit, (page-1)*limit)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "organizations retrieved successfully",
+			Data:    newOrganizationResponses(orgs),
+		})
+	}
+}
+
+func (h *OrganizationHandler) GetDetail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		org, err := h.orgUC.GetDetail(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "organization retrieved successfully",
+			Data:    newOrganizationResponse(org),
+		})
+	}
+}
+
+// ListMine lists the organizations the caller is a member of, the ones
+// they can log in to or exchange their token for
+func (h *OrganizationHandler) ListMine() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := middleware.GetUserFromContext(c)
+		if claims == nil {
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "missing user claims")
+		}
+
+		orgs, err := h.orgUC.ListByUser(c.Request().Context(), claims.UserID)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "organizations retrieved successfully",
+			Data:    newOrganizationResponses(orgs),
+		})
+	}
+}
+
+func (h *OrganizationHandler) ListMembers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		members, err := h.orgUC.ListMembers(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		resp := make([]*OrganizationMemberResponse, 0, len(members))
+		for _, m := range members {
+			resp = append(resp, &OrganizationMemberResponse{
+				UserID:   m.UserID,
+				JoinedAt: m.CreatedAt,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "organization members retrieved successfully",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *OrganizationHandler) AddMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &AddOrganizationMemberRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.orgUC.AddMember(c.Request().Context(), c.Param("id"), req.UserID); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "organization member added successfully",
+		})
+	}
+}
+
+func (h *OrganizationHandler) RemoveMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.orgUC.RemoveMember(c.Request().Context(), c.Param("id"), c.Param("user_id")); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "organization member removed successfully",
+		})
+	}
+}
+
+// AssignRoles replaces a member's tenant roles in one application of the
+// organization
+func (h *OrganizationHandler) AssignRoles() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		orgID := c.Param("id")
+		userID := c.Param("user_id")
+		appID := c.Param("app_id")
+
+		req := &AssignOrganizationRolesRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.orgUC.AssignRoles(c.Request().Context(), orgID, userID, appID, req.Roles); err != nil {
+			h.logger.Warn("Failed to assign organization roles", service.Fields{
+				"org_id":  orgID,
+				"user_id": userID,
+				"app_id":  appID,
+				"error":   err.Error(),
+			})
+			if errors.Is(err, service.ErrSoDViolation) {
+				return response.ErrorHandler(c, http.StatusConflict, "Conflict", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "roles assigned successfully",
+		})
+	}
+}
+
+func newOrganizationResponse(org *entity.Organization) *OrganizationResponse {
+	return &OrganizationResponse{
+		ID:        org.ID,
+		Code:      org.Code,
+		Name:      org.Name,
+		CreatedAt: org.CreatedAt,
+	}
+}
+
+func newOrganizationResponses(orgs []*entity.Organization) []*OrganizationResponse {
+	resp := make([]*OrganizationResponse, 0, len(orgs))
+	for _, o := range orgs {
+		resp = append(resp, newOrganizationResponse(o))
+	}
+	return resp
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/internal/usecase/organization"
+)
+
+// MockOrganizationUseCase is a mock implementation of organization.Usecase
+type MockOrganizationUseCase struct {
+	CreateFunc       func(ctx context.Context, input *organization.CreateInput) (*entity.Organization, error)
+	GetDetailFunc    func(ctx context.Context, id string) (*entity.Organization, error)
+	GetListFunc      func(ctx context.Context, limit, offset int) ([]*entity.Organization, error)
+	ListByUserFunc   func(ctx context.Context, userID string) ([]*entity.Organization, error)
+	AddMemberFunc    func(ctx context.Context, orgID, userID string) error
+	RemoveMemberFunc func(ctx context.Context, orgID, userID string) error
+	ListMembersFunc  func(ctx context.Context, orgID string) ([]*entity.OrganizationMember, error)
+	AssignRolesFunc  func(ctx context.Context, orgID, userID, appID string, roles []string) error
+}
+
+func (m *MockOrganizationUseCase) Create(ctx context.Context, input *organization.CreateInput) (*entity.Organization, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOrganizationUseCase) GetDetail(ctx context.Context, id string) (*entity.Organization, error) {
+	if m.GetDetailFunc != nil {
+		return m.GetDetailFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOrganizationUseCase) GetList(ctx context.Context, limit, offset int) ([]*entity.Organization, error) {
+	if m.GetListFunc != nil {
+		return m.GetListFunc(ctx, limit, offset)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOrganizationUseCase) ListByUser(ctx context.Context, userID string) ([]*entity.Organization, error) {
+	if m.ListByUserFunc != nil {
+		return m.ListByUserFunc(ctx, userID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOrganizationUseCase) AddMember(ctx context.Context, orgID, userID string) error {
+	if m.AddMemberFunc != nil {
+		return m.AddMemberFunc(ctx, orgID, userID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockOrganizationUseCase) RemoveMember(ctx context.Context, orgID, userID string) error {
+	if m.RemoveMemberFunc != nil {
+		return m.RemoveMemberFunc(ctx, orgID, userID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockOrganizationUseCase) ListMembers(ctx context.Context, orgID string) ([]*entity.OrganizationMember, error) {
+	if m.ListMembersFunc != nil {
+		return m.ListMembersFunc(ctx, orgID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOrganizationUseCase) AssignRoles(ctx context.Context, orgID, userID, appID string, roles []string) error {
+	if m.AssignRolesFunc != nil {
+		return m.AssignRolesFunc(ctx, orgID, userID, appID, roles)
+	}
+	return errors.New("not implemented")
+}
+
+func TestOrganizationHandler_AssignRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"success", nil, http.StatusOK},
+		{"not a member", errors.New("user is not a member of the organization"), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOrg, gotUser, gotApp string
+			var gotRoles []string
+			mockUC := &MockOrganizationUseCase{
+				AssignRolesFunc: func(ctx context.Context, orgID, userID, appID string, roles []string) error {
+					gotOrg, gotUser, gotApp, gotRoles = orgID, userID, appID, roles
+					return tt.err
+				},
+			}
+			handler := NewOrganizationHandler(mockUC, logger.New())
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"roles":["role-1","role-2"]}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id", "user_id", "app_id")
+			c.SetParamValues("org-1", "user-1", "app-1")
+
+			_ = handler.AssignRoles()(c)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if gotOrg != "org-1" || gotUser != "user-1" || gotApp != "app-1" {
+				t.Errorf("Unexpected params: %s %s %s", gotOrg, gotUser, gotApp)
+			}
+			if !reflect.DeepEqual(gotRoles, []string{"role-1", "role-2"}) {
+				t.Errorf("Unexpected roles: %v", gotRoles)
+			}
+		})
+	}
+}
+
+func TestOrganizationHandler_ListMine(t *testing.T) {
+	var gotUser string
+	mockUC := &MockOrganizationUseCase{
+		ListByUserFunc: func(ctx context.Context, userID string) ([]*entity.Organization, error) {
+			gotUser = userID
+			return []*entity.Organization{{ID: "org-1", Code: "ACME", Name: "Acme"}}, nil
+		},
+	}
+	handler := NewOrganizationHandler(mockUC, logger.New())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/auth/organizations", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_claims", &middleware.JWTClaims{UserID: "user-123"})
+
+	_ = handler.ListMine()(c)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if gotUser != "user-123" {
+		t.Errorf("Expected organizations of user-123, got %q", gotUser)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"ACME"`) {
+		t.Errorf("Expected ACME in response, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/usecase/relation"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	SchemaRequest struct {
+		Application string          `json:"application"`
+		Schema      json.RawMessage `json:"schema"`
+	}
+
+	SchemaResponse struct {
+		Application string          `json:"application"`
+		Schema      json.RawMessage `json:"schema"`
+		Version     int             `json:"version"`
+	}
+
+	// TuplesRequest carries tuples written as
+	// namespace:object_id#relation@subject
+	TuplesRequest struct {
+		Application string   `json:"application"`
+		Tuples      []string `json:"tuples"`
+	}
+
+	RelationCheckRequest struct {
+		Application string `json:"application"`
+		Object      string `json:"object"`
+		Relation    string `json:"relation"`
+		Subject     string `json:"subject"`
+	}
+
+	RelationCheckResponse struct {
+		Allowed bool `json:"allowed"`
+	}
+
+	ExpandRequest struct {
+		Application string `json:"application"`
+		Object      string `json:"object"`
+		Relation    string `json:"relation"`
+	}
+
+	ListObjectsRequest struct {
+		Application string `json:"application"`
+		Namespace   string `json:"namespace"`
+		Relation    string `json:"relation"`
+		Subject     string `json:"subject"`
+	}
+
+	ListObjectsResponse struct {
+		Objects []string `json:"objects"`
+	}
+)
+
+type RelationHandler struct {
+	relationUC relation.Usecase
+	logger     service.Logger
+}
+
+func NewRelationHandler(uc relation.Usecase, logger service.Logger) *RelationHandler {
+	return &RelationHandler{
+		relationUC: uc,
+		logger:     logger,
+	}
+}
+
+func (h *RelationHandler) SetSchema() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &SchemaRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		out, err := h.relationUC.SetSchema(c.Request().Context(), req.Application, req.Schema)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "relation schema saved",
+			Data:    newSchemaResponse(out),
+		})
+	}
+}
+
+func (h *RelationHandler) GetSchema() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		out, err := h.relationUC.GetSchema(c.Request().Context(), c.QueryParam("application"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "relation schema retrieved",
+			Data:    newSchemaResponse(out),
+		})
+	}
+}
+
+func (h *RelationHandler) WriteTuples() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &TuplesRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		err := h.relationUC.WriteTuples(c.Request().Context(), &relation.TuplesInput{
+			AppCode: req.Application,
+			Tuples:  req.Tuples,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "relation tuples written",
+		})
+	}
+}
+
+func (h *RelationHandler) DeleteTuples() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &TuplesRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		err := h.relationUC.DeleteTuples(c.Request().Context(), &relation.TuplesInput{
+			AppCode: req.Application,
+			Tuples:  req.Tuples,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "relation tuples deleted",
+		})
+	}
+}
+
+// Check answers whether a subject has a relation to an object. As with
+// permission checks, a denial is a successful response.
+func (h *RelationHandler) Check() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &RelationCheckRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		allowed, err := h.relationUC.Check(c.Request().Context(), &relation.CheckInput{
+			AppCode:  req.Application,
+			Object:   req.Object,
+			Relation: req.Relation,
+			Subject:  req.Subject,
+		})
+		if err != nil {
+			return relationError(c, err)
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "relation checked",
+			Data:    &RelationCheckResponse{Allowed: allowed},
+		})
+	}
+}
+
+func (h *RelationHandler) Expand() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &ExpandRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		tree, err := h.relationUC.Expand(c.Request().Context(), &relation.ExpandInput{
+			AppCode:  req.Application,
+			Object:   req.Object,
+			Relation: req.Relation,
+		})
+		if err != nil {
+			return relationError(c, err)
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "relation expanded",
+			Data:    tree,
+		})
+	}
+}
+
+func (h *RelationHandler) ListObjects() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &ListObjectsRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		ids, err := h.relationUC.ListObjects(c.Request().Context(), &relation.ListObjectsInput{
+			AppCode:   req.Application,
+			Namespace: req.Namespace,
+			Relation:  req.Relation,
+			Subject:   req.Subject,
+		})
+		if err != nil {
+			return relationError(c, err)
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "objects listed",
+			Data:    &ListObjectsResponse{Objects: ids},
+		})
+	}
+}
+
+func relationError(c echo.Context, err error) error {
+	if errors.Is(err, relation.ErrEvaluationFailed) {
+		return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+	}
+	return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+}
+
+func newSchemaResponse(out *relation.SchemaOutput) *SchemaResponse {
+	return &SchemaResponse{
+		Application: out.AppCode,
+		Schema:      out.Definition,
+		Version:     out.Version,
+	}
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/internal/usecase/relation"
+	rebac "github.com/mafzaidi/authorizer/pkg/relation"
+)
+
+// MockRelationUseCase is a mock implementation of relation.Usecase
+type MockRelationUseCase struct {
+	SetSchemaFunc    func(ctx context.Context, appCode string, definition json.RawMessage) (*relation.SchemaOutput, error)
+	GetSchemaFunc    func(ctx context.Context, appCode string) (*relation.SchemaOutput, error)
+	WriteTuplesFunc  func(ctx context.Context, input *relation.TuplesInput) error
+	DeleteTuplesFunc func(ctx context.Context, input *relation.TuplesInput) error
+	CheckFunc        func(ctx context.Context, input *relation.CheckInput) (bool, error)
+	ExpandFunc       func(ctx context.Context, input *relation.ExpandInput) (*rebac.Tree, error)
+	ListObjectsFunc  func(ctx context.Context, input *relation.ListObjectsInput) ([]string, error)
+}
+
+func (m *MockRelationUseCase) SetSchema(ctx context.Context, appCode string, definition json.RawMessage) (*relation.SchemaOutput, error) {
+	if m.SetSchemaFunc != nil {
+		return m.SetSchemaFunc(ctx, appCode, definition)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockRelationUseCase) GetSchema(ctx context.Context, appCode string) (*relation.SchemaOutput, error) {
+	if m.GetSchemaFunc != nil {
+		return m.GetSchemaFunc(ctx, appCode)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockRelationUseCase) WriteTuples(ctx context.Context, input *relation.TuplesInput) error {
+	if m.WriteTuplesFunc != nil {
+		return m.WriteTuplesFunc(ctx, input)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockRelationUseCase) DeleteTuples(ctx context.Context, input *relation.TuplesInput) error {
+	if m.DeleteTuplesFunc != nil {
+		return m.DeleteTuplesFunc(ctx, input)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockRelationUseCase) Check(ctx context.Context, input *relation.CheckInput) (bool, error) {
+	if m.CheckFunc != nil {
+		return m.CheckFunc(ctx, input)
+	}
+	return false, errors.New("not implemented")
+}
+
+func (m *MockRelationUseCase) Expand(ctx context.Context, input *relation.ExpandInput) (*rebac.Tree, error) {
+	if m.ExpandFunc != nil {
+		return m.ExpandFunc(ctx, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockRelationUseCase) ListObjects(ctx context.Context, input *relation.ListObjectsInput) ([]string, error) {
+	if m.ListObjectsFunc != nil {
+		return m.ListObjectsFunc(ctx, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func TestRelationHandler_WriteTuples(t *testing.T) {
+	var got *relation.TuplesInput
+	mockUC := &MockRelationUseCase{
+		WriteTuplesFunc: func(ctx context.Context, input *relation.TuplesInput) error {
+			got = input
+			return nil
+		},
+	}
+	handler := NewRelationHandler(mockUC, logger.New())
+
+	rec := postJSON(handler.WriteTuples(), `{"application":"APP1","tuples":["document:1#owner@user:alice"]}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got == nil || got.AppCode != "APP1" || !reflect.DeepEqual(got.Tuples, []string{"document:1#owner@user:alice"}) {
+		t.Errorf("Unexpected tuples input: %+v", got)
+	}
+}
+
+func TestRelationHandler_Check(t *testing.T) {
+	var got *relation.CheckInput
+	mockUC := &MockRelationUseCase{
+		CheckFunc: func(ctx context.Context, input *relation.CheckInput) (bool, error) {
+			got = input
+			return true, nil
+		},
+	}
+	handler := NewRelationHandler(mockUC, logger.New())
+
+	rec := postJSON(handler.Check(), `{"application":"APP1","object":"document:1","relation":"viewer","subject":"user:alice"}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got == nil || got.Object != "document:1" || got.Relation != "viewer" || got.Subject != "user:alice" {
+		t.Errorf("Unexpected check input: %+v", got)
+	}
+
+	var body struct {
+		Data RelationCheckResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !body.Data.Allowed {
+		t.Errorf("Expected allowed, got %+v", body.Data)
+	}
+}
+
+func TestRelationHandler_Check_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"invalid input", errors.New("relation document#admin is not defined"), http.StatusBadRequest},
+		{"limit exceeded", rebac.ErrLimitExceeded, http.StatusBadRequest},
+		{"evaluation failure", relation.ErrEvaluationFailed, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUC := &MockRelationUseCase{
+				CheckFunc: func(ctx context.Context, input *relation.CheckInput) (bool, error) {
+					return false, tt.err
+				},
+			}
+			handler := NewRelationHandler(mockUC, logger.New())
+
+			rec := postJSON(handler.Check(), `{"application":"APP1","object":"document:1","relation":"admin","subject":"user:alice"}`)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRelationHandler_ListObjects(t *testing.T) {
+	mockUC := &MockRelationUseCase{
+		ListObjectsFunc: func(ctx context.Context, input *relation.ListObjectsInput) ([]string, error) {
+			if input.Namespace != "document" || input.Relation != "viewer" || input.Subject != "user:bob" {
+				t.Errorf("Unexpected list input: %+v", input)
+			}
+			return []string{"1", "3"}, nil
+		},
+	}
+	handler := NewRelationHandler(mockUC, logger.New())
+
+	rec := postJSON(handler.ListObjects(), `{"application":"APP1","namespace":"document","relation":"viewer","subject":"user:bob"}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var body struct {
+		Data ListObjectsResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !reflect.DeepEqual(body.Data.Objects, []string{"1", "3"}) {
+		t.Errorf("Unexpected objects: %v", body.Data.Objects)
+	}
+}
@@ -2,9 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
 	"github.com/mafzaidi/authorizer/internal/usecase/role"
 	"github.com/mafzaidi/authorizer/pkg/response"
@@ -16,10 +18,50 @@ type (
 		Code        string `json:"code" validate:"required"`
 		Name        string `json:"name" validate:"required"`
 		Description string `json:"description" validate:"required"`
+		// Scope is APPLICATION (default), GLOBAL or TENANT
+		Scope string `json:"scope"`
 	}
 
 	GrantRolePermissionsRequest struct {
+		// Perms are permission codes of the role's application. Global
+		// roles take APP_CODE:permission.code instead.
 		Perms []string `json:"permissions" validate:"required"`
+		// Conditions maps a permission code of Perms to the expression the
+		// grant holds under
+		Conditions map[string]string `json:"conditions"`
+	}
+
+	SetRoleParentsRequest struct {
+		// ParentIDs replace the role's parents; an empty list removes them
+		ParentIDs []string `json:"parent_ids"`
+	}
+
+	RolePermissionsResponse struct {
+		RoleID    string                     `json:"role_id"`
+		RoleCode  string                     `json:"role_code"`
+		Parents   []*RoleParentResponse      `json:"parents"`
+		Direct    []string                   `json:"direct"`
+		Inherited []*InheritedPermissionItem `json:"inherited"`
+		// Conditions maps conditional direct grants to their expression
+		Conditions map[string]string `json:"conditions,omitempty"`
+	}
+
+	RoleParentResponse struct {
+		ID   string `json:"id"`
+		Code string `json:"code"`
+	}
+
+	InheritedPermissionItem struct {
+		Permission   string `json:"permission"`
+		FromRoleID   string `json:"from_role_id"`
+		FromRoleCode string `json:"from_role_code"`
+		Depth        int    `json:"depth"`
+		Condition    string `json:"condition,omitempty"`
+	}
+
+	ConditionResponse struct {
+		ID         string `json:"id"`
+		Expression string `json:"expression"`
 	}
 )
 
@@ -51,6 +93,7 @@ func (h *RoleHandler) Create() echo.HandlerFunc {
 			Code:        req.Code,
 			Name:        req.Name,
 			Description: req.Description,
+			Scope:       req.Scope,
 		}
 
 		if err := h.roleUC.Create(c.Request().Context(), in); err != nil {
@@ -88,7 +131,7 @@ func (h *RoleHandler) GrantRolePermissions() echo.HandlerFunc {
 
 		perms := req.Perms
 
-		if err := h.roleUC.GrantPerms(c.Request().Context(), roleID, perms); err != nil {
+		if err := h.roleUC.GrantPerms(c.Request().Context(), roleID, perms, req.Conditions); err != nil {
 			h.logger.Error("Failed to grant role permissions", logger.Fields{
 				"role_id":     roleID,
 				"permissions": perms,
@@ -107,3 +150,97 @@ func (h *RoleHandler) GrantRolePermissions() echo.HandlerFunc {
 		})
 	}
 }
+
+func (h *RoleHandler) SetRoleParents() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		roleID := c.Param("id")
+		req := &SetRoleParentsRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			h.logger.Warn("Failed to decode set role parents request", logger.Fields{
+				"role_id": roleID,
+				"error":   err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.roleUC.SetParents(c.Request().Context(), roleID, req.ParentIDs); err != nil {
+			h.logger.Error("Failed to set role parents", logger.Fields{
+				"role_id":    roleID,
+				"parent_ids": req.ParentIDs,
+				"error":      err.Error(),
+			})
+			if errors.Is(err, role.ErrHierarchyCycle) || errors.Is(err, service.ErrSoDViolation) {
+				return response.ErrorHandler(c, http.StatusConflict, "Conflict", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "role parents set successfully",
+		})
+	}
+}
+
+func (h *RoleHandler) GetRolePermissions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		roleID := c.Param("id")
+
+		out, err := h.roleUC.GetEffectivePerms(c.Request().Context(), roleID)
+		if err != nil {
+			h.logger.Error("Failed to get role permissions", logger.Fields{
+				"role_id": roleID,
+				"error":   err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		resp := &RolePermissionsResponse{
+			RoleID:     out.RoleID,
+			RoleCode:   out.RoleCode,
+			Parents:    make([]*RoleParentResponse, 0, len(out.Parents)),
+			Direct:     out.Direct,
+			Inherited:  make([]*InheritedPermissionItem, 0, len(out.Inherited)),
+			Conditions: out.Conditions,
+		}
+		for _, p := range out.Parents {
+			resp.Parents = append(resp.Parents, &RoleParentResponse{ID: p.ID, Code: p.Code})
+		}
+		for _, p := range out.Inherited {
+			resp.Inherited = append(resp.Inherited, &InheritedPermissionItem{
+				Permission:   p.Code,
+				FromRoleID:   p.FromRoleID,
+				FromRoleCode: p.FromRoleCode,
+				Depth:        p.Depth,
+				Condition:    p.Condition,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "role permissions retrieved successfully",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *RoleHandler) GetCondition() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Param("id")
+
+		cond, err := h.roleUC.GetCondition(c.Request().Context(), id)
+		if err != nil {
+			h.logger.Warn("Failed to get grant condition", logger.Fields{
+				"condition_id": id,
+				"error":        err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", "condition not found")
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "condition retrieved successfully",
+			Data: &ConditionResponse{
+				ID:         cond.ID,
+				Expression: cond.Expression,
+			},
+		})
+	}
+}
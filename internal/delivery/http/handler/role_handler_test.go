@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/internal/usecase/role"
+)
+
+// MockRoleUseCase is a mock implementation of role.Usecase
+type MockRoleUseCase struct {
+	CreateFunc            func(ctx context.Context, input *role.CreateInput) error
+	GrantPermsFunc        func(ctx context.Context, roleID string, perms []string, conditions map[string]string) error
+	SetParentsFunc        func(ctx context.Context, roleID string, parentIDs []string) error
+	GetEffectivePermsFunc func(ctx context.Context, roleID string) (*role.EffectivePermsOutput, error)
+	GetConditionFunc      func(ctx context.Context, id string) (*entity.GrantCondition, error)
+}
+
+func (m *MockRoleUseCase) Create(ctx context.Context, input *role.CreateInput) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, input)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockRoleUseCase) GrantPerms(ctx context.Context, roleID string, perms []string, conditions map[string]string) error {
+	if m.GrantPermsFunc != nil {
+		return m.GrantPermsFunc(ctx, roleID, perms, conditions)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockRoleUseCase) SetParents(ctx context.Context, roleID string, parentIDs []string) error {
+	if m.SetParentsFunc != nil {
+		return m.SetParentsFunc(ctx, roleID, parentIDs)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockRoleUseCase) GetEffectivePerms(ctx context.Context, roleID string) (*role.EffectivePermsOutput, error) {
+	if m.GetEffectivePermsFunc != nil {
+		return m.GetEffectivePermsFunc(ctx, roleID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockRoleUseCase) GetCondition(ctx context.Context, id string) (*entity.GrantCondition, error) {
+	if m.GetConditionFunc != nil {
+		return m.GetConditionFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+// serveRole runs a role handler for the role with the given ID
+func serveRole(handlerFunc echo.HandlerFunc, method, roleID, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(roleID)
+	_ = handlerFunc(c)
+	return rec
+}
+
+func TestRoleHandler_SetRoleParents(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"success", nil, http.StatusOK},
+		{"cycle", role.ErrHierarchyCycle, http.StatusConflict},
+		{"separation of duties", fmt.Errorf("%w: constraint pay allows at most 1", service.ErrSoDViolation), http.StatusConflict},
+		{"failure", errors.New("parent role r-9 not found"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRole string
+			var gotParents []string
+			mockUC := &MockRoleUseCase{
+				SetParentsFunc: func(ctx context.Context, roleID string, parentIDs []string) error {
+					gotRole, gotParents = roleID, parentIDs
+					return tt.err
+				},
+			}
+			handler := NewRoleHandler(mockUC, logger.New())
+
+			rec := serveRole(handler.SetRoleParents(), http.MethodPut, "role-admin", `{"parent_ids":["role-editor"]}`)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if gotRole != "role-admin" || len(gotParents) != 1 || gotParents[0] != "role-editor" {
+				t.Errorf("Unexpected input: role %q, parents %v", gotRole, gotParents)
+			}
+		})
+	}
+}
+
+func TestRoleHandler_GetRolePermissions(t *testing.T) {
+	mockUC := &MockRoleUseCase{
+		GetEffectivePermsFunc: func(ctx context.Context, roleID string) (*role.EffectivePermsOutput, error) {
+			return &role.EffectivePermsOutput{
+				RoleID:   roleID,
+				RoleCode: "admin",
+				Parents:  []*role.ParentRole{{ID: "role-editor", Code: "editor"}},
+				Direct:   []string{"user.delete"},
+				Inherited: []*role.InheritedPerm{
+					{Code: "user.update", FromRoleID: "role-editor", FromRoleCode: "editor", Depth: 1},
+					{Code: "user.read", FromRoleID: "role-viewer", FromRoleCode: "viewer", Depth: 2},
+				},
+			}, nil
+		},
+	}
+	handler := NewRoleHandler(mockUC, logger.New())
+
+	rec := serveRole(handler.GetRolePermissions(), http.MethodGet, "role-admin", "")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var body struct {
+		Data RolePermissionsResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Data.RoleID != "role-admin" || len(body.Data.Parents) != 1 || body.Data.Parents[0].Code != "editor" {
+		t.Errorf("Unexpected role: %+v", body.Data)
+	}
+	if len(body.Data.Direct) != 1 || body.Data.Direct[0] != "user.delete" {
+		t.Errorf("Unexpected direct permissions: %v", body.Data.Direct)
+	}
+	if len(body.Data.Inherited) != 2 || body.Data.Inherited[1].FromRoleCode != "viewer" || body.Data.Inherited[1].Depth != 2 {
+		t.Errorf("Unexpected inherited permissions: %+v", body.Data.Inherited)
+	}
+}
+
+func TestRoleHandler_GrantRolePermissions_Conditions(t *testing.T) {
+	var gotPerms []string
+	var gotConds map[string]string
+	mockUC := &MockRoleUseCase{
+		GrantPermsFunc: func(ctx context.Context, roleID string, perms []string, conditions map[string]string) error {
+			gotPerms, gotConds = perms, conditions
+			return nil
+		},
+	}
+	handler := NewRoleHandler(mockUC, logger.New())
+
+	body := `{"permissions":["doc.read","doc.update"],"conditions":{"doc.update":"resource.owner == subject.id"}}`
+	rec := serveRole(handler.GrantRolePermissions(), http.MethodPost, "role-editor", body)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if len(gotPerms) != 2 || gotConds["doc.update"] != "resource.owner == subject.id" {
+		t.Errorf("Unexpected input: perms %v, conditions %v", gotPerms, gotConds)
+	}
+}
+
+func TestRoleHandler_GetCondition(t *testing.T) {
+	mockUC := &MockRoleUseCase{
+		GetConditionFunc: func(ctx context.Context, id string) (*entity.GrantCondition, error) {
+			if id != "cond_abc" {
+				return nil, errors.New("not found")
+			}
+			return &entity.GrantCondition{ID: id, Expression: "time.hour >= 9"}, nil
+		},
+	}
+	handler := NewRoleHandler(mockUC, logger.New())
+
+	rec := serveRole(handler.GetCondition(), http.MethodGet, "cond_abc", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var resp struct {
+		Data ConditionResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Data.ID != "cond_abc" || resp.Data.Expression != "time.hour >= 9" {
+		t.Errorf("Unexpected condition: %+v", resp.Data)
+	}
+
+	rec = serveRole(handler.GetCondition(), http.MethodGet, "cond_missing", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/usecase/seal"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	UnsealRequest struct {
+		Password string `json:"password"`
+		// Share is one base64 encoded Shamir share of the unseal password
+		Share string `json:"share"`
+	}
+
+	SealStatusResponse struct {
+		Sealed    bool `json:"sealed"`
+		Threshold int  `json:"threshold,omitempty"`
+		Progress  int  `json:"progress,omitempty"`
+	}
+)
+
+type SealHandler struct {
+	sealUC seal.Usecase
+	logger service.Logger
+}
+
+func NewSealHandler(uc seal.Usecase, logger service.Logger) *SealHandler {
+	return &SealHandler{
+		sealUC: uc,
+		logger: logger,
+	}
+}
+
+func (h *SealHandler) Status() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return response.SuccesHandler(c, &response.Response{
+			Message: "seal status retrieved",
+			Data:    newSealStatusResponse(h.sealUC.Status(c.Request().Context())),
+		})
+	}
+}
+
+// Unseal submits the unseal password or one Shamir share
+func (h *SealHandler) Unseal() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &UnsealRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		status, err := h.sealUC.Unseal(c.Request().Context(), &seal.UnsealInput{
+			Password: req.Password,
+			Share:    req.Share,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "unseal request accepted",
+			Data:    newSealStatusResponse(status),
+		})
+	}
+}
+
+// Seal wipes the signing key from memory
+func (h *SealHandler) Seal() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.sealUC.Seal(c.Request().Context()); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		claims := middleware.GetUserFromContext(c)
+		if claims != nil {
+			h.logger.Warn("Service sealed by operator", service.Fields{
+				"user_id": claims.UserID,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "service sealed",
+			Data:    &SealStatusResponse{Sealed: true},
+		})
+	}
+}
+
+func newSealStatusResponse(st *seal.Status) *SealStatusResponse {
+	return &SealStatusResponse{
+		Sealed:    st.Sealed,
+		Threshold: st.Threshold,
+		Progress:  st.Progress,
+	}
+}
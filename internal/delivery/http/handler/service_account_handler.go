@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/serviceaccount"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	CreateServiceAccountRequest struct {
+		ApplicationID string `json:"application_id" validate:"required"`
+		Name          string `json:"name" validate:"required"`
+		Description   string `json:"description"`
+	}
+
+	UpdateServiceAccountRequest struct {
+		IsActive bool `json:"is_active"`
+	}
+
+	ServiceAccountListQuery struct {
+		ApplicationID string `query:"application_id"`
+		Page          int    `query:"page"`
+		Limit         int    `query:"limit"`
+	}
+
+	AssignServiceAccountRolesRequest struct {
+		Roles []string `json:"roles"`
+	}
+
+	CreateServiceAccountKeyRequest struct {
+		// ExpiresInDays of zero creates a key that does not expire
+		ExpiresInDays int `json:"expires_in_days"`
+	}
+
+	ServiceTokenRequest struct {
+		Key string `json:"key" validate:"required"`
+	}
+
+	ServiceAccountResponse struct {
+		ID            string    `json:"id"`
+		PrincipalType string    `json:"principal_type"`
+		ApplicationID string    `json:"application_id"`
+		Name          string    `json:"name"`
+		Description   string    `json:"description,omitempty"`
+		IsActive      bool      `json:"is_active"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+
+	ServiceAccountKeyResponse struct {
+		ID         string     `json:"id"`
+		Key        string     `json:"key,omitempty"`
+		Prefix     string     `json:"prefix,omitempty"`
+		ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+		LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+		RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+		CreatedAt  *time.Time `json:"created_at,omitempty"`
+	}
+)
+
+type ServiceAccountHandler struct {
+	saUC   serviceaccount.Usecase
+	cfg    *config.Config
+	logger service.Logger
+}
+
+func NewServiceAccountHandler(uc serviceaccount.Usecase, cfg *config.Config, logger service.Logger) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		saUC:   uc,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+func (h *ServiceAccountHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &CreateServiceAccountRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			h.logger.Warn("Failed to decode create service account request", service.Fields{
+				"error": err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		sa, err := h.saUC.Create(c.Request().Context(), &serviceaccount.CreateInput{
+			ApplicationID: req.ApplicationID,
+			Name:          req.Name,
+			Description:   req.Description,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "service account created successfully",
+			Data:    toServiceAccountResponse(sa),
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := ServiceAccountListQuery{}
+		if err := c.Bind(&query); err != nil {
+			h.logger.Warn("Failed to bind service account list query", service.Fields{
+				"error": err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		page := query.Page
+		if page <= 0 {
+			page = 1
+		}
+		limit := query.Limit
+		if limit <= 0 {
+			limit = 50 // default limit
+		}
+
+		accounts, err := h.saUC.List(c.Request().Context(), &serviceaccount.ListInput{
+			ApplicationID: query.ApplicationID,
+			Limit:         limit,
+			Offset:        (page - 1) * limit,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		resp := make([]*ServiceAccountResponse, 0, len(accounts))
+		for _, sa := range accounts {
+			resp = append(resp, toServiceAccountResponse(sa))
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) Get() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sa, err := h.saUC.Get(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data:    toServiceAccountResponse(sa),
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) Update() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &UpdateServiceAccountRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.saUC.SetActive(c.Request().Context(), c.Param("id"), req.IsActive); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "service account updated successfully",
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.saUC.Delete(c.Request().Context(), c.Param("id")); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "service account deleted successfully",
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) AssignRoles() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		saID := c.Param("id")
+		req := &AssignServiceAccountRolesRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			h.logger.Warn("Failed to decode assign service account roles request", service.Fields{
+				"service_account_id": saID,
+				"error":              err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		if err := h.saUC.AssignRoles(c.Request().Context(), saID, req.Roles); err != nil {
+			if errors.Is(err, service.ErrSoDViolation) {
+				return response.ErrorHandler(c, http.StatusConflict, "Conflict", err.Error())
+			}
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "roles assigned successfully",
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) GetRoles() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		roles, err := h.saUC.GetRoles(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data:    roles,
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) CreateKey() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &CreateServiceAccountKeyRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		key, err := h.saUC.CreateKey(c.Request().Context(), c.Param("id"), req.ExpiresInDays)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "service account key created successfully",
+			Data: &ServiceAccountKeyResponse{
+				ID:        key.ID,
+				Key:       key.Key,
+				ExpiresAt: key.ExpiresAt,
+			},
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) ListKeys() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		keys, err := h.saUC.ListKeys(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		resp := make([]*ServiceAccountKeyResponse, 0, len(keys))
+		for _, k := range keys {
+			createdAt := k.CreatedAt
+			resp = append(resp, &ServiceAccountKeyResponse{
+				ID:         k.ID,
+				Prefix:     k.Prefix,
+				ExpiresAt:  k.ExpiresAt,
+				LastUsedAt: k.LastUsedAt,
+				RevokedAt:  k.RevokedAt,
+				CreatedAt:  &createdAt,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *ServiceAccountHandler) RevokeKey() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.saUC.RevokeKey(c.Request().Context(), c.Param("id"), c.Param("key_id")); err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "service account key revoked successfully",
+		})
+	}
+}
+
+// IssueToken exchanges a service account key for an access token
+func (h *ServiceAccountHandler) IssueToken() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &ServiceTokenRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		issued, err := h.saUC.IssueToken(c.Request().Context(), req.Key, h.cfg)
+		if err != nil {
+			h.logger.Warn("Service token request failed", service.Fields{
+				"error": err.Error(),
+			})
+			return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "OK",
+			Data: &AccessToken{
+				Type:      "Bearer",
+				Token:     issued.Token,
+				ExpiresAt: issued.ExpiresAt,
+			},
+		})
+	}
+}
+
+func toServiceAccountResponse(sa *entity.ServiceAccount) *ServiceAccountResponse {
+	return &ServiceAccountResponse{
+		ID:            sa.ID,
+		PrincipalType: entity.PrincipalTypeServiceAccount,
+		ApplicationID: sa.ApplicationID,
+		Name:          sa.Name,
+		Description:   sa.Description,
+		IsActive:      sa.IsActive,
+		CreatedAt:     sa.CreatedAt,
+	}
+}
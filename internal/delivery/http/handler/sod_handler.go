@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/usecase/sod"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+type (
+	CreateSoDConstraintRequest struct {
+		Code        string   `json:"code" validate:"required"`
+		Name        string   `json:"name" validate:"required"`
+		Description *string  `json:"description"`
+		MaxRoles    int      `json:"max_roles"`
+		Roles       []string `json:"roles" validate:"required"`
+	}
+
+	GetSoDViolationsQuery struct {
+		ApplicationID string `query:"application_id"`
+	}
+
+	SoDConstraintResponse struct {
+		ID            string    `json:"id"`
+		ApplicationID string    `json:"application_id"`
+		Code          string    `json:"code"`
+		Name          string    `json:"name"`
+		Description   *string   `json:"description,omitempty"`
+		MaxRoles      int       `json:"max_roles"`
+		Roles         []string  `json:"roles"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+
+	SoDViolationResponse struct {
+		ConstraintID     string   `json:"constraint_id"`
+		Constraint       string   `json:"constraint"`
+		ApplicationID    string   `json:"application_id"`
+		MaxRoles         int      `json:"max_roles"`
+		UserID           string   `json:"user_id,omitempty"`
+		ServiceAccountID string   `json:"service_account_id,omitempty"`
+		Roles            []string `json:"roles"`
+	}
+)
+
+type SoDHandler struct {
+	sodUC  sod.Usecase
+	logger service.Logger
+}
+
+func NewSoDHandler(uc sod.Usecase, logger service.Logger) *SoDHandler {
+	return &SoDHandler{
+		sodUC:  uc,
+		logger: logger,
+	}
+}
+
+// Create adds a separation-of-duties constraint to the application. Max
+// roles defaults to 1, making the roles mutually exclusive.
+func (h *SoDHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := &CreateSoDConstraintRequest{}
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		maxRoles := req.MaxRoles
+		if maxRoles == 0 {
+			maxRoles = 1
+		}
+
+		constraint, err := h.sodUC.Create(c.Request().Context(), c.Param("id"), &sod.CreateInput{
+			Code:        req.Code,
+			Name:        req.Name,
+			Description: req.Description,
+			MaxRoles:    maxRoles,
+			Roles:       req.Roles,
+		})
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "constraint created successfully",
+			Data:    newSoDConstraintResponse(constraint),
+		})
+	}
+}
+
+func (h *SoDHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		constraints, err := h.sodUC.List(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		resp := make([]*SoDConstraintResponse, 0, len(constraints))
+		for _, constraint := range constraints {
+			resp = append(resp, newSoDConstraintResponse(constraint))
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "constraints retrieved successfully",
+			Data:    resp,
+		})
+	}
+}
+
+func (h *SoDHandler) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.sodUC.Delete(c.Request().Context(), c.Param("id"), c.Param("constraint_id")); err != nil {
+			return response.ErrorHandler(c, http.StatusNotFound, "NotFound", err.Error())
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "constraint deleted successfully",
+		})
+	}
+}
+
+// GetViolations reports the users and service accounts holding more roles
+// of a constraint than it allows, optionally in one application only
+func (h *SoDHandler) GetViolations() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := GetSoDViolationsQuery{}
+		if err := c.Bind(&query); err != nil {
+			return response.ErrorHandler(c, http.StatusBadRequest, "BadRequest", err.Error())
+		}
+
+		violations, err := h.sodUC.GetViolations(c.Request().Context(), query.ApplicationID)
+		if err != nil {
+			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
+		}
+
+		resp := make([]*SoDViolationResponse, 0, len(violations))
+		for _, v := range violations {
+			resp = append(resp, &SoDViolationResponse{
+				ConstraintID:     v.ConstraintID,
+				Constraint:       v.ConstraintCode,
+				ApplicationID:    v.ApplicationID,
+				MaxRoles:         v.MaxRoles,
+				UserID:           v.UserID,
+				ServiceAccountID: v.ServiceAccountID,
+				Roles:            v.RoleCodes,
+			})
+		}
+
+		return response.SuccesHandler(c, &response.Response{
+			Message: "violations retrieved successfully",
+			Data:    resp,
+		})
+	}
+}
+
+func newSoDConstraintResponse(constraint *entity.SoDConstraint) *SoDConstraintResponse {
+	return &SoDConstraintResponse{
+		ID:            constraint.ID,
+		ApplicationID: constraint.ApplicationID,
+		Code:          constraint.Code,
+		Name:          constraint.Name,
+		Description:   constraint.Description,
+		MaxRoles:      constraint.MaxRoles,
+		Roles:         constraint.RoleCodes,
+		CreatedAt:     constraint.CreatedAt,
+	}
+}
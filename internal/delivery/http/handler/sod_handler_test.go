@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/internal/usecase/sod"
+)
+
+// MockSoDUseCase is a mock implementation of sod.Usecase
+type MockSoDUseCase struct {
+	CreateFunc        func(ctx context.Context, appID string, input *sod.CreateInput) (*entity.SoDConstraint, error)
+	DeleteFunc        func(ctx context.Context, appID, id string) error
+	ListFunc          func(ctx context.Context, appID string) ([]*entity.SoDConstraint, error)
+	GetViolationsFunc func(ctx context.Context, appID string) ([]*entity.SoDViolation, error)
+}
+
+func (m *MockSoDUseCase) Create(ctx context.Context, appID string, input *sod.CreateInput) (*entity.SoDConstraint, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, appID, input)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockSoDUseCase) Delete(ctx context.Context, appID, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, appID, id)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockSoDUseCase) List(ctx context.Context, appID string) ([]*entity.SoDConstraint, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, appID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockSoDUseCase) GetViolations(ctx context.Context, appID string) ([]*entity.SoDViolation, error) {
+	if m.GetViolationsFunc != nil {
+		return m.GetViolationsFunc(ctx, appID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func TestSoDHandler_Create_DefaultsToMutualExclusion(t *testing.T) {
+	var gotApp string
+	var got *sod.CreateInput
+	mockUC := &MockSoDUseCase{
+		CreateFunc: func(ctx context.Context, appID string, input *sod.CreateInput) (*entity.SoDConstraint, error) {
+			gotApp, got = appID, input
+			return &entity.SoDConstraint{ID: "c-1", ApplicationID: appID, Code: input.Code, MaxRoles: input.MaxRoles}, nil
+		},
+	}
+	handler := NewSoDHandler(mockUC, logger.New())
+
+	body := `{"code":"payments","name":"Payments","roles":["payments.initiator","payments.approver"]}`
+	rec := serveGroup(handler.Create(), http.MethodPost, body, "id", "app-1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if gotApp != "app-1" || got == nil || got.MaxRoles != 1 ||
+		!reflect.DeepEqual(got.Roles, []string{"payments.initiator", "payments.approver"}) {
+		t.Errorf("Unexpected constraint input: %s %+v", gotApp, got)
+	}
+}
+
+func TestSoDHandler_GetViolations(t *testing.T) {
+	var gotApp string
+	mockUC := &MockSoDUseCase{
+		GetViolationsFunc: func(ctx context.Context, appID string) ([]*entity.SoDViolation, error) {
+			gotApp = appID
+			return []*entity.SoDViolation{{
+				ConstraintCode: "payments",
+				UserID:         "u-1",
+				MaxRoles:       1,
+				RoleCodes:      []string{"payments.approver", "payments.initiator"},
+			}}, nil
+		},
+	}
+	handler := NewSoDHandler(mockUC, logger.New())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/?application_id=app-1", nil)
+	rec := httptest.NewRecorder()
+	_ = handler.GetViolations()(e.NewContext(req, rec))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if gotApp != "app-1" {
+		t.Errorf("Expected application filter app-1, got %q", gotApp)
+	}
+}
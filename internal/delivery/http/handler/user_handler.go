@@ -2,10 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
 	"github.com/mafzaidi/authorizer/internal/usecase/user"
 	"github.com/mafzaidi/authorizer/pkg/response"
@@ -40,8 +42,11 @@ type (
 		Limit int `query:"limit"`
 	}
 
+	// AssignUserRoleRequest optionally bounds when the roles are in effect
 	AssignUserRoleRequest struct {
-		Roles []string `json:"roles" validate:"required"`
+		Roles      []string   `json:"roles" validate:"required"`
+		ValidFrom  *time.Time `json:"valid_from,omitempty"`
+		ValidUntil *time.Time `json:"valid_until,omitempty"`
 	}
 )
 
@@ -294,13 +299,21 @@ func (h *UserHandler) AssignUserRoles() echo.HandlerFunc {
 
 		roles := req.Roles
 
-		if err := h.userUC.AssignRoles(c.Request().Context(), userID, appID, roles); err != nil {
+		err := h.userUC.AssignRoles(c.Request().Context(), userID, appID, &user.AssignRolesInput{
+			Roles:      roles,
+			ValidFrom:  req.ValidFrom,
+			ValidUntil: req.ValidUntil,
+		})
+		if err != nil {
 			h.logger.Error("Failed to assign user roles", logger.Fields{
 				"user_id": userID,
 				"app_id":  appID,
 				"roles":   roles,
 				"error":   err.Error(),
 			})
+			if errors.Is(err, service.ErrSoDViolation) {
+				return response.ErrorHandler(c, http.StatusConflict, "Conflict", err.Error())
+			}
 			return response.ErrorHandler(c, http.StatusInternalServerError, "InternalServerError", err.Error())
 		}
 
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+// AppResolver returns the ID of the application a request targets, or an
+// empty string when it targets none or cannot be resolved
+type AppResolver func(c echo.Context) string
+
+// RoleLookup finds roles, resolving the application a role route targets
+type RoleLookup interface {
+	GetByID(ctx context.Context, id string) (*entity.Role, error)
+}
+
+// AppFromParam resolves the application from the route parameter holding
+// its ID
+func AppFromParam(name string) AppResolver {
+	return func(c echo.Context) string {
+		return c.Param(name)
+	}
+}
+
+// AppFromBody resolves the application from a top-level string field of
+// the JSON request body. The body is restored for the handler.
+func AppFromBody(field string) AppResolver {
+	return func(c echo.Context) string {
+		req := c.Request()
+		if req.Body == nil {
+			return ""
+		}
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return ""
+		}
+		var appID string
+		if err := json.Unmarshal(fields[field], &appID); err != nil {
+			return ""
+		}
+		return appID
+	}
+}
+
+// AppFromRole resolves the application of the role whose ID is in the
+// route parameter. Global roles belong to no application.
+func AppFromRole(param string, roles RoleLookup) AppResolver {
+	return func(c echo.Context) string {
+		id := c.Param(param)
+		if id == "" || roles == nil {
+			return ""
+		}
+		role, err := roles.GetByID(c.Request().Context(), id)
+		if err != nil || role.ApplicationID == nil {
+			return ""
+		}
+		return *role.ApplicationID
+	}
+}
@@ -12,27 +12,72 @@ import (
 	"github.com/mafzaidi/authorizer/internal/infrastructure/auth"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/mafzaidi/authorizer/pkg/permission"
 	"github.com/mafzaidi/authorizer/pkg/response"
 )
 
 type contextKey string
 
+// Authentication methods recorded on JWTClaims.AuthMethod
+const (
+	AuthMethodJWT = "jwt"
+	AuthMethodPAT = "pat"
+)
+
+// AccessTokenAuthenticator verifies personal access tokens presented as
+// bearer credentials
+type AccessTokenAuthenticator interface {
+	Authenticate(ctx context.Context, rawToken string) (*entity.Claims, error)
+}
+
 const userContextKey = contextKey("user_claims")
 
 // JWTAuthMiddleware creates a JWT authentication middleware with explicit dependencies
+// The token is read from the Authorization bearer header, or from the session
+// cookie when no header is present. Cookie-authenticated requests with an
+// unsafe method must carry a CSRF header matching the CSRF cookie.
+// Bearer credentials with the personal access token prefix are verified by
+// patAuth instead of the JWT service.
 // Parameters:
 //   - jwtService: JWT service for token validation
+//   - patAuth: personal access token verifier (nil disables access tokens)
 //   - cfg: configuration containing JWT public key
 //   - log: logger for structured logging of auth failures
 //
 // Returns:
 //   - echo.MiddlewareFunc: middleware function that validates JWT tokens
-func JWTAuthMiddleware(jwtService auth.JWTService, cfg *config.Config, log service.Logger) echo.MiddlewareFunc {
+func JWTAuthMiddleware(jwtService auth.JWTService, patAuth AccessTokenAuthenticator, cfg *config.Config, log service.Logger) echo.MiddlewareFunc {
+	session := cfg.Session
+	if session == nil {
+		session = config.DefaultSession()
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
 
-			if authHeader == "" {
+			var rawToken string
+			if authHeader != "" {
+				parts := strings.Split(authHeader, " ")
+				if len(parts) != 2 || parts[0] != "Bearer" {
+					log.Warn("Authentication failed: invalid token format", service.Fields{
+						"path":   c.Request().URL.Path,
+						"method": c.Request().Method,
+					})
+					return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "invalid token format")
+				}
+				rawToken = parts[1]
+			} else if cookie, err := c.Cookie(session.CookieName); err == nil && cookie.Value != "" {
+				// Cookie session mode: state-changing requests must pass the CSRF check
+				if !isSafeMethod(c.Request().Method) && !validCSRF(c, session) {
+					log.Warn("Authentication failed: CSRF token mismatch", service.Fields{
+						"path":   c.Request().URL.Path,
+						"method": c.Request().Method,
+					})
+					return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", "invalid CSRF token")
+				}
+				rawToken = cookie.Value
+			} else {
 				log.Warn("Authentication failed: missing token", service.Fields{
 					"path":   c.Request().URL.Path,
 					"method": c.Request().Method,
@@ -40,36 +85,42 @@ func JWTAuthMiddleware(jwtService auth.JWTService, cfg *config.Config, log servi
 				return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "token is missing")
 			}
 
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
-				log.Warn("Authentication failed: invalid token format", service.Fields{
-					"path":   c.Request().URL.Path,
-					"method": c.Request().Method,
-				})
-				return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "invalid token format")
-			}
-
-			rawToken := parts[1]
-
-			// Use JWT service to validate token
+			// Use the access token verifier or the JWT service to validate token
 			ctx := context.Background()
-			claims, err := jwtService.ValidateToken(ctx, rawToken, cfg.JWT.PublicKey)
+			authMethod := AuthMethodJWT
+			var (
+				claims *entity.Claims
+				err    error
+			)
+			if strings.HasPrefix(rawToken, entity.PersonalAccessTokenPrefix) && patAuth != nil {
+				authMethod = AuthMethodPAT
+				claims, err = patAuth.Authenticate(ctx, rawToken)
+			} else {
+				publicKey, _ := cfg.JWT.VerificationKey()
+				claims, err = jwtService.ValidateToken(ctx, rawToken, publicKey)
+			}
 			if err != nil {
 				log.Warn("Authentication failed: token validation error", service.Fields{
-					"path":  c.Request().URL.Path,
+					"path":   c.Request().URL.Path,
 					"method": c.Request().Method,
-					"error": err.Error(),
-					"token": logger.TruncateToken(rawToken),
+					"error":  err.Error(),
+					"token":  logger.TruncateToken(rawToken),
 				})
 				return response.ErrorHandler(c, http.StatusUnauthorized, "Unauthorized", "invalid token")
 			}
 
 			// Convert entity.Claims to JWTClaims for backward compatibility with existing code
 			jwtClaims := &JWTClaims{
-				UserID:        claims.Subject,
-				Username:      claims.Username,
-				Email:         claims.Email,
-				Authorization: convertAuthorization(claims.Authorization),
+				UserID:             claims.Subject,
+				Username:           claims.Username,
+				Email:              claims.Email,
+				PrincipalType:      claims.PrincipalType,
+				Authorization:      convertAuthorization(claims.Authorization),
+				Organization:       claims.Organization,
+				AppAdmin:           claims.AppAdmin,
+				PermissionsVersion: claims.PermissionsVersion,
+				Extra:              claims.Extra,
+				AuthMethod:         authMethod,
 			}
 
 			c.Set(string(userContextKey), jwtClaims)
@@ -87,17 +138,22 @@ func convertAuthorization(entityAuth []entity.Authorization) []Authorization {
 			App:         auth.App,
 			Roles:       auth.Roles,
 			Permissions: auth.Permissions,
+			Conditions:  auth.Conditions,
 		}
 	}
 	return result
 }
 
-func RequirePermission(app, perm string) echo.MiddlewareFunc {
+// RequirePermission rejects requests whose claims do not grant perm in app.
+// For AUTHORIZER permissions, scopes resolve the application the request
+// targets; an admin of that application holding perm for it is let through
+// as well, so owners can administer their own application only.
+func RequirePermission(app, perm string, scopes ...AppResolver) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 
 			claims := GetUserFromContext(c)
-			if !HasPermission(claims, app, perm) {
+			if !HasPermission(claims, app, perm) && !hasScopedPermission(c, claims, app, perm, scopes) {
 				return response.ErrorHandler(c, http.StatusForbidden, "Forbidden", "missing required permission")
 			}
 
@@ -106,20 +162,45 @@ func RequirePermission(app, perm string) echo.MiddlewareFunc {
 	}
 }
 
+func hasScopedPermission(c echo.Context, claims *JWTClaims, app, perm string, scopes []AppResolver) bool {
+	if app != entity.AuthorizerAppCode || claims == nil || len(claims.AppAdmin) == 0 {
+		return false
+	}
+	for _, resolve := range scopes {
+		if appID := resolve(c); appID != "" && HasAppAdminPermission(claims, appID, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAppAdminPermission reports whether the claims grant the AUTHORIZER
+// permission perm for the application appID only, through delegated
+// administration of it
+func HasAppAdminPermission(claims *JWTClaims, appID, perm string) bool {
+	if claims == nil {
+		return false
+	}
+	return permission.Any(claims.AppAdmin[appID], perm)
+}
+
+// HasPermission reports whether the claims grant perm in app. Granted
+// permissions may be wildcards, matched with permission.Match. Global roles
+// grant everything only through the reserved superadmin permission; their
+// other permissions are listed under the application they belong to.
 func HasPermission(claims *JWTClaims, app, perm string) bool {
 	if claims == nil {
 		return false
 	}
 	for _, a := range claims.Authorization {
-		if a.App == "GLOBAL" {
-			return true
-		}
-		if a.App == app {
-			for _, p := range a.Permissions {
-				if p == perm {
-					return true
-				}
+		if a.App == entity.GlobalAuthorization {
+			if slices.Contains(a.Permissions, entity.SuperadminPermission) {
+				return true
 			}
+			continue
+		}
+		if a.App == app && permission.Any(a.Permissions, perm) {
+			return true
 		}
 	}
 	return false
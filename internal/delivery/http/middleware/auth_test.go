@@ -4,8 +4,11 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -62,7 +65,7 @@ func TestJWTAuthMiddleware_MissingToken(t *testing.T) {
 	jwtService := auth.NewJWTService(logger)
 
 	// Create middleware
-	middleware := JWTAuthMiddleware(jwtService, cfg, logger)
+	middleware := JWTAuthMiddleware(jwtService, nil, cfg, logger)
 	handler := middleware(func(c echo.Context) error {
 		return c.String(http.StatusOK, "success")
 	})
@@ -100,7 +103,7 @@ func TestJWTAuthMiddleware_InvalidTokenFormat(t *testing.T) {
 	jwtService := auth.NewJWTService(logger)
 
 	// Create middleware
-	middleware := JWTAuthMiddleware(jwtService, cfg, logger)
+	middleware := JWTAuthMiddleware(jwtService, nil, cfg, logger)
 	handler := middleware(func(c echo.Context) error {
 		return c.String(http.StatusOK, "success")
 	})
@@ -161,7 +164,7 @@ func TestJWTAuthMiddleware_ValidToken(t *testing.T) {
 	c := e.NewContext(req, rec)
 
 	// Create middleware
-	middleware := JWTAuthMiddleware(jwtService, cfg, logger)
+	middleware := JWTAuthMiddleware(jwtService, nil, cfg, logger)
 	handler := middleware(func(c echo.Context) error {
 		// Verify claims are set in context
 		jwtClaims := GetUserFromContext(c)
@@ -206,7 +209,7 @@ func TestJWTAuthMiddleware_InvalidToken(t *testing.T) {
 	jwtService := auth.NewJWTService(logger)
 
 	// Create middleware
-	middleware := JWTAuthMiddleware(jwtService, cfg, logger)
+	middleware := JWTAuthMiddleware(jwtService, nil, cfg, logger)
 	handler := middleware(func(c echo.Context) error {
 		return c.String(http.StatusOK, "success")
 	})
@@ -220,6 +223,132 @@ func TestJWTAuthMiddleware_InvalidToken(t *testing.T) {
 	assert.Contains(t, logger.lastMessage, "token validation error")
 }
 
+func TestJWTAuthMiddleware_CookieSession(t *testing.T) {
+	// Generate test keys
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		JWT: &config.JWT{
+			PrivateKey: privateKey,
+			PublicKey:  &privateKey.PublicKey,
+			KeyID:      "test-key-id",
+		},
+	}
+
+	logger := &mockLogger{}
+	jwtService := auth.NewJWTService(logger)
+
+	claims := &entity.Claims{
+		Subject:   "user-123",
+		ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		IssuedAt:  time.Now().Unix(),
+	}
+	token, err := jwtService.GenerateToken(context.Background(), claims, privateKey, cfg.JWT.KeyID)
+	require.NoError(t, err)
+
+	session := config.DefaultSession()
+
+	tests := []struct {
+		name       string
+		method     string
+		csrfCookie string
+		csrfHeader string
+		wantStatus int
+	}{
+		{"safe method without CSRF", http.MethodGet, "", "", http.StatusOK},
+		{"unsafe method without CSRF", http.MethodPost, "", "", http.StatusForbidden},
+		{"unsafe method with mismatched CSRF", http.MethodPost, "csrf-a", "csrf-b", http.StatusForbidden},
+		{"unsafe method with matching CSRF", http.MethodPost, "csrf-a", "csrf-a", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			req.AddCookie(&http.Cookie{Name: session.CookieName, Value: token})
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: session.CSRFCookieName, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(session.CSRFHeaderName, tt.csrfHeader)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := JWTAuthMiddleware(jwtService, nil, cfg, logger)(func(c echo.Context) error {
+				assert.Equal(t, "user-123", GetUserFromContext(c).UserID)
+				return c.String(http.StatusOK, "success")
+			})
+
+			assert.NoError(t, handler(c))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+// mockAccessTokenAuthenticator implements AccessTokenAuthenticator for testing
+type mockAccessTokenAuthenticator struct {
+	claims *entity.Claims
+	err    error
+}
+
+func (m *mockAccessTokenAuthenticator) Authenticate(ctx context.Context, rawToken string) (*entity.Claims, error) {
+	return m.claims, m.err
+}
+
+func TestJWTAuthMiddleware_PersonalAccessToken(t *testing.T) {
+	cfg := &config.Config{JWT: &config.JWT{KeyID: "test-key-id"}}
+	logger := &mockLogger{}
+	jwtService := auth.NewJWTService(logger)
+
+	patAuth := &mockAccessTokenAuthenticator{
+		claims: &entity.Claims{
+			Subject: "user-123",
+			Authorization: []entity.Authorization{
+				{App: "APP1", Permissions: []string{"user.read"}},
+			},
+		},
+	}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+entity.PersonalAccessTokenPrefix+"abcdef")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := JWTAuthMiddleware(jwtService, patAuth, cfg, logger)(func(c echo.Context) error {
+		claims := GetUserFromContext(c)
+		assert.Equal(t, "user-123", claims.UserID)
+		assert.Equal(t, AuthMethodPAT, claims.AuthMethod)
+		assert.True(t, HasPermission(claims, "APP1", "user.read"))
+		return c.String(http.StatusOK, "success")
+	})
+
+	assert.NoError(t, handler(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestJWTAuthMiddleware_RevokedPersonalAccessToken(t *testing.T) {
+	cfg := &config.Config{JWT: &config.JWT{KeyID: "test-key-id"}}
+	logger := &mockLogger{}
+	jwtService := auth.NewJWTService(logger)
+	patAuth := &mockAccessTokenAuthenticator{err: errors.New("access token revoked")}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+entity.PersonalAccessTokenPrefix+"abcdef")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := JWTAuthMiddleware(jwtService, patAuth, cfg, logger)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "success")
+	})
+
+	assert.NoError(t, handler(c))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
 func TestRequirePermission_WithPermission(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -299,7 +428,7 @@ func TestRequirePermission_GlobalPermission(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	// Set claims with GLOBAL app (should have access to everything)
+	// Set claims with the superadmin permission in the GLOBAL entry
 	claims := &JWTClaims{
 		UserID:   "admin-123",
 		Username: "admin",
@@ -308,7 +437,7 @@ func TestRequirePermission_GlobalPermission(t *testing.T) {
 			{
 				App:         "GLOBAL",
 				Roles:       []string{"superadmin"},
-				Permissions: []string{},
+				Permissions: []string{"superadmin"},
 			},
 		},
 	}
@@ -387,6 +516,167 @@ func TestRequirePermission_WrongApp(t *testing.T) {
 	assert.Equal(t, http.StatusForbidden, rec.Code)
 }
 
+// stubRoleLookup finds roles in a map keyed by ID
+type stubRoleLookup map[string]*entity.Role
+
+func (s stubRoleLookup) GetByID(ctx context.Context, id string) (*entity.Role, error) {
+	if role, ok := s[id]; ok {
+		return role, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestRequirePermission_AppScoped(t *testing.T) {
+	appX := "app-x"
+	roles := stubRoleLookup{
+		"role-x":      {ID: "role-x", ApplicationID: &appX},
+		"role-global": {ID: "role-global"},
+	}
+
+	tests := []struct {
+		name     string
+		app      string
+		perm     string
+		appAdmin map[string][]string
+		scope    AppResolver
+		param    string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "admin of the target application",
+			app:      "AUTHORIZER",
+			perm:     "user.assign_roles",
+			appAdmin: map[string][]string{"app-x": {"user.assign_roles"}},
+			scope:    AppFromParam("app_id"),
+			param:    "app-x",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "admin of another application",
+			app:      "AUTHORIZER",
+			perm:     "user.assign_roles",
+			appAdmin: map[string][]string{"app-x": {"user.assign_roles"}},
+			scope:    AppFromParam("app_id"),
+			param:    "app-y",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "wildcard grant",
+			app:      "AUTHORIZER",
+			perm:     "role.create",
+			appAdmin: map[string][]string{"app-x": {"role.*"}},
+			scope:    AppFromParam("app_id"),
+			param:    "app-x",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "permission not delegated",
+			app:      "AUTHORIZER",
+			perm:     "role.create",
+			appAdmin: map[string][]string{"app-x": {"role.read"}},
+			scope:    AppFromParam("app_id"),
+			param:    "app-x",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "route without scope",
+			app:      "AUTHORIZER",
+			perm:     "role.create",
+			appAdmin: map[string][]string{"app-x": {"role.create"}},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "application from body",
+			app:      "AUTHORIZER",
+			perm:     "role.create",
+			appAdmin: map[string][]string{"app-x": {"role.create"}},
+			scope:    AppFromBody("application_id"),
+			body:     `{"application_id":"app-x","code":"editor"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "body without application",
+			app:      "AUTHORIZER",
+			perm:     "role.create",
+			appAdmin: map[string][]string{"app-x": {"role.create"}},
+			scope:    AppFromBody("application_id"),
+			body:     `{"code":"editor","scope":"GLOBAL"}`,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "application of the role",
+			app:      "AUTHORIZER",
+			perm:     "role.update",
+			appAdmin: map[string][]string{"app-x": {"role.update"}},
+			scope:    AppFromRole("id", roles),
+			param:    "role-x",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "global role",
+			app:      "AUTHORIZER",
+			perm:     "role.update",
+			appAdmin: map[string][]string{"app-x": {"role.update"}},
+			scope:    AppFromRole("id", roles),
+			param:    "role-global",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "unknown role",
+			app:      "AUTHORIZER",
+			perm:     "role.update",
+			appAdmin: map[string][]string{"app-x": {"role.update"}},
+			scope:    AppFromRole("id", roles),
+			param:    "role-missing",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "permission of another catalog",
+			app:      "test-app",
+			perm:     "write",
+			appAdmin: map[string][]string{"app-x": {"write"}},
+			scope:    AppFromParam("app_id"),
+			param:    "app-x",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.param != "" {
+				c.SetParamNames("app_id", "id")
+				c.SetParamValues(tt.param, tt.param)
+			}
+			c.Set(string(userContextKey), &JWTClaims{UserID: "user-123", AppAdmin: tt.appAdmin})
+
+			var scopes []AppResolver
+			if tt.scope != nil {
+				scopes = append(scopes, tt.scope)
+			}
+			var gotBody string
+			handler := RequirePermission(tt.app, tt.perm, scopes...)(func(c echo.Context) error {
+				body, _ := io.ReadAll(c.Request().Body)
+				gotBody = string(body)
+				return c.String(http.StatusOK, "success")
+			})
+
+			err := handler(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode == http.StatusOK {
+				// The handler still reads the whole body
+				assert.Equal(t, tt.body, gotBody)
+			}
+		})
+	}
+}
+
 func TestHasPermission(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -424,19 +714,49 @@ func TestHasPermission(t *testing.T) {
 			expected: false,
 		},
 		{
-			name: "global permission",
+			name: "global role without superadmin",
 			claims: &JWTClaims{
 				Authorization: []Authorization{
 					{
 						App:         "GLOBAL",
+						Roles:       []string{"auditor"},
 						Permissions: []string{},
 					},
 				},
 			},
 			app:      "test-app",
 			perm:     "write",
+			expected: false,
+		},
+		{
+			name: "global superadmin",
+			claims: &JWTClaims{
+				Authorization: []Authorization{
+					{
+						App:         "GLOBAL",
+						Permissions: []string{"superadmin"},
+					},
+				},
+			},
+			app:      "test-app",
+			perm:     "write",
 			expected: true,
 		},
+		{
+			name: "conditional permission only",
+			claims: &JWTClaims{
+				Authorization: []Authorization{
+					{
+						App:         "test-app",
+						Permissions: []string{"read"},
+						Conditions:  map[string][]string{"write": {"cond_abc"}},
+					},
+				},
+			},
+			app:      "test-app",
+			perm:     "write",
+			expected: false,
+		},
 		{
 			name: "wrong app",
 			claims: &JWTClaims{
@@ -451,6 +771,34 @@ func TestHasPermission(t *testing.T) {
 			perm:     "write",
 			expected: false,
 		},
+		{
+			name: "trailing wildcard grant",
+			claims: &JWTClaims{
+				Authorization: []Authorization{
+					{
+						App:         "test-app",
+						Permissions: []string{"user.*"},
+					},
+				},
+			},
+			app:      "test-app",
+			perm:     "user.assign_roles",
+			expected: true,
+		},
+		{
+			name: "leading wildcard grant",
+			claims: &JWTClaims{
+				Authorization: []Authorization{
+					{
+						App:         "test-app",
+						Permissions: []string{"*.read"},
+					},
+				},
+			},
+			app:      "test-app",
+			perm:     "user.write",
+			expected: false,
+		},
 		{
 			name:     "nil claims",
 			claims:   nil,
@@ -6,14 +6,23 @@ import (
 
 type JWTClaims struct {
 	jwt.RegisteredClaims
-	UserID        string          `json:"sub"`
-	Username      string          `json:"username"`
-	Email         string          `json:"email"`
-	Authorization []Authorization `json:"authorization"`
+	UserID             string                 `json:"sub"`
+	Username           string                 `json:"username"`
+	Email              string                 `json:"email"`
+	PrincipalType      string                 `json:"principal_type,omitempty"`
+	Authorization      []Authorization        `json:"authorization"`
+	Organization       string                 `json:"org,omitempty"`
+	AppAdmin           map[string][]string    `json:"app_admin,omitempty"`
+	PermissionsVersion int64                  `json:"pv,omitempty"`
+	Extra              map[string]interface{} `json:"ext,omitempty"`
+
+	// AuthMethod records how the request was authenticated: "jwt" or "pat"
+	AuthMethod string `json:"-"`
 }
 
 type Authorization struct {
-	App         string   `json:"app"`
-	Roles       []string `json:"roles"`
-	Permissions []string `json:"permissions"`
+	App         string              `json:"app"`
+	Roles       []string            `json:"roles"`
+	Permissions []string            `json:"permissions"`
+	Conditions  map[string][]string `json:"conditions,omitempty"`
 }
@@ -6,7 +6,6 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
-	"fmt"
 
 	"golang.org/x/crypto/argon2"
 )
@@ -88,7 +87,6 @@ func Decrypt(encryptedMasterKey, password, saltBase64 string) (*Decrypted, error
 	nonce, ciphertext := cipherData[:nonceSize], cipherData[nonceSize:]
 	masterKey, err := aesGCM.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		fmt.Println("error", err)
 		return nil, errors.New("failed to decrypt master key: authentication failed")
 	}
 
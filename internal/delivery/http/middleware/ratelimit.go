@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+// RateLimiter counts events per key in fixed windows
+type RateLimiter interface {
+	Hit(ctx context.Context, key string, window time.Duration) (int64, error)
+}
+
+// RateLimit creates a middleware allowing at most limit requests per
+// client address and scope within each window. Routes sharing a scope
+// share the budget. When the limiter is unavailable requests are let
+// through so a cache outage does not lock everyone out.
+// Parameters:
+//   - limiter: counter store
+//   - scope: name of the budget, e.g. "login"
+//   - limit: requests allowed per window
+//   - window: length of the window
+//   - log: logger for structured logging of rejected requests
+func RateLimit(limiter RateLimiter, scope string, limit int, window time.Duration, log service.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			count, err := limiter.Hit(c.Request().Context(), scope+":"+c.RealIP(), window)
+			if err != nil {
+				log.Error("Rate limiter unavailable", service.Fields{
+					"scope": scope,
+					"error": err.Error(),
+				})
+				return next(c)
+			}
+
+			if count > int64(limit) {
+				log.Warn("Rate limit exceeded", service.Fields{
+					"scope":     scope,
+					"remote_ip": c.RealIP(),
+					"path":      c.Request().URL.Path,
+				})
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(window.Seconds())))
+				return response.ErrorHandler(c, http.StatusTooManyRequests, "TooManyRequests", "too many requests, try again later")
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockRateLimiter counts hits per key in memory
+type mockRateLimiter struct {
+	hits map[string]int64
+	err  error
+}
+
+func (m *mockRateLimiter) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	m.hits[key]++
+	return m.hits[key], nil
+}
+
+func serveRateLimited(mw echo.MiddlewareFunc, remoteAddr string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := mw(func(c echo.Context) error {
+		return c.String(http.StatusOK, "success")
+	})
+	_ = handler(c)
+
+	return rec
+}
+
+func TestRateLimit_RejectsOverLimit(t *testing.T) {
+	limiter := &mockRateLimiter{hits: map[string]int64{}}
+	logger := &mockLogger{}
+	mw := RateLimit(limiter, "login", 2, time.Minute, logger)
+
+	assert.Equal(t, http.StatusOK, serveRateLimited(mw, "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, serveRateLimited(mw, "10.0.0.1:1234").Code)
+
+	rec := serveRateLimited(mw, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "Rate limit exceeded", logger.lastMessage)
+
+	// Other clients have their own budget
+	assert.Equal(t, http.StatusOK, serveRateLimited(mw, "10.0.0.2:1234").Code)
+}
+
+func TestRateLimit_FailsOpen(t *testing.T) {
+	limiter := &mockRateLimiter{err: errors.New("redis down")}
+	logger := &mockLogger{}
+	mw := RateLimit(limiter, "login", 1, time.Minute, logger)
+
+	assert.Equal(t, http.StatusOK, serveRateLimited(mw, "10.0.0.1:1234").Code)
+	assert.Equal(t, "Rate limiter unavailable", logger.lastMessage)
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mafzaidi/authorizer/pkg/response"
+)
+
+// SealGate admits requests while the service is unsealed
+type SealGate interface {
+	Acquire() bool
+	Release()
+}
+
+// SealGuard creates a middleware rejecting requests while the service is
+// sealed. Requests whose path starts with one of the exempt prefixes (e.g.
+// health and unseal endpoints) are always served.
+func SealGuard(gate SealGate, exempt ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			for _, prefix := range exempt {
+				if strings.HasPrefix(path, prefix) {
+					return next(c)
+				}
+			}
+
+			if !gate.Acquire() {
+				return response.ErrorHandler(c, http.StatusServiceUnavailable, "ServiceUnavailable", "service is sealed")
+			}
+			defer gate.Release()
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSealGate is a SealGate with a fixed state that counts admissions
+type mockSealGate struct {
+	sealed   bool
+	acquired int
+	released int
+}
+
+func (m *mockSealGate) Acquire() bool {
+	if m.sealed {
+		return false
+	}
+	m.acquired++
+	return true
+}
+
+func (m *mockSealGate) Release() {
+	m.released++
+}
+
+func serveSealGuarded(gate SealGate, path string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := SealGuard(gate, "/authorizer/v1/health", "/authorizer/v1/sys/")(func(c echo.Context) error {
+		return c.String(http.StatusOK, "success")
+	})
+	_ = handler(c)
+
+	return rec
+}
+
+func TestSealGuard_Sealed(t *testing.T) {
+	gate := &mockSealGate{sealed: true}
+
+	assert.Equal(t, http.StatusServiceUnavailable, serveSealGuarded(gate, "/authorizer/v1/users").Code)
+	assert.Equal(t, http.StatusOK, serveSealGuarded(gate, "/authorizer/v1/health").Code)
+	assert.Equal(t, http.StatusOK, serveSealGuarded(gate, "/authorizer/v1/sys/unseal").Code)
+}
+
+func TestSealGuard_Unsealed(t *testing.T) {
+	gate := &mockSealGate{}
+
+	assert.Equal(t, http.StatusOK, serveSealGuarded(gate, "/authorizer/v1/users").Code)
+	assert.Equal(t, 1, gate.acquired)
+	assert.Equal(t, 1, gate.released)
+}
@@ -18,9 +18,33 @@ type RouterConfig struct {
 	AppHandler    *handler.AppHandler
 	HealthHandler *handler.HealthHandler
 
+	AccessTokenHandler    *handler.AccessTokenHandler
+	ServiceAccountHandler *handler.ServiceAccountHandler
+	OAuthHandler          *handler.OAuthHandler
+	FederationHandler     *handler.FederationHandler
+	MagicLinkHandler      *handler.MagicLinkHandler
+	SealHandler           *handler.SealHandler
+	AuthorizeHandler      *handler.AuthorizeHandler
+	RelationHandler       *handler.RelationHandler
+	OrganizationHandler   *handler.OrganizationHandler
+	GroupHandler          *handler.GroupHandler
+	AccessRequestHandler  *handler.AccessRequestHandler
+	SoDHandler            *handler.SoDHandler
+
 	// Middleware
 	JWTMiddleware echo.MiddlewareFunc
 
+	// LoginRateLimit limits login attempts per client; it is shared by
+	// every route that authenticates a user
+	LoginRateLimit echo.MiddlewareFunc
+
+	// SealGuard rejects requests while the service is sealed
+	SealGuard echo.MiddlewareFunc
+
+	// RoleLookup resolves the application of role routes, so application
+	// admins can manage the roles of their application
+	RoleLookup appMiddleware.RoleLookup
+
 	// Logger
 	Logger *logger.Logger
 }
@@ -30,7 +54,7 @@ func Setup(e *echo.Echo, cfg *RouterConfig) error {
 	// Setup CORS middleware
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-CSRF-Token"},
 		AllowCredentials: true,
 		AllowMethods:     []string{echo.POST, echo.GET, echo.OPTIONS, echo.PATCH, echo.PUT, echo.DELETE},
 	}))
@@ -38,6 +62,9 @@ func Setup(e *echo.Echo, cfg *RouterConfig) error {
 	// Setup basic middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
+	if cfg.SealGuard != nil {
+		e.Use(cfg.SealGuard)
+	}
 
 	// API version group
 	v1 := e.Group("authorizer/v1")
@@ -47,16 +74,27 @@ func Setup(e *echo.Echo, cfg *RouterConfig) error {
 
 	// Public auth routes
 	pblAuth := public.Group("/auth")
-	mapAuthPublicRoutes(pblAuth, cfg.AuthHandler)
+	mapAuthPublicRoutes(pblAuth, cfg.AuthHandler, cfg.LoginRateLimit)
+	mapMagicLinkPublicRoutes(pblAuth, cfg.MagicLinkHandler, cfg.LoginRateLimit)
+	mapServiceAccountPublicRoutes(pblAuth, cfg.ServiceAccountHandler)
+	mapFederationPublicRoutes(pblAuth, cfg.FederationHandler)
 
 	// Public user routes
 	pblUser := public.Group("/users")
 	mapUserPublicRoutes(pblUser, cfg.UserHandler)
 
+	// Public OAuth routes
+	pblOAuth := public.Group("/oauth")
+	mapOAuthPublicRoutes(pblOAuth, cfg.OAuthHandler)
+
 	// Public health routes
 	pblHealth := public.Group("/health")
 	pblHealth.GET("", cfg.HealthHandler.Check())
 
+	// Public seal routes
+	pblSys := public.Group("/sys")
+	mapSealPublicRoutes(pblSys, cfg.SealHandler, cfg.LoginRateLimit)
+
 	// JWKS endpoint (public, outside of /v1)
 	e.GET("/.well-known/jwks.json", cfg.AuthHandler.GetJWKS())
 
@@ -67,6 +105,7 @@ func Setup(e *echo.Echo, cfg *RouterConfig) error {
 	// Private auth routes
 	pvtAuth := private.Group("/auth")
 	mapAuthPrivateRoutes(pvtAuth, cfg.AuthHandler)
+	pvtAuth.GET("/organizations", cfg.OrganizationHandler.ListMine())
 
 	// Private user routes
 	pvtUser := private.Group("/users")
@@ -74,7 +113,11 @@ func Setup(e *echo.Echo, cfg *RouterConfig) error {
 
 	// Private role routes
 	pvtRole := private.Group("/roles")
-	mapRolePrivateRoutes(pvtRole, cfg.RoleHandler)
+	mapRolePrivateRoutes(pvtRole, cfg.RoleHandler, cfg.RoleLookup)
+
+	// Private grant condition routes
+	pvtCond := private.Group("/conditions")
+	mapConditionPrivateRoutes(pvtCond, cfg.RoleHandler)
 
 	// Private application routes
 	pvtApp := private.Group("/applications")
@@ -84,17 +127,151 @@ func Setup(e *echo.Echo, cfg *RouterConfig) error {
 	pvtPerm := private.Group("/permissions")
 	mapPermPrivateRoutes(pvtPerm, cfg.PermHandler)
 
+	// Private personal access token routes
+	pvtToken := private.Group("/tokens")
+	mapAccessTokenPrivateRoutes(pvtToken, cfg.AccessTokenHandler)
+
+	// Private service account routes
+	pvtServiceAccount := private.Group("/service-accounts")
+	mapServiceAccountPrivateRoutes(pvtServiceAccount, cfg.ServiceAccountHandler)
+
+	// Private OAuth routes
+	pvtOAuth := private.Group("/oauth")
+	mapOAuthPrivateRoutes(pvtOAuth, cfg.OAuthHandler)
+
+	// Private seal routes
+	pvtSys := private.Group("/sys")
+	mapSealPrivateRoutes(pvtSys, cfg.SealHandler)
+
+	// Private authorization decision routes
+	pvtAuthorize := private.Group("/authorize")
+	mapAuthorizePrivateRoutes(pvtAuthorize, cfg.AuthorizeHandler)
+
+	// Private organization routes
+	pvtOrg := private.Group("/organizations")
+	mapOrganizationPrivateRoutes(pvtOrg, cfg.OrganizationHandler)
+
+	// Private group routes
+	pvtGroup := private.Group("/groups")
+	mapGroupPrivateRoutes(pvtGroup, cfg.GroupHandler)
+
+	// Private access request routes
+	pvtAccessRequest := private.Group("/access-requests")
+	mapAccessRequestPrivateRoutes(pvtAccessRequest, cfg.AccessRequestHandler)
+	mapAccessApproverPrivateRoutes(pvtApp, cfg.AccessRequestHandler)
+
+	// Private separation-of-duties routes
+	pvtSoD := private.Group("/sod-constraints")
+	mapSoDPrivateRoutes(pvtSoD, pvtApp, cfg.SoDHandler)
+
+	// Private relationship-based access routes
+	pvtRelation := private.Group("/relations")
+	mapRelationPrivateRoutes(pvtRelation, cfg.RelationHandler)
+
 	return nil
 }
 
 // mapAuthPublicRoutes maps public authentication routes
-func mapAuthPublicRoutes(g *echo.Group, h *handler.AuthHandler) {
-	g.POST("/login", h.Login())
+func mapAuthPublicRoutes(g *echo.Group, h *handler.AuthHandler, rateLimit echo.MiddlewareFunc) {
+	g.POST("/login", h.Login(), rateLimit)
+}
+
+// mapMagicLinkPublicRoutes maps public passwordless login routes
+func mapMagicLinkPublicRoutes(g *echo.Group, h *handler.MagicLinkHandler, rateLimit echo.MiddlewareFunc) {
+	g.POST("/magic-link", h.Request(), rateLimit)
+	g.POST("/magic-link/consume", h.Consume(), rateLimit)
 }
 
 // mapAuthPrivateRoutes maps private authentication routes
 func mapAuthPrivateRoutes(g *echo.Group, h *handler.AuthHandler) {
 	g.POST("/logout", h.Logout())
+	g.POST("/token/exchange", h.ExchangeToken())
+	g.GET("/permissions", h.GetPermissions())
+}
+
+// mapSealPublicRoutes maps public seal status and unseal routes
+func mapSealPublicRoutes(g *echo.Group, h *handler.SealHandler, rateLimit echo.MiddlewareFunc) {
+	g.GET("/seal-status", h.Status())
+	g.POST("/unseal", h.Unseal(), rateLimit)
+}
+
+// mapSealPrivateRoutes maps private seal routes
+func mapSealPrivateRoutes(g *echo.Group, h *handler.SealHandler) {
+	g.POST("/seal", h.Seal(), appMiddleware.RequirePermission("AUTHORIZER", "system.seal"))
+}
+
+// mapAuthorizePrivateRoutes maps private authorization decision routes
+func mapAuthorizePrivateRoutes(g *echo.Group, h *handler.AuthorizeHandler) {
+	g.POST("/check", h.Check(), appMiddleware.RequirePermission("AUTHORIZER", "authorize.check"))
+	g.POST("/check/batch", h.BatchCheck(), appMiddleware.RequirePermission("AUTHORIZER", "authorize.check"))
+}
+
+// mapOrganizationPrivateRoutes maps private organization routes
+func mapOrganizationPrivateRoutes(g *echo.Group, h *handler.OrganizationHandler) {
+	g.POST("", h.Create(), appMiddleware.RequirePermission("AUTHORIZER", "organization.create"))
+	g.GET("", h.GetList(), appMiddleware.RequirePermission("AUTHORIZER", "organization.read"))
+	g.GET("/:id", h.GetDetail(), appMiddleware.RequirePermission("AUTHORIZER", "organization.read"))
+	g.GET("/:id/members", h.ListMembers(), appMiddleware.RequirePermission("AUTHORIZER", "organization.read"))
+	g.POST("/:id/members", h.AddMember(), appMiddleware.RequirePermission("AUTHORIZER", "organization.manage_members"))
+	g.DELETE("/:id/members/:user_id", h.RemoveMember(), appMiddleware.RequirePermission("AUTHORIZER", "organization.manage_members"))
+	g.PUT("/:id/members/:user_id/applications/:app_id/roles", h.AssignRoles(), appMiddleware.RequirePermission("AUTHORIZER", "organization.assign_roles"))
+}
+
+// mapGroupPrivateRoutes maps private group routes
+func mapGroupPrivateRoutes(g *echo.Group, h *handler.GroupHandler) {
+	g.POST("", h.Create(), appMiddleware.RequirePermission("AUTHORIZER", "group.create"))
+	g.GET("", h.GetList(), appMiddleware.RequirePermission("AUTHORIZER", "group.read"))
+	g.GET("/:id", h.GetDetail(), appMiddleware.RequirePermission("AUTHORIZER", "group.read"))
+	g.DELETE("/:id", h.Delete(), appMiddleware.RequirePermission("AUTHORIZER", "group.delete"))
+	g.GET("/:id/members", h.ListMembers(), appMiddleware.RequirePermission("AUTHORIZER", "group.read"))
+	g.POST("/:id/members", h.AddMember(), appMiddleware.RequirePermission("AUTHORIZER", "group.manage_members"))
+	g.DELETE("/:id/members/:user_id", h.RemoveMember(), appMiddleware.RequirePermission("AUTHORIZER", "group.manage_members"))
+	g.GET("/:id/subgroups", h.ListSubgroups(), appMiddleware.RequirePermission("AUTHORIZER", "group.read"))
+	g.POST("/:id/subgroups", h.AddSubgroup(), appMiddleware.RequirePermission("AUTHORIZER", "group.manage_members"))
+	g.DELETE("/:id/subgroups/:subgroup_id", h.RemoveSubgroup(), appMiddleware.RequirePermission("AUTHORIZER", "group.manage_members"))
+	g.GET("/:id/roles", h.GetRoles(), appMiddleware.RequirePermission("AUTHORIZER", "group.read"))
+	g.PUT("/:id/applications/:app_id/roles", h.AssignRoles(), appMiddleware.RequirePermission("AUTHORIZER", "group.assign_roles"))
+}
+
+// mapAccessRequestPrivateRoutes maps private access request routes. Any
+// user may request access; deciding is limited to designated approvers.
+func mapAccessRequestPrivateRoutes(g *echo.Group, h *handler.AccessRequestHandler) {
+	g.POST("", h.Create())
+	g.GET("/mine", h.ListMine())
+	g.GET("/pending", h.ListPending())
+	g.GET("/:id", h.GetDetail())
+	g.POST("/:id/approve", h.Approve())
+	g.POST("/:id/deny", h.Deny())
+	g.POST("/:id/cancel", h.Cancel())
+}
+
+// mapAccessApproverPrivateRoutes maps private application approver routes
+func mapAccessApproverPrivateRoutes(g *echo.Group, h *handler.AccessRequestHandler) {
+	byID := appMiddleware.AppFromParam("id")
+	g.GET("/:id/approvers", h.ListApprovers(), appMiddleware.RequirePermission("AUTHORIZER", "access_request.manage_approvers", byID))
+	g.POST("/:id/approvers", h.AddApprover(), appMiddleware.RequirePermission("AUTHORIZER", "access_request.manage_approvers", byID))
+	g.DELETE("/:id/approvers/:approver_id", h.RemoveApprover(), appMiddleware.RequirePermission("AUTHORIZER", "access_request.manage_approvers", byID))
+}
+
+// mapSoDPrivateRoutes maps private separation-of-duties routes; constraints
+// are managed per application
+func mapSoDPrivateRoutes(g, app *echo.Group, h *handler.SoDHandler) {
+	byID := appMiddleware.AppFromParam("id")
+	g.GET("/violations", h.GetViolations(), appMiddleware.RequirePermission("AUTHORIZER", "sod_constraint.read"))
+	app.GET("/:id/sod-constraints", h.List(), appMiddleware.RequirePermission("AUTHORIZER", "sod_constraint.read", byID))
+	app.POST("/:id/sod-constraints", h.Create(), appMiddleware.RequirePermission("AUTHORIZER", "sod_constraint.manage", byID))
+	app.DELETE("/:id/sod-constraints/:constraint_id", h.Delete(), appMiddleware.RequirePermission("AUTHORIZER", "sod_constraint.manage", byID))
+}
+
+// mapRelationPrivateRoutes maps private relation schema, tuple and query routes
+func mapRelationPrivateRoutes(g *echo.Group, h *handler.RelationHandler) {
+	g.PUT("/schema", h.SetSchema(), appMiddleware.RequirePermission("AUTHORIZER", "relation.manage_schema"))
+	g.GET("/schema", h.GetSchema(), appMiddleware.RequirePermission("AUTHORIZER", "relation.read"))
+	g.POST("/tuples", h.WriteTuples(), appMiddleware.RequirePermission("AUTHORIZER", "relation.write"))
+	g.POST("/tuples/delete", h.DeleteTuples(), appMiddleware.RequirePermission("AUTHORIZER", "relation.write"))
+	g.POST("/check", h.Check(), appMiddleware.RequirePermission("AUTHORIZER", "relation.check"))
+	g.POST("/expand", h.Expand(), appMiddleware.RequirePermission("AUTHORIZER", "relation.read"))
+	g.POST("/list-objects", h.ListObjects(), appMiddleware.RequirePermission("AUTHORIZER", "relation.check"))
 }
 
 // mapUserPublicRoutes maps public user routes
@@ -107,21 +284,85 @@ func mapUserPrivateRoutes(g *echo.Group, h *handler.UserHandler) {
 	g.GET("/:id", h.GetUserProfile())
 	g.GET("", h.GetUserList())
 	g.PATCH("/:id", h.UpdateUserProfile())
-	g.POST("/:id/applications/:app_id/roles", h.AssignUserRoles(), appMiddleware.RequirePermission("AUTHORIZER", "user.assign_roles"))
+	g.POST("/:id/applications/:app_id/roles", h.AssignUserRoles(), appMiddleware.RequirePermission("AUTHORIZER", "user.assign_roles", appMiddleware.AppFromParam("app_id")))
+}
+
+// mapRolePrivateRoutes maps private role routes. Admins of an application
+// may manage its roles; the application is that of the role, or the one
+// named in the body when creating it.
+func mapRolePrivateRoutes(g *echo.Group, h *handler.RoleHandler, roles appMiddleware.RoleLookup) {
+	ofRole := appMiddleware.AppFromRole("id", roles)
+	g.POST("", h.Create(), appMiddleware.RequirePermission("AUTHORIZER", "role.create", appMiddleware.AppFromBody("application_id")))
+	g.POST("/:id/permissions", h.GrantRolePermissions(), appMiddleware.RequirePermission("AUTHORIZER", "role.update", ofRole))
+	g.GET("/:id/permissions", h.GetRolePermissions(), appMiddleware.RequirePermission("AUTHORIZER", "role.read", ofRole))
+	g.PUT("/:id/parents", h.SetRoleParents(), appMiddleware.RequirePermission("AUTHORIZER", "role.update", ofRole))
 }
 
-// mapRolePrivateRoutes maps private role routes
-func mapRolePrivateRoutes(g *echo.Group, h *handler.RoleHandler) {
-	g.POST("", h.Create(), appMiddleware.RequirePermission("AUTHORIZER", "role.create"))
-	g.POST("/:id/permissions", h.GrantRolePermissions())
+// mapConditionPrivateRoutes maps private grant condition routes
+func mapConditionPrivateRoutes(g *echo.Group, h *handler.RoleHandler) {
+	g.GET("/:id", h.GetCondition(), appMiddleware.RequirePermission("AUTHORIZER", "condition.read"))
 }
 
-// mapAppPrivateRoutes maps private application routes
+// mapAppPrivateRoutes maps private application routes. Admins of an
+// application may manage it, including its other admins.
 func mapAppPrivateRoutes(g *echo.Group, h *handler.AppHandler) {
+	byID := appMiddleware.AppFromParam("id")
 	g.POST("", h.Create(), appMiddleware.RequirePermission("AUTHORIZER", "application.create"))
+	g.PATCH("/:id/token-mode", h.UpdateTokenMode(), appMiddleware.RequirePermission("AUTHORIZER", "application.update", byID))
+	g.PATCH("/:id/hook-failure-policy", h.UpdateHookFailurePolicy(), appMiddleware.RequirePermission("AUTHORIZER", "application.manage_hooks", byID))
+	g.GET("/:id/hooks", h.ListHooks(), appMiddleware.RequirePermission("AUTHORIZER", "application.manage_hooks", byID))
+	g.POST("/:id/hooks", h.CreateHook(), appMiddleware.RequirePermission("AUTHORIZER", "application.manage_hooks", byID))
+	g.DELETE("/:id/hooks/:hook_id", h.DeleteHook(), appMiddleware.RequirePermission("AUTHORIZER", "application.manage_hooks", byID))
+	g.GET("/:id/admins", h.ListAdmins(), appMiddleware.RequirePermission("AUTHORIZER", "application.manage_admins", byID))
+	g.PUT("/:id/admins/:user_id", h.SaveAdmin(), appMiddleware.RequirePermission("AUTHORIZER", "application.manage_admins", byID))
+	g.DELETE("/:id/admins/:user_id", h.RemoveAdmin(), appMiddleware.RequirePermission("AUTHORIZER", "application.manage_admins", byID))
 }
 
 // mapPermPrivateRoutes maps private permission routes
 func mapPermPrivateRoutes(g *echo.Group, h *handler.PermHandler) {
 	g.POST("/sync", h.Sync(), appMiddleware.RequirePermission("AUTHORIZER", "permission.sync"))
 }
+
+// mapAccessTokenPrivateRoutes maps private personal access token routes
+func mapAccessTokenPrivateRoutes(g *echo.Group, h *handler.AccessTokenHandler) {
+	g.POST("", h.Create())
+	g.GET("", h.List())
+	g.DELETE("/:id", h.Revoke())
+}
+
+// mapServiceAccountPublicRoutes maps public service account routes
+func mapServiceAccountPublicRoutes(g *echo.Group, h *handler.ServiceAccountHandler) {
+	g.POST("/service-token", h.IssueToken())
+}
+
+// mapServiceAccountPrivateRoutes maps private service account routes
+func mapServiceAccountPrivateRoutes(g *echo.Group, h *handler.ServiceAccountHandler) {
+	g.POST("", h.Create(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.create"))
+	g.GET("", h.List(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.read"))
+	g.GET("/:id", h.Get(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.read"))
+	g.PATCH("/:id", h.Update(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.update"))
+	g.DELETE("/:id", h.Delete(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.delete"))
+	g.GET("/:id/roles", h.GetRoles(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.read"))
+	g.PUT("/:id/roles", h.AssignRoles(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.assign_roles"))
+	g.GET("/:id/keys", h.ListKeys(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.manage_keys"))
+	g.POST("/:id/keys", h.CreateKey(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.manage_keys"))
+	g.DELETE("/:id/keys/:key_id", h.RevokeKey(), appMiddleware.RequirePermission("AUTHORIZER", "service_account.manage_keys"))
+}
+
+// mapOAuthPublicRoutes maps public OAuth routes
+func mapOAuthPublicRoutes(g *echo.Group, h *handler.OAuthHandler) {
+	g.POST("/device/code", h.DeviceCode())
+	g.POST("/token", h.Token())
+}
+
+// mapOAuthPrivateRoutes maps private OAuth routes
+func mapOAuthPrivateRoutes(g *echo.Group, h *handler.OAuthHandler) {
+	g.GET("/device", h.GetDevice())
+	g.POST("/device", h.ApproveDevice())
+}
+
+// mapFederationPublicRoutes maps public federated login routes
+func mapFederationPublicRoutes(g *echo.Group, h *handler.FederationHandler) {
+	g.GET("/federated/:provider/login", h.Login())
+	g.GET("/federated/:provider/callback", h.Callback())
+}
@@ -0,0 +1,8 @@
+package entity
+
+// AccessDecision is the outcome of an authorization check, with a
+// human-readable reason for audit and debugging
+type AccessDecision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+}
@@ -0,0 +1,66 @@
+package entity
+
+import "time"
+
+// Access request statuses. A request is created pending and is closed once
+// by approval, denial or cancellation.
+const (
+	AccessRequestPending   = "PENDING"
+	AccessRequestApproved  = "APPROVED"
+	AccessRequestDenied    = "DENIED"
+	AccessRequestCancelled = "CANCELLED"
+)
+
+// Access request event actions
+const (
+	AccessRequestActionRequested = "REQUESTED"
+	AccessRequestActionApproved  = "APPROVED"
+	AccessRequestActionDenied    = "DENIED"
+	AccessRequestActionCancelled = "CANCELLED"
+)
+
+// AccessRequest is a user's request to be assigned a role in an
+// application. ValidUntil is the end of the assignment the user asked
+// for; nil asks for an open-ended one. RoleCode and AppCode are resolved
+// when the request is read.
+type AccessRequest struct {
+	ID              string     `db:"id"`
+	UserID          string     `db:"user_id"`
+	ApplicationID   string     `db:"application_id"`
+	RoleID          string     `db:"role_id"`
+	RoleCode        string     `db:"-"`
+	AppCode         string     `db:"-"`
+	Justification   string     `db:"justification"`
+	ValidUntil      *time.Time `db:"valid_until"`
+	Status          string     `db:"status"`
+	DecidedBy       *string    `db:"decided_by"`
+	DecidedAt       *time.Time `db:"decided_at"`
+	DecisionComment *string    `db:"decision_comment"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+}
+
+// IsPending reports whether the request is still open
+func (r *AccessRequest) IsPending() bool {
+	return r.Status == AccessRequestPending
+}
+
+// AccessRequestEvent is one step in the lifecycle of an access request
+type AccessRequestEvent struct {
+	ID        int64     `db:"id"`
+	RequestID string    `db:"request_id"`
+	ActorID   *string   `db:"actor_id"`
+	Action    string    `db:"action"`
+	Comment   *string   `db:"comment"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// AccessApprover designates a user who decides access requests for every
+// role of an application, or for one role when RoleID is set
+type AccessApprover struct {
+	ID            string    `db:"id"`
+	ApplicationID string    `db:"application_id"`
+	RoleID        *string   `db:"role_id"`
+	UserID        string    `db:"user_id"`
+	CreatedAt     time.Time `db:"created_at"`
+}
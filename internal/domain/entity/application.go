@@ -2,13 +2,24 @@ package entity
 
 import "time"
 
+// Token modes decide what an access token for an application carries.
+// FAT tokens embed every role and permission; THIN tokens carry only the
+// subject, audience and permissions version, and relying services resolve
+// permissions through the permissions endpoint.
+const (
+	TokenModeFat  = "FAT"
+	TokenModeThin = "THIN"
+)
+
 type Application struct {
-	ID          string                 `db:"id"`
-	Code        string                 `db:"code"`
-	Name        string                 `db:"name"`
-	Description string                 `db:"description"`
-	Metadata    map[string]interface{} `db:"metadata"`
-	CreatedAt   time.Time              `db:"created_at"`
-	UpdatedAt   time.Time              `db:"updated_at"`
-	DeletedAt   *time.Time             `db:"deleted_at"`
+	ID                string                 `db:"id"`
+	Code              string                 `db:"code"`
+	Name              string                 `db:"name"`
+	Description       string                 `db:"description"`
+	Metadata          map[string]interface{} `db:"metadata"`
+	TokenMode         string                 `db:"token_mode"`
+	HookFailurePolicy string                 `db:"hook_failure_policy"`
+	CreatedAt         time.Time              `db:"created_at"`
+	UpdatedAt         time.Time              `db:"updated_at"`
+	DeletedAt         *time.Time             `db:"deleted_at"`
 }
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// ApplicationAdmin delegates administration of one application to a user.
+// Permissions are AUTHORIZER permission codes, possibly wildcards, that
+// the user holds for that application only.
+type ApplicationAdmin struct {
+	ApplicationID string    `db:"application_id"`
+	UserID        string    `db:"user_id"`
+	Permissions   []string  `db:"permissions"`
+	CreatedBy     *string   `db:"created_by"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
@@ -24,8 +24,26 @@ type Claims struct {
 	// Email is the email address of the authenticated user
 	Email string `json:"email"`
 
+	// PrincipalType tells whether the subject is a user or a service account
+	PrincipalType string `json:"principal_type,omitempty"`
+
 	// Authorization contains the authorization information for the user across different applications
 	Authorization []Authorization `json:"authorization"`
+
+	// Organization is the code of the organization whose tenant roles the
+	// authorization includes, empty when the token is not for one
+	Organization string `json:"org,omitempty"`
+
+	// AppAdmin maps the IDs of the applications the user administers to
+	// the AUTHORIZER permissions delegated for each of them
+	AppAdmin map[string][]string `json:"app_admin,omitempty"`
+
+	// PermissionsVersion is the user's permissions version at issuance time.
+	// Thin tokens carry it instead of the authorization array.
+	PermissionsVersion int64 `json:"pv,omitempty"`
+
+	// Extra contains additional claims contributed by token hooks
+	Extra map[string]interface{} `json:"ext,omitempty"`
 }
 
 // Authorization represents the authorization information for a specific application.
@@ -39,4 +57,11 @@ type Authorization struct {
 
 	// Permissions is the list of permission codes granted to the user for this application
 	Permissions []string `json:"permissions"`
+
+	// Conditions lists permissions granted only under conditions, mapping
+	// each to the references of the conditions it is granted under; any
+	// one of them holding grants the permission. These permissions are
+	// not in Permissions, so consumers that do not evaluate conditions
+	// deny them.
+	Conditions map[string][]string `json:"conditions,omitempty"`
 }
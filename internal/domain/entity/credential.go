@@ -0,0 +1,31 @@
+package entity
+
+// Credential sources reported by credential verifiers
+const (
+	CredentialSourceLocal = "local"
+	CredentialSourceLDAP  = "ldap"
+)
+
+// VerifiedCredential is the outcome of a successful password check by a
+// credential verifier. Local verifiers resolve the user directly; directory
+// verifiers return the external identity to be linked to a local user.
+type VerifiedCredential struct {
+	Source   string
+	User     *User
+	Identity *ExternalIdentity
+
+	// Groups are the directory groups the user is a member of
+	Groups []string
+
+	// GroupRoleMappings are the group to role mappings configured for the
+	// source, applied to the user's roles on login
+	GroupRoleMappings []GroupRoleMapping
+}
+
+// GroupRoleMapping grants the listed roles of an application to members of
+// a directory group
+type GroupRoleMapping struct {
+	Group   string
+	AppCode string
+	Roles   []string
+}
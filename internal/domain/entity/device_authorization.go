@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// Device authorization states (RFC 8628)
+const (
+	DeviceAuthorizationPending  = "PENDING"
+	DeviceAuthorizationApproved = "APPROVED"
+	DeviceAuthorizationDenied   = "DENIED"
+)
+
+// DeviceAuthorization is a pending OAuth 2.0 device authorization request.
+// ID is a hash of the device code; the device code itself is never stored.
+type DeviceAuthorization struct {
+	ID           string        `json:"id"`
+	UserCode     string        `json:"user_code"`
+	AppCode      string        `json:"app_code"`
+	Status       string        `json:"status"`
+	UserID       string        `json:"user_id,omitempty"`
+	Interval     time.Duration `json:"interval"`
+	LastPolledAt *time.Time    `json:"last_polled_at,omitempty"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// Group is a set of users that roles can be assigned to at once. Groups
+// nest: the members of a subgroup are members of every group containing
+// it.
+type Group struct {
+	ID          string    `db:"id"`
+	Code        string    `db:"code"`
+	Name        string    `db:"name"`
+	Description *string   `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+type GroupMember struct {
+	GroupID   string    `db:"group_id"`
+	UserID    string    `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// MagicLink is a pending passwordless login. It is stored under the hash
+// of the emailed token and can be consumed once for the bound application.
+type MagicLink struct {
+	UserID    string    `json:"user_id"`
+	AppCode   string    `json:"app_code"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// Organization is a tenant. Users become members of organizations and hold
+// tenant roles within each one.
+type Organization struct {
+	ID        string    `db:"id"`
+	Code      string    `db:"code"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type OrganizationMember struct {
+	OrganizationID string    `db:"organization_id"`
+	UserID         string    `db:"user_id"`
+	CreatedAt      time.Time `db:"created_at"`
+}
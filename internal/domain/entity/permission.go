@@ -2,6 +2,21 @@ package entity
 
 import "time"
 
+const (
+	// AuthorizerAppCode is the application code of the authorizer itself,
+	// whose catalog holds the permissions guarding its admin API
+	AuthorizerAppCode = "AUTHORIZER"
+
+	// SuperadminPermission is reserved in the AUTHORIZER catalog. Granted
+	// to a global role it grants every permission in every application;
+	// application roles cannot hold it.
+	SuperadminPermission = "superadmin"
+
+	// GlobalAuthorization is the App of the authorization entry that
+	// lists a user's global roles
+	GlobalAuthorization = "GLOBAL"
+)
+
 type Permission struct {
 	ID            string     `db:"id"`
 	ApplicationID *string    `db:"application _id"`
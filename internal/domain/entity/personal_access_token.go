@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// PersonalAccessTokenPrefix marks a bearer credential as a personal access
+// token rather than a JWT
+const PersonalAccessTokenPrefix = "azp_"
+
+// PersonalAccessToken is a long-lived, user-owned credential scoped to one
+// application and a subset of the user's permissions in it. Only a hash of
+// the token is stored; the prefix is used to look it up.
+type PersonalAccessToken struct {
+	ID            string     `db:"id"`
+	UserID        string     `db:"user_id"`
+	ApplicationID string     `db:"application_id"`
+	Name          string     `db:"name"`
+	Prefix        string     `db:"prefix"`
+	TokenHash     string     `db:"token_hash"`
+	Permissions   []string   `db:"permissions"`
+	ExpiresAt     time.Time  `db:"expires_at"`
+	LastUsedAt    *time.Time `db:"last_used_at"`
+	RevokedAt     *time.Time `db:"revoked_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
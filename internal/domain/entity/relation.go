@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// RelationTuple is a stored relation tuple of an application: the subject
+// SubjectNamespace:SubjectID, or the userset SubjectNamespace:SubjectID#
+// SubjectRelation when SubjectRelation is set, has Relation to the object
+// Namespace:ObjectID.
+type RelationTuple struct {
+	ApplicationID    string    `db:"application_id"`
+	Namespace        string    `db:"namespace"`
+	ObjectID         string    `db:"object_id"`
+	Relation         string    `db:"relation"`
+	SubjectNamespace string    `db:"subject_namespace"`
+	SubjectID        string    `db:"subject_id"`
+	SubjectRelation  string    `db:"subject_relation"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+// RelationSchema is an application's namespace schema, stored as the JSON
+// document described by relation.Schema
+type RelationSchema struct {
+	ApplicationID string    `db:"application_id"`
+	Definition    []byte    `db:"definition"`
+	Version       int       `db:"version"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// RelationRef is a namespace and relation referenced by stored tuples.
+// Relation is empty for namespaces used by plain subjects.
+type RelationRef struct {
+	Namespace string
+	Relation  string
+}
@@ -2,6 +2,16 @@ package entity
 
 import "time"
 
+// Role scopes. Application roles belong to one application; global roles
+// are not bound to one and may be granted permissions of any application.
+// Tenant roles belong to one application like application roles, but are
+// only assigned to users within an organization.
+const (
+	RoleScopeGlobal      = "GLOBAL"
+	RoleScopeApplication = "APPLICATION"
+	RoleScopeTenant      = "TENANT"
+)
+
 type Role struct {
 	ID            string     `db:"id"`
 	ApplicationID *string    `db:"application _id"`
@@ -13,3 +23,19 @@ type Role struct {
 	UpdatedAt     time.Time  `db:"updated_at"`
 	DeletedAt     *time.Time `db:"deleted_at"`
 }
+
+// IsGlobal reports whether the role has global scope
+func (r *Role) IsGlobal() bool {
+	return r.Scope != nil && *r.Scope == RoleScopeGlobal
+}
+
+// IsTenant reports whether the role has tenant scope
+func (r *Role) IsTenant() bool {
+	return r.Scope != nil && *r.Scope == RoleScopeTenant
+}
+
+// RoleParents is the set of roles a role directly inherits from
+type RoleParents struct {
+	RoleID    string
+	ParentIDs []string
+}
@@ -5,5 +5,36 @@ import "time"
 type RolePermission struct {
 	RoleID       string    `db:"role_id"`
 	PermissionID string    `db:"permission_id"`
+	ConditionID  *string   `db:"condition_id"`
 	CreatedAt    time.Time `db:"created_at"`
 }
+
+// GrantCondition is an expression a conditional grant is evaluated
+// against. Its ID is derived from the expression, see condition.Ref.
+type GrantCondition struct {
+	ID         string    `db:"id"`
+	Expression string    `db:"expression"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// EffectivePermission is one permission a role holds, granted to the role
+// itself or inherited from one of its ancestors. SourceRoleID and
+// SourceRoleCode name the role the permission was granted to; Depth is 0
+// for direct grants and the number of parent links otherwise. ApplicationID
+// is the permission's application. ConditionID
+// and Condition are set for conditional grants.
+type EffectivePermission struct {
+	PermissionID   string
+	PermissionCode string
+	ApplicationID  string
+	SourceRoleID   string
+	SourceRoleCode string
+	Depth          int
+	ConditionID    string
+	Condition      string
+}
+
+// Inherited reports whether the permission comes from an ancestor role
+func (p *EffectivePermission) Inherited() bool {
+	return p.Depth > 0
+}
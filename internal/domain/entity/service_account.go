@@ -0,0 +1,38 @@
+package entity
+
+import "time"
+
+// Principal types distinguish human users from service accounts in claims
+const (
+	PrincipalTypeUser           = "user"
+	PrincipalTypeServiceAccount = "service_account"
+)
+
+// ServiceAccountKeyPrefix marks a credential as a service account key
+const ServiceAccountKeyPrefix = "azs_"
+
+// ServiceAccount is a non-human principal owned by an application. It has
+// no email or password and authenticates only with its keys.
+type ServiceAccount struct {
+	ID            string     `db:"id"`
+	ApplicationID string     `db:"application_id"`
+	Name          string     `db:"name"`
+	Description   string     `db:"description"`
+	IsActive      bool       `db:"is_active"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	DeletedAt     *time.Time `db:"deleted_at"`
+}
+
+// ServiceAccountKey is a credential of a service account. Only a hash of
+// the key is stored; the prefix is used to look it up.
+type ServiceAccountKey struct {
+	ID               string     `db:"id"`
+	ServiceAccountID string     `db:"service_account_id"`
+	Prefix           string     `db:"prefix"`
+	KeyHash          string     `db:"key_hash"`
+	ExpiresAt        *time.Time `db:"expires_at"`
+	LastUsedAt       *time.Time `db:"last_used_at"`
+	RevokedAt        *time.Time `db:"revoked_at"`
+	CreatedAt        time.Time  `db:"created_at"`
+}
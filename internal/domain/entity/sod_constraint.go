@@ -0,0 +1,54 @@
+package entity
+
+import "time"
+
+// Role sources: how a user holds a role
+const (
+	RoleSourceDirect       = "DIRECT"
+	RoleSourceGroup        = "GROUP"
+	RoleSourceOrganization = "ORGANIZATION"
+)
+
+// SoDConstraint is a static separation-of-duties constraint: a user may
+// hold at most MaxRoles of its roles in the application at once, however
+// they hold them. RoleIDs and RoleCodes list the roles in the same order.
+type SoDConstraint struct {
+	ID            string    `db:"id"`
+	ApplicationID string    `db:"application_id"`
+	Code          string    `db:"code"`
+	Name          string    `db:"name"`
+	Description   *string   `db:"description"`
+	MaxRoles      int       `db:"max_roles"`
+	RoleIDs       []string  `db:"-"`
+	RoleCodes     []string  `db:"-"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// RoleSource is where a held role comes from. ID is the group or
+// organization for those kinds and empty for direct assignments.
+type RoleSource struct {
+	Kind string
+	ID   string
+}
+
+// HeldRole is a role a user or service account holds, or will hold once
+// its validity starts
+type HeldRole struct {
+	RoleID   string
+	RoleCode string
+	Source   RoleSource
+}
+
+// SoDViolation is a user or service account holding more roles of a
+// constraint than it allows. Exactly one of UserID and ServiceAccountID is
+// set.
+type SoDViolation struct {
+	ConstraintID     string
+	ConstraintCode   string
+	ApplicationID    string
+	MaxRoles         int
+	UserID           string
+	ServiceAccountID string
+	RoleCodes        []string
+}
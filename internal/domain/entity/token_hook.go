@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+// Hook failure policies decide what happens to a login when one of the
+// application's hooks cannot be reached or answers with an invalid
+// response. FAIL_CLOSED is the default.
+const (
+	HookFailOpen   = "FAIL_OPEN"
+	HookFailClosed = "FAIL_CLOSED"
+)
+
+// TokenHook is an HTTP callback invoked after claims are built and
+// before the access token is signed for an application.
+type TokenHook struct {
+	ID            string     `db:"id"`
+	ApplicationID string     `db:"application_id"`
+	Name          string     `db:"name"`
+	URL           string     `db:"url"`
+	Secret        string     `db:"secret"`
+	TimeoutMs     int        `db:"timeout_ms"`
+	IsActive      bool       `db:"is_active"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	DeletedAt     *time.Time `db:"deleted_at"`
+}
+
+// TokenHookRequest is the payload sent to a token hook.
+type TokenHookRequest struct {
+	Event       string  `json:"event"`
+	Application string  `json:"application"`
+	UserID      string  `json:"user_id"`
+	Username    string  `json:"username"`
+	Email       string  `json:"email"`
+	Claims      *Claims `json:"claims"`
+}
+
+// TokenHookResult is the decision returned by a token hook.
+// A hook that allows the login may add extra claims to the token.
+type TokenHookResult struct {
+	Allow  bool                   `json:"allow"`
+	Reason string                 `json:"reason,omitempty"`
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// UserIdentity links a user to an account at an upstream identity provider
+type UserIdentity struct {
+	ID          string     `db:"id"`
+	UserID      string     `db:"user_id"`
+	Provider    string     `db:"provider"`
+	Subject     string     `db:"subject"`
+	Email       string     `db:"email"`
+	CreatedAt   time.Time  `db:"created_at"`
+	LastLoginAt *time.Time `db:"last_login_at"`
+}
+
+// ExternalIdentity is the identity asserted by an upstream provider's ID
+// token after claim mapping
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	FullName      string
+}
+
+// FederationState is kept between the login redirect and the callback of a
+// federated login
+type FederationState struct {
+	Provider     string    `json:"provider"`
+	AppCode      string    `json:"app_code"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	Mode         string    `json:"mode"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -2,8 +2,33 @@ package entity
 
 import "time"
 
+// UserRole is a role assigned directly to a user. The assignment is in
+// effect from ValidFrom until ValidUntil; a nil bound is open.
 type UserRole struct {
-	UserID    string    `db:"user_id"`
-	RoleID    string    `db:"role_id"`
-	CreatedAt time.Time `db:"created_at"`
+	UserID     string     `db:"user_id"`
+	RoleID     string     `db:"role_id"`
+	ValidFrom  *time.Time `db:"valid_from"`
+	ValidUntil *time.Time `db:"valid_until"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+// RoleGrant is one permission a user holds through one role. AppCode is
+// the application the permission belongs to. An application role without
+// permissions appears once with an empty PermissionCode and its own
+// application. InheritedFrom is the code of the ancestor role the
+// permission was granted to, empty for the role's own grants. Condition
+// is the expression a conditional grant holds under. Organization is the
+// code of the organization a tenant role is held in. Group is the code of
+// the group the role was assigned to, empty for roles assigned directly.
+type RoleGrant struct {
+	RoleID         string
+	RoleCode       string
+	Scope          string
+	Organization   string
+	Group          string
+	AppCode        string
+	PermissionCode string
+	InheritedFrom  string
+	ConditionID    string
+	Condition      string
 }
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+// ErrAccessRequestClosed is returned when deciding or cancelling a request
+// that is no longer pending
+var ErrAccessRequestClosed = errors.New("access request is not pending")
+
+// AccessRequestRepository stores access requests, their lifecycle events
+// and the approvers who decide them. Every change to a request records an
+// event in the same transaction.
+type AccessRequestRepository interface {
+	// Create stores a pending request and its REQUESTED event
+	Create(ctx context.Context, req *entity.AccessRequest) error
+	GetByID(ctx context.Context, id string) (*entity.AccessRequest, error)
+	// HasPending reports whether the user has an open request for the role
+	HasPending(ctx context.Context, userID, roleID string) (bool, error)
+	ListByUser(ctx context.Context, userID string) ([]*entity.AccessRequest, error)
+	// ListPendingForApprover returns the open requests the user is an
+	// approver for, leaving out their own
+	ListPendingForApprover(ctx context.Context, approverID string) ([]*entity.AccessRequest, error)
+	ListEvents(ctx context.Context, requestID string) ([]*entity.AccessRequestEvent, error)
+	// Approve closes the pending request as approved and assigns its role
+	// to the requester until validUntil, nil for no end. An existing
+	// assignment of the role takes the new bounds. It returns
+	// ErrAccessRequestClosed when the request is no longer pending.
+	Approve(ctx context.Context, requestID, actorID string, comment *string, validUntil *time.Time) error
+	// Close closes the pending request with status DENIED or CANCELLED. It
+	// returns ErrAccessRequestClosed when the request is no longer pending.
+	Close(ctx context.Context, requestID, actorID, status string, comment *string) error
+
+	AddApprover(ctx context.Context, approver *entity.AccessApprover) error
+	RemoveApprover(ctx context.Context, appID, id string) error
+	ListApprovers(ctx context.Context, appID string) ([]*entity.AccessApprover, error)
+	// IsApprover reports whether the user decides requests for the role,
+	// as an approver of the role or of its whole application
+	IsApprover(ctx context.Context, userID, appID, roleID string) (bool, error)
+	// HasApprovers reports whether anyone decides requests for the role
+	HasApprovers(ctx context.Context, appID, roleID string) (bool, error)
+}
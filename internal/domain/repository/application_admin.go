@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type ApplicationAdminRepository interface {
+	// Save adds the admin or replaces the permissions of an existing one
+	Save(ctx context.Context, admin *entity.ApplicationAdmin) error
+	Delete(ctx context.Context, appID, userID string) error
+	ListByApp(ctx context.Context, appID string) ([]*entity.ApplicationAdmin, error)
+	ListByUser(ctx context.Context, userID string) ([]*entity.ApplicationAdmin, error)
+}
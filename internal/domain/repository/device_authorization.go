@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+// DeviceAuthorizationRepository stores pending device authorizations until
+// they expire. Entries are addressable by ID and by user code.
+type DeviceAuthorizationRepository interface {
+	Save(ctx context.Context, auth *entity.DeviceAuthorization) error
+	GetByID(ctx context.Context, id string) (*entity.DeviceAuthorization, error)
+	GetByUserCode(ctx context.Context, userCode string) (*entity.DeviceAuthorization, error)
+	// UpdatePoll records a poll by the device, setting only its last poll
+	// time and interval so a decision saved concurrently is kept
+	UpdatePoll(ctx context.Context, id string, polledAt time.Time, interval time.Duration) error
+	// Delete removes the authorization. It fails when the authorization
+	// is already gone, so of concurrent callers exactly one succeeds.
+	Delete(ctx context.Context, auth *entity.DeviceAuthorization) error
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+// ErrGroupCycle is returned when adding a subgroup would make a group
+// contain itself
+var ErrGroupCycle = errors.New("group nesting cycle")
+
+type GroupRepository interface {
+	Create(ctx context.Context, group *entity.Group) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*entity.Group, error)
+	GetByCode(ctx context.Context, code string) (*entity.Group, error)
+	List(ctx context.Context, limit, offset int) ([]*entity.Group, error)
+	// AddMember makes the user a direct member; adding an existing member
+	// is a no-op
+	AddMember(ctx context.Context, groupID, userID string) error
+	RemoveMember(ctx context.Context, groupID, userID string) error
+	ListMembers(ctx context.Context, groupID string) ([]*entity.GroupMember, error)
+	// GetEffectiveMemberIDs returns the IDs of the group's members, direct
+	// or through subgroups
+	GetEffectiveMemberIDs(ctx context.Context, groupID string) ([]string, error)
+	// AddSubgroup nests subgroupID in groupID. It returns ErrGroupCycle
+	// when groupID is the subgroup itself or one of its subgroups.
+	AddSubgroup(ctx context.Context, groupID, subgroupID string) error
+	RemoveSubgroup(ctx context.Context, groupID, subgroupID string) error
+	ListSubgroups(ctx context.Context, groupID string) ([]*entity.Group, error)
+	// ReplaceRoles replaces the group's roles in one application, leaving
+	// its roles in other applications untouched
+	ReplaceRoles(ctx context.Context, groupID, appID string, roleIDs []string) error
+	GetRoles(ctx context.Context, groupID string) ([]*entity.Role, error)
+	// GetRolesByMemberAndApp returns the roles of the application the user
+	// holds through the groups they are a member of, directly or through
+	// subgroups
+	GetRolesByMemberAndApp(ctx context.Context, userID, appID string) ([]*entity.Role, error)
+	// GetConferredRoles returns the roles the group's members hold through
+	// it: the roles of the group and of every group containing it
+	GetConferredRoles(ctx context.Context, groupID string) ([]*entity.Role, error)
+}
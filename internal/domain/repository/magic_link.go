@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+// MagicLinkRepository holds pending magic links keyed by token hash. Take
+// returns and deletes the link atomically so a token is used at most once.
+type MagicLinkRepository interface {
+	Save(ctx context.Context, tokenHash string, link *entity.MagicLink, ttl time.Duration) error
+	Take(ctx context.Context, tokenHash string) (*entity.MagicLink, error)
+}
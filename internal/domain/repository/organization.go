@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *entity.Organization) error
+	GetByID(ctx context.Context, id string) (*entity.Organization, error)
+	GetByCode(ctx context.Context, code string) (*entity.Organization, error)
+	List(ctx context.Context, limit, offset int) ([]*entity.Organization, error)
+	ListByUser(ctx context.Context, userID string) ([]*entity.Organization, error)
+	// AddMember makes the user a member; adding an existing member is a
+	// no-op
+	AddMember(ctx context.Context, orgID, userID string) error
+	// RemoveMember removes the member together with the roles they hold in
+	// the organization
+	RemoveMember(ctx context.Context, orgID, userID string) error
+	IsMember(ctx context.Context, orgID, userID string) (bool, error)
+	ListMembers(ctx context.Context, orgID string) ([]*entity.OrganizationMember, error)
+	// ReplaceRoles replaces the member's roles in one application of the
+	// organization, leaving their roles in other applications untouched
+	ReplaceRoles(ctx context.Context, orgID, userID, appID string, roleIDs []string) error
+	GetRolesByMemberAndApp(ctx context.Context, orgID, userID, appID string) ([]*entity.Role, error)
+}
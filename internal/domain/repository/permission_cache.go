@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+// PermissionCacheRepository tracks per-user permissions versions and caches
+// resolved authorizations and access decisions keyed by that version.
+// Bumping a user's version invalidates everything cached for that user.
+// Authorizations are cached per application and organization.
+type PermissionCacheRepository interface {
+	GetVersion(ctx context.Context, userID string) (int64, error)
+	BumpVersion(ctx context.Context, userIDs []string) error
+	GetAuthorization(ctx context.Context, userID, appCode, orgCode string, version int64) ([]entity.Authorization, error)
+	SetAuthorization(ctx context.Context, userID, appCode, orgCode string, version int64, auths []entity.Authorization, ttl time.Duration) error
+	// GetDecisions returns the cached decisions among keys; missing keys
+	// are absent from the result
+	GetDecisions(ctx context.Context, userID string, keys []string, version int64) (map[string]*entity.AccessDecision, error)
+	SetDecisions(ctx context.Context, userID string, decisions map[string]*entity.AccessDecision, version int64, ttl time.Duration) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *entity.PersonalAccessToken) error
+	GetByPrefix(ctx context.Context, prefix string) (*entity.PersonalAccessToken, error)
+	ListByUser(ctx context.Context, userID string) ([]*entity.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id string) error
+	TouchLastUsed(ctx context.Context, id string) error
+}
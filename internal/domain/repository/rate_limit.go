@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitRepository counts events per key in fixed windows. Hit records
+// an event and returns the number of events in the current window.
+type RateLimitRepository interface {
+	Hit(ctx context.Context, key string, window time.Duration) (int64, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type RelationRepository interface {
+	GetSchema(ctx context.Context, appID string) (*entity.RelationSchema, error)
+	// SaveSchema stores the schema, incrementing its version
+	SaveSchema(ctx context.Context, schema *entity.RelationSchema) error
+	// WriteTuples stores tuples; tuples already stored are left as is
+	WriteTuples(ctx context.Context, tuples []*entity.RelationTuple) error
+	DeleteTuples(ctx context.Context, tuples []*entity.RelationTuple) error
+	ListObjectTuples(ctx context.Context, appID, namespace, objectID, relation string) ([]*entity.RelationTuple, error)
+	// ListSubjectTuples returns the tuples whose subject is exactly the
+	// given subject or userset
+	ListSubjectTuples(ctx context.Context, appID, subjectNamespace, subjectID, subjectRelation string) ([]*entity.RelationTuple, error)
+	// ListUsedRelations returns the distinct relations stored tuples
+	// refer to, on either side
+	ListUsedRelations(ctx context.Context, appID string) ([]*entity.RelationRef, error)
+}
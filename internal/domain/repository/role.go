@@ -2,16 +2,29 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
 )
 
+// ErrRoleCycle is returned when setting a role's parents would make the
+// role its own ancestor
+var ErrRoleCycle = errors.New("role hierarchy cycle")
+
 type RoleRepository interface {
 	Create(ctx context.Context, role *entity.Role) error
 	Update(ctx context.Context, role *entity.Role) error
 	Delete(ctx context.Context, id string) error
 	GetByID(ctx context.Context, id string) (*entity.Role, error)
 	GetByAppAndCode(ctx context.Context, appID, code string) (*entity.Role, error)
+	GetGlobalByCode(ctx context.Context, code string) (*entity.Role, error)
 	List(ctx context.Context, limit, offset int) ([]*entity.Role, error)
 	ListByApp(ctx context.Context, appID string) ([]*entity.Role, error)
+	// SetParents replaces the role's parents. It returns ErrRoleCycle when
+	// a parent is the role itself or one of its descendants.
+	SetParents(ctx context.Context, roleID string, parentIDs []string) error
+	GetParents(ctx context.Context, roleID string) ([]*entity.Role, error)
+	// GetDescendantIDs returns the IDs of every role inheriting from the
+	// role, directly or transitively
+	GetDescendantIDs(ctx context.Context, roleID string) ([]string, error)
 }
@@ -9,7 +9,14 @@ import (
 type RolePermRepository interface {
 	Grant(ctx context.Context, roleID, permID string) error
 	Revoke(ctx context.Context, roleID, permID string) error
-	Replace(ctx context.Context, roleID string, permIDs []string) error
+	// Replace sets the role's grants. Conditions are stored alongside,
+	// keyed by their ID; each grant may reference one.
+	Replace(ctx context.Context, roleID string, grants []*entity.RolePermission, conditions []*entity.GrantCondition) error
 	GetPermsByRole(ctx context.Context, roleID string) ([]*entity.Permission, error)
 	GetPermsByRoles(ctx context.Context, roleIDs []string) ([]*entity.Permission, error)
+	// GetEffectivePermsByRole returns the role's own permissions and those
+	// inherited from its ancestors. A permission held through several
+	// roles appears once per role.
+	GetEffectivePermsByRole(ctx context.Context, roleID string) ([]*entity.EffectivePermission, error)
+	GetCondition(ctx context.Context, id string) (*entity.GrantCondition, error)
 }
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+// ServiceAccountFilter narrows service account listings. Empty fields match
+// every service account.
+type ServiceAccountFilter struct {
+	ApplicationID string
+	Limit         int
+	Offset        int
+}
+
+type ServiceAccountRepository interface {
+	Create(ctx context.Context, sa *entity.ServiceAccount) error
+	GetByID(ctx context.Context, id string) (*entity.ServiceAccount, error)
+	Update(ctx context.Context, sa *entity.ServiceAccount) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filter ServiceAccountFilter) ([]*entity.ServiceAccount, error)
+}
+
+type ServiceAccountRoleRepository interface {
+	Assign(ctx context.Context, serviceAccountID string, roleIDs []string) error
+	Unassign(ctx context.Context, serviceAccountID, roleID string) error
+	Replace(ctx context.Context, serviceAccountID string, roleIDs []string) error
+	GetRolesByServiceAccount(ctx context.Context, serviceAccountID string) ([]*entity.Role, error)
+}
+
+type ServiceAccountKeyRepository interface {
+	Create(ctx context.Context, key *entity.ServiceAccountKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*entity.ServiceAccountKey, error)
+	ListByServiceAccount(ctx context.Context, serviceAccountID string) ([]*entity.ServiceAccountKey, error)
+	Revoke(ctx context.Context, serviceAccountID, id string) error
+	TouchLastUsed(ctx context.Context, id string) error
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type SoDConstraintRepository interface {
+	// Create stores the constraint together with its roles
+	Create(ctx context.Context, constraint *entity.SoDConstraint) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*entity.SoDConstraint, error)
+	GetByAppAndCode(ctx context.Context, appID, code string) (*entity.SoDConstraint, error)
+	ListByApp(ctx context.Context, appID string) ([]*entity.SoDConstraint, error)
+	// GetHeldRoles returns the roles of the application the principal, a
+	// user or a service account, holds directly, through groups or in any
+	// organization, and every role they inherit from, each with the source
+	// of the assigned role. Direct assignments that have not started yet
+	// are included; ended ones are not. When reparent is set, roles are
+	// expanded as if reparent.RoleID inherited from reparent.ParentIDs
+	// instead of its current parents.
+	GetHeldRoles(ctx context.Context, principalID, appID string, reparent *entity.RoleParents) ([]*entity.HeldRole, error)
+	// ExpandRoles returns the roles and every role they inherit from,
+	// expanded as GetHeldRoles does
+	ExpandRoles(ctx context.Context, roleIDs []string, reparent *entity.RoleParents) ([]string, error)
+	// GetHolders returns the IDs of the users and service accounts holding
+	// the role or a role inheriting from it, however they hold it
+	GetHolders(ctx context.Context, roleID string) ([]string, error)
+	// LockApplication, LockPrincipals and LockGroups serialize separation
+	// of duties checks until the end of the caller's transaction, and fail
+	// outside one. LockApplication locks the application exclusively.
+	// LockPrincipals locks it shared, then each principal within it.
+	// LockGroups takes a single lock covering every group.
+	LockApplication(ctx context.Context, appID string) error
+	LockPrincipals(ctx context.Context, appID string, principalIDs []string) error
+	LockGroups(ctx context.Context) error
+	// GetViolations returns every user and service account holding more
+	// roles of a constraint than it allows, counting roles as GetHeldRoles
+	// does. An empty appID reports every application.
+	GetViolations(ctx context.Context, appID string) ([]*entity.SoDViolation, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type TokenHookRepository interface {
+	Create(ctx context.Context, hook *entity.TokenHook) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*entity.TokenHook, error)
+	ListByApp(ctx context.Context, appID string) ([]*entity.TokenHook, error)
+	// ListActiveByApp returns the application's active hooks in creation
+	// order
+	ListActiveByApp(ctx context.Context, appID string) ([]*entity.TokenHook, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "context"
+
+// Transactor runs a unit of work in a database transaction. Repositories
+// called with the context passed to fn make their reads and writes within
+// the transaction; a nested call joins the outer transaction.
+type Transactor interface {
+	// WithinTransaction commits when fn returns nil and rolls back
+	// otherwise, returning fn's error
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -14,3 +14,10 @@ type UserRepository interface {
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, limit, offset int) ([]*entity.User, error)
 }
+
+// UserKeyRotationRepository re-encrypts user fields still stored under an
+// older key version. RekeyBatch processes up to limit rows and returns how
+// many were re-encrypted.
+type UserKeyRotationRepository interface {
+	RekeyBatch(ctx context.Context, limit int) (int, error)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *entity.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*entity.UserIdentity, error)
+	ListByUser(ctx context.Context, userID string) ([]*entity.UserIdentity, error)
+	TouchLastLogin(ctx context.Context, id string) error
+}
+
+// FederationStateRepository holds federated login state until the
+// callback consumes it. Take returns and deletes the state atomically.
+type FederationStateRepository interface {
+	Save(ctx context.Context, state string, st *entity.FederationState, ttl time.Duration) error
+	Take(ctx context.Context, state string) (*entity.FederationState, error)
+}
@@ -2,16 +2,49 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
 )
 
+// UserRoleRepository stores the roles assigned directly to users. Its
+// GetRolesByUser* and GetGrantsByUser queries only return assignments in
+// effect, whose validity has started and not yet ended.
 type UserRoleRepository interface {
 	Assign(ctx context.Context, userID string, roleIDs []string) error
 	Unassign(ctx context.Context, userID, roleID string) error
-	Replace(ctx context.Context, userID string, roleIDs []string) error
+	// Replace replaces the user's roles in the application with the
+	// assignments, each in effect over its own ValidFrom and ValidUntil.
+	// Roles of other applications and global roles are kept.
+	Replace(ctx context.Context, userID, appID string, assignments []*entity.UserRole) error
 	GetRolesByUser(ctx context.Context, userID string) ([]*entity.Role, error)
 	GetRolesByUserAndApp(ctx context.Context, userID, appID string) ([]*entity.Role, error)
 	GetGlobalRolesByUser(ctx context.Context, userID string) ([]*entity.Role, error)
+	// GetUsersByRole returns the users holding the role, directly, within
+	// an organization or through a group
 	GetUsersByRole(ctx context.Context, roleID string) ([]*entity.User, error)
+	// GetGrantsByUser returns, in a single query, the user's grants in the
+	// given applications, from application roles and global roles alike,
+	// plus any superadmin grant. Roles held through groups are included,
+	// with the group that was assigned them. With an orgCode, the tenant
+	// roles the user holds in that organization are included too, as long
+	// as the user is a member of it.
+	// Permissions inherited from ancestor roles are included. AppCode is
+	// the application of the permission.
+	GetGrantsByUser(ctx context.Context, userID, orgCode string, appCodes []string) ([]*entity.RoleGrant, error)
+	// GetAssignmentsByUser returns the user's assignments in effect
+	GetAssignmentsByUser(ctx context.Context, userID string) ([]*entity.UserRole, error)
+	// GetAllAssignmentsByUser returns all the user's assignments, including
+	// those not yet or no longer in effect
+	GetAllAssignmentsByUser(ctx context.Context, userID string) ([]*entity.UserRole, error)
+}
+
+// UserRoleExpiryRepository finds user role assignments whose validity
+// ended or started. DeleteExpired deletes up to limit ended assignments
+// and returns the IDs of the users that held them, one per assignment.
+// GetUsersActivatedBetween returns the users with assignments taking
+// effect in (from, to].
+type UserRoleExpiryRepository interface {
+	DeleteExpired(ctx context.Context, limit int) ([]string, error)
+	GetUsersActivatedBetween(ctx context.Context, from, to time.Time) ([]string, error)
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
@@ -14,25 +15,35 @@ import (
 type AuthService interface {
 	// BuildClaims constructs JWT claims from user data and authorization rules
 	// It queries user roles and permissions for the specified application
-	// and builds the authorization array for JWT claims
+	// and builds the authorization array for JWT claims. A user's roles are
+	// the union of the roles assigned to them and to their groups.
 	//
 	// Parameters:
 	//   - ctx: context for cancellation and timeout
 	//   - user: the authenticated user
 	//   - appCode: the application code for which to build claims
+	//   - orgCode: the organization whose tenant roles to include, if any;
+	//     the user must be a member of it
 	//
 	// Returns:
 	//   - *entity.Claims: the constructed claims with authorization data
 	//   - error: if there's an error querying roles/permissions or building claims
-	BuildClaims(ctx context.Context, user *entity.User, appCode string) (*entity.Claims, error)
+	BuildClaims(ctx context.Context, user *entity.User, appCode, orgCode string) (*entity.Claims, error)
 }
 
+// ErrNotOrganizationMember is returned when claims are requested for an
+// organization the user is not a member of
+var ErrNotOrganizationMember = errors.New("user is not a member of the organization")
+
 // authService implements the AuthService interface
 type authService struct {
 	userRoleRepo repository.UserRoleRepository
 	roleRepo     repository.RoleRepository
 	rolePermRepo repository.RolePermRepository
 	appRepo      repository.AppRepository
+	orgRepo      repository.OrganizationRepository
+	groupRepo    repository.GroupRepository
+	adminRepo    repository.ApplicationAdminRepository
 }
 
 // NewAuthService creates a new instance of AuthService
@@ -41,35 +52,79 @@ func NewAuthService(
 	roleRepo repository.RoleRepository,
 	rolePermRepo repository.RolePermRepository,
 	appRepo repository.AppRepository,
+	orgRepo repository.OrganizationRepository,
+	groupRepo repository.GroupRepository,
+	adminRepo repository.ApplicationAdminRepository,
 ) AuthService {
 	return &authService{
 		userRoleRepo: userRoleRepo,
 		roleRepo:     roleRepo,
 		rolePermRepo: rolePermRepo,
 		appRepo:      appRepo,
+		orgRepo:      orgRepo,
+		groupRepo:    groupRepo,
+		adminRepo:    adminRepo,
 	}
 }
 
 // BuildClaims constructs JWT claims from user data and authorization rules
-func (s *authService) BuildClaims(ctx context.Context, user *entity.User, appCode string) (*entity.Claims, error) {
+func (s *authService) BuildClaims(ctx context.Context, user *entity.User, appCode, orgCode string) (*entity.Claims, error) {
 	var authorizations []entity.Authorization
 	var audiences []string
 
-	// Handle global roles
+	// Tenant roles are only included for an organization the user is a
+	// member of
+	var org *entity.Organization
+	if orgCode != "" {
+		var err error
+		org, err = s.resolveMembership(ctx, user.ID, orgCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The token must not outlive the time-bound roles it carries. expiry
+	// is the earliest end of those roles, zero while there is none.
+	ends := s.assignmentEnds(ctx, user.ID)
+	var expiry time.Time
+
+	// Global roles grant permissions of any application's catalog, each
+	// in its own application; only the reserved superadmin permission
+	// grants everything
 	globalRoles, _ := s.userRoleRepo.GetGlobalRolesByUser(ctx, user.ID)
+	globalGrants := make(map[string]*roleGrants)
 	if len(globalRoles) > 0 {
+		authorizerID := s.authorizerAppID(ctx)
 		var roles []string
+		globalPerms := []string{}
 		for _, r := range globalRoles {
 			roles = append(roles, r.Code)
+			expiry = earliest(expiry, ends[r.ID])
+
+			perms, _ := s.rolePermRepo.GetEffectivePermsByRole(ctx, r.ID)
+			for _, p := range perms {
+				if p.PermissionCode == entity.SuperadminPermission && p.ConditionID == "" &&
+					authorizerID != "" && p.ApplicationID == authorizerID {
+					globalPerms = append(globalPerms, p.PermissionCode)
+					continue
+				}
+				g, ok := globalGrants[p.ApplicationID]
+				if !ok {
+					g = newRoleGrants()
+					globalGrants[p.ApplicationID] = g
+				}
+				g.roles[r.Code] = struct{}{}
+				g.add(p)
+			}
 		}
 
 		authorizations = append(authorizations, entity.Authorization{
-			App:         "GLOBAL",
+			App:         entity.GlobalAuthorization,
 			Roles:       roles,
-			Permissions: []string{"*"},
+			Permissions: globalPerms,
 		})
 
-		audiences = append(audiences, "GLOBAL")
+		audiences = append(audiences, entity.GlobalAuthorization)
 	}
 
 	// Resolve applications
@@ -80,32 +135,70 @@ func (s *authService) BuildClaims(ctx context.Context, user *entity.User, appCod
 
 	// Build authorizations for each app
 	for _, app := range apps {
-		appRoles, _ := s.userRoleRepo.GetRolesByUserAndApp(ctx, user.ID, app.ID)
-		if len(appRoles) == 0 {
-			continue
+		grants := globalGrants[app.ID]
+		if grants == nil {
+			grants = newRoleGrants()
 		}
 
-		roleSet := make(map[string]struct{})
-		permSet := make(map[string]struct{})
+		// Application roles also carry the permissions of their ancestors.
+		// Roles held through a group or an organization have no end.
+		appRoles, _ := s.userRoleRepo.GetRolesByUserAndApp(ctx, user.ID, app.ID)
+		heldRoles, _ := s.groupRepo.GetRolesByMemberAndApp(ctx, user.ID, app.ID)
+		if org != nil {
+			tenantRoles, _ := s.orgRepo.GetRolesByMemberAndApp(ctx, org.ID, user.ID, app.ID)
+			heldRoles = append(heldRoles, tenantRoles...)
+		}
+		unbounded := make(map[string]struct{}, len(heldRoles))
+		for _, r := range heldRoles {
+			unbounded[r.ID] = struct{}{}
+		}
+		appRoles = append(appRoles, heldRoles...)
 
+		// A role held both directly and through a group is resolved once
+		resolved := make(map[string]struct{}, len(appRoles))
 		for _, r := range appRoles {
-			roleSet[r.Code] = struct{}{}
+			if _, ok := resolved[r.ID]; ok {
+				continue
+			}
+			resolved[r.ID] = struct{}{}
+			grants.roles[r.Code] = struct{}{}
+			if _, ok := unbounded[r.ID]; !ok {
+				expiry = earliest(expiry, ends[r.ID])
+			}
 
-			perms, _ := s.rolePermRepo.GetPermsByRole(ctx, r.ID)
+			perms, _ := s.rolePermRepo.GetEffectivePermsByRole(ctx, r.ID)
 			for _, p := range perms {
-				permSet[p.Code] = struct{}{}
+				grants.add(p)
 			}
 		}
 
+		if len(grants.roles) == 0 {
+			continue
+		}
+
 		authorizations = append(authorizations, entity.Authorization{
 			App:         app.Code,
-			Roles:       mapKeys(roleSet),
-			Permissions: mapKeys(permSet),
+			Roles:       mapKeys(grants.roles),
+			Permissions: mapKeys(grants.perms),
+			Conditions:  grants.conditions(),
 		})
 
 		audiences = append(audiences, app.Code)
 	}
 
+	// Delegated administration is granted in the AUTHORIZER catalog, so
+	// only tokens covering the authorizer carry it
+	var appAdmin map[string][]string
+	if appCode == "" || appCode == entity.AuthorizerAppCode {
+		admins, _ := s.adminRepo.ListByUser(ctx, user.ID)
+		for _, a := range admins {
+			if appAdmin == nil {
+				appAdmin = make(map[string][]string, len(admins))
+			}
+			appAdmin[a.ApplicationID] = a.Permissions
+		}
+	}
+
 	// Build claims
 	now := time.Now()
 	claims := &entity.Claims{
@@ -116,12 +209,124 @@ func (s *authService) BuildClaims(ctx context.Context, user *entity.User, appCod
 		ExpiresAt:     now.Add(time.Hour).Unix(),
 		Username:      user.Username,
 		Email:         user.Email,
+		PrincipalType: entity.PrincipalTypeUser,
 		Authorization: authorizations,
+		AppAdmin:      appAdmin,
+	}
+	if org != nil {
+		claims.Organization = org.Code
+	}
+	if !expiry.IsZero() && expiry.Unix() < claims.ExpiresAt {
+		claims.ExpiresAt = expiry.Unix()
 	}
 
 	return claims, nil
 }
 
+// assignmentEnds maps the user's time-bound roles to the end of their
+// validity. A role held more than once ends with its latest assignment and
+// is not time-bound if any of them has no end.
+func (s *authService) assignmentEnds(ctx context.Context, userID string) map[string]time.Time {
+	assignments, _ := s.userRoleRepo.GetAssignmentsByUser(ctx, userID)
+	ends := make(map[string]time.Time)
+	unbounded := make(map[string]bool)
+	for _, a := range assignments {
+		if a.ValidUntil == nil {
+			unbounded[a.RoleID] = true
+			continue
+		}
+		if end, ok := ends[a.RoleID]; !ok || a.ValidUntil.After(end) {
+			ends[a.RoleID] = *a.ValidUntil
+		}
+	}
+	for roleID := range unbounded {
+		delete(ends, roleID)
+	}
+	return ends
+}
+
+// earliest returns the earlier of two times, ignoring zero times
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// roleGrants collects the roles and permissions a user holds in one
+// application. conds holds the condition references of permissions
+// granted conditionally.
+type roleGrants struct {
+	roles map[string]struct{}
+	perms map[string]struct{}
+	conds map[string]map[string]struct{}
+}
+
+func newRoleGrants() *roleGrants {
+	return &roleGrants{
+		roles: make(map[string]struct{}),
+		perms: make(map[string]struct{}),
+		conds: make(map[string]map[string]struct{}),
+	}
+}
+
+func (g *roleGrants) add(p *entity.EffectivePermission) {
+	if p.ConditionID == "" {
+		g.perms[p.PermissionCode] = struct{}{}
+		return
+	}
+	refs, ok := g.conds[p.PermissionCode]
+	if !ok {
+		refs = make(map[string]struct{})
+		g.conds[p.PermissionCode] = refs
+	}
+	refs[p.ConditionID] = struct{}{}
+}
+
+// conditions returns the condition references of permissions that are not
+// also granted unconditionally
+func (g *roleGrants) conditions() map[string][]string {
+	var out map[string][]string
+	for code, refs := range g.conds {
+		if _, ok := g.perms[code]; ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string][]string)
+		}
+		out[code] = mapKeys(refs)
+	}
+	return out
+}
+
+// resolveMembership returns the organization, verifying the user is a
+// member of it
+func (s *authService) resolveMembership(ctx context.Context, userID, orgCode string) (*entity.Organization, error) {
+	org, err := s.orgRepo.GetByCode(ctx, orgCode)
+	if err != nil {
+		return nil, ErrNotOrganizationMember
+	}
+
+	member, err := s.orgRepo.IsMember(ctx, org.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, ErrNotOrganizationMember
+	}
+	return org, nil
+}
+
+// authorizerAppID returns the ID of the authorizer's own application, or
+// an empty string when it cannot be found
+func (s *authService) authorizerAppID(ctx context.Context) string {
+	app, err := s.appRepo.GetByCode(ctx, entity.AuthorizerAppCode)
+	if err != nil {
+		return ""
+	}
+	return app.ID
+}
+
 // resolveApps resolves the applications based on the appCode
 // If appCode is empty, returns all applications
 // Otherwise, returns the specific application
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+// ErrInvalidCredentials is returned by a CredentialVerifier when it does
+// not know the login or the password does not match
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// CredentialVerifier checks a login and password against a credential
+// backend such as the local user table or an LDAP directory
+type CredentialVerifier interface {
+	// Name identifies the backend in logs
+	Name() string
+
+	// Verify returns the verified credential, ErrInvalidCredentials when
+	// the login or password is wrong, or another error when the backend is
+	// unavailable
+	Verify(ctx context.Context, login, password string) (*entity.VerifiedCredential, error)
+}
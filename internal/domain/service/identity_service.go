@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/pkg/idgen"
+)
+
+const maxUsernameLength = 50
+
+// IdentityService links identities asserted by external systems (OIDC
+// providers, LDAP directories) to local users
+type IdentityService interface {
+	// ResolveUser finds the user linked to the external identity. Unlinked
+	// identities are linked to an existing user with the same email only
+	// when that email is verified; otherwise a new user is provisioned.
+	ResolveUser(ctx context.Context, identity *entity.ExternalIdentity) (*entity.User, error)
+
+	// SyncGroupRoles applies group to role mappings to a user. Roles named
+	// by a mapping are granted when the user is a member of the mapped
+	// group and revoked otherwise; roles not named by any mapping are left
+	// untouched. Grants that would violate a separation-of-duties
+	// constraint are skipped for that application.
+	SyncGroupRoles(ctx context.Context, userID string, groups []string, mappings []entity.GroupRoleMapping) error
+}
+
+type identityService struct {
+	identityRepo repository.UserIdentityRepository
+	userRepo     repository.UserRepository
+	appRepo      repository.AppRepository
+	roleRepo     repository.RoleRepository
+	userRoleRepo repository.UserRoleRepository
+	permCache    repository.PermissionCacheRepository
+	transactor   repository.Transactor
+	sodSvc       SoDService
+	logger       Logger
+}
+
+// NewIdentityService creates a new instance of IdentityService
+func NewIdentityService(
+	identityRepo repository.UserIdentityRepository,
+	userRepo repository.UserRepository,
+	appRepo repository.AppRepository,
+	roleRepo repository.RoleRepository,
+	userRoleRepo repository.UserRoleRepository,
+	permCache repository.PermissionCacheRepository,
+	transactor repository.Transactor,
+	sodSvc SoDService,
+	logger Logger,
+) IdentityService {
+	return &identityService{
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		appRepo:      appRepo,
+		roleRepo:     roleRepo,
+		userRoleRepo: userRoleRepo,
+		permCache:    permCache,
+		transactor:   transactor,
+		sodSvc:       sodSvc,
+		logger:       logger,
+	}
+}
+
+// ResolveUser finds, links or provisions the user for an external identity
+func (s *identityService) ResolveUser(ctx context.Context, identity *entity.ExternalIdentity) (*entity.User, error) {
+	link, err := s.identityRepo.GetByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, link.UserID)
+		if err != nil {
+			return nil, errors.New("linked user not found")
+		}
+		if err := s.identityRepo.TouchLastLogin(ctx, link.ID); err != nil {
+			s.logger.Warn("Failed to update identity last login", Fields{
+				"identity_id": link.ID,
+				"error":       err.Error(),
+			})
+		}
+		return user, nil
+	}
+
+	if identity.Email == "" {
+		return nil, errors.New("identity provider did not return an email")
+	}
+
+	user, _ := s.userRepo.GetByEmail(ctx, identity.Email)
+	if user != nil {
+		if !identity.EmailVerified {
+			s.logger.Warn("Identity link refused: unverified email matches existing user", Fields{
+				"provider": identity.Provider,
+				"user_id":  user.ID,
+			})
+			return nil, errors.New("email is not verified by the identity provider")
+		}
+	} else {
+		user, err = s.provisionUser(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	link = &entity.UserIdentity{
+		ID:        idgen.NewUUIDv7(),
+		UserID:    user.ID,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	}
+	if err := s.identityRepo.Create(ctx, link); err != nil {
+		s.logger.Error("Failed to link identity", Fields{
+			"provider": identity.Provider,
+			"user_id":  user.ID,
+			"error":    err.Error(),
+		})
+		return nil, errors.New("failed to link identity")
+	}
+
+	s.logger.Info("Identity linked", Fields{
+		"provider": identity.Provider,
+		"user_id":  user.ID,
+	})
+
+	return user, nil
+}
+
+// provisionUser creates a user just in time from an external identity.
+// Externally authenticated users have no local password.
+func (s *identityService) provisionUser(ctx context.Context, identity *entity.ExternalIdentity) (*entity.User, error) {
+	username := identity.Username
+	if username == "" {
+		username = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	if len(username) > maxUsernameLength {
+		username = username[:maxUsernameLength]
+	}
+
+	user := &entity.User{
+		ID:            idgen.NewUUIDv7(),
+		Email:         identity.Email,
+		Username:      username,
+		FullName:      identity.FullName,
+		IsActive:      true,
+		EmailVerified: identity.EmailVerified,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	err := s.userRepo.Create(ctx, user)
+	if err != nil {
+		// The username may be taken; retry once with a random suffix
+		b := make([]byte, 3)
+		if _, rerr := rand.Read(b); rerr != nil {
+			return nil, errors.New("failed to provision user")
+		}
+		suffix := hex.EncodeToString(b)
+		base := username
+		if len(base) > maxUsernameLength-len(suffix)-1 {
+			base = base[:maxUsernameLength-len(suffix)-1]
+		}
+		user.Username = base + "-" + suffix
+		err = s.userRepo.Create(ctx, user)
+	}
+	if err != nil {
+		s.logger.Error("Failed to provision external user", Fields{
+			"provider": identity.Provider,
+			"error":    err.Error(),
+		})
+		return nil, errors.New("failed to provision user")
+	}
+
+	s.logger.Info("External user provisioned", Fields{
+		"provider": identity.Provider,
+		"user_id":  user.ID,
+	})
+
+	return user, nil
+}
+
+// SyncGroupRoles grants and revokes mapped roles to match group membership
+func (s *identityService) SyncGroupRoles(ctx context.Context, userID string, groups []string, mappings []entity.GroupRoleMapping) error {
+	member := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		member[strings.ToLower(g)] = true
+	}
+
+	// managed[appCode][roleCode] reports whether the role should be held
+	managed := make(map[string]map[string]bool)
+	for _, m := range mappings {
+		if managed[m.AppCode] == nil {
+			managed[m.AppCode] = make(map[string]bool)
+		}
+		for _, code := range m.Roles {
+			managed[m.AppCode][code] = managed[m.AppCode][code] || member[strings.ToLower(m.Group)]
+		}
+	}
+
+	// held covers assignments not in effect too, as a user holds each role
+	// at most once
+	assignments, err := s.userRoleRepo.GetAllAssignmentsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	held := make(map[string]bool, len(assignments))
+	for _, a := range assignments {
+		held[a.RoleID] = true
+	}
+
+	changed := false
+	for appCode, roles := range managed {
+		app, err := s.appRepo.GetByCode(ctx, appCode)
+		if err != nil {
+			s.logger.Warn("Group role mapping references unknown application", Fields{
+				"app_code": appCode,
+			})
+			continue
+		}
+
+		var grant []string
+		for code, want := range roles {
+			role, err := s.roleRepo.GetByAppAndCode(ctx, app.ID, code)
+			if err != nil {
+				s.logger.Warn("Group role mapping references unknown role", Fields{
+					"app_code":  appCode,
+					"role_code": code,
+				})
+				continue
+			}
+			if role.IsTenant() {
+				s.logger.Warn("Group role mapping references a tenant role", Fields{
+					"app_code":  appCode,
+					"role_code": code,
+				})
+				continue
+			}
+
+			switch {
+			case want && !held[role.ID]:
+				grant = append(grant, role.ID)
+			case !want && held[role.ID]:
+				if err := s.userRoleRepo.Unassign(ctx, userID, role.ID); err != nil {
+					return err
+				}
+				changed = true
+			}
+		}
+
+		// Revocations are applied first so they count towards the check
+		if len(grant) > 0 {
+			err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				if err := s.sodSvc.CheckUsers(ctx, []string{userID}, &RoleChange{AppID: app.ID, RoleIDs: grant}); err != nil {
+					return err
+				}
+				return s.userRoleRepo.Assign(ctx, userID, grant)
+			})
+			if errors.Is(err, ErrSoDViolation) {
+				s.logger.Warn("Group role mapping skipped", Fields{
+					"app_code": appCode,
+					"user_id":  userID,
+					"error":    err.Error(),
+				})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := s.permCache.BumpVersion(ctx, []string{userID}); err != nil {
+			s.logger.Warn("Failed to bump permissions version", Fields{
+				"user_id": userID,
+				"error":   err.Error(),
+			})
+		}
+		s.logger.Info("Group role mappings applied", Fields{
+			"user_id": userID,
+		})
+	}
+
+	return nil
+}
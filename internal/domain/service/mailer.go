@@ -0,0 +1,9 @@
+package service
+
+import "context"
+
+// Mailer sends plain text emails. Implementations live in the
+// infrastructure layer.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+// ErrSoDViolation is returned when an assignment would leave a user or
+// service account holding more roles of a separation-of-duties constraint
+// than it allows
+var ErrSoDViolation = errors.New("separation of duties violation")
+
+// RoleChange is a change to the roles principals hold in one application.
+// The roles they hold through Replace, when set, are replaced by RoleIDs;
+// otherwise RoleIDs are added to the roles they hold.
+type RoleChange struct {
+	AppID   string
+	Replace *entity.RoleSource
+	RoleIDs []string
+}
+
+// SoDService enforces static separation-of-duties constraints on every
+// path that assigns roles to users or service accounts. Checks run within
+// the transaction that makes the change and lock what they check until it
+// ends, so concurrent changes are checked one after another.
+type SoDService interface {
+	// CheckUsers returns an error wrapping ErrSoDViolation when the change
+	// would leave any of the principals, users or service accounts,
+	// holding more roles of a constraint of the application than it
+	// allows. Roles are counted however they are held: directly, through
+	// groups or in any organization, together with every role they inherit
+	// from. The principals are locked within the application.
+	CheckUsers(ctx context.Context, principalIDs []string, change *RoleChange) error
+	// CheckRoleParents returns an error wrapping ErrSoDViolation when
+	// making the role of the application inherit from parentIDs, instead
+	// of its current parents, would leave any holder of the role or of a
+	// role inheriting from it holding more roles of a constraint than it
+	// allows. The application is locked before the holders are listed.
+	CheckRoleParents(ctx context.Context, appID, roleID string, parentIDs []string) error
+	// LockGroups serializes changes to group members, subgroups and group
+	// roles. Such changes take it before listing the members they affect,
+	// so members added concurrently are checked as well.
+	LockGroups(ctx context.Context) error
+}
+
+type sodService struct {
+	sodRepo repository.SoDConstraintRepository
+	logger  Logger
+}
+
+// NewSoDService creates a new instance of SoDService
+func NewSoDService(sodRepo repository.SoDConstraintRepository, logger Logger) SoDService {
+	return &sodService{
+		sodRepo: sodRepo,
+		logger:  logger,
+	}
+}
+
+func (s *sodService) CheckUsers(ctx context.Context, principalIDs []string, change *RoleChange) error {
+	return s.check(ctx, principalIDs, change, nil)
+}
+
+func (s *sodService) CheckRoleParents(ctx context.Context, appID, roleID string, parentIDs []string) error {
+	if err := s.sodRepo.LockApplication(ctx, appID); err != nil {
+		return fmt.Errorf("failed to lock separation of duties checks: %w", err)
+	}
+
+	holders, err := s.sodRepo.GetHolders(ctx, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch role holders: %w", err)
+	}
+
+	return s.check(ctx, holders, &RoleChange{AppID: appID}, &entity.RoleParents{
+		RoleID:    roleID,
+		ParentIDs: parentIDs,
+	})
+}
+
+func (s *sodService) LockGroups(ctx context.Context) error {
+	if err := s.sodRepo.LockGroups(ctx); err != nil {
+		return fmt.Errorf("failed to lock separation of duties checks: %w", err)
+	}
+	return nil
+}
+
+// check verifies the change against the application's constraints,
+// expanding roles through the hierarchy as changed by reparent when set
+func (s *sodService) check(ctx context.Context, principalIDs []string, change *RoleChange, reparent *entity.RoleParents) error {
+	if change.AppID == "" || len(principalIDs) == 0 {
+		return nil
+	}
+
+	if err := s.sodRepo.LockPrincipals(ctx, change.AppID, principalIDs); err != nil {
+		return fmt.Errorf("failed to lock separation of duties checks: %w", err)
+	}
+
+	constraints, err := s.sodRepo.ListByApp(ctx, change.AppID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch separation of duties constraints: %w", err)
+	}
+	if len(constraints) == 0 {
+		return nil
+	}
+
+	added, err := s.sodRepo.ExpandRoles(ctx, change.RoleIDs, reparent)
+	if err != nil {
+		return fmt.Errorf("failed to expand roles: %w", err)
+	}
+
+	for _, principalID := range principalIDs {
+		held, err := s.sodRepo.GetHeldRoles(ctx, principalID, change.AppID, reparent)
+		if err != nil {
+			return fmt.Errorf("failed to fetch held roles: %w", err)
+		}
+
+		roles := make(map[string]bool, len(held)+len(added))
+		for _, h := range held {
+			if change.Replace != nil && h.Source == *change.Replace {
+				continue
+			}
+			roles[h.RoleID] = true
+		}
+		for _, id := range added {
+			roles[id] = true
+		}
+
+		for _, c := range constraints {
+			var holding []string
+			for i, id := range c.RoleIDs {
+				if roles[id] {
+					holding = append(holding, c.RoleCodes[i])
+				}
+			}
+			if len(holding) <= c.MaxRoles {
+				continue
+			}
+
+			s.logger.Warn("Assignment rejected: separation of duties violation", Fields{
+				"constraint":   c.Code,
+				"principal_id": principalID,
+				"app_id":       change.AppID,
+				"roles":        holding,
+			})
+			return fmt.Errorf("%w: constraint %s allows at most %d of %s, principal %s would hold %s",
+				ErrSoDViolation, c.Code, c.MaxRoles, strings.Join(c.RoleCodes, ", "), principalID, strings.Join(holding, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(message string, fields Fields)  {}
+func (nopLogger) Warn(message string, fields Fields)  {}
+func (nopLogger) Error(message string, fields Fields) {}
+
+// fakeSoDRepo holds the assignments and role hierarchy of one application
+// in memory and expands roles through parents as the database does
+type fakeSoDRepo struct {
+	repository.SoDConstraintRepository
+	constraints []*entity.SoDConstraint
+	// assigned maps principals to the roles assigned to them
+	assigned map[string][]*entity.HeldRole
+	// parents maps roles to the roles they inherit from
+	parents map[string][]string
+	holders []string
+	// calls records the locks taken and the principals read, in order
+	calls []string
+}
+
+func (r *fakeSoDRepo) LockApplication(ctx context.Context, appID string) error {
+	r.calls = append(r.calls, "app:"+appID)
+	return nil
+}
+
+func (r *fakeSoDRepo) LockPrincipals(ctx context.Context, appID string, principalIDs []string) error {
+	for _, id := range principalIDs {
+		r.calls = append(r.calls, "principal:"+appID+":"+id)
+	}
+	return nil
+}
+
+func (r *fakeSoDRepo) ListByApp(ctx context.Context, appID string) ([]*entity.SoDConstraint, error) {
+	return r.constraints, nil
+}
+
+func (r *fakeSoDRepo) GetHeldRoles(ctx context.Context, principalID, appID string, reparent *entity.RoleParents) ([]*entity.HeldRole, error) {
+	r.calls = append(r.calls, "held:"+principalID)
+	var held []*entity.HeldRole
+	for _, a := range r.assigned[principalID] {
+		for _, id := range r.expand(a.RoleID, reparent) {
+			held = append(held, &entity.HeldRole{RoleID: id, Source: a.Source})
+		}
+	}
+	return held, nil
+}
+
+func (r *fakeSoDRepo) ExpandRoles(ctx context.Context, roleIDs []string, reparent *entity.RoleParents) ([]string, error) {
+	var roles []string
+	for _, id := range roleIDs {
+		roles = append(roles, r.expand(id, reparent)...)
+	}
+	return roles, nil
+}
+
+func (r *fakeSoDRepo) GetHolders(ctx context.Context, roleID string) ([]string, error) {
+	r.calls = append(r.calls, "holders")
+	return r.holders, nil
+}
+
+// expand returns the role and every role it inherits from
+func (r *fakeSoDRepo) expand(roleID string, reparent *entity.RoleParents) []string {
+	roles := []string{roleID}
+	seen := map[string]bool{roleID: true}
+	for i := 0; i < len(roles); i++ {
+		parents := r.parents[roles[i]]
+		if reparent != nil && roles[i] == reparent.RoleID {
+			parents = reparent.ParentIDs
+		}
+		for _, p := range parents {
+			if !seen[p] {
+				seen[p] = true
+				roles = append(roles, p)
+			}
+		}
+	}
+	return roles
+}
+
+// makerChecker allows holding only one of maker and checker
+var makerChecker = &entity.SoDConstraint{
+	Code:      "MAKER_CHECKER",
+	MaxRoles:  1,
+	RoleIDs:   []string{"maker", "checker"},
+	RoleCodes: []string{"MAKER", "CHECKER"},
+}
+
+var (
+	direct = entity.RoleSource{Kind: entity.RoleSourceDirect}
+	group  = entity.RoleSource{Kind: entity.RoleSourceGroup, ID: "group-1"}
+)
+
+func TestSoDService_CheckUsers(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints []*entity.SoDConstraint
+		assigned    []*entity.HeldRole
+		parents     map[string][]string
+		change      *RoleChange
+		wantErr     bool
+	}{
+		{
+			name:     "no constraints",
+			assigned: []*entity.HeldRole{{RoleID: "maker", Source: direct}},
+			change:   &RoleChange{AppID: "app", RoleIDs: []string{"checker"}},
+		},
+		{
+			name:        "adding a conflicting role",
+			constraints: []*entity.SoDConstraint{makerChecker},
+			assigned:    []*entity.HeldRole{{RoleID: "maker", Source: direct}},
+			change:      &RoleChange{AppID: "app", RoleIDs: []string{"checker"}},
+			wantErr:     true,
+		},
+		{
+			name:        "adding an unrelated role",
+			constraints: []*entity.SoDConstraint{makerChecker},
+			assigned:    []*entity.HeldRole{{RoleID: "maker", Source: direct}},
+			change:      &RoleChange{AppID: "app", RoleIDs: []string{"viewer"}},
+		},
+		{
+			name: "constraint allowing two roles",
+			constraints: []*entity.SoDConstraint{{
+				Code:      "TWO_OF_THREE",
+				MaxRoles:  2,
+				RoleIDs:   []string{"maker", "checker", "approver"},
+				RoleCodes: []string{"MAKER", "CHECKER", "APPROVER"},
+			}},
+			assigned: []*entity.HeldRole{{RoleID: "maker", Source: direct}},
+			change:   &RoleChange{AppID: "app", RoleIDs: []string{"checker"}},
+		},
+		{
+			name:        "replacing the source holding the conflicting role",
+			constraints: []*entity.SoDConstraint{makerChecker},
+			assigned:    []*entity.HeldRole{{RoleID: "maker", Source: direct}},
+			change:      &RoleChange{AppID: "app", Replace: &direct, RoleIDs: []string{"checker"}},
+		},
+		{
+			name:        "replacing another source keeps the conflicting role",
+			constraints: []*entity.SoDConstraint{makerChecker},
+			assigned:    []*entity.HeldRole{{RoleID: "maker", Source: group}},
+			change:      &RoleChange{AppID: "app", Replace: &direct, RoleIDs: []string{"checker"}},
+			wantErr:     true,
+		},
+		{
+			name:        "replacing one group keeps roles of another group",
+			constraints: []*entity.SoDConstraint{makerChecker},
+			assigned: []*entity.HeldRole{{
+				RoleID: "maker",
+				Source: entity.RoleSource{Kind: entity.RoleSourceGroup, ID: "group-2"},
+			}},
+			change:  &RoleChange{AppID: "app", Replace: &group, RoleIDs: []string{"checker"}},
+			wantErr: true,
+		},
+		{
+			name:        "added role inherits a conflicting role",
+			constraints: []*entity.SoDConstraint{makerChecker},
+			assigned:    []*entity.HeldRole{{RoleID: "maker", Source: direct}},
+			parents:     map[string][]string{"senior": {"checker"}},
+			change:      &RoleChange{AppID: "app", RoleIDs: []string{"senior"}},
+			wantErr:     true,
+		},
+		{
+			name:        "held role inherits a conflicting role",
+			constraints: []*entity.SoDConstraint{makerChecker},
+			assigned:    []*entity.HeldRole{{RoleID: "lead", Source: group}},
+			parents:     map[string][]string{"lead": {"senior"}, "senior": {"maker"}},
+			change:      &RoleChange{AppID: "app", RoleIDs: []string{"checker"}},
+			wantErr:     true,
+		},
+		{
+			name:        "no application",
+			constraints: []*entity.SoDConstraint{makerChecker},
+			assigned:    []*entity.HeldRole{{RoleID: "maker", Source: direct}},
+			change:      &RoleChange{RoleIDs: []string{"checker"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSoDRepo{
+				constraints: tt.constraints,
+				assigned:    map[string][]*entity.HeldRole{"user-1": tt.assigned},
+				parents:     tt.parents,
+			}
+			svc := NewSoDService(repo, nopLogger{})
+
+			err := svc.CheckUsers(context.Background(), []string{"user-1"}, tt.change)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrSoDViolation)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSoDService_CheckUsers_EveryPrincipal(t *testing.T) {
+	repo := &fakeSoDRepo{
+		constraints: []*entity.SoDConstraint{makerChecker},
+		assigned: map[string][]*entity.HeldRole{
+			"user-1": {{RoleID: "viewer", Source: direct}},
+			"user-2": {{RoleID: "maker", Source: direct}},
+		},
+	}
+	svc := NewSoDService(repo, nopLogger{})
+
+	err := svc.CheckUsers(context.Background(), []string{"user-1", "user-2"}, &RoleChange{AppID: "app", RoleIDs: []string{"checker"}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrSoDViolation)
+	assert.Contains(t, err.Error(), "user-2")
+}
+
+func TestSoDService_CheckRoleParents(t *testing.T) {
+	tests := []struct {
+		name      string
+		assigned  []*entity.HeldRole
+		parents   map[string][]string
+		holders   []string
+		roleID    string
+		parentIDs []string
+		wantErr   bool
+	}{
+		{
+			name:      "holder would inherit a conflicting role",
+			assigned:  []*entity.HeldRole{{RoleID: "maker", Source: direct}, {RoleID: "senior", Source: group}},
+			holders:   []string{"user-1"},
+			roleID:    "senior",
+			parentIDs: []string{"checker"},
+			wantErr:   true,
+		},
+		{
+			name:      "holder of a descendant would inherit a conflicting role",
+			assigned:  []*entity.HeldRole{{RoleID: "maker", Source: direct}, {RoleID: "lead", Source: direct}},
+			parents:   map[string][]string{"lead": {"senior"}},
+			holders:   []string{"user-1"},
+			roleID:    "senior",
+			parentIDs: []string{"checker"},
+			wantErr:   true,
+		},
+		{
+			name:      "new parents replace the current ones",
+			assigned:  []*entity.HeldRole{{RoleID: "maker", Source: direct}, {RoleID: "senior", Source: direct}},
+			parents:   map[string][]string{"senior": {"checker"}},
+			holders:   []string{"user-1"},
+			roleID:    "senior",
+			parentIDs: []string{"viewer"},
+		},
+		{
+			name:      "no holders",
+			parents:   map[string][]string{},
+			roleID:    "senior",
+			parentIDs: []string{"checker"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSoDRepo{
+				constraints: []*entity.SoDConstraint{makerChecker},
+				assigned:    map[string][]*entity.HeldRole{"user-1": tt.assigned},
+				parents:     tt.parents,
+				holders:     tt.holders,
+			}
+			svc := NewSoDService(repo, nopLogger{})
+
+			err := svc.CheckRoleParents(context.Background(), "app", tt.roleID, tt.parentIDs)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrSoDViolation)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSoDService_LocksBeforeReading(t *testing.T) {
+	repo := &fakeSoDRepo{
+		constraints: []*entity.SoDConstraint{makerChecker},
+		holders:     []string{"user-1"},
+	}
+	svc := NewSoDService(repo, nopLogger{})
+
+	require.NoError(t, svc.CheckUsers(context.Background(), []string{"user-1", "user-2"}, &RoleChange{AppID: "app", RoleIDs: []string{"maker"}}))
+	assert.Equal(t, []string{
+		"principal:app:user-1", "principal:app:user-2",
+		"held:user-1", "held:user-2",
+	}, repo.calls)
+
+	// The application is locked before its holders are listed
+	repo.calls = nil
+	require.NoError(t, svc.CheckRoleParents(context.Background(), "app", "senior", []string{"maker"}))
+	assert.Equal(t, []string{"app:app", "holders", "principal:app:user-1", "held:user-1"}, repo.calls)
+}
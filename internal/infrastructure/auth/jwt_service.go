@@ -57,9 +57,14 @@ func NewJWTService(logger service.Logger) JWTService {
 // It embeds jwt.RegisteredClaims for standard JWT fields and adds custom fields
 type jwtClaims struct {
 	jwt.RegisteredClaims
-	Username      string                 `json:"username"`
-	Email         string                 `json:"email"`
-	Authorization []entity.Authorization `json:"authorization"`
+	Username      string                 `json:"username,omitempty"`
+	Email         string                 `json:"email,omitempty"`
+	PrincipalType string                 `json:"principal_type,omitempty"`
+	Authorization []entity.Authorization `json:"authorization,omitempty"`
+	Org           string                 `json:"org,omitempty"`
+	AppAdmin      map[string][]string    `json:"app_admin,omitempty"`
+	PV            int64                  `json:"pv,omitempty"`
+	Ext           map[string]interface{} `json:"ext,omitempty"`
 }
 
 // GenerateToken creates a signed JWT token from the provided claims
@@ -83,7 +88,12 @@ func (s *jwtService) GenerateToken(ctx context.Context, claims *entity.Claims, p
 		},
 		Username:      claims.Username,
 		Email:         claims.Email,
+		PrincipalType: claims.PrincipalType,
 		Authorization: claims.Authorization,
+		Org:           claims.Organization,
+		AppAdmin:      claims.AppAdmin,
+		PV:            claims.PermissionsVersion,
+		Ext:           claims.Extra,
 	}
 
 	// Set timestamps from Unix timestamps
@@ -157,14 +167,19 @@ func (s *jwtService) ValidateToken(ctx context.Context, tokenString string, publ
 
 	// Convert jwt.Claims back to entity.Claims
 	entityClaims := &entity.Claims{
-		Issuer:        claims.Issuer,
-		Subject:       claims.Subject,
-		Audience:      claims.Audience,
-		ExpiresAt:     claims.ExpiresAt.Unix(),
-		IssuedAt:      claims.IssuedAt.Unix(),
-		Username:      claims.Username,
-		Email:         claims.Email,
-		Authorization: claims.Authorization,
+		Issuer:             claims.Issuer,
+		Subject:            claims.Subject,
+		Audience:           claims.Audience,
+		ExpiresAt:          claims.ExpiresAt.Unix(),
+		IssuedAt:           claims.IssuedAt.Unix(),
+		Username:           claims.Username,
+		Email:              claims.Email,
+		PrincipalType:      claims.PrincipalType,
+		Authorization:      claims.Authorization,
+		Organization:       claims.Org,
+		AppAdmin:           claims.AppAdmin,
+		PermissionsVersion: claims.PV,
+		Extra:              claims.Ext,
 	}
 
 	return entityClaims, nil
@@ -312,6 +312,25 @@ func TestGenerateAndValidate_RoundTrip(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Service account",
+			claims: &entity.Claims{
+				Issuer:        "authorizer",
+				Subject:       "sa-1",
+				Audience:      []string{"APP1"},
+				ExpiresAt:     time.Now().Add(time.Hour).Unix(),
+				IssuedAt:      time.Now().Unix(),
+				Username:      "billing-worker",
+				PrincipalType: entity.PrincipalTypeServiceAccount,
+				Authorization: []entity.Authorization{
+					{
+						App:         "APP1",
+						Roles:       []string{"worker"},
+						Permissions: []string{"invoice.read"},
+					},
+				},
+			},
+		},
 	}
 	
 	for _, tc := range testCases {
@@ -331,6 +350,7 @@ func TestGenerateAndValidate_RoundTrip(t *testing.T) {
 			assert.Equal(t, tc.claims.Subject, validatedClaims.Subject)
 			assert.Equal(t, tc.claims.Username, validatedClaims.Username)
 			assert.Equal(t, tc.claims.Email, validatedClaims.Email)
+			assert.Equal(t, tc.claims.PrincipalType, validatedClaims.PrincipalType)
 			assert.Equal(t, tc.claims.Audience, validatedClaims.Audience)
 			assert.Equal(t, tc.claims.ExpiresAt, validatedClaims.ExpiresAt)
 			assert.Equal(t, tc.claims.IssuedAt, validatedClaims.IssuedAt)
@@ -8,6 +8,8 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
@@ -16,6 +18,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/keystore"
 )
 
 // Default paths for JWT private key (public key is derived from it).
@@ -28,7 +31,19 @@ type (
 		PostgresDB *PostgresDB
 		Redis      *Redis
 		JWT        *JWT
-		logger     service.Logger
+		Session    *Session
+		OAuth      *OAuth
+		LDAP       *LDAP
+		MagicLink  *MagicLink
+		Mail       *Mail
+		RateLimit  *RateLimit
+		Seal       *Seal
+		PII        *PII
+		RoleExpiry *RoleExpiry
+
+		// IdentityProviders are upstream OIDC providers users may sign in with
+		IdentityProviders []*IdentityProvider
+		logger            service.Logger
 	}
 
 	App struct {
@@ -60,11 +75,145 @@ type (
 	JWT struct {
 		PrivateKeyPath string
 		PublicKeyPath  string
+		// EncryptedKeyPath points to an envelope-encrypted signing key
+		// file. When set it is used instead of the plaintext key and is
+		// decrypted in memory with the MASTER_KEY_PASSWORD secret.
+		EncryptedKeyPath string
 		PrivateKey     *rsa.PrivateKey
 		PublicKey      *rsa.PublicKey
 		KeyID          string
 		TokenExpiry    time.Duration
 		RefreshExpiry  time.Duration
+
+		// mu guards the key fields once the service can be sealed and
+		// unsealed at runtime; use the accessor methods to read them
+		mu sync.RWMutex
+
+		// masterKey is the master key the signing key was unwrapped with,
+		// when it came from an encrypted key file
+		masterKey []byte
+	}
+
+	// Seal configures sealed startup. When enabled the service starts
+	// without its signing key and only serves health and unseal endpoints
+	// until an operator unseals it. Requires jwt.encryptedKeyPath.
+	Seal struct {
+		Enabled bool
+	}
+
+	// Session configures the browser session mode, where the access token
+	// lives in an HttpOnly cookie and state-changing requests are protected
+	// by a double-submit CSRF token.
+	Session struct {
+		CookieName     string
+		CSRFCookieName string
+		CSRFHeaderName string
+		SameSite       string
+		Domain         string
+		Insecure       bool
+	}
+
+	// OAuth configures the OAuth 2.0 device authorization grant
+	OAuth struct {
+		VerificationURI  string
+		DeviceCodeExpiry time.Duration
+		PollInterval     time.Duration
+	}
+
+	// IdentityProvider configures an upstream OIDC provider for federated
+	// login. Name is used in the login and callback URLs.
+	IdentityProvider struct {
+		Name         string
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+		RedirectURL  string
+		Scopes       []string
+		ClaimMapping ClaimMapping
+
+		// PostLoginRedirect is where browsers are sent after a cookie-mode
+		// federated login. Empty returns the login response as JSON.
+		PostLoginRedirect string
+	}
+
+	// MagicLink configures passwordless login links sent by email. URL is
+	// the page the link points to; the token is appended as a query
+	// parameter.
+	MagicLink struct {
+		URL    string
+		Expiry time.Duration
+	}
+
+	// Mail configures the SMTP server used to send emails. With no host
+	// configured emails are not sent.
+	Mail struct {
+		Host     string
+		Port     int
+		Username string
+		Password string
+		From     string
+	}
+
+	// RateLimit bounds login attempts per client address within a window
+	RateLimit struct {
+		LoginAttempts int
+		LoginWindow   time.Duration
+	}
+
+	// PII configures field-level encryption of user data. Fields are only
+	// encrypted when the signing key is loaded from an encrypted key file,
+	// whose master key the field keys are derived from. Raising KeyVersion
+	// makes the re-key job re-encrypt existing rows in the background.
+	PII struct {
+		KeyVersion     int
+		RekeyInterval  time.Duration
+		RekeyBatchSize int
+	}
+
+	// RoleExpiry configures the job removing role assignments whose
+	// validity has ended
+	RoleExpiry struct {
+		CleanupInterval  time.Duration
+		CleanupBatchSize int
+	}
+
+	// LDAP configures password login against an LDAP or Active Directory
+	// server. Users are looked up with UserFilter using the service
+	// account, then authenticated by binding as the entry found.
+	LDAP struct {
+		Enabled            bool
+		URL                string
+		StartTLS           bool
+		InsecureSkipVerify bool
+		BindDN             string
+		BindPassword       string
+		BaseDN             string
+		UserFilter         string
+		EmailAttribute     string
+		UsernameAttribute  string
+		FullNameAttribute  string
+		GroupAttribute     string
+		Timeout            time.Duration
+
+		// GroupRoleMappings grant application roles to members of
+		// directory groups when they log in
+		GroupRoleMappings []LDAPGroupRoleMapping
+	}
+
+	// LDAPGroupRoleMapping maps a directory group DN to roles of one
+	// application
+	LDAPGroupRoleMapping struct {
+		Group       string
+		Application string
+		Roles       []string
+	}
+
+	// ClaimMapping names the ID token claims that populate a user
+	ClaimMapping struct {
+		Email         string
+		EmailVerified string
+		Username      string
+		FullName      string
 	}
 )
 
@@ -106,6 +255,15 @@ func Load(logger service.Logger) (*Config, error) {
 		PostgresDB: &PostgresDB{},
 		Redis:      &Redis{},
 		JWT:        &JWT{},
+		Session:    &Session{},
+		OAuth:      &OAuth{},
+		LDAP:       &LDAP{},
+		MagicLink:  &MagicLink{},
+		Mail:       &Mail{},
+		RateLimit:  &RateLimit{},
+		Seal:       &Seal{},
+		PII:        &PII{},
+		RoleExpiry: &RoleExpiry{},
 		logger:     logger,
 	}
 
@@ -124,16 +282,31 @@ func Load(logger service.Logger) (*Config, error) {
 	cfg.Redis.Host = getEnvOrDefault("REDIS_HOST", cfg.Redis.Host)
 	cfg.Redis.Port = getEnvOrDefault("REDIS_PORT", cfg.Redis.Port)
 
-	// Load private key only (from env PEM or file). Public key is derived from it
-	// so only one secret (private.pem) is needed; JWKS endpoint serves the public key.
-	privateKey, err := loadPrivateKeyFromEnvOrFile()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load private key: %w", err)
+	// Load private key only (from the encrypted key file, env PEM or file). Public key
+	// is derived from it so only one secret is needed; JWKS endpoint serves the public key.
+	cfg.JWT.EncryptedKeyPath = getEnvOrDefault("JWT_ENCRYPTED_KEY_PATH", cfg.JWT.EncryptedKeyPath)
+	switch {
+	case cfg.Seal.Enabled:
+		// Sealed startup: the signing key is decrypted when the service is unsealed
+		if cfg.JWT.EncryptedKeyPath == "" {
+			return nil, errors.New("sealed startup requires jwt.encryptedKeyPath")
+		}
+	case cfg.JWT.EncryptedKeyPath != "":
+		key, err := loadEncryptedKey(cfg.JWT.EncryptedKeyPath, os.Getenv("MASTER_KEY_PASSWORD"))
+		// The password is only needed once; keep it out of the environment
+		_ = os.Unsetenv("MASTER_KEY_PASSWORD")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key: %w", err)
+		}
+		cfg.JWT.SetKeys(key.PrivateKey)
+		cfg.JWT.SetMasterKey(key.MasterKey)
+	default:
+		privateKey, err := loadPrivateKeyFromEnvOrFile()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key: %w", err)
+		}
+		cfg.JWT.SetKeys(privateKey)
 	}
-	cfg.JWT.PrivateKey = privateKey
-	cfg.JWT.PublicKey = &privateKey.PublicKey
-
-	cfg.JWT.KeyID = generateKID(cfg.JWT.PublicKey)
 
 	if s := viper.GetString("jwt.tokenExpiry"); s != "" {
 		cfg.JWT.TokenExpiry, _ = time.ParseDuration(s)
@@ -142,6 +315,44 @@ func Load(logger service.Logger) (*Config, error) {
 		cfg.JWT.RefreshExpiry, _ = time.ParseDuration(s)
 	}
 
+	if s := viper.GetString("oauth.deviceCodeExpiry"); s != "" {
+		cfg.OAuth.DeviceCodeExpiry, _ = time.ParseDuration(s)
+	}
+	if s := viper.GetString("oauth.pollInterval"); s != "" {
+		cfg.OAuth.PollInterval, _ = time.ParseDuration(s)
+	}
+
+	cfg.LDAP.BindPassword = getEnvOrDefault("LDAP_BIND_PASSWORD", cfg.LDAP.BindPassword)
+	if s := viper.GetString("ldap.timeout"); s != "" {
+		cfg.LDAP.Timeout, _ = time.ParseDuration(s)
+	}
+
+	if s := viper.GetString("magicLink.expiry"); s != "" {
+		cfg.MagicLink.Expiry, _ = time.ParseDuration(s)
+	}
+	cfg.Mail.Password = getEnvOrDefault("MAIL_PASSWORD", cfg.Mail.Password)
+	if s := viper.GetString("rateLimit.loginWindow"); s != "" {
+		cfg.RateLimit.LoginWindow, _ = time.ParseDuration(s)
+	}
+	if s := viper.GetString("pii.rekeyInterval"); s != "" {
+		cfg.PII.RekeyInterval, _ = time.ParseDuration(s)
+	}
+	if s := viper.GetString("roleExpiry.cleanupInterval"); s != "" {
+		cfg.RoleExpiry.CleanupInterval, _ = time.ParseDuration(s)
+	}
+
+	cfg.Session.applyDefaults()
+	cfg.OAuth.applyDefaults()
+	cfg.LDAP.applyDefaults()
+	cfg.MagicLink.applyDefaults()
+	cfg.Mail.applyDefaults()
+	cfg.RateLimit.applyDefaults()
+	cfg.PII.applyDefaults()
+	cfg.RoleExpiry.applyDefaults()
+	for _, idp := range cfg.IdentityProviders {
+		idp.applyDefaults()
+	}
+
 	if logger != nil {
 		logger.Info("Configuration loaded successfully", service.Fields{
 			"server_port": cfg.Server.Port,
@@ -153,6 +364,260 @@ func Load(logger service.Logger) (*Config, error) {
 	return cfg, nil
 }
 
+// DefaultSession returns the session settings used when none are configured
+func DefaultSession() *Session {
+	s := &Session{}
+	s.applyDefaults()
+	return s
+}
+
+func (s *Session) applyDefaults() {
+	if s.CookieName == "" {
+		s.CookieName = "jwt_user_token"
+	}
+	if s.CSRFCookieName == "" {
+		s.CSRFCookieName = "csrf_token"
+	}
+	if s.CSRFHeaderName == "" {
+		s.CSRFHeaderName = "X-CSRF-Token"
+	}
+	if s.SameSite == "" {
+		s.SameSite = "lax"
+	}
+}
+
+// DefaultOAuth returns the OAuth settings used when none are configured
+func DefaultOAuth() *OAuth {
+	o := &OAuth{}
+	o.applyDefaults()
+	return o
+}
+
+func (o *OAuth) applyDefaults() {
+	if o.VerificationURI == "" {
+		o.VerificationURI = "http://localhost:3000/device"
+	}
+	if o.DeviceCodeExpiry <= 0 {
+		o.DeviceCodeExpiry = 10 * time.Minute
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 5 * time.Second
+	}
+}
+
+// DefaultMagicLink returns the magic link settings used when none are
+// configured
+func DefaultMagicLink() *MagicLink {
+	m := &MagicLink{}
+	m.applyDefaults()
+	return m
+}
+
+func (m *MagicLink) applyDefaults() {
+	if m.URL == "" {
+		m.URL = "http://localhost:3000/magic-link"
+	}
+	if m.Expiry <= 0 {
+		m.Expiry = 15 * time.Minute
+	}
+}
+
+func (m *Mail) applyDefaults() {
+	if m.Port == 0 {
+		m.Port = 587
+	}
+}
+
+// DefaultRateLimit returns the rate limits used when none are configured
+func DefaultRateLimit() *RateLimit {
+	r := &RateLimit{}
+	r.applyDefaults()
+	return r
+}
+
+func (r *RateLimit) applyDefaults() {
+	if r.LoginAttempts <= 0 {
+		r.LoginAttempts = 10
+	}
+	if r.LoginWindow <= 0 {
+		r.LoginWindow = time.Minute
+	}
+}
+
+// DefaultPII returns the field encryption settings used when none are
+// configured
+func DefaultPII() *PII {
+	p := &PII{}
+	p.applyDefaults()
+	return p
+}
+
+func (p *PII) applyDefaults() {
+	if p.KeyVersion <= 0 {
+		p.KeyVersion = 1
+	}
+	if p.RekeyInterval <= 0 {
+		p.RekeyInterval = time.Hour
+	}
+	if p.RekeyBatchSize <= 0 {
+		p.RekeyBatchSize = 100
+	}
+}
+
+// DefaultRoleExpiry returns the role expiry settings used when none are
+// configured
+func DefaultRoleExpiry() *RoleExpiry {
+	r := &RoleExpiry{}
+	r.applyDefaults()
+	return r
+}
+
+func (r *RoleExpiry) applyDefaults() {
+	if r.CleanupInterval <= 0 {
+		r.CleanupInterval = time.Minute
+	}
+	if r.CleanupBatchSize <= 0 {
+		r.CleanupBatchSize = 500
+	}
+}
+
+// DefaultLDAP returns the LDAP settings used when none are configured.
+// LDAP login is disabled by default.
+func DefaultLDAP() *LDAP {
+	l := &LDAP{}
+	l.applyDefaults()
+	return l
+}
+
+func (l *LDAP) applyDefaults() {
+	if l.UserFilter == "" {
+		l.UserFilter = "(mail=%s)"
+	}
+	if l.EmailAttribute == "" {
+		l.EmailAttribute = "mail"
+	}
+	if l.UsernameAttribute == "" {
+		l.UsernameAttribute = "uid"
+	}
+	if l.FullNameAttribute == "" {
+		l.FullNameAttribute = "cn"
+	}
+	if l.GroupAttribute == "" {
+		l.GroupAttribute = "memberOf"
+	}
+	if l.Timeout <= 0 {
+		l.Timeout = 5 * time.Second
+	}
+}
+
+func (p *IdentityProvider) applyDefaults() {
+	if len(p.Scopes) == 0 {
+		p.Scopes = []string{"openid", "email", "profile"}
+	}
+	if p.ClaimMapping.Email == "" {
+		p.ClaimMapping.Email = "email"
+	}
+	if p.ClaimMapping.EmailVerified == "" {
+		p.ClaimMapping.EmailVerified = "email_verified"
+	}
+	if p.ClaimMapping.Username == "" {
+		p.ClaimMapping.Username = "preferred_username"
+	}
+	if p.ClaimMapping.FullName == "" {
+		p.ClaimMapping.FullName = "name"
+	}
+}
+
+// SetKeys installs the signing key, deriving the public key and key ID
+func (j *JWT) SetKeys(privateKey *rsa.PrivateKey) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.PrivateKey = privateKey
+	j.PublicKey = &privateKey.PublicKey
+	j.KeyID = generateKID(j.PublicKey)
+}
+
+// ClearKeys drops the signing key and master key, overwriting the master
+// key and the private exponent and primes. Internal copies held by the crypto library are released to the
+// garbage collector.
+func (j *JWT) ClearKeys() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.PrivateKey != nil {
+		wipeInt(j.PrivateKey.D)
+		for _, p := range j.PrivateKey.Primes {
+			wipeInt(p)
+		}
+	}
+	for i := range j.masterKey {
+		j.masterKey[i] = 0
+	}
+	j.masterKey = nil
+	j.PrivateKey = nil
+	j.PublicKey = nil
+	j.KeyID = ""
+}
+
+// SetMasterKey installs the master key recovered with the signing key.
+// The JWT config takes ownership of the slice and wipes it on ClearKeys.
+func (j *JWT) SetMasterKey(masterKey []byte) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.masterKey = masterKey
+}
+
+// MasterKey returns a copy of the master key, or nil when the signing key
+// is not envelope-encrypted or the service is sealed
+func (j *JWT) MasterKey() []byte {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.masterKey == nil {
+		return nil
+	}
+	return append([]byte(nil), j.masterKey...)
+}
+
+// SigningKey returns the private key and key ID, or a nil key while sealed
+func (j *JWT) SigningKey() (*rsa.PrivateKey, string) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.PrivateKey, j.KeyID
+}
+
+// VerificationKey returns the public key and key ID, or a nil key while
+// sealed
+func (j *JWT) VerificationKey() (*rsa.PublicKey, string) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.PublicKey, j.KeyID
+}
+
+func wipeInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	n.SetInt64(0)
+}
+
+// SameSiteMode converts the configured SameSite value to its http constant.
+// Unknown values fall back to Lax.
+func (s *Session) SameSiteMode() http.SameSite {
+	switch strings.ToLower(s.SameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
 func getEnvOrDefault(envKey, fallback string) string {
 	if val := os.Getenv(envKey); val != "" {
 		return val
@@ -184,6 +649,21 @@ func loadPrivateKeyFromEnvOrFile() (*rsa.PrivateKey, error) {
 	return nil, fmt.Errorf("private key not found: tried paths %v and JWT_PRIVATE_KEY env", paths)
 }
 
+// loadEncryptedKey decrypts an envelope-encrypted signing key. The
+// plaintext PEM only exists in memory.
+func loadEncryptedKey(path, password string) (*keystore.Key, error) {
+	if password == "" {
+		return nil, errors.New("MASTER_KEY_PASSWORD is required to decrypt the signing key")
+	}
+
+	f, err := keystore.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return keystore.Unlock(f, password)
+}
+
 func parsePrivateKeyPEM(keyBytes []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(keyBytes)
 	if block == nil {
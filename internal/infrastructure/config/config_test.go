@@ -1,8 +1,15 @@
 package config
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/mafzaidi/authorizer/internal/infrastructure/keystore"
 )
 
 func TestGetEnvOrDefault(t *testing.T) {
@@ -64,3 +71,41 @@ func TestGenerateKID(t *testing.T) {
 		t.Errorf("generateKID() not consistent: %v != %v", kid, kid2)
 	}
 }
+
+func TestLoadEncryptedKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+
+	f, err := keystore.Seal(keyPEM, "unseal-password")
+	if err != nil {
+		t.Fatalf("failed to seal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "signing-key.enc.json")
+	if err := keystore.Save(path, f); err != nil {
+		t.Fatalf("failed to save key file: %v", err)
+	}
+
+	loaded, err := loadEncryptedKey(path, "unseal-password")
+	if err != nil {
+		t.Fatalf("loadEncryptedKey() error = %v", err)
+	}
+	if !loaded.PrivateKey.Equal(privateKey) {
+		t.Error("loadEncryptedKey() returned a different key")
+	}
+	if len(loaded.MasterKey) != 32 {
+		t.Errorf("loadEncryptedKey() master key length = %d, want 32", len(loaded.MasterKey))
+	}
+
+	if _, err := loadEncryptedKey(path, "wrong"); err == nil {
+		t.Error("loadEncryptedKey() accepted a wrong password")
+	}
+	if _, err := loadEncryptedKey(path, ""); err == nil {
+		t.Error("loadEncryptedKey() accepted an empty password")
+	}
+}
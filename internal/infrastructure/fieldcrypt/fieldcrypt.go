@@ -0,0 +1,174 @@
+// Package fieldcrypt encrypts individual database columns with keys derived
+// from the master key. Ciphertexts are tagged with a key version so rows can
+// be re-encrypted when the version changes; version 0 marks plaintext.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// PlaintextVersion is the key version of values that are not encrypted
+const PlaintextVersion = 0
+
+// ErrKeyUnavailable is returned when a value must be encrypted or decrypted
+// but no master key is loaded
+var ErrKeyUnavailable = errors.New("field encryption key is unavailable")
+
+// KeySource provides the current master key, or nil when there is none.
+// config.JWT implements it, so keys follow seal and unseal.
+type KeySource interface {
+	MasterKey() []byte
+}
+
+// Cipher encrypts fields with AES-GCM keys derived per key version and
+// computes blind indexes for equality lookups
+type Cipher struct {
+	source  KeySource
+	version int
+}
+
+// New creates a cipher encrypting with the currentVersion key. A nil source
+// disables encryption.
+func New(source KeySource, currentVersion int) *Cipher {
+	if currentVersion < 1 {
+		currentVersion = 1
+	}
+	return &Cipher{source: source, version: currentVersion}
+}
+
+// Enabled reports whether a master key is available
+func (c *Cipher) Enabled() bool {
+	mk := c.masterKey()
+	defer wipe(mk)
+	return mk != nil
+}
+
+// CurrentVersion is the key version new values are encrypted with
+func (c *Cipher) CurrentVersion() int {
+	return c.version
+}
+
+// Encrypt encrypts plaintext with the current key version. Without a master
+// key the plaintext is returned unchanged with PlaintextVersion.
+func (c *Cipher) Encrypt(plaintext, aad string) (string, int, error) {
+	if !c.Enabled() {
+		return plaintext, PlaintextVersion, nil
+	}
+	value, err := c.EncryptVersion(plaintext, aad, c.version)
+	if err != nil {
+		return "", 0, err
+	}
+	return value, c.version, nil
+}
+
+// EncryptVersion encrypts plaintext with the key of the given version.
+// aad binds the ciphertext to its row and column so values cannot be
+// swapped between them.
+func (c *Cipher) EncryptVersion(plaintext, aad string, version int) (string, error) {
+	if version == PlaintextVersion {
+		return plaintext, nil
+	}
+	aead, err := c.aead(version)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses EncryptVersion for a value stored with version
+func (c *Cipher) Decrypt(value, aad string, version int) (string, error) {
+	if version == PlaintextVersion {
+		return value, nil
+	}
+	aead, err := c.aead(version)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", errors.New("invalid ciphertext encoding")
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(aad))
+	if err != nil {
+		return "", errors.New("failed to decrypt field")
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash of the normalised email for equality
+// lookups on encrypted emails. It reports false without a master key.
+func (c *Cipher) BlindIndex(email string) (string, bool) {
+	key, err := c.deriveKey("user-email-index")
+	if err != nil {
+		return "", false
+	}
+	defer wipe(key)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil)), true
+}
+
+func (c *Cipher) aead(version int) (cipher.AEAD, error) {
+	key, err := c.deriveKey(fmt.Sprintf("user-pii/v%d", version))
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *Cipher) deriveKey(info string) ([]byte, error) {
+	mk := c.masterKey()
+	if mk == nil {
+		return nil, ErrKeyUnavailable
+	}
+	defer wipe(mk)
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, mk, nil, []byte(info)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (c *Cipher) masterKey() []byte {
+	if c == nil || c.source == nil {
+		return nil
+	}
+	return c.source.MasterKey()
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
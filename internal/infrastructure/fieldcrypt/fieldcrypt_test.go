@@ -0,0 +1,87 @@
+package fieldcrypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticKey []byte
+
+func (k staticKey) MasterKey() []byte {
+	if k == nil {
+		return nil
+	}
+	return append([]byte(nil), k...)
+}
+
+var testMasterKey = staticKey([]byte("0123456789abcdef0123456789abcdef"))
+
+func TestEncryptDecrypt(t *testing.T) {
+	c := New(testMasterKey, 2)
+
+	value, version, err := c.Encrypt("Jane Doe", "users.full_name:1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.NotContains(t, value, "Jane")
+
+	plaintext, err := c.Decrypt(value, "users.full_name:1", version)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", plaintext)
+}
+
+func TestDecrypt_WrongAAD(t *testing.T) {
+	c := New(testMasterKey, 1)
+
+	value, version, err := c.Encrypt("Jane Doe", "users.full_name:1")
+	require.NoError(t, err)
+
+	_, err = c.Decrypt(value, "users.full_name:2", version)
+	assert.Error(t, err)
+}
+
+func TestDecrypt_OlderVersion(t *testing.T) {
+	old := New(testMasterKey, 1)
+	value, version, err := old.Encrypt("+100", "users.phone:1")
+	require.NoError(t, err)
+
+	current := New(testMasterKey, 2)
+	plaintext, err := current.Decrypt(value, "users.phone:1", version)
+	require.NoError(t, err)
+	assert.Equal(t, "+100", plaintext)
+
+	_, err = current.Decrypt(value, "users.phone:1", 2)
+	assert.Error(t, err, "a value must only decrypt under its own version")
+}
+
+func TestDisabled(t *testing.T) {
+	c := New(staticKey(nil), 1)
+	assert.False(t, c.Enabled())
+
+	value, version, err := c.Encrypt("Jane Doe", "users.full_name:1")
+	require.NoError(t, err)
+	assert.Equal(t, PlaintextVersion, version)
+	assert.Equal(t, "Jane Doe", value)
+
+	_, ok := c.BlindIndex("jane@example.com")
+	assert.False(t, ok)
+
+	_, err = c.Decrypt("Zm9v", "users.full_name:1", 1)
+	assert.ErrorIs(t, err, ErrKeyUnavailable)
+}
+
+func TestBlindIndex(t *testing.T) {
+	c := New(testMasterKey, 1)
+
+	a, ok := c.BlindIndex("Jane@Example.com ")
+	require.True(t, ok)
+	b, _ := c.BlindIndex("jane@example.com")
+	assert.Equal(t, a, b)
+
+	other, _ := c.BlindIndex("john@example.com")
+	assert.NotEqual(t, a, other)
+
+	rotated, _ := New(testMasterKey, 2).BlindIndex("jane@example.com")
+	assert.Equal(t, a, rotated, "the blind index must not depend on the key version")
+}
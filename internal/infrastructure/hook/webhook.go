@@ -0,0 +1,122 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+)
+
+const (
+	// SignatureHeader carries the HMAC signature of the request body
+	SignatureHeader = "X-Authorizer-Signature"
+
+	// TimestampHeader carries the unix timestamp used in the signature
+	TimestampHeader = "X-Authorizer-Timestamp"
+
+	defaultTimeout  = 2 * time.Second
+	maxResponseSize = 64 * 1024
+)
+
+// TokenHookInvoker calls pre-token-issuance webhooks (infrastructure concern)
+// Each call is bounded by the hook timeout and signed with the hook secret
+// so receivers can verify the request came from the authorizer.
+type TokenHookInvoker interface {
+	// Invoke sends the hook request and returns the hook decision
+	// Parameters:
+	//   - ctx: context for cancellation and timeout
+	//   - hook: the hook configuration (URL, secret, timeout)
+	//   - req: the payload describing the login being processed
+	// Returns:
+	//   - *entity.TokenHookResult: the decision returned by the hook
+	//   - error: if the hook cannot be reached or answers with an invalid response
+	Invoke(ctx context.Context, hook *entity.TokenHook, req *entity.TokenHookRequest) (*entity.TokenHookResult, error)
+}
+
+type tokenHookInvoker struct {
+	client *http.Client
+	logger service.Logger
+}
+
+// NewTokenHookInvoker creates a new webhook invoker instance
+func NewTokenHookInvoker(logger service.Logger) TokenHookInvoker {
+	return &tokenHookInvoker{
+		client: &http.Client{},
+		logger: logger,
+	}
+}
+
+// Invoke sends the hook request and returns the hook decision
+func (i *tokenHookInvoker) Invoke(ctx context.Context, hook *entity.TokenHook, req *entity.TokenHookRequest) (*entity.TokenHookResult, error) {
+	if hook == nil {
+		return nil, errors.New("hook cannot be nil")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode hook request: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if hook.TimeoutMs > 0 {
+		timeout = time.Duration(hook.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build hook request: %w", err)
+	}
+
+	ts := time.Now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	httpReq.Header.Set(SignatureHeader, Sign(hook.Secret, ts, body))
+
+	resp, err := i.client.Do(httpReq)
+	if err != nil {
+		i.logger.Warn("Token hook request failed", service.Fields{
+			"hook_id": hook.ID,
+			"error":   err.Error(),
+		})
+		return nil, fmt.Errorf("hook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		i.logger.Warn("Token hook returned unexpected status", service.Fields{
+			"hook_id": hook.ID,
+			"status":  resp.StatusCode,
+		})
+		return nil, fmt.Errorf("hook returned status %d", resp.StatusCode)
+	}
+
+	result := &entity.TokenHookResult{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode hook response: %w", err)
+	}
+
+	return result, nil
+}
+
+// Sign computes the signature header value for a hook request body.
+// The format is "t=<unix timestamp>,v1=<hex HMAC-SHA256 of timestamp.body>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
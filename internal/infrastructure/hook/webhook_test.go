@@ -0,0 +1,112 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestRequest() *entity.TokenHookRequest {
+	return &entity.TokenHookRequest{
+		Event:       "pre_token_issuance",
+		Application: "APP1",
+		UserID:      "user-123",
+		Username:    "testuser",
+		Email:       "test@example.com",
+		Claims:      &entity.Claims{Subject: "user-123", Audience: []string{"APP1"}},
+	}
+}
+
+func TestInvoke_AllowWithClaims(t *testing.T) {
+	secret := "test-secret"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, Sign(secret, ts, body), r.Header.Get(SignatureHeader), "Signature should match body")
+
+		var req entity.TokenHookRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "user-123", req.UserID)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"allow":  true,
+			"claims": map[string]interface{}{"tier": "gold"},
+		})
+	}))
+	defer server.Close()
+
+	invoker := NewTokenHookInvoker(logger.New())
+	result, err := invoker.Invoke(context.Background(), &entity.TokenHook{
+		ID:     "hook-1",
+		URL:    server.URL,
+		Secret: secret,
+	}, createTestRequest())
+
+	require.NoError(t, err)
+	assert.True(t, result.Allow)
+	assert.Equal(t, "gold", result.Claims["tier"])
+}
+
+func TestInvoke_Deny(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"allow": false, "reason": "subscription expired"}`))
+	}))
+	defer server.Close()
+
+	invoker := NewTokenHookInvoker(logger.New())
+	result, err := invoker.Invoke(context.Background(), &entity.TokenHook{URL: server.URL}, createTestRequest())
+
+	require.NoError(t, err)
+	assert.False(t, result.Allow)
+	assert.Equal(t, "subscription expired", result.Reason)
+}
+
+func TestInvoke_UnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	invoker := NewTokenHookInvoker(logger.New())
+	result, err := invoker.Invoke(context.Background(), &entity.TokenHook{URL: server.URL}, createTestRequest())
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestInvoke_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"allow": true}`))
+	}))
+	defer server.Close()
+
+	invoker := NewTokenHookInvoker(logger.New())
+	result, err := invoker.Invoke(context.Background(), &entity.TokenHook{
+		URL:       server.URL,
+		TimeoutMs: 50,
+	}, createTestRequest())
+
+	assert.Error(t, err, "Hook slower than its timeout should fail")
+	assert.Nil(t, result)
+}
+
+func TestSign_Deterministic(t *testing.T) {
+	body := []byte(`{"a":1}`)
+
+	assert.Equal(t, Sign("s", 100, body), Sign("s", 100, body))
+	assert.NotEqual(t, Sign("s", 100, body), Sign("other", 100, body))
+	assert.NotEqual(t, Sign("s", 100, body), Sign("s", 101, body))
+}
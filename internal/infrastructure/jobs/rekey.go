@@ -0,0 +1,83 @@
+// Package jobs contains background jobs started alongside the HTTP server
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/fieldcrypt"
+)
+
+// batchTimeout bounds a single re-key batch
+const batchTimeout = 30 * time.Second
+
+// UserRekey re-encrypts users stored under an old key version, or still in
+// cleartext, with the current field key
+type UserRekey struct {
+	repo      repository.UserKeyRotationRepository
+	interval  time.Duration
+	batchSize int
+	logger    service.Logger
+}
+
+func NewUserRekey(repo repository.UserKeyRotationRepository, interval time.Duration, batchSize int, logger service.Logger) *UserRekey {
+	return &UserRekey{
+		repo:      repo,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Run re-keys on every interval until ctx is cancelled. Each run drains all
+// pending rows in batches. Runs while no master key is loaded, such as when
+// the service is sealed, are skipped.
+func (j *UserRekey) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce re-keys batches until none are left and returns the number of
+// users re-encrypted
+func (j *UserRekey) RunOnce(ctx context.Context) int {
+	total := 0
+	for ctx.Err() == nil {
+		batchCtx, cancel := context.WithTimeout(ctx, batchTimeout)
+		n, err := j.repo.RekeyBatch(batchCtx, j.batchSize)
+		cancel()
+
+		if errors.Is(err, fieldcrypt.ErrKeyUnavailable) {
+			break
+		}
+		if err != nil {
+			j.logger.Error("Failed to re-key users", service.Fields{
+				"error": err.Error(),
+			})
+			break
+		}
+		total += n
+		if n < j.batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		j.logger.Info("Users re-keyed", service.Fields{
+			"count": total,
+		})
+	}
+	return total
+}
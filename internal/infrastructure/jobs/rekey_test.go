@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/fieldcrypt"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRotationRepo struct {
+	pending int
+	err     error
+	calls   int
+}
+
+func (f *fakeRotationRepo) RekeyBatch(ctx context.Context, limit int) (int, error) {
+	f.calls++
+	if f.err != nil {
+		return 0, f.err
+	}
+	n := limit
+	if f.pending < n {
+		n = f.pending
+	}
+	f.pending -= n
+	return n, nil
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, service.Fields)  {}
+func (nopLogger) Warn(string, service.Fields)  {}
+func (nopLogger) Error(string, service.Fields) {}
+
+func TestUserRekey_DrainsAllBatches(t *testing.T) {
+	repo := &fakeRotationRepo{pending: 25}
+	job := NewUserRekey(repo, time.Hour, 10, nopLogger{})
+
+	assert.Equal(t, 25, job.RunOnce(context.Background()))
+	assert.Equal(t, 0, repo.pending)
+	assert.Equal(t, 3, repo.calls)
+}
+
+func TestUserRekey_SkipsWithoutKey(t *testing.T) {
+	repo := &fakeRotationRepo{err: fieldcrypt.ErrKeyUnavailable}
+	job := NewUserRekey(repo, time.Hour, 10, nopLogger{})
+
+	assert.Equal(t, 0, job.RunOnce(context.Background()))
+	assert.Equal(t, 1, repo.calls)
+}
+
+func TestUserRekey_StopsOnError(t *testing.T) {
+	repo := &fakeRotationRepo{err: errors.New("db down")}
+	job := NewUserRekey(repo, time.Hour, 10, nopLogger{})
+
+	assert.Equal(t, 0, job.RunOnce(context.Background()))
+	assert.Equal(t, 1, repo.calls)
+}
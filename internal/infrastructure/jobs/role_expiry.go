@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+)
+
+// RoleExpiry removes role assignments whose validity has ended. Queries
+// already ignore them; removing them bumps the permissions version of
+// their holders so cached authorizations and decisions are dropped.
+// Assignments that took effect since the previous run bump their holders
+// too.
+type RoleExpiry struct {
+	repo      repository.UserRoleExpiryRepository
+	permCache repository.PermissionCacheRepository
+	interval  time.Duration
+	batchSize int
+	logger    service.Logger
+	lastRun   time.Time
+}
+
+func NewRoleExpiry(repo repository.UserRoleExpiryRepository, permCache repository.PermissionCacheRepository, interval time.Duration, batchSize int, logger service.Logger) *RoleExpiry {
+	return &RoleExpiry{
+		repo:      repo,
+		permCache: permCache,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Run cleans up on every interval until ctx is cancelled
+func (j *RoleExpiry) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce deletes expired assignments in batches until none are left and
+// returns the number deleted
+func (j *RoleExpiry) RunOnce(ctx context.Context) int {
+	now := time.Now()
+
+	total := 0
+	for ctx.Err() == nil {
+		batchCtx, cancel := context.WithTimeout(ctx, batchTimeout)
+		userIDs, err := j.repo.DeleteExpired(batchCtx, j.batchSize)
+		if err == nil {
+			j.bumpVersion(batchCtx, userIDs)
+		}
+		cancel()
+
+		if err != nil {
+			j.logger.Error("Failed to delete expired role assignments", service.Fields{
+				"error": err.Error(),
+			})
+			break
+		}
+		total += len(userIDs)
+		if len(userIDs) < j.batchSize {
+			break
+		}
+	}
+
+	// The first run has no previous run to look back to; a failed lookup
+	// is retried over the same period on the next run
+	if j.lastRun.IsZero() || j.bumpActivated(ctx, now) {
+		j.lastRun = now
+	}
+
+	if total > 0 {
+		j.logger.Info("Expired role assignments removed", service.Fields{
+			"count": total,
+		})
+	}
+	return total
+}
+
+// bumpActivated bumps the holders of assignments that took effect since
+// the previous run and reports whether it succeeded
+func (j *RoleExpiry) bumpActivated(ctx context.Context, now time.Time) bool {
+	ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+	defer cancel()
+
+	userIDs, err := j.repo.GetUsersActivatedBetween(ctx, j.lastRun, now)
+	if err != nil {
+		j.logger.Error("Failed to get activated role assignments", service.Fields{
+			"error": err.Error(),
+		})
+		return false
+	}
+	j.bumpVersion(ctx, userIDs)
+	return true
+}
+
+func (j *RoleExpiry) bumpVersion(ctx context.Context, userIDs []string) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if err := j.permCache.BumpVersion(ctx, userIDs); err != nil {
+		j.logger.Warn("Failed to bump permissions version", service.Fields{
+			"user_ids": userIDs,
+			"error":    err.Error(),
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExpiryRepo struct {
+	expired   []string
+	activated []string
+	err       error
+	calls     int
+	windows   [][2]time.Time
+}
+
+func (f *fakeExpiryRepo) DeleteExpired(ctx context.Context, limit int) ([]string, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	n := limit
+	if len(f.expired) < n {
+		n = len(f.expired)
+	}
+	batch := f.expired[:n]
+	f.expired = f.expired[n:]
+	return batch, nil
+}
+
+func (f *fakeExpiryRepo) GetUsersActivatedBetween(ctx context.Context, from, to time.Time) ([]string, error) {
+	f.windows = append(f.windows, [2]time.Time{from, to})
+	return f.activated, nil
+}
+
+// fakePermCache records version bumps; other methods are not used by the
+// job
+type fakePermCache struct {
+	repository.PermissionCacheRepository
+	bumped []string
+}
+
+func (f *fakePermCache) BumpVersion(ctx context.Context, userIDs []string) error {
+	f.bumped = append(f.bumped, userIDs...)
+	return nil
+}
+
+func TestRoleExpiry_DeletesAllBatches(t *testing.T) {
+	repo := &fakeExpiryRepo{expired: []string{"u1", "u2", "u3", "u4", "u5"}}
+	cache := &fakePermCache{}
+	job := NewRoleExpiry(repo, cache, time.Minute, 2, nopLogger{})
+
+	assert.Equal(t, 5, job.RunOnce(context.Background()))
+	assert.Equal(t, 3, repo.calls)
+	assert.Equal(t, []string{"u1", "u2", "u3", "u4", "u5"}, cache.bumped)
+}
+
+func TestRoleExpiry_BumpsActivatedSinceLastRun(t *testing.T) {
+	repo := &fakeExpiryRepo{activated: []string{"u9"}}
+	cache := &fakePermCache{}
+	job := NewRoleExpiry(repo, cache, time.Minute, 10, nopLogger{})
+
+	// The first run only records when it ran
+	job.RunOnce(context.Background())
+	assert.Empty(t, repo.windows)
+	assert.Empty(t, cache.bumped)
+
+	first := job.lastRun
+	job.RunOnce(context.Background())
+	if assert.Len(t, repo.windows, 1) {
+		assert.Equal(t, first, repo.windows[0][0])
+	}
+	assert.Equal(t, []string{"u9"}, cache.bumped)
+}
+
+func TestRoleExpiry_StopsOnError(t *testing.T) {
+	repo := &fakeExpiryRepo{err: errors.New("db down")}
+	cache := &fakePermCache{}
+	job := NewRoleExpiry(repo, cache, time.Minute, 10, nopLogger{})
+
+	assert.Equal(t, 0, job.RunOnce(context.Background()))
+	assert.Equal(t, 1, repo.calls)
+	assert.Empty(t, cache.bumped)
+}
@@ -0,0 +1,255 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware/masterkey"
+)
+
+const dataKeyLength = 32
+
+// File is an envelope-encrypted signing key as stored on disk. The master
+// key is encrypted with the unseal password (argon2id + AES-GCM), the data
+// key is wrapped by the master key and the private key PEM is encrypted
+// with the data key. Only ciphertext is ever written.
+type File struct {
+	MasterKey     string `json:"master_key"`
+	MasterKeySalt string `json:"master_key_salt"`
+	DataKey       string `json:"data_key"`
+	PrivateKey    string `json:"private_key"`
+
+	// Shares and Threshold are set when the password was split with
+	// Shamir secret sharing; Threshold shares are needed to unseal
+	Shares    int `json:"shares,omitempty"`
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// Seal encrypts a private key PEM under a new master key and data key,
+// protecting the master key with password
+func Seal(privateKeyPEM []byte, password string) (*File, error) {
+	if password == "" {
+		return nil, errors.New("password cannot be empty")
+	}
+
+	encodedMasterKey, err := masterkey.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+	masterKey, err := base64.StdEncoding.DecodeString(encodedMasterKey)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(masterKey)
+
+	encryptedMasterKey, err := masterkey.Encrypt(encodedMasterKey, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt master key: %w", err)
+	}
+
+	dataKey := make([]byte, dataKeyLength)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	defer wipe(dataKey)
+
+	wrappedDataKey, err := seal(masterKey, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	encryptedPrivateKey, err := seal(dataKey, privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{
+		MasterKey:     encryptedMasterKey.EncodedCipher,
+		MasterKeySalt: encryptedMasterKey.EncodedSalt,
+		DataKey:       base64.StdEncoding.EncodeToString(wrappedDataKey),
+		PrivateKey:    base64.StdEncoding.EncodeToString(encryptedPrivateKey),
+	}, nil
+}
+
+// Key is the key material recovered from an encrypted key file
+type Key struct {
+	// MasterKey is the raw master key, used to derive further keys such
+	// as field encryption keys
+	MasterKey  []byte
+	PrivateKey *rsa.PrivateKey
+}
+
+// Open decrypts the private key PEM in memory. The caller should wipe the
+// returned bytes once the key has been parsed.
+func Open(f *File, password string) ([]byte, error) {
+	masterKey, err := openMasterKey(f, password)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(masterKey)
+
+	return openPrivateKeyPEM(f, masterKey)
+}
+
+// Unlock recovers the master key and the parsed RSA signing key. The
+// plaintext PEM is wiped once parsed.
+func Unlock(f *File, password string) (*Key, error) {
+	masterKey, err := openMasterKey(f, password)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := openPrivateKeyPEM(f, masterKey)
+	if err != nil {
+		wipe(masterKey)
+		return nil, err
+	}
+	defer wipe(keyPEM)
+
+	privateKey, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		wipe(masterKey)
+		return nil, err
+	}
+
+	return &Key{MasterKey: masterKey, PrivateKey: privateKey}, nil
+}
+
+// OpenPrivateKey decrypts and parses the RSA signing key
+func OpenPrivateKey(f *File, password string) (*rsa.PrivateKey, error) {
+	key, err := Unlock(f, password)
+	if err != nil {
+		return nil, err
+	}
+	wipe(key.MasterKey)
+	return key.PrivateKey, nil
+}
+
+func openMasterKey(f *File, password string) ([]byte, error) {
+	decrypted, err := masterkey.Decrypt(f.MasterKey, password, f.MasterKeySalt)
+	if err != nil {
+		return nil, errors.New("invalid unseal password")
+	}
+	masterKey, err := base64.StdEncoding.DecodeString(decrypted.MasterKey)
+	if err != nil {
+		return nil, errors.New("invalid master key encoding")
+	}
+	return masterKey, nil
+}
+
+func openPrivateKeyPEM(f *File, masterKey []byte) ([]byte, error) {
+	wrappedDataKey, err := base64.StdEncoding.DecodeString(f.DataKey)
+	if err != nil {
+		return nil, errors.New("invalid data key encoding")
+	}
+	dataKey, err := open(masterKey, wrappedDataKey)
+	if err != nil {
+		return nil, errors.New("failed to unwrap data key")
+	}
+	defer wipe(dataKey)
+
+	encryptedPrivateKey, err := base64.StdEncoding.DecodeString(f.PrivateKey)
+	if err != nil {
+		return nil, errors.New("invalid private key encoding")
+	}
+	privateKeyPEM, err := open(dataKey, encryptedPrivateKey)
+	if err != nil {
+		return nil, errors.New("failed to decrypt private key")
+	}
+
+	return privateKeyPEM, nil
+}
+
+func parsePrivateKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	defer wipe(block.Bytes)
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("key is not RSA private key")
+	}
+	return rsaKey, nil
+}
+
+// Load reads an encrypted key file
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+	if f.MasterKey == "" || f.MasterKeySalt == "" || f.DataKey == "" || f.PrivateKey == "" {
+		return nil, errors.New("key file is incomplete")
+	}
+	return &f, nil
+}
+
+// Save writes an encrypted key file readable only by the owner
+func Save(path string, f *File) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// wipe overwrites key material held in memory
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,137 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"errors"
+)
+
+// Shamir secret sharing over GF(2^8). Each share holds one evaluation per
+// secret byte followed by the share's x coordinate, so shares are one
+// byte longer than the secret.
+
+var (
+	gfExp [510]byte
+	gfLog [256]byte
+)
+
+func init() {
+	// Generator 3 over the AES polynomial x^8 + x^4 + x^3 + x + 1
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfExp[i+255] = x
+		gfLog[x] = byte(i)
+		x ^= gfMulSlow(x, 2)
+	}
+}
+
+func gfMulSlow(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 == 1 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// Split divides secret into n shares, any threshold of which reconstruct
+// it. Fewer shares reveal nothing about the secret.
+func Split(secret []byte, n, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret cannot be empty")
+	}
+	if threshold < 2 || n < threshold || n > 255 {
+		return nil, errors.New("shares must satisfy 2 <= threshold <= shares <= 255")
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+
+	coeffs := make([]byte, threshold)
+	for j, s := range secret {
+		coeffs[0] = s
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			x := byte(i + 1)
+			// Horner's rule
+			var y byte
+			for k := threshold - 1; k >= 0; k-- {
+				y = gfMul(y, x) ^ coeffs[k]
+			}
+			shares[i][j] = y
+		}
+	}
+	wipe(coeffs)
+
+	return shares, nil
+}
+
+// Combine reconstructs a secret from shares produced by Split. With fewer
+// shares than the threshold the result is unrelated to the secret.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least two shares are required")
+	}
+	length := len(shares[0])
+	if length < 2 {
+		return nil, errors.New("invalid share")
+	}
+
+	xs := make([]byte, len(shares))
+	seen := make(map[byte]bool, len(shares))
+	for i, share := range shares {
+		if len(share) != length {
+			return nil, errors.New("shares have different lengths")
+		}
+		x := share[length-1]
+		if x == 0 || seen[x] {
+			return nil, errors.New("invalid or duplicate share")
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	secret := make([]byte, length-1)
+	for j := range secret {
+		// Lagrange interpolation at x = 0
+		var s byte
+		for i, xi := range xs {
+			basis := byte(1)
+			for k, xk := range xs {
+				if k != i {
+					basis = gfMul(basis, gfDiv(xk, xk^xi))
+				}
+			}
+			s ^= gfMul(shares[i][j], basis)
+		}
+		secret[j] = s
+	}
+
+	return secret, nil
+}
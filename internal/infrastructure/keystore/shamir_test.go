@@ -0,0 +1,69 @@
+package keystore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("unseal-password")
+
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	tests := []struct {
+		name    string
+		indexes []int
+	}{
+		{name: "first three", indexes: []int{0, 1, 2}},
+		{name: "last three", indexes: []int{2, 3, 4}},
+		{name: "out of order", indexes: []int{4, 0, 2}},
+		{name: "all shares", indexes: []int{0, 1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subset [][]byte
+			for _, i := range tt.indexes {
+				subset = append(subset, shares[i])
+			}
+
+			got, err := Combine(subset)
+			require.NoError(t, err)
+			assert.Equal(t, secret, got)
+		})
+	}
+}
+
+func TestCombine_BelowThreshold(t *testing.T) {
+	secret := []byte("unseal-password")
+
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+
+	got, err := Combine(shares[:2])
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, got)
+}
+
+func TestSplit_InvalidParameters(t *testing.T) {
+	_, err := Split([]byte("secret"), 3, 1)
+	assert.Error(t, err)
+
+	_, err = Split([]byte("secret"), 2, 3)
+	assert.Error(t, err)
+
+	_, err = Split(nil, 3, 2)
+	assert.Error(t, err)
+}
+
+func TestCombine_DuplicateShare(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	_, err = Combine([][]byte{shares[0], shares[0]})
+	assert.Error(t, err)
+}
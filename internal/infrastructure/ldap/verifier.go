@@ -0,0 +1,169 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+)
+
+// Verifier authenticates users against an LDAP or Active Directory server
+// (infrastructure concern). It searches for the user entry with the
+// service account, then binds as that entry with the supplied password.
+type Verifier struct {
+	cfg      *config.LDAP
+	mappings []entity.GroupRoleMapping
+}
+
+// NewVerifier creates a credential verifier for the configured directory
+func NewVerifier(cfg *config.LDAP) (*Verifier, error) {
+	if cfg.URL == "" || cfg.BaseDN == "" {
+		return nil, errors.New("ldap url and baseDN are required")
+	}
+
+	mappings := make([]entity.GroupRoleMapping, 0, len(cfg.GroupRoleMappings))
+	for _, m := range cfg.GroupRoleMappings {
+		mappings = append(mappings, entity.GroupRoleMapping{
+			Group:   m.Group,
+			AppCode: m.Application,
+			Roles:   m.Roles,
+		})
+	}
+
+	return &Verifier{
+		cfg:      cfg,
+		mappings: mappings,
+	}, nil
+}
+
+// Name identifies the directory in logs
+func (v *Verifier) Name() string {
+	return entity.CredentialSourceLDAP
+}
+
+// Verify looks the login up in the directory and checks the password by
+// binding as the user's entry
+func (v *Verifier) Verify(ctx context.Context, login, password string) (*entity.VerifiedCredential, error) {
+	// An empty password would be an unauthenticated bind, which most
+	// servers accept for any DN
+	if login == "" || password == "" {
+		return nil, service.ErrInvalidCredentials
+	}
+
+	conn, err := v.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if v.cfg.BindDN != "" {
+		if err := conn.Bind(v.cfg.BindDN, v.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("service account bind failed: %w", err)
+		}
+	}
+
+	entry, err := v.findUser(conn, login)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, service.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("user bind failed: %w", err)
+	}
+
+	return &entity.VerifiedCredential{
+		Source: entity.CredentialSourceLDAP,
+		Identity: &entity.ExternalIdentity{
+			Provider: entity.CredentialSourceLDAP,
+			Subject:  entry.DN,
+			Email:    entry.GetAttributeValue(v.cfg.EmailAttribute),
+			// Directory emails are maintained by administrators, so they
+			// are trusted for linking to existing users
+			EmailVerified: true,
+			Username:      entry.GetAttributeValue(v.cfg.UsernameAttribute),
+			FullName:      entry.GetAttributeValue(v.cfg.FullNameAttribute),
+		},
+		Groups:            entry.GetAttributeValues(v.cfg.GroupAttribute),
+		GroupRoleMappings: v.mappings,
+	}, nil
+}
+
+func (v *Verifier) dial(ctx context.Context) (*ldap.Conn, error) {
+	timeout := v.cfg.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout <= 0 {
+		return nil, context.DeadlineExceeded
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: v.cfg.InsecureSkipVerify}
+	conn, err := ldap.DialURL(v.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to directory: %w", err)
+	}
+	conn.SetTimeout(timeout)
+
+	if v.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// findUser returns the single entry matching the login. Unknown and
+// ambiguous logins are both reported as invalid credentials.
+func (v *Verifier) findUser(conn *ldap.Conn, login string) (*ldap.Entry, error) {
+	filter := strings.ReplaceAll(v.cfg.UserFilter, "%s", ldap.EscapeFilter(login))
+
+	req := ldap.NewSearchRequest(
+		v.cfg.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(v.cfg.Timeout.Seconds()),
+		false,
+		filter,
+		[]string{
+			v.cfg.EmailAttribute,
+			v.cfg.UsernameAttribute,
+			v.cfg.FullNameAttribute,
+			v.cfg.GroupAttribute,
+		},
+		nil,
+	)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, service.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("user search failed: %w", err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, service.ErrInvalidCredentials
+	}
+
+	return res.Entries[0], nil
+}
+
+var _ service.CredentialVerifier = (*Verifier)(nil)
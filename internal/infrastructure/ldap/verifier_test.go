@@ -0,0 +1,250 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	serviceDN       = "cn=authorizer,ou=services,dc=example,dc=com"
+	servicePassword = "service-secret"
+	baseDN          = "ou=people,dc=example,dc=com"
+	engineeringDN   = "cn=engineering,ou=groups,dc=example,dc=com"
+)
+
+// testEntry is a directory entry served by testServer
+type testEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// testServer is a minimal in-process LDAP server supporting simple bind,
+// equality searches on mail and unbind
+type testServer struct {
+	listener net.Listener
+	entries  []testEntry
+}
+
+func newTestServer(t *testing.T, entries ...testEntry) *testServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &testServer{listener: l, entries: entries}
+	go s.serve()
+	t.Cleanup(func() { _ = l.Close() })
+
+	return s
+}
+
+func (s *testServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Data.String()
+			password := op.Children[2].Data.String()
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if dn == serviceDN && password == servicePassword {
+				code = ldap.LDAPResultSuccess
+			}
+			for _, e := range s.entries {
+				if e.dn == dn && e.password == password {
+					code = ldap.LDAPResultSuccess
+				}
+			}
+			s.write(conn, messageID, result(ldap.ApplicationBindResponse, code))
+
+		case ldap.ApplicationSearchRequest:
+			filter, _ := ldap.DecompileFilter(op.Children[6])
+			for _, e := range s.entries {
+				for _, mail := range e.attrs["mail"] {
+					if filter == "(mail="+mail+")" {
+						s.write(conn, messageID, searchEntry(e))
+					}
+				}
+			}
+			s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (s *testServer) write(conn net.Conn, messageID int64, op *ber.Packet) {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	envelope.AppendChild(op)
+	_, _ = conn.Write(envelope.Bytes())
+}
+
+func result(tag ber.Tag, code uint16) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return p
+}
+
+func searchEntry(e testEntry) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "objectName"))
+
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range e.attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "value"))
+		}
+		attr.AppendChild(set)
+		attrs.AppendChild(attr)
+	}
+	p.AppendChild(attrs)
+
+	return p
+}
+
+func testConfig(url string) *config.LDAP {
+	cfg := config.DefaultLDAP()
+	cfg.Enabled = true
+	cfg.URL = url
+	cfg.BindDN = serviceDN
+	cfg.BindPassword = servicePassword
+	cfg.BaseDN = baseDN
+	cfg.Timeout = 2 * time.Second
+	cfg.GroupRoleMappings = []config.LDAPGroupRoleMapping{
+		{Group: engineeringDN, Application: "APP1", Roles: []string{"developer"}},
+	}
+	return cfg
+}
+
+var alice = testEntry{
+	dn:       "uid=alice," + baseDN,
+	password: "alice-secret",
+	attrs: map[string][]string{
+		"mail":     {"alice@example.com"},
+		"uid":      {"alice"},
+		"cn":       {"Alice Example"},
+		"memberOf": {engineeringDN},
+	},
+}
+
+func TestNewVerifier_RequiresURLAndBaseDN(t *testing.T) {
+	_, err := NewVerifier(config.DefaultLDAP())
+	assert.Error(t, err)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	srv := newTestServer(t, alice)
+	v, err := NewVerifier(testConfig(srv.url()))
+	require.NoError(t, err)
+
+	cred, err := v.Verify(context.Background(), "alice@example.com", "alice-secret")
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.CredentialSourceLDAP, cred.Source)
+	assert.Nil(t, cred.User)
+	require.NotNil(t, cred.Identity)
+	assert.Equal(t, entity.CredentialSourceLDAP, cred.Identity.Provider)
+	assert.Equal(t, alice.dn, cred.Identity.Subject)
+	assert.Equal(t, "alice@example.com", cred.Identity.Email)
+	assert.True(t, cred.Identity.EmailVerified)
+	assert.Equal(t, "alice", cred.Identity.Username)
+	assert.Equal(t, "Alice Example", cred.Identity.FullName)
+	assert.Equal(t, []string{engineeringDN}, cred.Groups)
+	assert.Equal(t, []entity.GroupRoleMapping{
+		{Group: engineeringDN, AppCode: "APP1", Roles: []string{"developer"}},
+	}, cred.GroupRoleMappings)
+}
+
+func TestVerifier_InvalidCredentials(t *testing.T) {
+	srv := newTestServer(t, alice)
+	v, err := NewVerifier(testConfig(srv.url()))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		login    string
+		password string
+	}{
+		{name: "wrong password", login: "alice@example.com", password: "wrong"},
+		{name: "unknown user", login: "bob@example.com", password: "alice-secret"},
+		{name: "empty password", login: "alice@example.com", password: ""},
+		{name: "filter injection", login: "*", password: "alice-secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := v.Verify(context.Background(), tt.login, tt.password)
+			assert.Nil(t, cred)
+			assert.True(t, errors.Is(err, service.ErrInvalidCredentials), "got %v", err)
+		})
+	}
+}
+
+func TestVerifier_ServiceBindFailure(t *testing.T) {
+	srv := newTestServer(t, alice)
+	cfg := testConfig(srv.url())
+	cfg.BindPassword = "wrong"
+
+	v, err := NewVerifier(cfg)
+	require.NoError(t, err)
+
+	_, err = v.Verify(context.Background(), "alice@example.com", "alice-secret")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, service.ErrInvalidCredentials))
+	assert.True(t, strings.Contains(err.Error(), "service account bind failed"))
+}
+
+func TestVerifier_DirectoryUnavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	url := "ldap://" + l.Addr().String()
+	require.NoError(t, l.Close())
+
+	v, err := NewVerifier(testConfig(url))
+	require.NoError(t, err)
+
+	_, err = v.Verify(context.Background(), "alice@example.com", "alice-secret")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, service.ErrInvalidCredentials))
+}
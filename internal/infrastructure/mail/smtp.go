@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+)
+
+// smtpMailer sends emails through an SMTP server (infrastructure concern)
+type smtpMailer struct {
+	cfg *config.Mail
+}
+
+// NewMailer creates a mailer for the configured SMTP server. Without a
+// host it returns a mailer that refuses to send.
+func NewMailer(cfg *config.Mail) service.Mailer {
+	if cfg == nil || cfg.Host == "" {
+		return disabledMailer{}
+	}
+	return &smtpMailer{cfg: cfg}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("invalid email header value")
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.cfg.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	if err := smtp.SendMail(addr, auth, m.cfg.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+type disabledMailer struct{}
+
+func (disabledMailer) Send(ctx context.Context, to, subject, body string) error {
+	return errors.New("mail is not configured")
+}
@@ -0,0 +1,5 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP TABLE IF EXISTS token_hooks;
+DROP TYPE IF EXISTS hook_failure_policy;
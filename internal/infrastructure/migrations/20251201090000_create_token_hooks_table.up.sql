@@ -0,0 +1,29 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+CREATE TYPE hook_failure_policy AS ENUM ('FAIL_OPEN', 'FAIL_CLOSED');
+
+CREATE TABLE IF NOT EXISTS token_hooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    application_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    timeout_ms INTEGER NOT NULL DEFAULT 2000,
+    failure_policy hook_failure_policy NOT NULL DEFAULT 'FAIL_CLOSED',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    UNIQUE (application_id, name),
+
+    CONSTRAINT fk_token_hooks_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_token_hooks_application ON token_hooks(application_id);
+
+CREATE TRIGGER update_token_hooks_timestamp
+BEFORE UPDATE ON token_hooks
+FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
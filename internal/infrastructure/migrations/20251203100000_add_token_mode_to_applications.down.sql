@@ -0,0 +1,4 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+ALTER TABLE applications DROP COLUMN IF EXISTS token_mode;
@@ -0,0 +1,6 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS token_mode TEXT NOT NULL DEFAULT 'FAT'
+        CHECK (token_mode IN ('FAT', 'THIN'));
@@ -0,0 +1,25 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    application_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,
+    token_hash TEXT NOT NULL,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name),
+
+    CONSTRAINT fk_personal_access_tokens_user
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_personal_access_tokens_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_personal_access_tokens_user ON personal_access_tokens(user_id);
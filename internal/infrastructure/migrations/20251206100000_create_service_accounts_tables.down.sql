@@ -0,0 +1,6 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP TABLE IF EXISTS service_account_keys;
+DROP TABLE IF EXISTS service_account_roles;
+DROP TABLE IF EXISTS service_accounts;
@@ -0,0 +1,53 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    application_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+
+    CONSTRAINT fk_service_accounts_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_service_accounts_app_name
+    ON service_accounts(application_id, name) WHERE deleted_at IS NULL;
+
+CREATE TRIGGER update_service_accounts_timestamp
+BEFORE UPDATE ON service_accounts
+FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
+
+CREATE TABLE IF NOT EXISTS service_account_roles (
+    service_account_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (service_account_id, role_id),
+
+    CONSTRAINT fk_service_account_roles_account
+        FOREIGN KEY (service_account_id) REFERENCES service_accounts (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_service_account_roles_role
+        FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS service_account_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_account_id UUID NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_service_account_keys_account
+        FOREIGN KEY (service_account_id) REFERENCES service_accounts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_service_account_keys_account ON service_account_keys(service_account_id);
@@ -0,0 +1,4 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP TABLE IF EXISTS user_identities;
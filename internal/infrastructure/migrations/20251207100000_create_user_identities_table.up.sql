@@ -0,0 +1,18 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email public.citext,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ,
+    UNIQUE (provider, subject),
+
+    CONSTRAINT fk_user_identities_user
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
@@ -0,0 +1,19 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+-- Rows must be decrypted (key_version 0) before rolling back
+DROP INDEX IF EXISTS idx_users_key_version;
+DROP INDEX IF EXISTS idx_users_email_plaintext;
+DROP INDEX IF EXISTS idx_users_email_index;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS key_version,
+    DROP COLUMN IF EXISTS email_index;
+
+ALTER TABLE users
+    ALTER COLUMN email TYPE public.citext,
+    ALTER COLUMN full_name TYPE VARCHAR(100),
+    ALTER COLUMN phone TYPE VARCHAR(20);
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+CREATE INDEX idx_users_email ON users(email);
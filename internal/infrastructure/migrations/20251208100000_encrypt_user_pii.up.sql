@@ -0,0 +1,21 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- Encrypted values are base64 ciphertext, longer than the cleartext limits
+ALTER TABLE users
+    ALTER COLUMN email TYPE TEXT,
+    ALTER COLUMN full_name TYPE TEXT,
+    ALTER COLUMN phone TYPE TEXT;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS idx_users_email;
+
+-- email_index is an HMAC of the normalised email used for lookups once the
+-- email is encrypted; key_version 0 marks rows still in cleartext
+ALTER TABLE users
+    ADD COLUMN email_index TEXT,
+    ADD COLUMN key_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX idx_users_email_index ON users(email_index);
+CREATE UNIQUE INDEX idx_users_email_plaintext ON users(lower(email)) WHERE key_version = 0;
+CREATE INDEX idx_users_key_version ON users(key_version);
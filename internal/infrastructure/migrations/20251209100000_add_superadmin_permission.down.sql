@@ -0,0 +1,8 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DELETE FROM permissions p
+USING applications a
+WHERE a.id = p.application_id
+  AND a.code = 'AUTHORIZER'
+  AND p.code = 'superadmin';
@@ -0,0 +1,21 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- Reserved permission that grants every permission in every application.
+-- Only global roles may hold it.
+INSERT INTO permissions (application_id, code, description)
+SELECT id, 'superadmin', 'Unrestricted access to every application'
+FROM applications
+WHERE code = 'AUTHORIZER'
+ON CONFLICT (application_id, code) DO NOTHING;
+
+-- Global roles used to allow everything implicitly. Keep existing
+-- deployments working by granting them superadmin explicitly; narrow the
+-- grants afterwards as needed.
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON p.code = 'superadmin'
+JOIN applications a ON a.id = p.application_id AND a.code = 'AUTHORIZER'
+WHERE r.scope = 'GLOBAL'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
@@ -0,0 +1,20 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- A role inherits the permissions of its parents, transitively
+CREATE TABLE IF NOT EXISTS role_parents (
+    role_id UUID NOT NULL,
+    parent_role_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (role_id, parent_role_id),
+    CHECK (role_id <> parent_role_id),
+
+    CONSTRAINT fk_role_parents_role
+        FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_role_parents_parent
+        FOREIGN KEY (parent_role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_parents_parent_role_id ON role_parents (parent_role_id);
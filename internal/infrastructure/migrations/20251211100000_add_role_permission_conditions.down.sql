@@ -0,0 +1,6 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+ALTER TABLE role_permissions DROP COLUMN IF EXISTS condition_id;
+
+DROP TABLE IF EXISTS grant_conditions;
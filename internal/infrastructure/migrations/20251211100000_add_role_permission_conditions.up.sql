@@ -0,0 +1,14 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- Condition expressions are content-addressed: the ID is derived from the
+-- expression, so a reference carried in a token never changes meaning
+CREATE TABLE IF NOT EXISTS grant_conditions (
+    id TEXT PRIMARY KEY,
+    expression TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE role_permissions
+    ADD COLUMN IF NOT EXISTS condition_id TEXT NULL
+        REFERENCES grant_conditions (id);
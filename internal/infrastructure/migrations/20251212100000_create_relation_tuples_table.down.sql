@@ -0,0 +1,5 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP TABLE IF EXISTS relation_tuples;
+DROP TABLE IF EXISTS relation_schemas;
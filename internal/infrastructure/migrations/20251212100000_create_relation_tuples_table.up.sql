@@ -0,0 +1,34 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+CREATE TABLE IF NOT EXISTS relation_schemas (
+    application_id UUID PRIMARY KEY,
+    definition JSONB NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_relation_schemas_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE
+);
+
+-- object#relation@subject; subject_relation is empty for plain subjects
+-- and names the userset otherwise
+CREATE TABLE IF NOT EXISTS relation_tuples (
+    application_id UUID NOT NULL,
+    namespace TEXT NOT NULL,
+    object_id TEXT NOT NULL,
+    relation TEXT NOT NULL,
+    subject_namespace TEXT NOT NULL,
+    subject_id TEXT NOT NULL,
+    subject_relation TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (application_id, namespace, object_id, relation, subject_namespace, subject_id, subject_relation),
+
+    CONSTRAINT fk_relation_tuples_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE
+);
+
+-- Reverse lookups for list-objects
+CREATE INDEX IF NOT EXISTS idx_relation_tuples_subject
+    ON relation_tuples (application_id, subject_namespace, subject_id, subject_relation);
@@ -0,0 +1,9 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP TABLE IF EXISTS organization_user_roles;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
+
+-- Enum values cannot be dropped; tenant roles are removed instead
+DELETE FROM roles WHERE scope = 'TENANT';
@@ -0,0 +1,53 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- Tenant roles belong to an application and are assigned to a user within
+-- an organization
+ALTER TYPE public.scope ADD VALUE IF NOT EXISTS 'TENANT';
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_organizations_timestamp
+BEFORE UPDATE ON organizations
+FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (organization_id, user_id),
+
+    CONSTRAINT fk_organization_members_organization
+        FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_organization_members_user
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members (user_id);
+
+-- Removing a member removes the roles they held in the organization
+CREATE TABLE IF NOT EXISTS organization_user_roles (
+    organization_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (organization_id, user_id, role_id),
+
+    CONSTRAINT fk_organization_user_roles_member
+        FOREIGN KEY (organization_id, user_id) REFERENCES organization_members (organization_id, user_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_organization_user_roles_role
+        FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_user_roles_role ON organization_user_roles (role_id);
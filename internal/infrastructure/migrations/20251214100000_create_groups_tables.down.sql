@@ -0,0 +1,7 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP TABLE IF EXISTS group_roles;
+DROP TABLE IF EXISTS group_subgroups;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
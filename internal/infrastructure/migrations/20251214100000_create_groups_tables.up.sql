@@ -0,0 +1,66 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+CREATE TABLE IF NOT EXISTS groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_groups_timestamp
+BEFORE UPDATE ON groups
+FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (group_id, user_id),
+
+    CONSTRAINT fk_group_members_group
+        FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_group_members_user
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_members_user ON group_members (user_id);
+
+-- The members of a subgroup are members of the group, transitively
+CREATE TABLE IF NOT EXISTS group_subgroups (
+    group_id UUID NOT NULL,
+    subgroup_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (group_id, subgroup_id),
+    CHECK (group_id <> subgroup_id),
+
+    CONSTRAINT fk_group_subgroups_group
+        FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_group_subgroups_subgroup
+        FOREIGN KEY (subgroup_id) REFERENCES groups (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_subgroups_subgroup ON group_subgroups (subgroup_id);
+
+CREATE TABLE IF NOT EXISTS group_roles (
+    group_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (group_id, role_id),
+
+    CONSTRAINT fk_group_roles_group
+        FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_group_roles_role
+        FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_roles_role ON group_roles (role_id);
@@ -0,0 +1,10 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP INDEX IF EXISTS idx_user_roles_valid_from;
+DROP INDEX IF EXISTS idx_user_roles_valid_until;
+
+ALTER TABLE user_roles
+    DROP CONSTRAINT IF EXISTS chk_user_roles_validity,
+    DROP COLUMN IF EXISTS valid_until,
+    DROP COLUMN IF EXISTS valid_from;
@@ -0,0 +1,13 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- An assignment is in effect from valid_from until valid_until; an unset
+-- bound is open
+ALTER TABLE user_roles
+    ADD COLUMN IF NOT EXISTS valid_from TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS valid_until TIMESTAMPTZ,
+    ADD CONSTRAINT chk_user_roles_validity
+        CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_until > valid_from);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_valid_until ON user_roles (valid_until) WHERE valid_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_user_roles_valid_from ON user_roles (valid_from) WHERE valid_from IS NOT NULL;
@@ -0,0 +1,6 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP TABLE IF EXISTS access_request_events;
+DROP TABLE IF EXISTS access_requests;
+DROP TABLE IF EXISTS access_approvers;
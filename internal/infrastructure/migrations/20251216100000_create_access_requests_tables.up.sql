@@ -0,0 +1,84 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- An approver may decide requests for every role of an application, or
+-- for one role when role_id is set
+CREATE TABLE IF NOT EXISTS access_approvers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    application_id UUID NOT NULL,
+    role_id UUID,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_access_approvers_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_access_approvers_role
+        FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_access_approvers_user
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_access_approvers_app_user
+    ON access_approvers (application_id, user_id) WHERE role_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_access_approvers_role_user
+    ON access_approvers (role_id, user_id) WHERE role_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_access_approvers_user ON access_approvers (user_id);
+
+CREATE TABLE IF NOT EXISTS access_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    application_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    justification TEXT NOT NULL,
+    valid_until TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'PENDING'
+        CHECK (status IN ('PENDING', 'APPROVED', 'DENIED', 'CANCELLED')),
+    decided_by UUID,
+    decided_at TIMESTAMPTZ,
+    decision_comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_access_requests_user
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_access_requests_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_access_requests_role
+        FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_access_requests_decided_by
+        FOREIGN KEY (decided_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE TRIGGER update_access_requests_timestamp
+BEFORE UPDATE ON access_requests
+FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
+
+-- A user has at most one open request per role
+CREATE UNIQUE INDEX IF NOT EXISTS idx_access_requests_pending
+    ON access_requests (user_id, role_id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_access_requests_user ON access_requests (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_access_requests_role ON access_requests (role_id) WHERE status = 'PENDING';
+
+-- Every step of a request's lifecycle, in order
+CREATE TABLE IF NOT EXISTS access_request_events (
+    id BIGSERIAL PRIMARY KEY,
+    request_id UUID NOT NULL,
+    actor_id UUID,
+    action TEXT NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_access_request_events_request
+        FOREIGN KEY (request_id) REFERENCES access_requests (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_access_request_events_actor
+        FOREIGN KEY (actor_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_request_events_request ON access_request_events (request_id, id);
@@ -0,0 +1,5 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+DROP TABLE IF EXISTS sod_constraint_roles;
+DROP TABLE IF EXISTS sod_constraints;
@@ -0,0 +1,40 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- A separation-of-duties constraint: no user may hold more than max_roles
+-- of its roles in the application at once
+CREATE TABLE IF NOT EXISTS sod_constraints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    application_id UUID NOT NULL,
+    code TEXT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    max_roles INT NOT NULL CHECK (max_roles >= 1),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (application_id, code),
+
+    CONSTRAINT fk_sod_constraints_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_sod_constraints_timestamp
+BEFORE UPDATE ON sod_constraints
+FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
+
+CREATE TABLE IF NOT EXISTS sod_constraint_roles (
+    constraint_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+
+    PRIMARY KEY (constraint_id, role_id),
+
+    CONSTRAINT fk_sod_constraint_roles_constraint
+        FOREIGN KEY (constraint_id) REFERENCES sod_constraints (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_sod_constraint_roles_role
+        FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sod_constraint_roles_role ON sod_constraint_roles (role_id);
@@ -0,0 +1,32 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- An application admin holds AUTHORIZER permissions for one application
+-- only, letting the owner of an application administer it without
+-- administering every application
+CREATE TABLE IF NOT EXISTS application_admins (
+    application_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    permissions TEXT[] NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (application_id, user_id),
+
+    CONSTRAINT fk_application_admins_application
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_application_admins_user
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_application_admins_created_by
+        FOREIGN KEY (created_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_admins_user ON application_admins (user_id);
+
+CREATE TRIGGER update_application_admins_timestamp
+BEFORE UPDATE ON application_admins
+FOR EACH ROW
+EXECUTE PROCEDURE update_timestamp();
@@ -0,0 +1,12 @@
+-- +migrate Down
+SET search_path TO authorizer_service;
+
+ALTER TABLE token_hooks
+    ADD COLUMN IF NOT EXISTS failure_policy hook_failure_policy NOT NULL DEFAULT 'FAIL_CLOSED';
+
+UPDATE token_hooks h
+SET failure_policy = a.hook_failure_policy
+FROM applications a
+WHERE a.id = h.application_id;
+
+ALTER TABLE applications DROP COLUMN IF EXISTS hook_failure_policy;
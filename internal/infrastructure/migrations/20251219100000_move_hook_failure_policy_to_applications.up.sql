@@ -0,0 +1,20 @@
+-- +migrate Up
+SET search_path TO authorizer_service;
+
+-- The hook failure policy applies to every hook of an application
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS hook_failure_policy hook_failure_policy NOT NULL DEFAULT 'FAIL_CLOSED';
+
+-- Applications whose hooks were all fail-open stay fail-open
+UPDATE applications a
+SET hook_failure_policy = 'FAIL_OPEN'
+WHERE EXISTS (
+        SELECT 1 FROM token_hooks h
+        WHERE h.application_id = a.id AND h.deleted_at IS NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM token_hooks h
+        WHERE h.application_id = a.id AND h.deleted_at IS NULL AND h.failure_policy = 'FAIL_CLOSED'
+    );
+
+ALTER TABLE token_hooks DROP COLUMN IF EXISTS failure_policy;
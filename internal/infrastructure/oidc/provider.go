@@ -0,0 +1,337 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+)
+
+const (
+	httpTimeout     = 10 * time.Second
+	maxResponseSize = 1 << 20
+)
+
+// Provider is an OpenID Connect relying party for one upstream identity
+// provider (infrastructure concern). It drives the authorization code flow
+// with PKCE and verifies ID tokens against the provider's JWKS.
+type Provider interface {
+	// Name returns the configured provider name
+	Name() string
+
+	// AuthCodeURL returns the provider's authorization URL for a login
+	// Parameters:
+	//   - ctx: context for cancellation and timeout
+	//   - state: opaque value echoed back to the callback
+	//   - nonce: value the ID token must carry
+	//   - codeChallenge: S256 PKCE challenge
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+
+	// Identity exchanges an authorization code, verifies the returned ID
+	// token and maps its claims to an external identity
+	Identity(ctx context.Context, code, codeVerifier, nonce string) (*entity.ExternalIdentity, error)
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type provider struct {
+	cfg    *config.IdentityProvider
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]*rsa.PublicKey
+}
+
+// NewProvider creates a relying party for the configured provider.
+// Discovery is performed lazily on first use.
+func NewProvider(cfg *config.IdentityProvider, client *http.Client) Provider {
+	if client == nil {
+		client = &http.Client{Timeout: httpTimeout}
+	}
+	return &provider{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+func (p *provider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+func (p *provider) Identity(ctx context.Context, code, codeVerifier, nonce string) (*entity.ExternalIdentity, error) {
+	rawIDToken, err := p.exchange(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := p.verifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.mapClaims(claims)
+}
+
+// exchange redeems the authorization code at the token endpoint and
+// returns the raw ID token
+func (p *provider) exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	var tokenResp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &tokenResp)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s %s", status, tokenResp.Error, tokenResp.ErrorDescription)
+	}
+	if tokenResp.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+
+	return tokenResp.IDToken, nil
+}
+
+// verifyIDToken checks the ID token signature, issuer, audience, expiry
+// and nonce
+func (p *provider) verifyIDToken(ctx context.Context, raw, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}))
+
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if iss, _ := claims["iss"].(string); iss != p.cfg.Issuer {
+		return nil, fmt.Errorf("unexpected id token issuer %q", iss)
+	}
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, errors.New("id token audience does not match client")
+	}
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+func (p *provider) mapClaims(claims jwt.MapClaims) (*entity.ExternalIdentity, error) {
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	m := p.cfg.ClaimMapping
+	identity := &entity.ExternalIdentity{
+		Provider: p.cfg.Name,
+		Subject:  sub,
+		Email:    stringClaim(claims, m.Email),
+		Username: stringClaim(claims, m.Username),
+		FullName: stringClaim(claims, m.FullName),
+	}
+
+	// Some providers send email_verified as a string
+	switch v := claims[m.EmailVerified].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+
+	return identity, nil
+}
+
+// discover fetches and caches the provider's discovery document
+func (p *provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc discoveryDocument
+	status, err := p.doJSON(req, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery returned %d", status)
+	}
+	if doc.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match configured issuer", doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("discovery document is incomplete")
+	}
+
+	p.discovery = &doc
+	return p.discovery, nil
+}
+
+// key returns the signing key for kid, refreshing the JWKS once when the
+// key is unknown so provider key rotation is picked up
+func (p *provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+
+	keys, err := p.fetchKeys(ctx, doc.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, fmt.Errorf("jwks request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", status)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+func (p *provider) doJSON(req *http.Request, out interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	v, _ := claims[name].(string)
+	return v
+}
@@ -0,0 +1,193 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOIDCServer is a minimal OpenID provider serving discovery, JWKS and
+// a token endpoint that accepts a single authorization code
+type mockOIDCServer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	code      string
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockOIDCServer{key: key, code: "auth-code"}
+	mux := http.NewServeMux()
+	m.Server = httptest.NewServer(mux)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "mock-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		id, secret, _ := r.BasicAuth()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		verifierOK := base64.RawURLEncoding.EncodeToString(sum[:]) == m.challenge
+
+		if id != "client-1" || secret != "secret-1" || r.PostForm.Get("code") != m.code || !verifierOK {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.claims)
+		token.Header["kid"] = "mock-key"
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	})
+
+	return m
+}
+
+func testProviderConfig(issuer string) *config.IdentityProvider {
+	return &config.IdentityProvider{
+		Name:         "corp",
+		Issuer:       issuer,
+		ClientID:     "client-1",
+		ClientSecret: "secret-1",
+		RedirectURL:  "http://localhost/callback",
+		Scopes:       []string{"openid", "email"},
+		ClaimMapping: config.ClaimMapping{
+			Email:         "email",
+			EmailVerified: "email_verified",
+			Username:      "preferred_username",
+			FullName:      "name",
+		},
+	}
+}
+
+func challengeFor(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	server := newMockOIDCServer(t)
+	defer server.Close()
+
+	p := NewProvider(testProviderConfig(server.URL), nil)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", "challenge-1")
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "code", u.Query().Get("response_type"))
+	assert.Equal(t, "client-1", u.Query().Get("client_id"))
+	assert.Equal(t, "state-1", u.Query().Get("state"))
+	assert.Equal(t, "nonce-1", u.Query().Get("nonce"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+}
+
+func TestProvider_Identity(t *testing.T) {
+	server := newMockOIDCServer(t)
+	defer server.Close()
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                server.URL,
+			"sub":                "upstream-42",
+			"aud":                "client-1",
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"iat":                time.Now().Unix(),
+			"nonce":              "nonce-1",
+			"email":              "jane@example.com",
+			"email_verified":     true,
+			"preferred_username": "jane",
+			"name":               "Jane Doe",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(c jwt.MapClaims)
+		nonce   string
+		wantErr bool
+	}{
+		{"valid token", func(c jwt.MapClaims) {}, "nonce-1", false},
+		{"nonce mismatch", func(c jwt.MapClaims) {}, "other-nonce", true},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "client-2" }, "nonce-1", true},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, "nonce-1", true},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, "nonce-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.mutate(claims)
+			server.claims = claims
+			server.challenge = challengeFor("verifier-1")
+
+			p := NewProvider(testProviderConfig(server.URL), nil)
+			identity, err := p.Identity(context.Background(), "auth-code", "verifier-1", tt.nonce)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "corp", identity.Provider)
+			assert.Equal(t, "upstream-42", identity.Subject)
+			assert.Equal(t, "jane@example.com", identity.Email)
+			assert.True(t, identity.EmailVerified)
+			assert.Equal(t, "jane", identity.Username)
+			assert.Equal(t, "Jane Doe", identity.FullName)
+		})
+	}
+}
+
+func TestProvider_Identity_BadVerifier(t *testing.T) {
+	server := newMockOIDCServer(t)
+	defer server.Close()
+	server.challenge = challengeFor("verifier-1")
+
+	p := NewProvider(testProviderConfig(server.URL), nil)
+	_, err := p.Identity(context.Background(), "auth-code", "wrong-verifier", "nonce-1")
+
+	assert.Error(t, err)
+}
@@ -20,6 +20,7 @@ type User struct {
 	CreatedAt     pgtype.Timestamp
 	UpdatedAt     pgtype.Timestamp
 	DeletedAt     pgtype.Timestamp
+	KeyVersion    int
 }
 
 func (u *User) ToEntity() *entity.User {
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+// accessRequestColumns selects a request aliased ar with the codes of its
+// role and application
+const accessRequestColumns = `
+	ar.id, ar.user_id, ar.application_id, ar.role_id, r.code, a.code,
+	ar.justification, ar.valid_until, ar.status, ar.decided_by, ar.decided_at,
+	ar.decision_comment, ar.created_at, ar.updated_at
+`
+
+// accessRequestJoins joins the role and application of requests aliased ar
+const accessRequestJoins = `
+	INNER JOIN authorizer_service.roles r ON r.id = ar.role_id
+	INNER JOIN authorizer_service.applications a ON a.id = ar.application_id
+`
+
+type accessRequestRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewAccessRequestRepositoryPGX(pool *pgxpool.Pool) repository.AccessRequestRepository {
+	return &accessRequestRepositoryPGX{
+		pool: pool,
+	}
+}
+
+func (r *accessRequestRepositoryPGX) Create(ctx context.Context, req *entity.AccessRequest) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO authorizer_service.access_requests
+			(id, user_id, application_id, role_id, justification, valid_until, status)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = tx.Exec(ctx, query,
+		req.ID, req.UserID, req.ApplicationID, req.RoleID, req.Justification, req.ValidUntil, req.Status,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertAccessRequestEvent(ctx, tx, req.ID, req.UserID, entity.AccessRequestActionRequested, &req.Justification); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *accessRequestRepositoryPGX) GetByID(ctx context.Context, id string) (*entity.AccessRequest, error) {
+	query := `SELECT ` + accessRequestColumns + `
+		FROM authorizer_service.access_requests ar
+		` + accessRequestJoins + `
+		WHERE ar.id = $1;
+	`
+
+	return scanAccessRequest(r.pool.QueryRow(ctx, query, id))
+}
+
+func (r *accessRequestRepositoryPGX) HasPending(ctx context.Context, userID, roleID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM authorizer_service.access_requests
+			WHERE user_id = $1 AND role_id = $2 AND status = 'PENDING'
+		);
+	`
+
+	var pending bool
+	err := r.pool.QueryRow(ctx, query, userID, roleID).Scan(&pending)
+	return pending, err
+}
+
+func (r *accessRequestRepositoryPGX) ListByUser(ctx context.Context, userID string) ([]*entity.AccessRequest, error) {
+	query := `SELECT ` + accessRequestColumns + `
+		FROM authorizer_service.access_requests ar
+		` + accessRequestJoins + `
+		WHERE ar.user_id = $1
+		ORDER BY ar.created_at DESC;
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAccessRequests(rows)
+}
+
+func (r *accessRequestRepositoryPGX) ListPendingForApprover(ctx context.Context, approverID string) ([]*entity.AccessRequest, error) {
+	query := `SELECT ` + accessRequestColumns + `
+		FROM authorizer_service.access_requests ar
+		` + accessRequestJoins + `
+		WHERE ar.status = 'PENDING'
+			AND ar.user_id <> $1
+			AND EXISTS (
+				SELECT 1
+				FROM authorizer_service.access_approvers ap
+				WHERE ap.user_id = $1
+					AND ap.application_id = ar.application_id
+					AND (ap.role_id IS NULL OR ap.role_id = ar.role_id)
+			)
+		ORDER BY ar.created_at;
+	`
+
+	rows, err := r.pool.Query(ctx, query, approverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAccessRequests(rows)
+}
+
+func (r *accessRequestRepositoryPGX) ListEvents(ctx context.Context, requestID string) ([]*entity.AccessRequestEvent, error) {
+	query := `
+		SELECT id, request_id, actor_id, action, comment, created_at
+		FROM authorizer_service.access_request_events
+		WHERE request_id = $1
+		ORDER BY id;
+	`
+
+	rows, err := r.pool.Query(ctx, query, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*entity.AccessRequestEvent
+	for rows.Next() {
+		var e entity.AccessRequestEvent
+		if err := rows.Scan(&e.ID, &e.RequestID, &e.ActorID, &e.Action, &e.Comment, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *accessRequestRepositoryPGX) Approve(ctx context.Context, requestID, actorID string, comment *string, validUntil *time.Time) error {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID, roleID string
+	if err := closeAccessRequest(ctx, tx, requestID, actorID, entity.AccessRequestApproved, comment).Scan(&userID, &roleID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrAccessRequestClosed
+		}
+		return err
+	}
+
+	assignQuery := `
+		INSERT INTO authorizer_service.user_roles (user_id, role_id, valid_until)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, role_id) DO UPDATE
+			SET valid_from = NULL, valid_until = EXCLUDED.valid_until;
+	`
+	if _, err := tx.Exec(ctx, assignQuery, userID, roleID, validUntil); err != nil {
+		return err
+	}
+
+	if err := insertAccessRequestEvent(ctx, tx, requestID, actorID, entity.AccessRequestActionApproved, comment); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *accessRequestRepositoryPGX) Close(ctx context.Context, requestID, actorID, status string, comment *string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID, roleID string
+	if err := closeAccessRequest(ctx, tx, requestID, actorID, status, comment).Scan(&userID, &roleID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrAccessRequestClosed
+		}
+		return err
+	}
+
+	// Statuses and event actions share their names
+	if err := insertAccessRequestEvent(ctx, tx, requestID, actorID, status, comment); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *accessRequestRepositoryPGX) AddApprover(ctx context.Context, approver *entity.AccessApprover) error {
+	query := `
+		INSERT INTO authorizer_service.access_approvers
+			(id, application_id, role_id, user_id)
+		VALUES
+			($1, $2, $3, $4)
+	`
+	_, err := r.pool.Exec(ctx, query, approver.ID, approver.ApplicationID, approver.RoleID, approver.UserID)
+
+	return err
+}
+
+func (r *accessRequestRepositoryPGX) RemoveApprover(ctx context.Context, appID, id string) error {
+	query := `
+		DELETE FROM authorizer_service.access_approvers
+		WHERE application_id = $1 AND id = $2;
+	`
+
+	tag, err := r.pool.Exec(ctx, query, appID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (r *accessRequestRepositoryPGX) ListApprovers(ctx context.Context, appID string) ([]*entity.AccessApprover, error) {
+	query := `
+		SELECT id, application_id, role_id, user_id, created_at
+		FROM authorizer_service.access_approvers
+		WHERE application_id = $1
+		ORDER BY created_at;
+	`
+
+	rows, err := r.pool.Query(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var approvers []*entity.AccessApprover
+	for rows.Next() {
+		var a entity.AccessApprover
+		if err := rows.Scan(&a.ID, &a.ApplicationID, &a.RoleID, &a.UserID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		approvers = append(approvers, &a)
+	}
+
+	return approvers, rows.Err()
+}
+
+func (r *accessRequestRepositoryPGX) IsApprover(ctx context.Context, userID, appID, roleID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM authorizer_service.access_approvers
+			WHERE user_id = $1 AND application_id = $2 AND (role_id IS NULL OR role_id = $3)
+		);
+	`
+
+	var ok bool
+	err := r.pool.QueryRow(ctx, query, userID, appID, roleID).Scan(&ok)
+	return ok, err
+}
+
+func (r *accessRequestRepositoryPGX) HasApprovers(ctx context.Context, appID, roleID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM authorizer_service.access_approvers
+			WHERE application_id = $1 AND (role_id IS NULL OR role_id = $2)
+		);
+	`
+
+	var ok bool
+	err := r.pool.QueryRow(ctx, query, appID, roleID).Scan(&ok)
+	return ok, err
+}
+
+// closeAccessRequest sets the status of a pending request and returns its
+// user and role; no row is returned when the request is not pending
+func closeAccessRequest(ctx context.Context, tx pgx.Tx, requestID, actorID, status string, comment *string) pgx.Row {
+	query := `
+		UPDATE authorizer_service.access_requests
+		SET status = $2, decided_by = $3, decided_at = NOW(), decision_comment = $4
+		WHERE id = $1 AND status = 'PENDING'
+		RETURNING user_id, role_id;
+	`
+
+	return tx.QueryRow(ctx, query, requestID, status, actorID, comment)
+}
+
+func insertAccessRequestEvent(ctx context.Context, tx pgx.Tx, requestID, actorID, action string, comment *string) error {
+	query := `
+		INSERT INTO authorizer_service.access_request_events
+			(request_id, actor_id, action, comment)
+		VALUES
+			($1, $2, $3, $4)
+	`
+
+	_, err := tx.Exec(ctx, query, requestID, actorID, action, comment)
+	return err
+}
+
+func scanAccessRequest(row pgx.Row) (*entity.AccessRequest, error) {
+	var req entity.AccessRequest
+	err := row.Scan(
+		&req.ID, &req.UserID, &req.ApplicationID, &req.RoleID, &req.RoleCode, &req.AppCode,
+		&req.Justification, &req.ValidUntil, &req.Status, &req.DecidedBy, &req.DecidedAt,
+		&req.DecisionComment, &req.CreatedAt, &req.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+func scanAccessRequests(rows pgx.Rows) ([]*entity.AccessRequest, error) {
+	var reqs []*entity.AccessRequest
+	for rows.Next() {
+		req, err := scanAccessRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+
+	return reqs, rows.Err()
+}
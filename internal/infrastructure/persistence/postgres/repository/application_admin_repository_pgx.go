@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type applicationAdminRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewApplicationAdminRepositoryPGX(pool *pgxpool.Pool) repository.ApplicationAdminRepository {
+	return &applicationAdminRepositoryPGX{
+		pool: pool,
+	}
+}
+
+func (r *applicationAdminRepositoryPGX) Save(ctx context.Context, admin *entity.ApplicationAdmin) error {
+	query := `
+		INSERT INTO authorizer_service.application_admins
+			(application_id, user_id, permissions, created_by)
+		VALUES
+			($1, $2, $3, $4)
+		ON CONFLICT (application_id, user_id)
+		DO UPDATE SET permissions = EXCLUDED.permissions
+	`
+	_, err := r.pool.Exec(ctx, query, admin.ApplicationID, admin.UserID, admin.Permissions, admin.CreatedBy)
+
+	return err
+}
+
+func (r *applicationAdminRepositoryPGX) Delete(ctx context.Context, appID, userID string) error {
+	query := `
+		DELETE FROM authorizer_service.application_admins
+		WHERE application_id = $1 AND user_id = $2;
+	`
+
+	tag, err := r.pool.Exec(ctx, query, appID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (r *applicationAdminRepositoryPGX) ListByApp(ctx context.Context, appID string) ([]*entity.ApplicationAdmin, error) {
+	query := `
+		SELECT application_id, user_id, permissions, created_by, created_at, updated_at
+		FROM authorizer_service.application_admins
+		WHERE application_id = $1
+		ORDER BY created_at;
+	`
+
+	rows, err := r.pool.Query(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanApplicationAdmins(rows)
+}
+
+func (r *applicationAdminRepositoryPGX) ListByUser(ctx context.Context, userID string) ([]*entity.ApplicationAdmin, error) {
+	query := `
+		SELECT aa.application_id, aa.user_id, aa.permissions, aa.created_by, aa.created_at, aa.updated_at
+		FROM authorizer_service.application_admins aa
+		INNER JOIN authorizer_service.applications a ON a.id = aa.application_id
+		WHERE aa.user_id = $1 AND a.deleted_at IS NULL
+		ORDER BY aa.created_at;
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanApplicationAdmins(rows)
+}
+
+func scanApplicationAdmins(rows pgx.Rows) ([]*entity.ApplicationAdmin, error) {
+	var admins []*entity.ApplicationAdmin
+	for rows.Next() {
+		var a entity.ApplicationAdmin
+		if err := rows.Scan(&a.ApplicationID, &a.UserID, &a.Permissions, &a.CreatedBy, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		admins = append(admins, &a)
+	}
+
+	return admins, rows.Err()
+}
@@ -26,12 +26,12 @@ func (r *appRepositoryPGX) Create(ctx context.Context, app *entity.Application)
 
 	query := `
 		INSERT INTO authorizer_service.applications 
-			(id, code, name, description, metadata)
+			(id, code, name, description, metadata, token_mode, hook_failure_policy)
 		VALUES 
-			($1, $2, $3, $4, $5)
+			($1, $2, $3, $4, $5, $6, $7)
 	`
 	_, err := r.pool.Exec(ctx, query,
-		app.ID, app.Code, app.Name, app.Description, metadataJSON,
+		app.ID, app.Code, app.Name, app.Description, metadataJSON, app.TokenMode, app.HookFailurePolicy,
 	)
 
 	return err
@@ -79,11 +79,13 @@ func (r *appRepositoryPGX) Update(ctx context.Context, app *entity.Application)
 	query := `
 		UPDATE authorizer_service.applications
 		SET name = $1,
+			token_mode = $2,
+			hook_failure_policy = $3,
 			updated_at = NOW()
-		WHERE id = $2 AND deleted_at IS NULL
+		WHERE id = $4 AND deleted_at IS NULL
 	`
 	_, err := r.pool.Exec(ctx,
-		query, app.Name, app.ID,
+		query, app.Name, app.TokenMode, app.HookFailurePolicy, app.ID,
 	)
 	return err
 }
@@ -131,6 +133,8 @@ func scanApp(row pgx.Row) (*entity.Application, error) {
 		&a.CreatedAt,
 		&a.UpdatedAt,
 		&a.DeletedAt,
+		&a.TokenMode,
+		&a.HookFailurePolicy,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
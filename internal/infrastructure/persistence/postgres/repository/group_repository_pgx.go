@@ -0,0 +1,354 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type groupRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewGroupRepositoryPGX(pool *pgxpool.Pool) repository.GroupRepository {
+	return &groupRepositoryPGX{
+		pool: pool,
+	}
+}
+
+func (r *groupRepositoryPGX) Create(ctx context.Context, group *entity.Group) error {
+	query := `
+		INSERT INTO authorizer_service.groups
+			(id, code, name, description)
+		VALUES
+			($1, $2, $3, $4)
+	`
+	_, err := r.pool.Exec(ctx, query, group.ID, group.Code, group.Name, group.Description)
+
+	return err
+}
+
+func (r *groupRepositoryPGX) Delete(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM authorizer_service.groups WHERE id = $1`, id)
+	return err
+}
+
+func (r *groupRepositoryPGX) GetByID(ctx context.Context, id string) (*entity.Group, error) {
+	query := `
+		SELECT id, code, name, description, created_at, updated_at
+		FROM authorizer_service.groups
+		WHERE id = $1;
+	`
+
+	return scanGroup(r.pool.QueryRow(ctx, query, id))
+}
+
+func (r *groupRepositoryPGX) GetByCode(ctx context.Context, code string) (*entity.Group, error) {
+	query := `
+		SELECT id, code, name, description, created_at, updated_at
+		FROM authorizer_service.groups
+		WHERE code = $1;
+	`
+
+	return scanGroup(r.pool.QueryRow(ctx, query, code))
+}
+
+func (r *groupRepositoryPGX) List(ctx context.Context, limit, offset int) ([]*entity.Group, error) {
+	query := `
+		SELECT id, code, name, description, created_at, updated_at
+		FROM authorizer_service.groups
+		ORDER BY code
+		LIMIT $1 OFFSET $2;
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanGroups(rows)
+}
+
+func (r *groupRepositoryPGX) AddMember(ctx context.Context, groupID, userID string) error {
+	query := `
+		INSERT INTO authorizer_service.group_members (group_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query, groupID, userID)
+	return err
+}
+
+func (r *groupRepositoryPGX) RemoveMember(ctx context.Context, groupID, userID string) error {
+	query := `
+		DELETE FROM authorizer_service.group_members
+		WHERE group_id = $1 AND user_id = $2;
+	`
+
+	_, err := r.pool.Exec(ctx, query, groupID, userID)
+	return err
+}
+
+func (r *groupRepositoryPGX) ListMembers(ctx context.Context, groupID string) ([]*entity.GroupMember, error) {
+	query := `
+		SELECT group_id, user_id, created_at
+		FROM authorizer_service.group_members
+		WHERE group_id = $1
+		ORDER BY created_at;
+	`
+
+	rows, err := r.pool.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*entity.GroupMember
+	for rows.Next() {
+		var m entity.GroupMember
+		if err := rows.Scan(&m.GroupID, &m.UserID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &m)
+	}
+
+	return members, rows.Err()
+}
+
+func (r *groupRepositoryPGX) GetEffectiveMemberIDs(ctx context.Context, groupID string) ([]string, error) {
+	query := `
+		WITH RECURSIVE nested AS (
+			SELECT $1::uuid AS id
+			UNION
+			SELECT gs.subgroup_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN nested n ON gs.group_id = n.id
+		)
+		SELECT DISTINCT gm.user_id
+		FROM authorizer_service.group_members gm
+		INNER JOIN nested n ON n.id = gm.group_id;
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *groupRepositoryPGX) AddSubgroup(ctx context.Context, groupID, subgroupID string) error {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize nesting writes so two concurrent changes cannot close a
+	// cycle that neither sees on its own
+	if _, err := tx.Exec(ctx, `LOCK TABLE authorizer_service.group_subgroups IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	// The subgroup closes a cycle when the group is nested in it
+	cycleQuery := `
+		WITH RECURSIVE nested AS (
+			SELECT $2::uuid AS id
+			UNION
+			SELECT gs.subgroup_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN nested n ON gs.group_id = n.id
+		)
+		SELECT EXISTS (SELECT 1 FROM nested WHERE id = $1);
+	`
+	var cycle bool
+	if err := tx.QueryRow(ctx, cycleQuery, groupID, subgroupID).Scan(&cycle); err != nil {
+		return err
+	}
+	if cycle {
+		return repository.ErrGroupCycle
+	}
+
+	insQuery := `
+		INSERT INTO authorizer_service.group_subgroups (group_id, subgroup_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;
+	`
+	if _, err := tx.Exec(ctx, insQuery, groupID, subgroupID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *groupRepositoryPGX) RemoveSubgroup(ctx context.Context, groupID, subgroupID string) error {
+	query := `
+		DELETE FROM authorizer_service.group_subgroups
+		WHERE group_id = $1 AND subgroup_id = $2;
+	`
+
+	_, err := r.pool.Exec(ctx, query, groupID, subgroupID)
+	return err
+}
+
+func (r *groupRepositoryPGX) ListSubgroups(ctx context.Context, groupID string) ([]*entity.Group, error) {
+	query := `
+		SELECT g.id, g.code, g.name, g.description, g.created_at, g.updated_at
+		FROM authorizer_service.groups g
+		INNER JOIN authorizer_service.group_subgroups gs ON gs.subgroup_id = g.id
+		WHERE gs.group_id = $1
+		ORDER BY g.code;
+	`
+
+	rows, err := r.pool.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanGroups(rows)
+}
+
+func (r *groupRepositoryPGX) ReplaceRoles(ctx context.Context, groupID, appID string, roleIDs []string) error {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	delQuery := `
+		DELETE FROM authorizer_service.group_roles gr
+		USING authorizer_service.roles r
+		WHERE r.id = gr.role_id AND gr.group_id = $1 AND r.application_id = $2;
+	`
+	if _, err := tx.Exec(ctx, delQuery, groupID, appID); err != nil {
+		return err
+	}
+
+	if len(roleIDs) == 0 {
+		return tx.Commit(ctx)
+	}
+
+	insQuery := `
+		INSERT INTO authorizer_service.group_roles (group_id, role_id)
+		SELECT $1, unnest($2::uuid[]);
+	`
+	if _, err := tx.Exec(ctx, insQuery, groupID, roleIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *groupRepositoryPGX) GetRoles(ctx context.Context, groupID string) ([]*entity.Role, error) {
+	query := `
+		SELECT r.*
+		FROM authorizer_service.roles r
+		INNER JOIN authorizer_service.group_roles gr ON gr.role_id = r.id
+		WHERE gr.group_id = $1 AND r.deleted_at IS NULL
+		ORDER BY r.code;
+	`
+
+	rows, err := r.pool.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+func (r *groupRepositoryPGX) GetRolesByMemberAndApp(ctx context.Context, userID, appID string) ([]*entity.Role, error) {
+	// member_of holds the user's groups and every group containing them
+	query := `
+		WITH RECURSIVE member_of AS (
+			SELECT gm.group_id
+			FROM authorizer_service.group_members gm
+			WHERE gm.user_id = $1
+			UNION
+			SELECT gs.group_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN member_of m ON gs.subgroup_id = m.group_id
+		)
+		SELECT DISTINCT r.*
+		FROM authorizer_service.roles r
+		INNER JOIN authorizer_service.group_roles gr ON gr.role_id = r.id
+		INNER JOIN member_of m ON m.group_id = gr.group_id
+		WHERE r.application_id = $2 AND r.deleted_at IS NULL;
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+func (r *groupRepositoryPGX) GetConferredRoles(ctx context.Context, groupID string) ([]*entity.Role, error) {
+	query := `
+		WITH RECURSIVE containing AS (
+			SELECT $1::uuid AS id
+			UNION
+			SELECT gs.group_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN containing c ON gs.subgroup_id = c.id
+		)
+		SELECT DISTINCT r.*
+		FROM authorizer_service.roles r
+		INNER JOIN authorizer_service.group_roles gr ON gr.role_id = r.id
+		INNER JOIN containing c ON c.id = gr.group_id
+		WHERE r.deleted_at IS NULL;
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+func scanGroup(row pgx.Row) (*entity.Group, error) {
+	var g entity.Group
+	err := row.Scan(&g.ID, &g.Code, &g.Name, &g.Description, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+func scanGroups(rows pgx.Rows) ([]*entity.Group, error) {
+	var groups []*entity.Group
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type organizationRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewOrganizationRepositoryPGX(pool *pgxpool.Pool) repository.OrganizationRepository {
+	return &organizationRepositoryPGX{
+		pool: pool,
+	}
+}
+
+func (r *organizationRepositoryPGX) Create(ctx context.Context, org *entity.Organization) error {
+	query := `
+		INSERT INTO authorizer_service.organizations
+			(id, code, name)
+		VALUES
+			($1, $2, $3)
+	`
+	_, err := r.pool.Exec(ctx, query, org.ID, org.Code, org.Name)
+
+	return err
+}
+
+func (r *organizationRepositoryPGX) GetByID(ctx context.Context, id string) (*entity.Organization, error) {
+	query := `
+		SELECT id, code, name, created_at, updated_at
+		FROM authorizer_service.organizations
+		WHERE id = $1;
+	`
+
+	return scanOrganization(r.pool.QueryRow(ctx, query, id))
+}
+
+func (r *organizationRepositoryPGX) GetByCode(ctx context.Context, code string) (*entity.Organization, error) {
+	query := `
+		SELECT id, code, name, created_at, updated_at
+		FROM authorizer_service.organizations
+		WHERE code = $1;
+	`
+
+	return scanOrganization(r.pool.QueryRow(ctx, query, code))
+}
+
+func (r *organizationRepositoryPGX) List(ctx context.Context, limit, offset int) ([]*entity.Organization, error) {
+	query := `
+		SELECT id, code, name, created_at, updated_at
+		FROM authorizer_service.organizations
+		ORDER BY code
+		LIMIT $1 OFFSET $2;
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOrganizations(rows)
+}
+
+func (r *organizationRepositoryPGX) ListByUser(ctx context.Context, userID string) ([]*entity.Organization, error) {
+	query := `
+		SELECT o.id, o.code, o.name, o.created_at, o.updated_at
+		FROM authorizer_service.organizations o
+		INNER JOIN authorizer_service.organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.code;
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOrganizations(rows)
+}
+
+func (r *organizationRepositoryPGX) AddMember(ctx context.Context, orgID, userID string) error {
+	query := `
+		INSERT INTO authorizer_service.organization_members (organization_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;
+	`
+
+	_, err := r.pool.Exec(ctx, query, orgID, userID)
+	return err
+}
+
+func (r *organizationRepositoryPGX) RemoveMember(ctx context.Context, orgID, userID string) error {
+	query := `
+		DELETE FROM authorizer_service.organization_members
+		WHERE organization_id = $1 AND user_id = $2;
+	`
+
+	_, err := r.pool.Exec(ctx, query, orgID, userID)
+	return err
+}
+
+func (r *organizationRepositoryPGX) IsMember(ctx context.Context, orgID, userID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM authorizer_service.organization_members
+			WHERE organization_id = $1 AND user_id = $2
+		);
+	`
+
+	var ok bool
+	err := r.pool.QueryRow(ctx, query, orgID, userID).Scan(&ok)
+	return ok, err
+}
+
+func (r *organizationRepositoryPGX) ListMembers(ctx context.Context, orgID string) ([]*entity.OrganizationMember, error) {
+	query := `
+		SELECT organization_id, user_id, created_at
+		FROM authorizer_service.organization_members
+		WHERE organization_id = $1
+		ORDER BY created_at;
+	`
+
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*entity.OrganizationMember
+	for rows.Next() {
+		var m entity.OrganizationMember
+		if err := rows.Scan(&m.OrganizationID, &m.UserID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &m)
+	}
+
+	return members, rows.Err()
+}
+
+func (r *organizationRepositoryPGX) ReplaceRoles(ctx context.Context, orgID, userID, appID string, roleIDs []string) error {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	delQuery := `
+		DELETE FROM authorizer_service.organization_user_roles our
+		USING authorizer_service.roles r
+		WHERE r.id = our.role_id
+			AND our.organization_id = $1 AND our.user_id = $2 AND r.application_id = $3;
+	`
+	if _, err := tx.Exec(ctx, delQuery, orgID, userID, appID); err != nil {
+		return err
+	}
+
+	if len(roleIDs) == 0 {
+		return tx.Commit(ctx)
+	}
+
+	insQuery := `
+		INSERT INTO authorizer_service.organization_user_roles (organization_id, user_id, role_id)
+		SELECT $1, $2, unnest($3::uuid[]);
+	`
+	if _, err := tx.Exec(ctx, insQuery, orgID, userID, roleIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *organizationRepositoryPGX) GetRolesByMemberAndApp(ctx context.Context, orgID, userID, appID string) ([]*entity.Role, error) {
+	query := `
+		SELECT r.*
+		FROM authorizer_service.roles r
+		INNER JOIN authorizer_service.organization_user_roles our ON our.role_id = r.id
+		WHERE our.organization_id = $1 AND our.user_id = $2 AND r.application_id = $3 AND r.deleted_at IS NULL;
+	`
+
+	rows, err := r.pool.Query(ctx, query, orgID, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+func scanOrganization(row pgx.Row) (*entity.Organization, error) {
+	var o entity.Organization
+	err := row.Scan(&o.ID, &o.Code, &o.Name, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+func scanOrganizations(rows pgx.Rows) ([]*entity.Organization, error) {
+	var orgs []*entity.Organization
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+
+	return orgs, rows.Err()
+}
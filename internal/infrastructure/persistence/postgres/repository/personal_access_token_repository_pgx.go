@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type patRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewPersonalAccessTokenRepositoryPGX(pool *pgxpool.Pool) repository.PersonalAccessTokenRepository {
+	return &patRepositoryPGX{
+		pool: pool,
+	}
+}
+
+const patColumns = `id, user_id, application_id, name, prefix, token_hash, permissions, expires_at, last_used_at, revoked_at, created_at`
+
+func (r *patRepositoryPGX) Create(ctx context.Context, token *entity.PersonalAccessToken) error {
+	query := `
+		INSERT INTO authorizer_service.personal_access_tokens 
+			(id, user_id, application_id, name, prefix, token_hash, permissions, expires_at)
+		VALUES 
+			($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		token.ID, token.UserID, token.ApplicationID, token.Name,
+		token.Prefix, token.TokenHash, token.Permissions, token.ExpiresAt,
+	)
+
+	return err
+}
+
+func (r *patRepositoryPGX) GetByPrefix(ctx context.Context, prefix string) (*entity.PersonalAccessToken, error) {
+	query := `SELECT ` + patColumns + ` FROM authorizer_service.personal_access_tokens WHERE prefix = $1`
+
+	row := r.pool.QueryRow(ctx, query, prefix)
+	return scanPAT(row)
+}
+
+func (r *patRepositoryPGX) ListByUser(ctx context.Context, userID string) ([]*entity.PersonalAccessToken, error) {
+	query := `
+		SELECT ` + patColumns + `
+		FROM authorizer_service.personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*entity.PersonalAccessToken
+	for rows.Next() {
+		t, err := scanPAT(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *patRepositoryPGX) Revoke(ctx context.Context, userID, id string) error {
+	query := `
+		UPDATE authorizer_service.personal_access_tokens
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	tag, err := r.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (r *patRepositoryPGX) TouchLastUsed(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE authorizer_service.personal_access_tokens SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func scanPAT(row pgx.Row) (*entity.PersonalAccessToken, error) {
+	var t entity.PersonalAccessToken
+
+	err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.ApplicationID,
+		&t.Name,
+		&t.Prefix,
+		&t.TokenHash,
+		&t.Permissions,
+		&t.ExpiresAt,
+		&t.LastUsedAt,
+		&t.RevokedAt,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type relationRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewRelationRepositoryPGX(pool *pgxpool.Pool) repository.RelationRepository {
+	return &relationRepositoryPGX{
+		pool: pool,
+	}
+}
+
+func (r *relationRepositoryPGX) GetSchema(ctx context.Context, appID string) (*entity.RelationSchema, error) {
+	query := `
+		SELECT application_id, definition, version, updated_at
+		FROM authorizer_service.relation_schemas
+		WHERE application_id = $1;
+	`
+
+	var s entity.RelationSchema
+	err := r.pool.QueryRow(ctx, query, appID).Scan(&s.ApplicationID, &s.Definition, &s.Version, &s.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *relationRepositoryPGX) SaveSchema(ctx context.Context, schema *entity.RelationSchema) error {
+	query := `
+		INSERT INTO authorizer_service.relation_schemas (application_id, definition)
+		VALUES ($1, $2)
+		ON CONFLICT (application_id) DO UPDATE
+		SET definition = EXCLUDED.definition,
+			version = relation_schemas.version + 1,
+			updated_at = NOW()
+		RETURNING version, updated_at;
+	`
+
+	return r.pool.QueryRow(ctx, query, schema.ApplicationID, schema.Definition).Scan(&schema.Version, &schema.UpdatedAt)
+}
+
+func (r *relationRepositoryPGX) WriteTuples(ctx context.Context, tuples []*entity.RelationTuple) error {
+	query := `
+		INSERT INTO authorizer_service.relation_tuples
+			(application_id, namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING;
+	`
+
+	batch := &pgx.Batch{}
+	for _, t := range tuples {
+		batch.Queue(query, t.ApplicationID, t.Namespace, t.ObjectID, t.Relation, t.SubjectNamespace, t.SubjectID, t.SubjectRelation)
+	}
+
+	return r.sendBatch(ctx, batch)
+}
+
+func (r *relationRepositoryPGX) DeleteTuples(ctx context.Context, tuples []*entity.RelationTuple) error {
+	query := `
+		DELETE FROM authorizer_service.relation_tuples
+		WHERE application_id = $1 AND namespace = $2 AND object_id = $3 AND relation = $4
+			AND subject_namespace = $5 AND subject_id = $6 AND subject_relation = $7;
+	`
+
+	batch := &pgx.Batch{}
+	for _, t := range tuples {
+		batch.Queue(query, t.ApplicationID, t.Namespace, t.ObjectID, t.Relation, t.SubjectNamespace, t.SubjectID, t.SubjectRelation)
+	}
+
+	return r.sendBatch(ctx, batch)
+}
+
+// sendBatch runs the batch in one transaction so a write applies entirely
+// or not at all
+func (r *relationRepositoryPGX) sendBatch(ctx context.Context, batch *pgx.Batch) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *relationRepositoryPGX) ListObjectTuples(ctx context.Context, appID, namespace, objectID, relation string) ([]*entity.RelationTuple, error) {
+	query := `
+		SELECT application_id, namespace, object_id, relation, subject_namespace, subject_id, subject_relation, created_at
+		FROM authorizer_service.relation_tuples
+		WHERE application_id = $1 AND namespace = $2 AND object_id = $3 AND relation = $4;
+	`
+
+	rows, err := r.pool.Query(ctx, query, appID, namespace, objectID, relation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRelationTuples(rows)
+}
+
+func (r *relationRepositoryPGX) ListSubjectTuples(ctx context.Context, appID, subjectNamespace, subjectID, subjectRelation string) ([]*entity.RelationTuple, error) {
+	query := `
+		SELECT application_id, namespace, object_id, relation, subject_namespace, subject_id, subject_relation, created_at
+		FROM authorizer_service.relation_tuples
+		WHERE application_id = $1 AND subject_namespace = $2 AND subject_id = $3 AND subject_relation = $4;
+	`
+
+	rows, err := r.pool.Query(ctx, query, appID, subjectNamespace, subjectID, subjectRelation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRelationTuples(rows)
+}
+
+func (r *relationRepositoryPGX) ListUsedRelations(ctx context.Context, appID string) ([]*entity.RelationRef, error) {
+	query := `
+		SELECT namespace, relation
+		FROM authorizer_service.relation_tuples
+		WHERE application_id = $1
+		UNION
+		SELECT subject_namespace, subject_relation
+		FROM authorizer_service.relation_tuples
+		WHERE application_id = $1;
+	`
+
+	rows, err := r.pool.Query(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []*entity.RelationRef
+	for rows.Next() {
+		var ref entity.RelationRef
+		if err := rows.Scan(&ref.Namespace, &ref.Relation); err != nil {
+			return nil, err
+		}
+		refs = append(refs, &ref)
+	}
+
+	return refs, rows.Err()
+}
+
+func scanRelationTuples(rows pgx.Rows) ([]*entity.RelationTuple, error) {
+	var tuples []*entity.RelationTuple
+	for rows.Next() {
+		var t entity.RelationTuple
+		if err := rows.Scan(
+			&t.ApplicationID,
+			&t.Namespace,
+			&t.ObjectID,
+			&t.Relation,
+			&t.SubjectNamespace,
+			&t.SubjectID,
+			&t.SubjectRelation,
+			&t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, &t)
+	}
+
+	return tuples, rows.Err()
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
 	"github.com/mafzaidi/authorizer/internal/domain/repository"
@@ -40,7 +42,7 @@ func (r *rolePermRepositoryPGX) Revoke(ctx context.Context, roleID, permID strin
 	return err
 }
 
-func (r *rolePermRepositoryPGX) Replace(ctx context.Context, roleID string, permIDs []string) error {
+func (r *rolePermRepositoryPGX) Replace(ctx context.Context, roleID string, grants []*entity.RolePermission, conditions []*entity.GrantCondition) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return err
@@ -55,15 +57,35 @@ func (r *rolePermRepositoryPGX) Replace(ctx context.Context, roleID string, perm
 		return err
 	}
 
-	if len(permIDs) == 0 {
+	if len(grants) == 0 {
 		return tx.Commit(ctx)
 	}
 
+	// Conditions are immutable, an existing ID already has the expression
+	condQuery := `
+		INSERT INTO authorizer_service.grant_conditions (id, expression)
+		VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING;
+	`
+	for _, c := range conditions {
+		if _, err := tx.Exec(ctx, condQuery, c.ID, c.Expression); err != nil {
+			return err
+		}
+	}
+
+	permIDs := make([]string, len(grants))
+	condIDs := make([]*string, len(grants))
+	for i, g := range grants {
+		permIDs[i] = g.PermissionID
+		condIDs[i] = g.ConditionID
+	}
+
 	insQuery := `
-		INSERT INTO authorizer_service.role_permissions (role_id, permission_id)
-		SELECT $1, unnest($2::uuid[]);
+		INSERT INTO authorizer_service.role_permissions (role_id, permission_id, condition_id)
+		SELECT $1, g.permission_id, g.condition_id
+		FROM unnest($2::uuid[], $3::text[]) AS g(permission_id, condition_id);
 	`
-	if _, err := tx.Exec(ctx, insQuery, roleID, permIDs); err != nil {
+	if _, err := tx.Exec(ctx, insQuery, roleID, permIDs, condIDs); err != nil {
 		return err
 	}
 
@@ -103,3 +125,62 @@ func (r *rolePermRepositoryPGX) GetPermsByRoles(ctx context.Context, roleIDs []s
 
 	return scanPerms(rows)
 }
+
+func (r *rolePermRepositoryPGX) GetEffectivePermsByRole(ctx context.Context, roleID string) ([]*entity.EffectivePermission, error) {
+	query := `
+		WITH RECURSIVE lineage AS (
+			SELECT r.id, r.code, 0 AS depth, ARRAY[r.id] AS path
+			FROM authorizer_service.roles r
+			WHERE r.id = $1 AND r.deleted_at IS NULL
+			UNION ALL
+			SELECT pr.id, pr.code, l.depth + 1, l.path || pr.id
+			FROM lineage l
+			INNER JOIN authorizer_service.role_parents rh ON rh.role_id = l.id
+			INNER JOIN authorizer_service.roles pr ON pr.id = rh.parent_role_id AND pr.deleted_at IS NULL
+			WHERE NOT pr.id = ANY(l.path)
+		)
+		SELECT p.id, p.code, p.application_id, l.id, l.code, MIN(l.depth), COALESCE(gc.id, ''), COALESCE(gc.expression, '')
+		FROM lineage l
+		INNER JOIN authorizer_service.role_permissions rp ON rp.role_id = l.id
+		INNER JOIN authorizer_service.permissions p ON p.id = rp.permission_id AND p.deleted_at IS NULL
+		LEFT JOIN authorizer_service.grant_conditions gc ON gc.id = rp.condition_id
+		GROUP BY p.id, p.code, p.application_id, l.id, l.code, gc.id, gc.expression
+		ORDER BY MIN(l.depth), p.code;
+	`
+
+	rows, err := r.pool.Query(ctx, query, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []*entity.EffectivePermission
+	for rows.Next() {
+		var p entity.EffectivePermission
+		if err := rows.Scan(&p.PermissionID, &p.PermissionCode, &p.ApplicationID, &p.SourceRoleID, &p.SourceRoleCode, &p.Depth, &p.ConditionID, &p.Condition); err != nil {
+			return nil, err
+		}
+		perms = append(perms, &p)
+	}
+
+	return perms, rows.Err()
+}
+
+func (r *rolePermRepositoryPGX) GetCondition(ctx context.Context, id string) (*entity.GrantCondition, error) {
+	query := `
+		SELECT id, expression, created_at
+		FROM authorizer_service.grant_conditions
+		WHERE id = $1;
+	`
+
+	var c entity.GrantCondition
+	err := r.pool.QueryRow(ctx, query, id).Scan(&c.ID, &c.Expression, &c.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
@@ -24,12 +24,12 @@ func NewRoleRepositoryPGX(pool *pgxpool.Pool) repository.RoleRepository {
 func (r *roleRepositoryPGX) Create(ctx context.Context, role *entity.Role) error {
 	query := `
 		INSERT INTO authorizer_service.roles 
-			(id, application_id, code, name, description)
+			(id, application_id, code, name, description, scope)
 		VALUES 
-			($1, $2, $3, $4, $5)
+			($1, $2, $3, $4, $5, $6)
 	`
 	_, err := r.pool.Exec(ctx, query,
-		role.ID, role.ApplicationID, role.Code, role.Name, role.Description,
+		role.ID, role.ApplicationID, role.Code, role.Name, role.Description, role.Scope,
 	)
 
 	return err
@@ -70,6 +70,13 @@ func (r *roleRepositoryPGX) GetByAppAndCode(ctx context.Context, appID, code str
 	return scanRole(row)
 }
 
+func (r *roleRepositoryPGX) GetGlobalByCode(ctx context.Context, code string) (*entity.Role, error) {
+	query := `SELECT * FROM authorizer_service.roles WHERE scope = 'GLOBAL' AND code = $1 AND deleted_at IS NULL`
+
+	row := r.pool.QueryRow(ctx, query, code)
+	return scanRole(row)
+}
+
 func (r *roleRepositoryPGX) List(ctx context.Context, limit, offset int) ([]*entity.Role, error) {
 	query := `
 		SELECT * FROM authorizer_service.roles
@@ -99,6 +106,114 @@ func (r *roleRepositoryPGX) ListByApp(ctx context.Context, appID string) ([]*ent
 	return scanRoles(rows)
 }
 
+func (r *roleRepositoryPGX) SetParents(ctx context.Context, roleID string, parentIDs []string) error {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize hierarchy writes so two concurrent changes cannot close a
+	// cycle that neither sees on its own
+	if _, err := tx.Exec(ctx, `LOCK TABLE authorizer_service.role_parents IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	delQuery := `
+		DELETE FROM authorizer_service.role_parents
+		WHERE role_id = $1;
+	`
+	if _, err := tx.Exec(ctx, delQuery, roleID); err != nil {
+		return err
+	}
+
+	if len(parentIDs) == 0 {
+		return tx.Commit(ctx)
+	}
+
+	// A parent closes a cycle when it is the role itself or inherits from it
+	cycleQuery := `
+		WITH RECURSIVE descendants AS (
+			SELECT $1::uuid AS id
+			UNION
+			SELECT rp.role_id
+			FROM authorizer_service.role_parents rp
+			INNER JOIN descendants d ON rp.parent_role_id = d.id
+		)
+		SELECT EXISTS (SELECT 1 FROM descendants WHERE id = ANY($2::uuid[]));
+	`
+	var cycle bool
+	if err := tx.QueryRow(ctx, cycleQuery, roleID, parentIDs).Scan(&cycle); err != nil {
+		return err
+	}
+	if cycle {
+		return repository.ErrRoleCycle
+	}
+
+	insQuery := `
+		INSERT INTO authorizer_service.role_parents (role_id, parent_role_id)
+		SELECT $1, unnest($2::uuid[]);
+	`
+	if _, err := tx.Exec(ctx, insQuery, roleID, parentIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *roleRepositoryPGX) GetParents(ctx context.Context, roleID string) ([]*entity.Role, error) {
+	query := `
+		SELECT r.*
+		FROM authorizer_service.roles r
+		INNER JOIN authorizer_service.role_parents rp ON rp.parent_role_id = r.id
+		WHERE rp.role_id = $1 AND r.deleted_at IS NULL
+		ORDER BY r.code;
+	`
+
+	rows, err := r.pool.Query(ctx, query, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+func (r *roleRepositoryPGX) GetDescendantIDs(ctx context.Context, roleID string) ([]string, error) {
+	query := `
+		WITH RECURSIVE descendants AS (
+			SELECT rp.role_id AS id
+			FROM authorizer_service.role_parents rp
+			WHERE rp.parent_role_id = $1
+			UNION
+			SELECT rp.role_id
+			FROM authorizer_service.role_parents rp
+			INNER JOIN descendants d ON rp.parent_role_id = d.id
+		)
+		SELECT d.id
+		FROM descendants d
+		INNER JOIN authorizer_service.roles r ON r.id = d.id AND r.deleted_at IS NULL
+		WHERE d.id <> $1;
+	`
+
+	rows, err := r.pool.Query(ctx, query, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 func scanRole(row pgx.Row) (*entity.Role, error) {
 	var (
 		role  entity.Role
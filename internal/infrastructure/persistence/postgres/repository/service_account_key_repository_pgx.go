@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type serviceAccountKeyRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewServiceAccountKeyRepositoryPGX(pool *pgxpool.Pool) repository.ServiceAccountKeyRepository {
+	return &serviceAccountKeyRepositoryPGX{
+		pool: pool,
+	}
+}
+
+const serviceAccountKeyColumns = `id, service_account_id, prefix, key_hash, expires_at, last_used_at, revoked_at, created_at`
+
+func (r *serviceAccountKeyRepositoryPGX) Create(ctx context.Context, key *entity.ServiceAccountKey) error {
+	query := `
+		INSERT INTO authorizer_service.service_account_keys 
+			(id, service_account_id, prefix, key_hash, expires_at)
+		VALUES 
+			($1, $2, $3, $4, $5)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		key.ID, key.ServiceAccountID, key.Prefix, key.KeyHash, key.ExpiresAt,
+	)
+
+	return err
+}
+
+func (r *serviceAccountKeyRepositoryPGX) GetByPrefix(ctx context.Context, prefix string) (*entity.ServiceAccountKey, error) {
+	query := `SELECT ` + serviceAccountKeyColumns + ` FROM authorizer_service.service_account_keys WHERE prefix = $1`
+
+	row := r.pool.QueryRow(ctx, query, prefix)
+	return scanServiceAccountKey(row)
+}
+
+func (r *serviceAccountKeyRepositoryPGX) ListByServiceAccount(ctx context.Context, serviceAccountID string) ([]*entity.ServiceAccountKey, error) {
+	query := `
+		SELECT ` + serviceAccountKeyColumns + `
+		FROM authorizer_service.service_account_keys
+		WHERE service_account_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*entity.ServiceAccountKey
+	for rows.Next() {
+		k, err := scanServiceAccountKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *serviceAccountKeyRepositoryPGX) Revoke(ctx context.Context, serviceAccountID, id string) error {
+	query := `
+		UPDATE authorizer_service.service_account_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND service_account_id = $2 AND revoked_at IS NULL
+	`
+	tag, err := r.pool.Exec(ctx, query, id, serviceAccountID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (r *serviceAccountKeyRepositoryPGX) TouchLastUsed(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE authorizer_service.service_account_keys SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func scanServiceAccountKey(row pgx.Row) (*entity.ServiceAccountKey, error) {
+	var k entity.ServiceAccountKey
+
+	err := row.Scan(
+		&k.ID,
+		&k.ServiceAccountID,
+		&k.Prefix,
+		&k.KeyHash,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.RevokedAt,
+		&k.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type serviceAccountRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewServiceAccountRepositoryPGX(pool *pgxpool.Pool) repository.ServiceAccountRepository {
+	return &serviceAccountRepositoryPGX{
+		pool: pool,
+	}
+}
+
+const serviceAccountColumns = `id, application_id, name, description, is_active, created_at, updated_at, deleted_at`
+
+func (r *serviceAccountRepositoryPGX) Create(ctx context.Context, sa *entity.ServiceAccount) error {
+	query := `
+		INSERT INTO authorizer_service.service_accounts 
+			(id, application_id, name, description, is_active)
+		VALUES 
+			($1, $2, $3, $4, $5)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		sa.ID, sa.ApplicationID, sa.Name, sa.Description, sa.IsActive,
+	)
+
+	return err
+}
+
+func (r *serviceAccountRepositoryPGX) GetByID(ctx context.Context, id string) (*entity.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM authorizer_service.service_accounts WHERE id = $1 AND deleted_at IS NULL`
+
+	row := r.pool.QueryRow(ctx, query, id)
+	return scanServiceAccount(row)
+}
+
+func (r *serviceAccountRepositoryPGX) Update(ctx context.Context, sa *entity.ServiceAccount) error {
+	query := `
+		UPDATE authorizer_service.service_accounts
+		SET description = $1,
+			is_active = $2
+		WHERE id = $3 AND deleted_at IS NULL
+	`
+	tag, err := r.pool.Exec(ctx, query, sa.Description, sa.IsActive, sa.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (r *serviceAccountRepositoryPGX) Delete(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE authorizer_service.service_accounts SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+	return err
+}
+
+func (r *serviceAccountRepositoryPGX) List(ctx context.Context, filter repository.ServiceAccountFilter) ([]*entity.ServiceAccount, error) {
+	query := `
+		SELECT ` + serviceAccountColumns + `
+		FROM authorizer_service.service_accounts
+		WHERE deleted_at IS NULL
+			AND ($1 = '' OR application_id::text = $1)
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, filter.ApplicationID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*entity.ServiceAccount
+	for rows.Next() {
+		sa, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, sa)
+	}
+
+	return accounts, rows.Err()
+}
+
+func scanServiceAccount(row pgx.Row) (*entity.ServiceAccount, error) {
+	var (
+		sa          entity.ServiceAccount
+		description pgtype.Text
+	)
+
+	err := row.Scan(
+		&sa.ID,
+		&sa.ApplicationID,
+		&sa.Name,
+		&description,
+		&sa.IsActive,
+		&sa.CreatedAt,
+		&sa.UpdatedAt,
+		&sa.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	sa.Description = description.String
+	return &sa, nil
+}
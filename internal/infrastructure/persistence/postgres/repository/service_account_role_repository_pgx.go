@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type serviceAccountRoleRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewServiceAccountRoleRepositoryPGX(pool *pgxpool.Pool) repository.ServiceAccountRoleRepository {
+	return &serviceAccountRoleRepositoryPGX{
+		pool: pool,
+	}
+}
+
+func (r *serviceAccountRoleRepositoryPGX) Assign(ctx context.Context, serviceAccountID string, roleIDs []string) error {
+	query := `
+		INSERT INTO authorizer_service.service_account_roles 
+			(service_account_id, role_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING;
+	`
+	_, err := r.pool.Exec(ctx, query, serviceAccountID, roleIDs)
+
+	return err
+}
+
+func (r *serviceAccountRoleRepositoryPGX) Unassign(ctx context.Context, serviceAccountID, roleID string) error {
+	query := `
+		DELETE FROM authorizer_service.service_account_roles
+		WHERE service_account_id = $1 AND role_id = $2;
+	`
+
+	_, err := r.pool.Exec(ctx, query, serviceAccountID, roleID)
+	return err
+}
+
+func (r *serviceAccountRoleRepositoryPGX) Replace(ctx context.Context, serviceAccountID string, roleIDs []string) error {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	delQuery := `
+		DELETE FROM authorizer_service.service_account_roles
+		WHERE service_account_id = $1;
+	`
+	if _, err := tx.Exec(ctx, delQuery, serviceAccountID); err != nil {
+		return err
+	}
+
+	if len(roleIDs) == 0 {
+		return tx.Commit(ctx)
+	}
+
+	insQuery := `
+		INSERT INTO authorizer_service.service_account_roles (service_account_id, role_id)
+		SELECT $1, unnest($2::uuid[]);
+	`
+	if _, err := tx.Exec(ctx, insQuery, serviceAccountID, roleIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *serviceAccountRoleRepositoryPGX) GetRolesByServiceAccount(ctx context.Context, serviceAccountID string) ([]*entity.Role, error) {
+	query := `
+		SELECT r.*
+		FROM authorizer_service.roles r
+		INNER JOIN authorizer_service.service_account_roles sr ON sr.role_id = r.id
+		WHERE sr.service_account_id = $1 AND r.deleted_at IS NULL;
+	`
+
+	rows, err := r.pool.Query(ctx, query, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
@@ -0,0 +1,441 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+// sodConstraintSelect selects constraints aliased c with their roles
+// ordered by code; callers add the WHERE clause
+const sodConstraintSelect = `
+	SELECT c.id, c.application_id, c.code, c.name, c.description, c.max_roles,
+		COALESCE(array_agg(r.id::text ORDER BY r.code) FILTER (WHERE r.id IS NOT NULL), '{}'),
+		COALESCE(array_agg(r.code ORDER BY r.code) FILTER (WHERE r.id IS NOT NULL), '{}'),
+		c.created_at, c.updated_at
+	FROM authorizer_service.sod_constraints c
+	LEFT JOIN authorizer_service.sod_constraint_roles cr ON cr.constraint_id = c.id
+	LEFT JOIN authorizer_service.roles r ON r.id = cr.role_id AND r.deleted_at IS NULL
+`
+
+type sodConstraintRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewSoDConstraintRepositoryPGX(pool *pgxpool.Pool) repository.SoDConstraintRepository {
+	return &sodConstraintRepositoryPGX{
+		pool: pool,
+	}
+}
+
+func (r *sodConstraintRepositoryPGX) Create(ctx context.Context, c *entity.SoDConstraint) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO authorizer_service.sod_constraints
+			(id, application_id, code, name, description, max_roles)
+		VALUES
+			($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.Exec(ctx, query, c.ID, c.ApplicationID, c.Code, c.Name, c.Description, c.MaxRoles); err != nil {
+		return err
+	}
+
+	rolesQuery := `
+		INSERT INTO authorizer_service.sod_constraint_roles (constraint_id, role_id)
+		SELECT $1, unnest($2::uuid[]);
+	`
+	if _, err := tx.Exec(ctx, rolesQuery, c.ID, c.RoleIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *sodConstraintRepositoryPGX) Delete(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM authorizer_service.sod_constraints WHERE id = $1`, id)
+	return err
+}
+
+func (r *sodConstraintRepositoryPGX) GetByID(ctx context.Context, id string) (*entity.SoDConstraint, error) {
+	query := sodConstraintSelect + `
+		WHERE c.id = $1
+		GROUP BY c.id;
+	`
+
+	return scanSoDConstraint(r.pool.QueryRow(ctx, query, id))
+}
+
+func (r *sodConstraintRepositoryPGX) GetByAppAndCode(ctx context.Context, appID, code string) (*entity.SoDConstraint, error) {
+	query := sodConstraintSelect + `
+		WHERE c.application_id = $1 AND c.code = $2
+		GROUP BY c.id;
+	`
+
+	return scanSoDConstraint(r.pool.QueryRow(ctx, query, appID, code))
+}
+
+func (r *sodConstraintRepositoryPGX) ListByApp(ctx context.Context, appID string) ([]*entity.SoDConstraint, error) {
+	query := sodConstraintSelect + `
+		WHERE c.application_id = $1
+		GROUP BY c.id
+		ORDER BY c.code;
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var constraints []*entity.SoDConstraint
+	for rows.Next() {
+		c, err := scanSoDConstraint(rows)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+
+	return constraints, rows.Err()
+}
+
+// sodParents returns the role_parents edges as a CTE named parents. When
+// the role in parameter $n is set, its parents are replaced by the roles in
+// parameter $n+1.
+func sodParents(n int) string {
+	return fmt.Sprintf(`
+		parents AS (
+			SELECT rp.role_id, rp.parent_role_id
+			FROM authorizer_service.role_parents rp
+			WHERE $%[1]d::uuid IS NULL OR rp.role_id <> $%[1]d::uuid
+			UNION ALL
+			SELECT $%[1]d::uuid, unnest($%[2]d::uuid[])
+		)`, n, n+1)
+}
+
+func (r *sodConstraintRepositoryPGX) GetHeldRoles(ctx context.Context, principalID, appID string, reparent *entity.RoleParents) ([]*entity.HeldRole, error) {
+	// member_of holds the user's groups and every group containing them; a
+	// service account holds only its direct roles. held expands each
+	// assigned role into itself and its ancestors, keeping the source of
+	// the assigned role.
+	query := `
+		WITH RECURSIVE member_of AS (
+			SELECT gm.group_id
+			FROM authorizer_service.group_members gm
+			WHERE gm.user_id = $1
+			UNION
+			SELECT gs.group_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN member_of m ON gs.subgroup_id = m.group_id
+		),` + sodParents(3) + `,
+		assigned AS (
+			SELECT ur.role_id, 'DIRECT' AS kind, '' AS source_id
+			FROM authorizer_service.user_roles ur
+			WHERE ur.user_id = $1 AND (ur.valid_until IS NULL OR ur.valid_until > NOW())
+			UNION
+			SELECT sar.role_id, 'DIRECT', ''
+			FROM authorizer_service.service_account_roles sar
+			WHERE sar.service_account_id = $1
+			UNION
+			SELECT gr.role_id, 'GROUP', gr.group_id::text
+			FROM authorizer_service.group_roles gr
+			INNER JOIN member_of m ON m.group_id = gr.group_id
+			UNION
+			SELECT our.role_id, 'ORGANIZATION', our.organization_id::text
+			FROM authorizer_service.organization_user_roles our
+			WHERE our.user_id = $1
+		),
+		held AS (
+			SELECT r.id AS role_id, a.kind, a.source_id
+			FROM assigned a
+			INNER JOIN authorizer_service.roles r ON r.id = a.role_id AND r.deleted_at IS NULL
+			UNION
+			SELECT pr.id, h.kind, h.source_id
+			FROM held h
+			INNER JOIN parents p ON p.role_id = h.role_id
+			INNER JOIN authorizer_service.roles pr ON pr.id = p.parent_role_id AND pr.deleted_at IS NULL
+		)
+		SELECT r.id, r.code, h.kind, h.source_id
+		FROM held h
+		INNER JOIN authorizer_service.roles r ON r.id = h.role_id
+		WHERE r.application_id = $2;
+	`
+
+	reparentID, parentIDs := reparentArgs(reparent)
+	rows, err := conn(ctx, r.pool).Query(ctx, query, principalID, appID, reparentID, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var held []*entity.HeldRole
+	for rows.Next() {
+		var h entity.HeldRole
+		if err := rows.Scan(&h.RoleID, &h.RoleCode, &h.Source.Kind, &h.Source.ID); err != nil {
+			return nil, err
+		}
+		held = append(held, &h)
+	}
+
+	return held, rows.Err()
+}
+
+func (r *sodConstraintRepositoryPGX) ExpandRoles(ctx context.Context, roleIDs []string, reparent *entity.RoleParents) ([]string, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		WITH RECURSIVE` + sodParents(2) + `,
+		expanded AS (
+			SELECT r.id
+			FROM authorizer_service.roles r
+			WHERE r.id = ANY($1::uuid[]) AND r.deleted_at IS NULL
+			UNION
+			SELECT pr.id
+			FROM expanded e
+			INNER JOIN parents p ON p.role_id = e.id
+			INNER JOIN authorizer_service.roles pr ON pr.id = p.parent_role_id AND pr.deleted_at IS NULL
+		)
+		SELECT e.id::text
+		FROM expanded e;
+	`
+
+	reparentID, parentIDs := reparentArgs(reparent)
+	rows, err := conn(ctx, r.pool).Query(ctx, query, roleIDs, reparentID, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *sodConstraintRepositoryPGX) GetHolders(ctx context.Context, roleID string) ([]string, error) {
+	// descendants is the role and every role inheriting from it; member_of
+	// pairs users with their groups and every group containing them
+	query := `
+		WITH RECURSIVE descendants AS (
+			SELECT $1::uuid AS id
+			UNION
+			SELECT rp.role_id
+			FROM authorizer_service.role_parents rp
+			INNER JOIN descendants d ON rp.parent_role_id = d.id
+		),
+		member_of AS (
+			SELECT gm.user_id, gm.group_id
+			FROM authorizer_service.group_members gm
+			UNION
+			SELECT m.user_id, gs.group_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN member_of m ON gs.subgroup_id = m.group_id
+		)
+		SELECT ur.user_id::text
+		FROM authorizer_service.user_roles ur
+		INNER JOIN descendants d ON d.id = ur.role_id
+		WHERE ur.valid_until IS NULL OR ur.valid_until > NOW()
+		UNION
+		SELECT sar.service_account_id::text
+		FROM authorizer_service.service_account_roles sar
+		INNER JOIN descendants d ON d.id = sar.role_id
+		UNION
+		SELECT m.user_id::text
+		FROM authorizer_service.group_roles gr
+		INNER JOIN descendants d ON d.id = gr.role_id
+		INNER JOIN member_of m ON m.group_id = gr.group_id
+		UNION
+		SELECT our.user_id::text
+		FROM authorizer_service.organization_user_roles our
+		INNER JOIN descendants d ON d.id = our.role_id;
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *sodConstraintRepositoryPGX) GetViolations(ctx context.Context, appID string) ([]*entity.SoDViolation, error) {
+	// member_of pairs users with their groups and every group containing
+	// them; assigned is every role a user or service account holds,
+	// however they hold it, and held expands each into itself and its
+	// ancestors
+	query := `
+		WITH RECURSIVE member_of AS (
+			SELECT gm.user_id, gm.group_id
+			FROM authorizer_service.group_members gm
+			UNION
+			SELECT m.user_id, gs.group_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN member_of m ON gs.subgroup_id = m.group_id
+		),
+		assigned AS (
+			SELECT ur.user_id AS principal_id, 'user' AS principal_type, ur.role_id
+			FROM authorizer_service.user_roles ur
+			WHERE ur.valid_until IS NULL OR ur.valid_until > NOW()
+			UNION
+			SELECT sar.service_account_id, 'service_account', sar.role_id
+			FROM authorizer_service.service_account_roles sar
+			UNION
+			SELECT m.user_id, 'user', gr.role_id
+			FROM authorizer_service.group_roles gr
+			INNER JOIN member_of m ON m.group_id = gr.group_id
+			UNION
+			SELECT our.user_id, 'user', our.role_id
+			FROM authorizer_service.organization_user_roles our
+		),
+		held AS (
+			SELECT a.principal_id, a.principal_type, a.role_id
+			FROM assigned a
+			INNER JOIN authorizer_service.roles r ON r.id = a.role_id AND r.deleted_at IS NULL
+			UNION
+			SELECT h.principal_id, h.principal_type, pr.id
+			FROM held h
+			INNER JOIN authorizer_service.role_parents rp ON rp.role_id = h.role_id
+			INNER JOIN authorizer_service.roles pr ON pr.id = rp.parent_role_id AND pr.deleted_at IS NULL
+		)
+		SELECT c.id, c.code, c.application_id, c.max_roles, h.principal_type, h.principal_id, array_agg(r.code ORDER BY r.code)
+		FROM authorizer_service.sod_constraints c
+		INNER JOIN authorizer_service.sod_constraint_roles cr ON cr.constraint_id = c.id
+		INNER JOIN authorizer_service.roles r ON r.id = cr.role_id AND r.deleted_at IS NULL
+		INNER JOIN held h ON h.role_id = cr.role_id
+		WHERE $1 = '' OR c.application_id::text = $1
+		GROUP BY c.id, h.principal_type, h.principal_id
+		HAVING COUNT(*) > c.max_roles
+		ORDER BY c.code, h.principal_type, h.principal_id;
+	`
+
+	rows, err := r.pool.Query(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var violations []*entity.SoDViolation
+	for rows.Next() {
+		var (
+			v                          entity.SoDViolation
+			principalType, principalID string
+		)
+		if err := rows.Scan(&v.ConstraintID, &v.ConstraintCode, &v.ApplicationID, &v.MaxRoles, &principalType, &principalID, &v.RoleCodes); err != nil {
+			return nil, err
+		}
+		if principalType == entity.PrincipalTypeServiceAccount {
+			v.ServiceAccountID = principalID
+		} else {
+			v.UserID = principalID
+		}
+		violations = append(violations, &v)
+	}
+
+	return violations, rows.Err()
+}
+
+// sodLockKey is the advisory lock key prefix of separation of duties
+// checks; application locks are keyed by the application ID, principal
+// locks by the application and principal IDs
+const sodLockKey = "sod:"
+
+// sodGroupsLockKey is the advisory lock key of LockGroups
+const sodGroupsLockKey = sodLockKey + "groups"
+
+func (r *sodConstraintRepositoryPGX) LockApplication(ctx context.Context, appID string) error {
+	return r.lock(ctx, sodLockKey+appID)
+}
+
+func (r *sodConstraintRepositoryPGX) LockGroups(ctx context.Context) error {
+	return r.lock(ctx, sodGroupsLockKey)
+}
+
+// lock takes the exclusive advisory lock of key until the end of the
+// transaction of ctx
+func (r *sodConstraintRepositoryPGX) lock(ctx context.Context, key string) error {
+	tx, ok := inTransaction(ctx)
+	if !ok {
+		return errors.New("separation of duties locks require a transaction")
+	}
+
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0));`, key)
+	return err
+}
+
+func (r *sodConstraintRepositoryPGX) LockPrincipals(ctx context.Context, appID string, principalIDs []string) error {
+	tx, ok := inTransaction(ctx)
+	if !ok {
+		return errors.New("separation of duties locks require a transaction")
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock_shared(hashtextextended($1::text, 0));`, sodLockKey+appID); err != nil {
+		return err
+	}
+
+	// Locks are taken in order so concurrent checks cannot deadlock
+	keys := make([]string, len(principalIDs))
+	for i, id := range principalIDs {
+		keys[i] = sodLockKey + appID + ":" + id
+	}
+	sort.Strings(keys)
+
+	_, err := tx.Exec(ctx, `
+		SELECT pg_advisory_xact_lock(hashtextextended(k, 0))
+		FROM unnest($1::text[]) AS k;
+	`, keys)
+	return err
+}
+
+func scanSoDConstraint(row pgx.Row) (*entity.SoDConstraint, error) {
+	var c entity.SoDConstraint
+	err := row.Scan(
+		&c.ID, &c.ApplicationID, &c.Code, &c.Name, &c.Description, &c.MaxRoles,
+		&c.RoleIDs, &c.RoleCodes, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// reparentArgs returns the parameters sodParents expects for reparent,
+// both NULL when it is not set
+func reparentArgs(reparent *entity.RoleParents) (*string, []string) {
+	if reparent == nil {
+		return nil, nil
+	}
+	return &reparent.RoleID, reparent.ParentIDs
+}
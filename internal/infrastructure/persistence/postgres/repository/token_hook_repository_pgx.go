@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type tokenHookRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewTokenHookRepositoryPGX(pool *pgxpool.Pool) repository.TokenHookRepository {
+	return &tokenHookRepositoryPGX{
+		pool: pool,
+	}
+}
+
+const tokenHookColumns = `h.id, h.application_id, h.name, h.url, h.secret, h.timeout_ms, h.is_active, h.created_at, h.updated_at, h.deleted_at`
+
+func (r *tokenHookRepositoryPGX) Create(ctx context.Context, hook *entity.TokenHook) error {
+	query := `
+		INSERT INTO authorizer_service.token_hooks 
+			(id, application_id, name, url, secret, timeout_ms, is_active)
+		VALUES 
+			($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		hook.ID, hook.ApplicationID, hook.Name, hook.URL, hook.Secret,
+		hook.TimeoutMs, hook.IsActive,
+	)
+
+	return err
+}
+
+func (r *tokenHookRepositoryPGX) Delete(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE authorizer_service.token_hooks SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+	return err
+}
+
+func (r *tokenHookRepositoryPGX) GetByID(ctx context.Context, id string) (*entity.TokenHook, error) {
+	query := `SELECT ` + tokenHookColumns + ` FROM authorizer_service.token_hooks h WHERE h.id = $1 AND h.deleted_at IS NULL`
+
+	row := r.pool.QueryRow(ctx, query, id)
+	return scanTokenHook(row)
+}
+
+func (r *tokenHookRepositoryPGX) ListByApp(ctx context.Context, appID string) ([]*entity.TokenHook, error) {
+	query := `
+		SELECT ` + tokenHookColumns + `
+		FROM authorizer_service.token_hooks h
+		WHERE h.application_id = $1 AND h.deleted_at IS NULL
+		ORDER BY h.created_at ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTokenHooks(rows)
+}
+
+func (r *tokenHookRepositoryPGX) ListActiveByApp(ctx context.Context, appID string) ([]*entity.TokenHook, error) {
+	query := `
+		SELECT ` + tokenHookColumns + `
+		FROM authorizer_service.token_hooks h
+		WHERE h.application_id = $1 AND h.is_active AND h.deleted_at IS NULL
+		ORDER BY h.created_at ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTokenHooks(rows)
+}
+
+func scanTokenHook(row pgx.Row) (*entity.TokenHook, error) {
+	var h entity.TokenHook
+
+	err := row.Scan(
+		&h.ID,
+		&h.ApplicationID,
+		&h.Name,
+		&h.URL,
+		&h.Secret,
+		&h.TimeoutMs,
+		&h.IsActive,
+		&h.CreatedAt,
+		&h.UpdatedAt,
+		&h.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+func scanTokenHooks(rows pgx.Rows) ([]*entity.TokenHook, error) {
+	var hooks []*entity.TokenHook
+
+	for rows.Next() {
+		h, err := scanTokenHook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, h)
+	}
+
+	return hooks, rows.Err()
+}
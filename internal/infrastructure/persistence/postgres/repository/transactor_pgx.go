@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+// txKey is the context key of the transaction started by WithinTransaction
+type txKey struct{}
+
+// dbConn is implemented by both the pool and a transaction. Begin on a
+// transaction starts a savepoint, so methods that use their own
+// transaction nest within the caller's.
+type dbConn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// conn returns the transaction of ctx, or the pool outside one
+func conn(ctx context.Context, pool *pgxpool.Pool) dbConn {
+	if tx, ok := inTransaction(ctx); ok {
+		return tx
+	}
+	return pool
+}
+
+func inTransaction(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+type transactorPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewTransactorPGX(pool *pgxpool.Pool) repository.Transactor {
+	return &transactorPGX{
+		pool: pool,
+	}
+}
+
+func (t *transactorPGX) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := inTransaction(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
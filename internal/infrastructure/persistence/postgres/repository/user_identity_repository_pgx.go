@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+)
+
+type userIdentityRepositoryPGX struct {
+	pool *pgxpool.Pool
+}
+
+func NewUserIdentityRepositoryPGX(pool *pgxpool.Pool) repository.UserIdentityRepository {
+	return &userIdentityRepositoryPGX{
+		pool: pool,
+	}
+}
+
+const userIdentityColumns = `id, user_id, provider, subject, email, created_at, last_login_at`
+
+func (r *userIdentityRepositoryPGX) Create(ctx context.Context, identity *entity.UserIdentity) error {
+	query := `
+		INSERT INTO authorizer_service.user_identities 
+			(id, user_id, provider, subject, email)
+		VALUES 
+			($1, $2, $3, $4, $5)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		identity.ID, identity.UserID, identity.Provider, identity.Subject, identity.Email,
+	)
+
+	return err
+}
+
+func (r *userIdentityRepositoryPGX) GetByProviderSubject(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	query := `SELECT ` + userIdentityColumns + ` FROM authorizer_service.user_identities WHERE provider = $1 AND subject = $2`
+
+	row := r.pool.QueryRow(ctx, query, provider, subject)
+	return scanUserIdentity(row)
+}
+
+func (r *userIdentityRepositoryPGX) ListByUser(ctx context.Context, userID string) ([]*entity.UserIdentity, error) {
+	query := `
+		SELECT ` + userIdentityColumns + `
+		FROM authorizer_service.user_identities
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []*entity.UserIdentity
+	for rows.Next() {
+		identity, err := scanUserIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+func (r *userIdentityRepositoryPGX) TouchLastLogin(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE authorizer_service.user_identities SET last_login_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func scanUserIdentity(row pgx.Row) (*entity.UserIdentity, error) {
+	var (
+		identity entity.UserIdentity
+		email    pgtype.Text
+	)
+
+	err := row.Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&email,
+		&identity.CreatedAt,
+		&identity.LastLoginAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	identity.Email = email.String
+	return &identity, nil
+}
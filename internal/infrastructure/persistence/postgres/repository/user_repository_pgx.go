@@ -5,65 +5,145 @@ import (
 	"errors"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
 	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/fieldcrypt"
 	"github.com/mafzaidi/authorizer/internal/infrastructure/persistence/postgres/model"
 )
 
+// userColumns lists the users columns in the order scanUser expects
+const userColumns = `id, email, username, password, full_name, phone, is_active,
+	email_verified, phone_verified, created_at, updated_at, deleted_at, key_version`
+
+// userRepositoryPGX stores email, full name and phone encrypted with the
+// field cipher. Emails are looked up through a blind index; rows written
+// before encryption was enabled keep key_version 0 until re-keyed.
 type userRepositoryPGX struct {
-	pool *pgxpool.Pool
+	pool   *pgxpool.Pool
+	cipher *fieldcrypt.Cipher
+}
+
+func NewUserRepositoryPGX(pool *pgxpool.Pool, cipher *fieldcrypt.Cipher) repository.UserRepository {
+	return &userRepositoryPGX{
+		pool:   pool,
+		cipher: cipher,
+	}
 }
 
-func NewUserRepositoryPGX(pool *pgxpool.Pool) repository.UserRepository {
+// NewUserKeyRotationRepositoryPGX creates the repository used by the re-key
+// job to move users to the current key version
+func NewUserKeyRotationRepositoryPGX(pool *pgxpool.Pool, cipher *fieldcrypt.Cipher) repository.UserKeyRotationRepository {
 	return &userRepositoryPGX{
-		pool: pool,
+		pool:   pool,
+		cipher: cipher,
 	}
 }
 
 func (r *userRepositoryPGX) Create(ctx context.Context, user *entity.User) error {
+	fields, err := r.encryptFields(user.ID, user.Email, user.FullName, user.Phone, r.cipher.CurrentVersion())
+	if err != nil {
+		return err
+	}
+
+	// The unique indexes only cover one form each, so the email must not
+	// match an encrypted row's blind index or a cleartext row's email
 	query := `
 		INSERT INTO authorizer_service.users 
-			(id, email, username, password, full_name, phone, is_active, email_verified)
-		VALUES 
-			($1, $2, $3, $4, $5, $6, $7, $8)
+			(id, email, username, password, full_name, phone, is_active, email_verified, email_index, key_version)
+		SELECT $1::uuid, $2, $3, $4, $5, $6, $7::boolean, $8::boolean, $9, $10::integer
+		WHERE NOT EXISTS (
+			SELECT 1 FROM authorizer_service.users
+			WHERE email_index = $9 OR (key_version = 0 AND lower(email) = lower($11))
+		)
 	`
-	_, err := r.pool.Exec(ctx, query,
-		user.ID, user.Email, user.Username, user.Password, user.FullName,
-		user.Phone, user.IsActive,
-		user.EmailVerified,
+	tag, err := r.pool.Exec(ctx, query,
+		user.ID, fields.email, user.Username, user.Password, fields.fullName,
+		fields.phone, user.IsActive,
+		user.EmailVerified, fields.emailIndex, fields.version, user.Email,
 	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("email already exists")
+	}
 
-	return err
+	return nil
 }
 
 func (r *userRepositoryPGX) GetByID(ctx context.Context, id string) (*entity.User, error) {
-	query := `SELECT * FROM authorizer_service.users WHERE id = $1 AND deleted_at IS NULL`
+	query := `SELECT ` + userColumns + ` FROM authorizer_service.users WHERE id = $1 AND deleted_at IS NULL`
 
 	row := r.pool.QueryRow(ctx, query, id)
-	return scanUser(row)
+	return r.scanUser(row)
 }
 
 func (r *userRepositoryPGX) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
-	query := `SELECT * FROM authorizer_service.users WHERE email = $1 AND deleted_at IS NULL`
+	// Encrypted rows match on the blind index, rows not yet encrypted on
+	// the cleartext email
+	var emailIndex pgtype.Text
+	if index, ok := r.cipher.BlindIndex(email); ok {
+		emailIndex = pgtype.Text{String: index, Valid: true}
+	}
+
+	query := `
+		SELECT ` + userColumns + ` FROM authorizer_service.users
+		WHERE (email_index = $1 OR (key_version = 0 AND lower(email) = lower($2)))
+			AND deleted_at IS NULL
+		LIMIT 1
+	`
 
-	row := r.pool.QueryRow(ctx, query, email)
-	return scanUser(row)
+	row := r.pool.QueryRow(ctx, query, emailIndex, email)
+	return r.scanUser(row)
 }
 
 func (r *userRepositoryPGX) Update(ctx context.Context, user *entity.User) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Encrypt under the version the row is stored with so the email, which
+	// is not updated here, stays readable
+	var version int
+	err = tx.QueryRow(ctx,
+		`SELECT key_version FROM authorizer_service.users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		user.ID,
+	).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("not found")
+		}
+		return err
+	}
+
+	fullName, err := r.cipher.EncryptVersion(user.FullName, fieldAAD("full_name", user.ID), version)
+	if err != nil {
+		return err
+	}
+	phone, err := r.encryptPhone(user.ID, user.Phone, version)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE authorizer_service.users
 		SET full_name = $1,
 			phone = $2,
 			is_active = $3,
 			updated_at = NOW()
-		WHERE id = $5 AND deleted_at IS NULL
+		WHERE id = $4 AND deleted_at IS NULL
 	`
-	_, err := r.pool.Exec(ctx,
-		query, user.FullName, user.Phone, user.IsActive, user.ID,
-	)
-	return err
+	if _, err := tx.Exec(ctx,
+		query, fullName, phone, user.IsActive, user.ID,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *userRepositoryPGX) Delete(ctx context.Context, id string) error {
@@ -73,7 +153,7 @@ func (r *userRepositoryPGX) Delete(ctx context.Context, id string) error {
 
 func (r *userRepositoryPGX) List(ctx context.Context, limit, offset int) ([]*entity.User, error) {
 	query := `
-		SELECT * FROM authorizer_service.users
+		SELECT ` + userColumns + ` FROM authorizer_service.users
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -85,14 +165,147 @@ func (r *userRepositoryPGX) List(ctx context.Context, limit, offset int) ([]*ent
 	}
 	defer rows.Close()
 
-	return scanUsers(rows)
+	return r.scanUsers(rows)
 }
 
-func scanUser(row pgx.Row) (*entity.User, error) {
-	var (
-		user  entity.User
-		model model.User
-	)
+// RekeyBatch re-encrypts up to limit users stored under another key version,
+// including cleartext rows. Rows locked by concurrent updates are skipped
+// and picked up by a later batch.
+func (r *userRepositoryPGX) RekeyBatch(ctx context.Context, limit int) (int, error) {
+	if !r.cipher.Enabled() {
+		return 0, fieldcrypt.ErrKeyUnavailable
+	}
+	current := r.cipher.CurrentVersion()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, email, full_name, phone, key_version
+		FROM authorizer_service.users
+		WHERE key_version <> $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, current, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var users []*model.User
+	for rows.Next() {
+		var m model.User
+		if err := rows.Scan(&m.ID, &m.Email, &m.FullName, &m.Phone, &m.KeyVersion); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		users = append(users, &m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, m := range users {
+		if err := r.decryptModel(m); err != nil {
+			return 0, err
+		}
+		var phone *string
+		if m.Phone.Valid {
+			phone = &m.Phone.String
+		}
+
+		fields, err := r.encryptFields(m.ID, m.Email, m.FullName, phone, current)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE authorizer_service.users
+			SET email = $1, full_name = $2, phone = $3, email_index = $4, key_version = $5
+			WHERE id = $6
+		`, fields.email, fields.fullName, fields.phone, fields.emailIndex, fields.version, m.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(users), nil
+}
+
+// encryptedUserFields are the column values of a user's encrypted fields
+type encryptedUserFields struct {
+	email      string
+	fullName   string
+	phone      pgtype.Text
+	emailIndex pgtype.Text
+	version    int
+}
+
+// encryptFields encrypts the user's fields with version, or leaves them in
+// cleartext with key version 0 when no master key is loaded
+func (r *userRepositoryPGX) encryptFields(id, email, fullName string, phone *string, version int) (*encryptedUserFields, error) {
+	if !r.cipher.Enabled() {
+		version = fieldcrypt.PlaintextVersion
+	}
+
+	fields := &encryptedUserFields{version: version}
+	var err error
+	if fields.email, err = r.cipher.EncryptVersion(email, fieldAAD("email", id), version); err != nil {
+		return nil, err
+	}
+	if fields.fullName, err = r.cipher.EncryptVersion(fullName, fieldAAD("full_name", id), version); err != nil {
+		return nil, err
+	}
+	if fields.phone, err = r.encryptPhone(id, phone, version); err != nil {
+		return nil, err
+	}
+	if version != fieldcrypt.PlaintextVersion {
+		index, _ := r.cipher.BlindIndex(email)
+		fields.emailIndex = pgtype.Text{String: index, Valid: true}
+	}
+	return fields, nil
+}
+
+func (r *userRepositoryPGX) encryptPhone(id string, phone *string, version int) (pgtype.Text, error) {
+	if phone == nil {
+		return pgtype.Text{}, nil
+	}
+	value, err := r.cipher.EncryptVersion(*phone, fieldAAD("phone", id), version)
+	if err != nil {
+		return pgtype.Text{}, err
+	}
+	return pgtype.Text{String: value, Valid: true}, nil
+}
+
+// decryptModel replaces the encrypted fields of m with their cleartext
+func (r *userRepositoryPGX) decryptModel(m *model.User) error {
+	var err error
+	if m.Email, err = r.cipher.Decrypt(m.Email, fieldAAD("email", m.ID), m.KeyVersion); err != nil {
+		return err
+	}
+	if m.FullName, err = r.cipher.Decrypt(m.FullName, fieldAAD("full_name", m.ID), m.KeyVersion); err != nil {
+		return err
+	}
+	if m.Phone.Valid {
+		if m.Phone.String, err = r.cipher.Decrypt(m.Phone.String, fieldAAD("phone", m.ID), m.KeyVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldAAD binds a ciphertext to its column and row
+func fieldAAD(column, id string) string {
+	return "users." + column + ":" + id
+}
+
+func (r *userRepositoryPGX) scanUser(row pgx.Row) (*entity.User, error) {
+	var model model.User
 
 	err := row.Scan(
 		&model.ID,
@@ -107,6 +320,7 @@ func scanUser(row pgx.Row) (*entity.User, error) {
 		&model.CreatedAt,
 		&model.UpdatedAt,
 		&model.DeletedAt,
+		&model.KeyVersion,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -115,11 +329,13 @@ func scanUser(row pgx.Row) (*entity.User, error) {
 		return nil, err
 	}
 
-	user = *model.ToEntity()
-	return &user, nil
+	if err := r.decryptModel(&model); err != nil {
+		return nil, err
+	}
+	return model.ToEntity(), nil
 }
 
-func scanUsers(rows pgx.Rows) ([]*entity.User, error) {
+func (r *userRepositoryPGX) scanUsers(rows pgx.Rows) ([]*entity.User, error) {
 	var users []*entity.User
 
 	for rows.Next() {
@@ -137,9 +353,13 @@ func scanUsers(rows pgx.Rows) ([]*entity.User, error) {
 			&model.CreatedAt,
 			&model.UpdatedAt,
 			&model.DeletedAt,
+			&model.KeyVersion,
 		); err != nil {
 			return nil, err
 		}
+		if err := r.decryptModel(&model); err != nil {
+			return nil, err
+		}
 		users = append(users, model.ToEntity())
 	}
 
@@ -2,12 +2,17 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
 	"github.com/mafzaidi/authorizer/internal/domain/repository"
 )
 
+// activeAssignment restricts user_roles rows aliased ur to those in effect
+const activeAssignment = `(ur.valid_from IS NULL OR ur.valid_from <= NOW()) AND (ur.valid_until IS NULL OR ur.valid_until > NOW())`
+
 type userRoleRepositoryPGX struct {
 	pool *pgxpool.Pool
 }
@@ -18,13 +23,21 @@ func NewUserRoleRepositoryPGX(pool *pgxpool.Pool) repository.UserRoleRepository
 	}
 }
 
+// NewUserRoleExpiryRepositoryPGX creates the repository used by the role
+// expiry job
+func NewUserRoleExpiryRepositoryPGX(pool *pgxpool.Pool) repository.UserRoleExpiryRepository {
+	return &userRoleRepositoryPGX{
+		pool: pool,
+	}
+}
+
 func (r *userRoleRepositoryPGX) Assign(ctx context.Context, userID string, roleIDs []string) error {
 	query := `
 		INSERT INTO authorizer_service.user_roles 
 			(user_id, role_id)
 		SELECT $1, unnest($2::uuid[]);
 	`
-	_, err := r.pool.Exec(ctx, query, userID, roleIDs)
+	_, err := conn(ctx, r.pool).Exec(ctx, query, userID, roleIDs)
 
 	return err
 }
@@ -39,8 +52,8 @@ func (r *userRoleRepositoryPGX) Unassign(ctx context.Context, userID, roleID str
 	return err
 }
 
-func (r *userRoleRepositoryPGX) Replace(ctx context.Context, userID string, roleIDs []string) error {
-	tx, err := r.pool.Begin(ctx)
+func (r *userRoleRepositoryPGX) Replace(ctx context.Context, userID, appID string, assignments []*entity.UserRole) error {
+	tx, err := conn(ctx, r.pool).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -48,22 +61,21 @@ func (r *userRoleRepositoryPGX) Replace(ctx context.Context, userID string, role
 
 	delQuery := `
 		DELETE FROM authorizer_service.user_roles
-		WHERE user_id = $1;
+		WHERE user_id = $1
+			AND role_id IN (SELECT id FROM authorizer_service.roles WHERE application_id = $2);
 	`
-	if _, err := tx.Exec(ctx, delQuery, userID); err != nil {
+	if _, err := tx.Exec(ctx, delQuery, userID, appID); err != nil {
 		return err
 	}
 
-	if len(roleIDs) == 0 {
-		return tx.Commit(ctx)
-	}
-
 	insQuery := `
-		INSERT INTO authorizer_service.user_roles (user_id, role_id)
-		SELECT $1, unnest($2::uuid[]);
+		INSERT INTO authorizer_service.user_roles (user_id, role_id, valid_from, valid_until)
+		VALUES ($1, $2, $3, $4);
 	`
-	if _, err := tx.Exec(ctx, insQuery, userID, roleIDs); err != nil {
-		return err
+	for _, a := range assignments {
+		if _, err := tx.Exec(ctx, insQuery, userID, a.RoleID, a.ValidFrom, a.ValidUntil); err != nil {
+			return err
+		}
 	}
 
 	return tx.Commit(ctx)
@@ -74,7 +86,7 @@ func (r *userRoleRepositoryPGX) GetRolesByUser(ctx context.Context, userID strin
 		SELECT r.id, r.application_id, r.code, r.name, r.description, r.deleted_at
 		FROM authorizer_service.roles r
 		INNER JOIN authorizer_service.user_roles ur ON ur.role_id = r.id
-		WHERE ur.user_id = $1 AND r.deleted_at IS NULL;
+		WHERE ur.user_id = $1 AND r.deleted_at IS NULL AND ` + activeAssignment + `;
 	`
 
 	rows, err := r.pool.Query(ctx, query, userID)
@@ -91,7 +103,7 @@ func (r *userRoleRepositoryPGX) GetRolesByUserAndApp(ctx context.Context, userID
 		SELECT r.*
 		FROM authorizer_service.roles r
 		INNER JOIN authorizer_service.user_roles ur ON ur.role_id = r.id
-		WHERE ur.user_id = $1 AND r.application_id = $2 AND r.deleted_at IS NULL;
+		WHERE ur.user_id = $1 AND r.application_id = $2 AND r.deleted_at IS NULL AND ` + activeAssignment + `;
 	`
 
 	rows, err := r.pool.Query(ctx, query, userID, appID)
@@ -108,7 +120,7 @@ func (r *userRoleRepositoryPGX) GetGlobalRolesByUser(ctx context.Context, userID
 		SELECT r.*
 		FROM authorizer_service.roles r
 		INNER JOIN authorizer_service.user_roles ur ON ur.role_id = r.id
-		WHERE ur.user_id = $1 AND r.scope ='GLOBAL' AND r.deleted_at IS NULL;
+		WHERE ur.user_id = $1 AND r.scope ='GLOBAL' AND r.deleted_at IS NULL AND ` + activeAssignment + `;
 	`
 
 	rows, err := r.pool.Query(ctx, query, userID)
@@ -121,12 +133,31 @@ func (r *userRoleRepositoryPGX) GetGlobalRolesByUser(ctx context.Context, userID
 }
 
 func (r *userRoleRepositoryPGX) GetUsersByRole(ctx context.Context, roleID string) ([]*entity.User, error) {
+	// role_groups holds the groups assigned the role and every group nested
+	// in them, whose members hold the role too
 	query := `
-		SELECT u.id, u.username, u.full_name, r.deleted_at
+		WITH RECURSIVE role_groups AS (
+			SELECT gr.group_id
+			FROM authorizer_service.group_roles gr
+			WHERE gr.role_id = $1
+			UNION
+			SELECT gs.subgroup_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN role_groups rg ON gs.group_id = rg.group_id
+		)
+		SELECT u.id, u.username, u.deleted_at
 		FROM authorizer_service.roles r
-		INNER JOIN authorizer_service.user_roles ur ON ur.role_id = r.id
+		INNER JOIN (
+			SELECT user_id, role_id FROM authorizer_service.user_roles
+			UNION
+			SELECT user_id, role_id FROM authorizer_service.organization_user_roles
+			UNION
+			SELECT gm.user_id, $1::uuid
+			FROM authorizer_service.group_members gm
+			INNER JOIN role_groups rg ON rg.group_id = gm.group_id
+		) ur ON ur.role_id = r.id
 		INNER JOIN authorizer_service.users u ON u.id = ur.user_id
-		WHERE r.role_id = $1 AND r.deleted_at IS NULL;
+		WHERE ur.role_id = $1 AND r.deleted_at IS NULL AND u.deleted_at IS NULL;
 	`
 
 	rows, err := r.pool.Query(ctx, query, roleID)
@@ -138,7 +169,7 @@ func (r *userRoleRepositoryPGX) GetUsersByRole(ctx context.Context, roleID strin
 	var users []*entity.User
 	for rows.Next() {
 		var user entity.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.FullName, &user.DeletedAt); err != nil {
+		if err := rows.Scan(&user.ID, &user.Username, &user.DeletedAt); err != nil {
 			return nil, err
 		}
 		users = append(users, &user)
@@ -146,3 +177,166 @@ func (r *userRoleRepositoryPGX) GetUsersByRole(ctx context.Context, roleID strin
 
 	return users, nil
 }
+
+func (r *userRoleRepositoryPGX) GetGrantsByUser(ctx context.Context, userID, orgCode string, appCodes []string) ([]*entity.RoleGrant, error) {
+	// member_of holds the user's groups and every group containing them;
+	// assigned holds the user's own roles, their tenant roles in the
+	// organization and the roles of their groups; held expands each into
+	// itself and its ancestors, the permissions of every ancestor counting
+	// as the assigned role's
+	query := `
+		WITH RECURSIVE member_of AS (
+			SELECT gm.group_id
+			FROM authorizer_service.group_members gm
+			WHERE gm.user_id = $1
+			UNION
+			SELECT gs.group_id
+			FROM authorizer_service.group_subgroups gs
+			INNER JOIN member_of m ON gs.subgroup_id = m.group_id
+		),
+		assigned AS (
+			SELECT ur.role_id, '' AS org_code, '' AS group_code
+			FROM authorizer_service.user_roles ur
+			WHERE ur.user_id = $1 AND ` + activeAssignment + `
+			UNION
+			SELECT our.role_id, o.code, ''
+			FROM authorizer_service.organization_user_roles our
+			INNER JOIN authorizer_service.organizations o ON o.id = our.organization_id
+			INNER JOIN authorizer_service.organization_members om ON om.organization_id = our.organization_id AND om.user_id = our.user_id
+			WHERE our.user_id = $1 AND o.code = $5
+			UNION
+			SELECT gr.role_id, '', g.code
+			FROM authorizer_service.group_roles gr
+			INNER JOIN member_of m ON m.group_id = gr.group_id
+			INNER JOIN authorizer_service.groups g ON g.id = gr.group_id
+		),
+		held AS (
+			SELECT r.id AS role_id, a.org_code, a.group_code, r.id AS source_id, r.code AS source_code, ARRAY[r.id] AS path
+			FROM assigned a
+			INNER JOIN authorizer_service.roles r ON r.id = a.role_id AND r.deleted_at IS NULL
+			UNION ALL
+			SELECT h.role_id, h.org_code, h.group_code, pr.id, pr.code, h.path || pr.id
+			FROM held h
+			INNER JOIN authorizer_service.role_parents rh ON rh.role_id = h.source_id
+			INNER JOIN authorizer_service.roles pr ON pr.id = rh.parent_role_id AND pr.deleted_at IS NULL
+			WHERE NOT pr.id = ANY(h.path)
+		)
+		SELECT r.id, r.code, COALESCE(r.scope::text, ''), h.org_code, h.group_code, COALESCE(pa.code, ra.code, ''), COALESCE(p.code, ''),
+			CASE WHEN h.source_id = h.role_id THEN '' ELSE h.source_code END,
+			COALESCE(gc.id, ''), COALESCE(gc.expression, '')
+		FROM held h
+		INNER JOIN authorizer_service.roles r ON r.id = h.role_id
+		LEFT JOIN authorizer_service.applications ra ON ra.id = r.application_id AND ra.deleted_at IS NULL
+		LEFT JOIN authorizer_service.role_permissions rp ON rp.role_id = h.source_id
+		LEFT JOIN authorizer_service.permissions p ON p.id = rp.permission_id AND p.deleted_at IS NULL
+		LEFT JOIN authorizer_service.applications pa ON pa.id = p.application_id AND pa.deleted_at IS NULL
+		LEFT JOIN authorizer_service.grant_conditions gc ON gc.id = rp.condition_id
+		WHERE ra.code = ANY($2)
+			OR (r.scope = 'GLOBAL' AND (pa.code = ANY($2) OR (pa.code = $3 AND p.code = $4)));
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, appCodes, entity.AuthorizerAppCode, entity.SuperadminPermission, orgCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []*entity.RoleGrant
+	for rows.Next() {
+		var g entity.RoleGrant
+		if err := rows.Scan(&g.RoleID, &g.RoleCode, &g.Scope, &g.Organization, &g.Group, &g.AppCode, &g.PermissionCode, &g.InheritedFrom, &g.ConditionID, &g.Condition); err != nil {
+			return nil, err
+		}
+		grants = append(grants, &g)
+	}
+
+	return grants, rows.Err()
+}
+
+func (r *userRoleRepositoryPGX) GetAssignmentsByUser(ctx context.Context, userID string) ([]*entity.UserRole, error) {
+	query := `
+		SELECT ur.user_id, ur.role_id, ur.valid_from, ur.valid_until, ur.created_at
+		FROM authorizer_service.user_roles ur
+		WHERE ur.user_id = $1 AND ` + activeAssignment + `;
+	`
+
+	return r.queryAssignments(ctx, query, userID)
+}
+
+func (r *userRoleRepositoryPGX) GetAllAssignmentsByUser(ctx context.Context, userID string) ([]*entity.UserRole, error) {
+	query := `
+		SELECT ur.user_id, ur.role_id, ur.valid_from, ur.valid_until, ur.created_at
+		FROM authorizer_service.user_roles ur
+		WHERE ur.user_id = $1;
+	`
+
+	return r.queryAssignments(ctx, query, userID)
+}
+
+func (r *userRoleRepositoryPGX) queryAssignments(ctx context.Context, query string, args ...any) ([]*entity.UserRole, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []*entity.UserRole
+	for rows.Next() {
+		var a entity.UserRole
+		if err := rows.Scan(&a.UserID, &a.RoleID, &a.ValidFrom, &a.ValidUntil, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, &a)
+	}
+
+	return assignments, rows.Err()
+}
+
+func (r *userRoleRepositoryPGX) DeleteExpired(ctx context.Context, limit int) ([]string, error) {
+	query := `
+		DELETE FROM authorizer_service.user_roles
+		WHERE ctid IN (
+			SELECT ctid FROM authorizer_service.user_roles
+			WHERE valid_until <= NOW()
+			LIMIT $1
+		)
+		RETURNING user_id;
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUserIDs(rows)
+}
+
+func (r *userRoleRepositoryPGX) GetUsersActivatedBetween(ctx context.Context, from, to time.Time) ([]string, error) {
+	query := `
+		SELECT DISTINCT user_id
+		FROM authorizer_service.user_roles
+		WHERE valid_from > $1 AND valid_from <= $2;
+	`
+
+	rows, err := r.pool.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUserIDs(rows)
+}
+
+func scanUserIDs(rows pgx.Rows) ([]string, error) {
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPool connects to the database named by AUTHORIZER_TEST_DATABASE_URL,
+// which must already have the migrations applied. Tests are skipped when
+// it is not set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("AUTHORIZER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("Skipping test: AUTHORIZER_TEST_DATABASE_URL not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+func TestUserRoleRepository_Replace_KeepsOtherApplications(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	var userID string
+	require.NoError(t, pool.QueryRow(ctx, `
+		INSERT INTO authorizer_service.users (email, username, password)
+		VALUES ($1, $2, 'x') RETURNING id;
+	`, "replace-"+suffix+"@example.com", "replace-"+suffix).Scan(&userID))
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM authorizer_service.users WHERE id = $1`, userID)
+	})
+
+	createApp := func(code string) string {
+		var id string
+		require.NoError(t, pool.QueryRow(ctx, `
+			INSERT INTO authorizer_service.applications (code, name)
+			VALUES ($1, $1) RETURNING id;
+		`, code).Scan(&id))
+		t.Cleanup(func() {
+			pool.Exec(context.Background(), `DELETE FROM authorizer_service.applications WHERE id = $1`, id)
+		})
+		return id
+	}
+	createRole := func(appID *string, code string, scope string) string {
+		var id string
+		require.NoError(t, pool.QueryRow(ctx, `
+			INSERT INTO authorizer_service.roles (application_id, code, name, scope)
+			VALUES ($1, $2, $2, $3) RETURNING id;
+		`, appID, code, scope).Scan(&id))
+		t.Cleanup(func() {
+			pool.Exec(context.Background(), `DELETE FROM authorizer_service.roles WHERE id = $1`, id)
+		})
+		return id
+	}
+
+	appX := createApp("RPLX" + suffix)
+	appY := createApp("RPLY" + suffix)
+	oldX := createRole(&appX, "OLD", entity.RoleScopeApplication)
+	newX := createRole(&appX, "NEW", entity.RoleScopeApplication)
+	keptY := createRole(&appY, "KEPT", entity.RoleScopeApplication)
+	global := createRole(nil, "GLOBAL"+suffix, entity.RoleScopeGlobal)
+
+	keptUntil := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	_, err := pool.Exec(ctx, `
+		INSERT INTO authorizer_service.user_roles (user_id, role_id, valid_until)
+		VALUES ($1, $2, NULL), ($1, $3, $4), ($1, $5, NULL);
+	`, userID, oldX, keptY, keptUntil, global)
+	require.NoError(t, err)
+
+	newUntil := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	repo := &userRoleRepositoryPGX{pool: pool}
+	err = repo.Replace(ctx, userID, appX, []*entity.UserRole{
+		{UserID: userID, RoleID: newX, ValidUntil: &newUntil},
+	})
+	require.NoError(t, err)
+
+	assignments, err := repo.GetAssignmentsByUser(ctx, userID)
+	require.NoError(t, err)
+
+	byRole := make(map[string]*entity.UserRole)
+	for _, a := range assignments {
+		byRole[a.RoleID] = a
+	}
+
+	assert.NotContains(t, byRole, oldX, "replaced role in the same application should be removed")
+	require.Contains(t, byRole, newX)
+	require.NotNil(t, byRole[newX].ValidUntil)
+	assert.True(t, newUntil.Equal(*byRole[newX].ValidUntil))
+
+	require.Contains(t, byRole, keptY, "role in another application should survive")
+	require.NotNil(t, byRole[keptY].ValidUntil)
+	assert.True(t, keptUntil.Equal(*byRole[keptY].ValidUntil))
+
+	assert.Contains(t, byRole, global, "global role should survive")
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxPollUpdateAttempts bounds the retries of UpdatePoll when the
+// authorization changes while it is being updated
+const maxPollUpdateAttempts = 3
+
+type deviceAuthorizationRepository struct {
+	redis *redis.Client
+}
+
+func NewDeviceAuthorizationRepository(redis *redis.Client) repository.DeviceAuthorizationRepository {
+	return &deviceAuthorizationRepository{
+		redis: redis,
+	}
+}
+
+// Save stores the authorization and its user code index with a TTL that
+// ends at the authorization's expiry
+func (r *deviceAuthorizationRepository) Save(ctx context.Context, auth *entity.DeviceAuthorization) error {
+	ttl := time.Until(auth.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("device authorization has expired")
+	}
+
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.redis.TxPipeline()
+	pipe.Set(ctx, "device:"+auth.ID, data, ttl)
+	pipe.Set(ctx, "device_user:"+auth.UserCode, auth.ID, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *deviceAuthorizationRepository) GetByID(ctx context.Context, id string) (*entity.DeviceAuthorization, error) {
+	data, err := r.redis.Get(ctx, "device:"+id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	var auth entity.DeviceAuthorization
+	if err := json.Unmarshal(data, &auth); err != nil {
+		return nil, err
+	}
+	return &auth, nil
+}
+
+func (r *deviceAuthorizationRepository) GetByUserCode(ctx context.Context, userCode string) (*entity.DeviceAuthorization, error) {
+	id, err := r.redis.Get(ctx, "device_user:"+userCode).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *deviceAuthorizationRepository) UpdatePoll(ctx context.Context, id string, polledAt time.Time, interval time.Duration) error {
+	key := "device:" + id
+
+	// The write only commits if the authorization was not changed since it
+	// was read; otherwise it is read again, keeping the other writer's
+	// changes
+	update := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return errors.New("not found")
+			}
+			return err
+		}
+
+		var auth entity.DeviceAuthorization
+		if err := json.Unmarshal(data, &auth); err != nil {
+			return err
+		}
+		auth.LastPolledAt = &polledAt
+		auth.Interval = interval
+
+		data, err = json.Marshal(&auth)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.SetArgs(ctx, key, data, redis.SetArgs{KeepTTL: true})
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxPollUpdateAttempts; i++ {
+		err := r.redis.Watch(ctx, update, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return redis.TxFailedErr
+}
+
+func (r *deviceAuthorizationRepository) Delete(ctx context.Context, auth *entity.DeviceAuthorization) error {
+	pipe := r.redis.TxPipeline()
+	del := pipe.Del(ctx, "device:"+auth.ID)
+	pipe.Del(ctx, "device_user:"+auth.UserCode)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	if del.Val() != 1 {
+		return errors.New("not found")
+	}
+	return nil
+}
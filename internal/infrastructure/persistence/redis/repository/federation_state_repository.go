@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+type federationStateRepository struct {
+	redis *redis.Client
+}
+
+func NewFederationStateRepository(redis *redis.Client) repository.FederationStateRepository {
+	return &federationStateRepository{
+		redis: redis,
+	}
+}
+
+func (r *federationStateRepository) Save(ctx context.Context, state string, st *entity.FederationState, ttl time.Duration) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return r.redis.Set(ctx, "fedstate:"+state, data, ttl).Err()
+}
+
+func (r *federationStateRepository) Take(ctx context.Context, state string) (*entity.FederationState, error) {
+	data, err := r.redis.GetDel(ctx, "fedstate:"+state).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	var st entity.FederationState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+type magicLinkRepository struct {
+	redis *redis.Client
+}
+
+func NewMagicLinkRepository(redis *redis.Client) repository.MagicLinkRepository {
+	return &magicLinkRepository{
+		redis: redis,
+	}
+}
+
+func (r *magicLinkRepository) Save(ctx context.Context, tokenHash string, link *entity.MagicLink, ttl time.Duration) error {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+	return r.redis.Set(ctx, "magiclink:"+tokenHash, data, ttl).Err()
+}
+
+func (r *magicLinkRepository) Take(ctx context.Context, tokenHash string) (*entity.MagicLink, error) {
+	data, err := r.redis.GetDel(ctx, "magiclink:"+tokenHash).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.New("not found")
+		}
+		return nil, err
+	}
+
+	var link entity.MagicLink
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
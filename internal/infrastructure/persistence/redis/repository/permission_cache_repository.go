@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+type permissionCacheRepository struct {
+	redis *redis.Client
+}
+
+func NewPermissionCacheRepository(redis *redis.Client) repository.PermissionCacheRepository {
+	return &permissionCacheRepository{
+		redis: redis,
+	}
+}
+
+func (r *permissionCacheRepository) GetVersion(ctx context.Context, userID string) (int64, error) {
+	v, err := r.redis.Get(ctx, "pv:"+userID).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return v, err
+}
+
+func (r *permissionCacheRepository) BumpVersion(ctx context.Context, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	pipe := r.redis.Pipeline()
+	for _, id := range userIDs {
+		pipe.Incr(ctx, "pv:"+id)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *permissionCacheRepository) GetAuthorization(ctx context.Context, userID, appCode, orgCode string, version int64) ([]entity.Authorization, error) {
+	data, err := r.redis.Get(ctx, authzCacheKey(userID, appCode, orgCode, version)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var auths []entity.Authorization
+	if err := json.Unmarshal(data, &auths); err != nil {
+		return nil, err
+	}
+	return auths, nil
+}
+
+func (r *permissionCacheRepository) SetAuthorization(ctx context.Context, userID, appCode, orgCode string, version int64, auths []entity.Authorization, ttl time.Duration) error {
+	data, err := json.Marshal(auths)
+	if err != nil {
+		return err
+	}
+	return r.redis.Set(ctx, authzCacheKey(userID, appCode, orgCode, version), data, ttl).Err()
+}
+
+func (r *permissionCacheRepository) GetDecisions(ctx context.Context, userID string, keys []string, version int64) (map[string]*entity.AccessDecision, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	cacheKeys := make([]string, len(keys))
+	for i, key := range keys {
+		cacheKeys[i] = decisionCacheKey(userID, key, version)
+	}
+
+	values, err := r.redis.MGet(ctx, cacheKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make(map[string]*entity.AccessDecision, len(keys))
+	for i, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var decision entity.AccessDecision
+		if err := json.Unmarshal([]byte(data), &decision); err != nil {
+			continue
+		}
+		decisions[keys[i]] = &decision
+	}
+	return decisions, nil
+}
+
+func (r *permissionCacheRepository) SetDecisions(ctx context.Context, userID string, decisions map[string]*entity.AccessDecision, version int64, ttl time.Duration) error {
+	if len(decisions) == 0 {
+		return nil
+	}
+
+	pipe := r.redis.Pipeline()
+	for key, decision := range decisions {
+		data, err := json.Marshal(decision)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, decisionCacheKey(userID, key, version), data, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func authzCacheKey(userID, appCode, orgCode string, version int64) string {
+	return fmt.Sprintf("authz:%s:%s:%s:%d", userID, appCode, orgCode, version)
+}
+
+func decisionCacheKey(userID, key string, version int64) string {
+	return fmt.Sprintf("authzd:%s:%d:%s", userID, version, key)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+type rateLimitRepository struct {
+	redis *redis.Client
+}
+
+func NewRateLimitRepository(redis *redis.Client) repository.RateLimitRepository {
+	return &rateLimitRepository{
+		redis: redis,
+	}
+}
+
+func (r *rateLimitRepository) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	key = "ratelimit:" + key
+
+	pipe := r.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	// NX keeps the window fixed from the first hit
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
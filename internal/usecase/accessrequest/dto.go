@@ -0,0 +1,38 @@
+package accessrequest
+
+import (
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type (
+	// CreateInput asks for Role, a role code, in the application.
+	// ValidUntil is the end of the assignment asked for; nil asks for an
+	// open-ended one.
+	CreateInput struct {
+		ApplicationID string
+		Role          string
+		Justification string
+		ValidUntil    *time.Time
+	}
+
+	// DecisionInput is an approver's decision. On approval ValidUntil
+	// overrides the end the requester asked for.
+	DecisionInput struct {
+		Comment    *string
+		ValidUntil *time.Time
+	}
+
+	// AddApproverInput designates UserID as an approver of the application,
+	// or of one role when Role, a role code, is set
+	AddApproverInput struct {
+		UserID string
+		Role   *string
+	}
+
+	Detail struct {
+		Request *entity.AccessRequest
+		Events  []*entity.AccessRequestEvent
+	}
+)
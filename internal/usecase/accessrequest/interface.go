@@ -0,0 +1,20 @@
+package accessrequest
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type Usecase interface {
+	Create(ctx context.Context, userID string, input *CreateInput) (*entity.AccessRequest, error)
+	ListMine(ctx context.Context, userID string) ([]*entity.AccessRequest, error)
+	ListPending(ctx context.Context, approverID string) ([]*entity.AccessRequest, error)
+	GetDetail(ctx context.Context, callerID, id string) (*Detail, error)
+	Approve(ctx context.Context, approverID, id string, input *DecisionInput) error
+	Deny(ctx context.Context, approverID, id string, input *DecisionInput) error
+	Cancel(ctx context.Context, userID, id string) error
+	AddApprover(ctx context.Context, appID string, input *AddApproverInput) (*entity.AccessApprover, error)
+	RemoveApprover(ctx context.Context, appID, id string) error
+	ListApprovers(ctx context.Context, appID string) ([]*entity.AccessApprover, error)
+}
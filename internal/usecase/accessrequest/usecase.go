@@ -0,0 +1,454 @@
+package accessrequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/pkg/idgen"
+)
+
+var (
+	// ErrNotFound is returned when the request does not exist or is not
+	// visible to the caller
+	ErrNotFound = errors.New("access request not found")
+	// ErrNotApprover is returned when the caller is not an approver for
+	// the requested role
+	ErrNotApprover = errors.New("not an approver for this role")
+	// ErrSelfDecision is returned when a requester decides their own
+	// request
+	ErrSelfDecision = errors.New("requesters cannot decide their own access requests")
+	// ErrNotPending is returned when the request was already decided or
+	// cancelled
+	ErrNotPending = errors.New("access request is not pending")
+)
+
+type accessRequestUsecase struct {
+	repo         repository.AccessRequestRepository
+	appRepo      repository.AppRepository
+	roleRepo     repository.RoleRepository
+	userRepo     repository.UserRepository
+	userRoleRepo repository.UserRoleRepository
+	permCache    repository.PermissionCacheRepository
+	transactor   repository.Transactor
+	sodSvc       service.SoDService
+	logger       service.Logger
+}
+
+func NewAccessRequestUsecase(
+	repo repository.AccessRequestRepository,
+	appRepo repository.AppRepository,
+	roleRepo repository.RoleRepository,
+	userRepo repository.UserRepository,
+	userRoleRepo repository.UserRoleRepository,
+	permCache repository.PermissionCacheRepository,
+	transactor repository.Transactor,
+	sodSvc service.SoDService,
+	logger service.Logger,
+) Usecase {
+	return &accessRequestUsecase{
+		repo:         repo,
+		appRepo:      appRepo,
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		userRoleRepo: userRoleRepo,
+		permCache:    permCache,
+		transactor:   transactor,
+		sodSvc:       sodSvc,
+		logger:       logger,
+	}
+}
+
+// Create files a pending request for the user. The role must belong to
+// the application, be assignable directly and have an approver. The user
+// must not hold it already, have an open request for it or be barred from
+// it by a separation-of-duties constraint.
+func (uc *accessRequestUsecase) Create(ctx context.Context, userID string, in *CreateInput) (*entity.AccessRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.ApplicationID == "" || in.Role == "" {
+		return nil, errors.New("application_id and role is required")
+	}
+	if in.Justification == "" {
+		return nil, errors.New("justification is required")
+	}
+	if in.ValidUntil != nil && !in.ValidUntil.After(time.Now()) {
+		return nil, errors.New("valid_until must be in the future")
+	}
+
+	app, err := uc.appRepo.GetByID(ctx, in.ApplicationID)
+	if err != nil {
+		return nil, errors.New("application not found")
+	}
+
+	role, err := uc.roleRepo.GetByAppAndCode(ctx, app.ID, in.Role)
+	if err != nil {
+		return nil, fmt.Errorf("role %s not found", in.Role)
+	}
+	if role.IsTenant() {
+		return nil, fmt.Errorf("role %s is a tenant role and can only be assigned within an organization", role.Code)
+	}
+
+	held, err := uc.userRoleRepo.GetRolesByUserAndApp(ctx, userID, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	for _, r := range held {
+		if r.ID == role.ID {
+			return nil, fmt.Errorf("role %s is already assigned", role.Code)
+		}
+	}
+
+	pending, err := uc.repo.HasPending(ctx, userID, role.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending requests: %w", err)
+	}
+	if pending {
+		return nil, fmt.Errorf("a request for role %s is already pending", role.Code)
+	}
+
+	// Requests that could never be approved are rejected up front; the
+	// check is repeated on approval
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return uc.sodSvc.CheckUsers(ctx, []string{userID}, &service.RoleChange{
+			AppID:   app.ID,
+			RoleIDs: []string{role.ID},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hasApprovers, err := uc.repo.HasApprovers(ctx, app.ID, role.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check approvers: %w", err)
+	}
+	if !hasApprovers {
+		return nil, fmt.Errorf("no approvers are designated for role %s", role.Code)
+	}
+
+	req := &entity.AccessRequest{
+		ID:            idgen.NewUUIDv7(),
+		UserID:        userID,
+		ApplicationID: app.ID,
+		RoleID:        role.ID,
+		RoleCode:      role.Code,
+		AppCode:       app.Code,
+		Justification: in.Justification,
+		ValidUntil:    in.ValidUntil,
+		Status:        entity.AccessRequestPending,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := uc.repo.Create(ctx, req); err != nil {
+		uc.logger.Error("Failed to create access request", service.Fields{
+			"user_id": userID,
+			"app_id":  app.ID,
+			"role":    role.Code,
+			"error":   err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Access request created", service.Fields{
+		"request_id": req.ID,
+		"user_id":    userID,
+		"app_id":     app.ID,
+		"role":       role.Code,
+	})
+
+	return req, nil
+}
+
+func (uc *accessRequestUsecase) ListMine(ctx context.Context, userID string) ([]*entity.AccessRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	reqs, err := uc.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch access requests: %w", err)
+	}
+	return reqs, nil
+}
+
+func (uc *accessRequestUsecase) ListPending(ctx context.Context, approverID string) ([]*entity.AccessRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	reqs, err := uc.repo.ListPendingForApprover(ctx, approverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch access requests: %w", err)
+	}
+	return reqs, nil
+}
+
+// GetDetail returns the request with its lifecycle. Only the requester
+// and the request's approvers can see it.
+func (uc *accessRequestUsecase) GetDetail(ctx context.Context, callerID, id string) (*Detail, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	if req.UserID != callerID {
+		ok, err := uc.repo.IsApprover(ctx, callerID, req.ApplicationID, req.RoleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check approvers: %w", err)
+		}
+		if !ok {
+			return nil, ErrNotFound
+		}
+	}
+
+	events, err := uc.repo.ListEvents(ctx, req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch events: %w", err)
+	}
+
+	return &Detail{Request: req, Events: events}, nil
+}
+
+// Approve assigns the requested role to the requester, until the end the
+// approver sets or else the one asked for
+func (uc *accessRequestUsecase) Approve(ctx context.Context, approverID, id string, in *DecisionInput) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := uc.decidable(ctx, approverID, id)
+	if err != nil {
+		return err
+	}
+
+	validUntil := req.ValidUntil
+	if in.ValidUntil != nil {
+		validUntil = in.ValidUntil
+	}
+	if validUntil != nil && !validUntil.After(time.Now()) {
+		return errors.New("valid_until must be in the future")
+	}
+
+	// The requester's roles may have changed since the request was made
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.sodSvc.CheckUsers(ctx, []string{req.UserID}, &service.RoleChange{
+			AppID:   req.ApplicationID,
+			RoleIDs: []string{req.RoleID},
+		}); err != nil {
+			return err
+		}
+
+		err := uc.repo.Approve(ctx, req.ID, approverID, in.Comment, validUntil)
+		if errors.Is(err, repository.ErrAccessRequestClosed) {
+			return ErrNotPending
+		}
+		if err != nil {
+			uc.logger.Error("Failed to approve access request", service.Fields{
+				"request_id":  req.ID,
+				"approver_id": approverID,
+				"error":       err.Error(),
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Invalidate cached permissions so thin-token consumers see the change
+	if err := uc.permCache.BumpVersion(ctx, []string{req.UserID}); err != nil {
+		uc.logger.Warn("Failed to bump permissions version", service.Fields{
+			"user_id": req.UserID,
+			"error":   err.Error(),
+		})
+	}
+
+	uc.logger.Info("Access request approved", service.Fields{
+		"request_id":  req.ID,
+		"user_id":     req.UserID,
+		"role":        req.RoleCode,
+		"approver_id": approverID,
+		"valid_until": validUntil,
+	})
+
+	return nil
+}
+
+func (uc *accessRequestUsecase) Deny(ctx context.Context, approverID, id string, in *DecisionInput) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := uc.decidable(ctx, approverID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.Close(ctx, req.ID, approverID, entity.AccessRequestDenied, in.Comment); err != nil {
+		if errors.Is(err, repository.ErrAccessRequestClosed) {
+			return ErrNotPending
+		}
+		uc.logger.Error("Failed to deny access request", service.Fields{
+			"request_id":  req.ID,
+			"approver_id": approverID,
+			"error":       err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Access request denied", service.Fields{
+		"request_id":  req.ID,
+		"user_id":     req.UserID,
+		"role":        req.RoleCode,
+		"approver_id": approverID,
+	})
+
+	return nil
+}
+
+// Cancel withdraws the user's own pending request
+func (uc *accessRequestUsecase) Cancel(ctx context.Context, userID, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := uc.repo.GetByID(ctx, id)
+	if err != nil || req.UserID != userID {
+		return ErrNotFound
+	}
+	if !req.IsPending() {
+		return ErrNotPending
+	}
+
+	if err := uc.repo.Close(ctx, req.ID, userID, entity.AccessRequestCancelled, nil); err != nil {
+		if errors.Is(err, repository.ErrAccessRequestClosed) {
+			return ErrNotPending
+		}
+		uc.logger.Error("Failed to cancel access request", service.Fields{
+			"request_id": req.ID,
+			"error":      err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Access request cancelled", service.Fields{
+		"request_id": req.ID,
+		"user_id":    userID,
+	})
+
+	return nil
+}
+
+func (uc *accessRequestUsecase) AddApprover(ctx context.Context, appID string, in *AddApproverInput) (*entity.AccessApprover, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.UserID == "" {
+		return nil, errors.New("user_id is required")
+	}
+
+	app, err := uc.appRepo.GetByID(ctx, appID)
+	if err != nil {
+		return nil, errors.New("application not found")
+	}
+
+	if _, err := uc.userRepo.GetByID(ctx, in.UserID); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	approver := &entity.AccessApprover{
+		ID:            idgen.NewUUIDv7(),
+		ApplicationID: app.ID,
+		UserID:        in.UserID,
+		CreatedAt:     time.Now(),
+	}
+	if in.Role != nil {
+		role, err := uc.roleRepo.GetByAppAndCode(ctx, app.ID, *in.Role)
+		if err != nil {
+			return nil, fmt.Errorf("role %s not found", *in.Role)
+		}
+		approver.RoleID = &role.ID
+	}
+
+	if err := uc.repo.AddApprover(ctx, approver); err != nil {
+		uc.logger.Error("Failed to add access approver", service.Fields{
+			"app_id":  app.ID,
+			"user_id": in.UserID,
+			"error":   err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Access approver added", service.Fields{
+		"approver_id": approver.ID,
+		"app_id":      app.ID,
+		"user_id":     in.UserID,
+		"role_id":     approver.RoleID,
+	})
+
+	return approver, nil
+}
+
+func (uc *accessRequestUsecase) RemoveApprover(ctx context.Context, appID, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := uc.repo.RemoveApprover(ctx, appID, id); err != nil {
+		return errors.New("approver not found")
+	}
+
+	uc.logger.Info("Access approver removed", service.Fields{
+		"approver_id": id,
+		"app_id":      appID,
+	})
+
+	return nil
+}
+
+func (uc *accessRequestUsecase) ListApprovers(ctx context.Context, appID string) ([]*entity.AccessApprover, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := uc.appRepo.GetByID(ctx, appID); err != nil {
+		return nil, errors.New("application not found")
+	}
+
+	approvers, err := uc.repo.ListApprovers(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch approvers: %w", err)
+	}
+	return approvers, nil
+}
+
+// decidable returns the request when it is pending and approverID may
+// decide it
+func (uc *accessRequestUsecase) decidable(ctx context.Context, approverID, id string) (*entity.AccessRequest, error) {
+	req, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if req.UserID == approverID {
+		return nil, ErrSelfDecision
+	}
+
+	ok, err := uc.repo.IsApprover(ctx, approverID, req.ApplicationID, req.RoleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check approvers: %w", err)
+	}
+	if !ok {
+		uc.logger.Warn("Access request decision rejected: not an approver", service.Fields{
+			"request_id":  req.ID,
+			"approver_id": approverID,
+		})
+		return nil, ErrNotApprover
+	}
+
+	if !req.IsPending() {
+		return nil, ErrNotPending
+	}
+	return req, nil
+}
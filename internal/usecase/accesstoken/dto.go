@@ -0,0 +1,24 @@
+package accesstoken
+
+import "time"
+
+type (
+	CreateInput struct {
+		UserID      string
+		AppCode     string
+		Name        string
+		Permissions []string
+		ExpiresIn   time.Duration
+	}
+
+	// CreatedToken carries the plaintext token, which is only available
+	// at creation time
+	CreatedToken struct {
+		ID          string
+		Name        string
+		Token       string
+		AppCode     string
+		Permissions []string
+		ExpiresAt   time.Time
+	}
+)
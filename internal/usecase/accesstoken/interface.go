@@ -0,0 +1,14 @@
+package accesstoken
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type Usecase interface {
+	Create(ctx context.Context, input *CreateInput) (*CreatedToken, error)
+	List(ctx context.Context, userID string) ([]*entity.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, tokenID string) error
+	Authenticate(ctx context.Context, rawToken string) (*entity.Claims, error)
+}
@@ -0,0 +1,331 @@
+package accesstoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/pkg/idgen"
+	"github.com/mafzaidi/authorizer/pkg/permission"
+)
+
+const (
+	maxTokenLifetime = 365 * 24 * time.Hour
+	lookupPrefixLen  = 12
+)
+
+type accessTokenUsecase struct {
+	patRepo     repository.PersonalAccessTokenRepository
+	userRepo    repository.UserRepository
+	appRepo     repository.AppRepository
+	permRepo    repository.PermRepository
+	authService service.AuthService
+	logger      service.Logger
+}
+
+func NewAccessTokenUsecase(
+	patRepo repository.PersonalAccessTokenRepository,
+	userRepo repository.UserRepository,
+	appRepo repository.AppRepository,
+	permRepo repository.PermRepository,
+	authService service.AuthService,
+	logger service.Logger,
+) Usecase {
+	return &accessTokenUsecase{
+		patRepo:     patRepo,
+		userRepo:    userRepo,
+		appRepo:     appRepo,
+		permRepo:    permRepo,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+func (uc *accessTokenUsecase) Create(ctx context.Context, in *CreateInput) (*CreatedToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.UserID == "" {
+		return nil, errors.New("userID is required")
+	}
+
+	if in.Name == "" || in.AppCode == "" {
+		uc.logger.Warn("Create access token failed: name and application required", service.Fields{
+			"user_id": in.UserID,
+		})
+		return nil, errors.New("name and application is required")
+	}
+
+	if len(in.Permissions) == 0 {
+		return nil, errors.New("permissions is required")
+	}
+
+	if in.ExpiresIn <= 0 || in.ExpiresIn > maxTokenLifetime {
+		return nil, fmt.Errorf("expiry must be between 1s and %s", maxTokenLifetime)
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, in.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	app, err := uc.appRepo.GetByCode(ctx, in.AppCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	allowed, err := uc.allowedPermissions(ctx, user, app)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range in.Permissions {
+		if !permission.Any(allowed, p) {
+			uc.logger.Warn("Create access token failed: permission not held", service.Fields{
+				"user_id":    user.ID,
+				"app_code":   app.Code,
+				"permission": p,
+			})
+			return nil, fmt.Errorf("permission %q is not granted to the user in %s", p, app.Code)
+		}
+	}
+
+	prefix, secret, err := generateToken()
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+	raw := entity.PersonalAccessTokenPrefix + prefix + "_" + secret
+
+	pat := &entity.PersonalAccessToken{
+		ID:            idgen.NewUUIDv7(),
+		UserID:        user.ID,
+		ApplicationID: app.ID,
+		Name:          in.Name,
+		Prefix:        prefix,
+		TokenHash:     hashToken(raw),
+		Permissions:   in.Permissions,
+		ExpiresAt:     time.Now().Add(in.ExpiresIn),
+		CreatedAt:     time.Now(),
+	}
+
+	if err := uc.patRepo.Create(ctx, pat); err != nil {
+		uc.logger.Error("Failed to create access token", service.Fields{
+			"user_id": user.ID,
+			"name":    in.Name,
+			"error":   err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Access token created successfully", service.Fields{
+		"token_id": pat.ID,
+		"user_id":  user.ID,
+		"app_code": app.Code,
+	})
+
+	return &CreatedToken{
+		ID:          pat.ID,
+		Name:        pat.Name,
+		Token:       raw,
+		AppCode:     app.Code,
+		Permissions: pat.Permissions,
+		ExpiresAt:   pat.ExpiresAt,
+	}, nil
+}
+
+func (uc *accessTokenUsecase) List(ctx context.Context, userID string) ([]*entity.PersonalAccessToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if userID == "" {
+		return nil, errors.New("userID is required")
+	}
+
+	tokens, err := uc.patRepo.ListByUser(ctx, userID)
+	if err != nil {
+		uc.logger.Error("Failed to list access tokens", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (uc *accessTokenUsecase) Revoke(ctx context.Context, userID, tokenID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := uc.patRepo.Revoke(ctx, userID, tokenID); err != nil {
+		uc.logger.Warn("Failed to revoke access token", service.Fields{
+			"user_id":  userID,
+			"token_id": tokenID,
+			"error":    err.Error(),
+		})
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	uc.logger.Info("Access token revoked", service.Fields{
+		"user_id":  userID,
+		"token_id": tokenID,
+	})
+
+	return nil
+}
+
+// Authenticate verifies a raw personal access token and returns claims
+// equivalent to a JWT for the token's application. The granted permissions
+// are the intersection of the token's permissions and those the user still
+// holds, so revoking a role also narrows existing tokens.
+func (uc *accessTokenUsecase) Authenticate(ctx context.Context, rawToken string) (*entity.Claims, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	prefix, ok := parsePrefix(rawToken)
+	if !ok {
+		return nil, errors.New("invalid token format")
+	}
+
+	pat, err := uc.patRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(pat.TokenHash), []byte(hashToken(rawToken))) != 1 {
+		return nil, errors.New("invalid token")
+	}
+
+	if pat.RevokedAt != nil {
+		return nil, errors.New("token has been revoked")
+	}
+
+	if time.Now().After(pat.ExpiresAt) {
+		return nil, errors.New("token has expired")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, pat.UserID)
+	if err != nil || !user.IsActive {
+		return nil, errors.New("token owner is not active")
+	}
+
+	app, err := uc.appRepo.GetByID(ctx, pat.ApplicationID)
+	if err != nil {
+		return nil, errors.New("token application not found")
+	}
+
+	allowed, err := uc.allowedPermissions(ctx, user, app)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make([]string, 0, len(pat.Permissions))
+	for _, p := range pat.Permissions {
+		if permission.Any(allowed, p) {
+			perms = append(perms, p)
+		}
+	}
+
+	if err := uc.patRepo.TouchLastUsed(ctx, pat.ID); err != nil {
+		uc.logger.Warn("Failed to update access token last used", service.Fields{
+			"token_id": pat.ID,
+			"error":    err.Error(),
+		})
+	}
+
+	return &entity.Claims{
+		Issuer:        "authorizer",
+		Subject:       user.ID,
+		Audience:      []string{app.Code},
+		IssuedAt:      pat.CreatedAt.Unix(),
+		ExpiresAt:     pat.ExpiresAt.Unix(),
+		Username:      user.Username,
+		Email:         user.Email,
+		PrincipalType: entity.PrincipalTypeUser,
+		Authorization: []entity.Authorization{
+			{
+				App:         app.Code,
+				Roles:       []string{},
+				Permissions: perms,
+			},
+		},
+	}, nil
+}
+
+// allowedPermissions returns the permission grants the user currently holds
+// in the application, possibly wildcards. Superadmins may pick from the
+// whole application catalog.
+func (uc *accessTokenUsecase) allowedPermissions(ctx context.Context, user *entity.User, app *entity.Application) ([]string, error) {
+	claims, err := uc.authService.BuildClaims(ctx, user, app.Code, "")
+	if err != nil {
+		uc.logger.Error("Failed to build claims", service.Fields{
+			"user_id":  user.ID,
+			"app_code": app.Code,
+			"error":    err.Error(),
+		})
+		return nil, errors.New("failed to resolve user permissions")
+	}
+
+	var allowed []string
+	for _, a := range claims.Authorization {
+		switch a.App {
+		case app.Code:
+			allowed = append(allowed, a.Permissions...)
+		case entity.GlobalAuthorization:
+			if !slices.Contains(a.Permissions, entity.SuperadminPermission) {
+				continue
+			}
+			catalog, err := uc.permRepo.ListByApp(ctx, app.ID)
+			if err != nil {
+				return nil, errors.New("failed to resolve user permissions")
+			}
+			for _, p := range catalog {
+				allowed = append(allowed, p.Code)
+			}
+		}
+	}
+
+	return allowed, nil
+}
+
+// generateToken returns a random lookup prefix and secret
+func generateToken() (string, string, error) {
+	p := make([]byte, lookupPrefixLen/2)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", err
+	}
+	s := make([]byte, 32)
+	if _, err := rand.Read(s); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(p), base64.RawURLEncoding.EncodeToString(s), nil
+}
+
+func parsePrefix(raw string) (string, bool) {
+	if !strings.HasPrefix(raw, entity.PersonalAccessTokenPrefix) {
+		return "", false
+	}
+	rest := strings.TrimPrefix(raw, entity.PersonalAccessTokenPrefix)
+	if len(rest) <= lookupPrefixLen || rest[lookupPrefixLen] != '_' {
+		return "", false
+	}
+	return rest[:lookupPrefixLen], true
+}
+
+// hashToken hashes a token for storage. Tokens carry 256 bits of entropy,
+// so a fast hash is sufficient.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
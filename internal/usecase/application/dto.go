@@ -2,10 +2,30 @@ package application
 
 type (
 	CreateInput struct {
-		Code        string
-		Name        string
-		Description string
-		Metadata    map[string]interface{}
+		Code              string
+		Name              string
+		Description       string
+		Metadata          map[string]interface{}
+		TokenMode         string
+		HookFailurePolicy string
+	}
+
+	CreateHookInput struct {
+		AppID     string
+		Name      string
+		URL       string
+		Secret    string
+		TimeoutMs int
+	}
+
+	// SaveAdminInput makes UserID an admin of the application, holding
+	// Permissions, AUTHORIZER permission codes, for it only. Saving an
+	// existing admin replaces their permissions.
+	SaveAdminInput struct {
+		AppID       string
+		UserID      string
+		Permissions []string
+		ActorID     string
 	}
 
 	UpdateInput struct {
@@ -1,7 +1,19 @@
 package application
 
-import "context"
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
 
 type Usecase interface {
 	Create(ctx context.Context, input *CreateInput) error
+	UpdateTokenMode(ctx context.Context, appID, mode string) error
+	UpdateHookFailurePolicy(ctx context.Context, appID, policy string) error
+	CreateHook(ctx context.Context, input *CreateHookInput) (*entity.TokenHook, error)
+	ListHooks(ctx context.Context, appID string) ([]*entity.TokenHook, error)
+	DeleteHook(ctx context.Context, appID, hookID string) error
+	SaveAdmin(ctx context.Context, input *SaveAdminInput) (*entity.ApplicationAdmin, error)
+	ListAdmins(ctx context.Context, appID string) ([]*entity.ApplicationAdmin, error)
+	RemoveAdmin(ctx context.Context, appID, userID string) error
 }
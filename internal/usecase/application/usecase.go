@@ -2,24 +2,51 @@ package application
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
 	"github.com/mafzaidi/authorizer/internal/domain/repository"
 	"github.com/mafzaidi/authorizer/internal/domain/service"
 	"github.com/mafzaidi/authorizer/pkg/idgen"
+	"github.com/mafzaidi/authorizer/pkg/permission"
 )
 
+// ErrHookNotFound is returned when the hook does not exist or belongs to
+// another application
+var ErrHookNotFound = errors.New("hook not found")
+
 type appUsecase struct {
-	repo   repository.AppRepository
-	logger service.Logger
+	repo      repository.AppRepository
+	hookRepo  repository.TokenHookRepository
+	adminRepo repository.ApplicationAdminRepository
+	userRepo  repository.UserRepository
+	permRepo  repository.PermRepository
+	permCache repository.PermissionCacheRepository
+	logger    service.Logger
 }
 
-func NewAppUsecase(repo repository.AppRepository, logger service.Logger) Usecase {
+func NewAppUsecase(
+	repo repository.AppRepository,
+	hookRepo repository.TokenHookRepository,
+	adminRepo repository.ApplicationAdminRepository,
+	userRepo repository.UserRepository,
+	permRepo repository.PermRepository,
+	permCache repository.PermissionCacheRepository,
+	logger service.Logger,
+) Usecase {
 	return &appUsecase{
-		repo:   repo,
-		logger: logger,
+		repo:      repo,
+		hookRepo:  hookRepo,
+		adminRepo: adminRepo,
+		userRepo:  userRepo,
+		permRepo:  permRepo,
+		permCache: permCache,
+		logger:    logger,
 	}
 }
 
@@ -32,6 +59,26 @@ func (uc *appUsecase) Create(ctx context.Context, in *CreateInput) error {
 		return errors.New("code and name is required")
 	}
 
+	mode := in.TokenMode
+	if mode == "" {
+		mode = entity.TokenModeFat
+	}
+	if !isValidTokenMode(mode) {
+		uc.logger.Warn("Application creation failed: invalid token mode", service.Fields{
+			"code":       in.Code,
+			"token_mode": mode,
+		})
+		return fmt.Errorf("token mode must be %s or %s", entity.TokenModeFat, entity.TokenModeThin)
+	}
+
+	policy := in.HookFailurePolicy
+	if policy == "" {
+		policy = entity.HookFailClosed
+	}
+	if !isValidHookFailurePolicy(policy) {
+		return fmt.Errorf("hook failure policy must be %s or %s", entity.HookFailOpen, entity.HookFailClosed)
+	}
+
 	existingApp, _ := uc.repo.GetByCode(ctx, in.Code)
 	if existingApp != nil {
 		uc.logger.Warn("Application creation failed: application already exists", service.Fields{
@@ -41,13 +88,15 @@ func (uc *appUsecase) Create(ctx context.Context, in *CreateInput) error {
 	}
 
 	app := &entity.Application{
-		ID:          idgen.NewUUIDv7(),
-		Code:        in.Code,
-		Description: in.Description,
-		Name:        in.Name,
-		Metadata:    in.Metadata,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:                idgen.NewUUIDv7(),
+		Code:              in.Code,
+		Description:       in.Description,
+		Name:              in.Name,
+		Metadata:          in.Metadata,
+		TokenMode:         mode,
+		HookFailurePolicy: policy,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
 	}
 
 	err := uc.repo.Create(ctx, app)
@@ -67,3 +116,374 @@ func (uc *appUsecase) Create(ctx context.Context, in *CreateInput) error {
 
 	return nil
 }
+
+func (uc *appUsecase) UpdateTokenMode(ctx context.Context, appID, mode string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if !isValidTokenMode(mode) {
+		return fmt.Errorf("token mode must be %s or %s", entity.TokenModeFat, entity.TokenModeThin)
+	}
+
+	app, err := uc.repo.GetByID(ctx, appID)
+	if err != nil {
+		uc.logger.Error("Failed to get application by ID", service.Fields{
+			"app_id": appID,
+			"error":  err.Error(),
+		})
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	app.TokenMode = mode
+	if err := uc.repo.Update(ctx, app); err != nil {
+		uc.logger.Error("Failed to update token mode", service.Fields{
+			"app_id": appID,
+			"error":  err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Application token mode updated", service.Fields{
+		"app_id":     appID,
+		"token_mode": mode,
+	})
+
+	return nil
+}
+
+// UpdateHookFailurePolicy sets what happens to a login when one of the
+// application's hooks fails
+func (uc *appUsecase) UpdateHookFailurePolicy(ctx context.Context, appID, policy string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if !isValidHookFailurePolicy(policy) {
+		return fmt.Errorf("hook failure policy must be %s or %s", entity.HookFailOpen, entity.HookFailClosed)
+	}
+
+	app, err := uc.repo.GetByID(ctx, appID)
+	if err != nil {
+		uc.logger.Error("Failed to get application by ID", service.Fields{
+			"app_id": appID,
+			"error":  err.Error(),
+		})
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	app.HookFailurePolicy = policy
+	if err := uc.repo.Update(ctx, app); err != nil {
+		uc.logger.Error("Failed to update hook failure policy", service.Fields{
+			"app_id": appID,
+			"error":  err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Application hook failure policy updated", service.Fields{
+		"app_id":              appID,
+		"hook_failure_policy": policy,
+	})
+
+	return nil
+}
+
+func (uc *appUsecase) CreateHook(ctx context.Context, in *CreateHookInput) (*entity.TokenHook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.AppID == "" {
+		uc.logger.Warn("Hook creation failed: application ID is required", service.Fields{})
+		return nil, errors.New("application ID is required")
+	}
+
+	if in.Name == "" || in.URL == "" {
+		uc.logger.Warn("Hook creation failed: name and url required", service.Fields{
+			"app_id": in.AppID,
+		})
+		return nil, errors.New("name and url is required")
+	}
+
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		uc.logger.Warn("Hook creation failed: invalid url", service.Fields{
+			"app_id": in.AppID,
+			"url":    in.URL,
+		})
+		return nil, errors.New("url must be an absolute http(s) URL")
+	}
+
+	timeoutMs := in.TimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = 2000
+	}
+
+	app, err := uc.repo.GetByID(ctx, in.AppID)
+	if err != nil {
+		uc.logger.Error("Failed to get application by ID", service.Fields{
+			"app_id": in.AppID,
+			"error":  err.Error(),
+		})
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	secret := in.Secret
+	if secret == "" {
+		secret, err = generateHookSecret()
+		if err != nil {
+			return nil, errors.New("failed to generate hook secret")
+		}
+	}
+
+	hook := &entity.TokenHook{
+		ID:            idgen.NewUUIDv7(),
+		ApplicationID: app.ID,
+		Name:          in.Name,
+		URL:           in.URL,
+		Secret:        secret,
+		TimeoutMs:     timeoutMs,
+		IsActive:      true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	if err := uc.hookRepo.Create(ctx, hook); err != nil {
+		uc.logger.Error("Failed to create token hook", service.Fields{
+			"app_id": app.ID,
+			"name":   in.Name,
+			"error":  err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Token hook created successfully", service.Fields{
+		"hook_id": hook.ID,
+		"app_id":  app.ID,
+	})
+
+	return hook, nil
+}
+
+func (uc *appUsecase) ListHooks(ctx context.Context, appID string) ([]*entity.TokenHook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if appID == "" {
+		return nil, errors.New("application ID is required")
+	}
+
+	hooks, err := uc.hookRepo.ListByApp(ctx, appID)
+	if err != nil {
+		uc.logger.Error("Failed to list token hooks", service.Fields{
+			"app_id": appID,
+			"error":  err.Error(),
+		})
+		return nil, fmt.Errorf("failed to list hooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+func (uc *appUsecase) DeleteHook(ctx context.Context, appID, hookID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// A hook of another application is reported as missing, so its
+	// existence is not disclosed
+	hook, err := uc.hookRepo.GetByID(ctx, hookID)
+	if err != nil || hook.ApplicationID != appID {
+		return ErrHookNotFound
+	}
+
+	if err := uc.hookRepo.Delete(ctx, hook.ID); err != nil {
+		uc.logger.Error("Failed to delete token hook", service.Fields{
+			"hook_id": hook.ID,
+			"error":   err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Token hook deleted successfully", service.Fields{
+		"hook_id": hook.ID,
+		"app_id":  appID,
+	})
+
+	return nil
+}
+
+// SaveAdmin delegates administration of the application to a user. The
+// permissions, wildcards included, must match permissions of the AUTHORIZER
+// catalog, and AUTHORIZER itself cannot be delegated. The permissions take
+// effect in the user's next token, which is why their permissions version
+// is bumped.
+func (uc *appUsecase) SaveAdmin(ctx context.Context, in *SaveAdminInput) (*entity.ApplicationAdmin, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.UserID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	if len(in.Permissions) == 0 {
+		return nil, errors.New("permissions is required")
+	}
+
+	seen := make(map[string]bool, len(in.Permissions))
+	var perms []string
+	for _, code := range in.Permissions {
+		if err := permission.Validate(code); err != nil {
+			return nil, err
+		}
+		if code == entity.SuperadminPermission {
+			return nil, fmt.Errorf("%s cannot be delegated", entity.SuperadminPermission)
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		perms = append(perms, code)
+	}
+
+	app, err := uc.repo.GetByID(ctx, in.AppID)
+	if err != nil {
+		return nil, errors.New("application not found")
+	}
+	if app.Code == entity.AuthorizerAppCode {
+		return nil, fmt.Errorf("administration of %s cannot be delegated", entity.AuthorizerAppCode)
+	}
+
+	if err := uc.validateDelegated(ctx, perms); err != nil {
+		uc.logger.Warn("Save application admin failed: invalid permission", service.Fields{
+			"app_id": app.ID,
+			"error":  err.Error(),
+		})
+		return nil, err
+	}
+
+	if _, err := uc.userRepo.GetByID(ctx, in.UserID); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	admin := &entity.ApplicationAdmin{
+		ApplicationID: app.ID,
+		UserID:        in.UserID,
+		Permissions:   perms,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if in.ActorID != "" {
+		admin.CreatedBy = &in.ActorID
+	}
+
+	if err := uc.adminRepo.Save(ctx, admin); err != nil {
+		uc.logger.Error("Failed to save application admin", service.Fields{
+			"app_id":  app.ID,
+			"user_id": in.UserID,
+			"error":   err.Error(),
+		})
+		return nil, err
+	}
+	uc.bumpVersion(ctx, in.UserID)
+
+	uc.logger.Info("Application admin saved", service.Fields{
+		"app_id":      app.ID,
+		"user_id":     in.UserID,
+		"permissions": perms,
+		"actor_id":    in.ActorID,
+	})
+
+	return admin, nil
+}
+
+// validateDelegated checks that every delegated code, wildcards included,
+// matches at least one concrete permission of the AUTHORIZER catalog
+func (uc *appUsecase) validateDelegated(ctx context.Context, codes []string) error {
+	authorizer, err := uc.repo.GetByCode(ctx, entity.AuthorizerAppCode)
+	if err != nil {
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	catalog, err := uc.permRepo.ListByApp(ctx, authorizer.ID)
+	if err != nil {
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	var concrete []string
+	for _, p := range catalog {
+		if !permission.IsWildcard(p.Code) && p.Code != entity.SuperadminPermission {
+			concrete = append(concrete, p.Code)
+		}
+	}
+
+	for _, code := range codes {
+		matched := false
+		for _, c := range concrete {
+			if permission.Match(code, c) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("permission %q matches no permission of %s", code, entity.AuthorizerAppCode)
+		}
+	}
+	return nil
+}
+
+func (uc *appUsecase) ListAdmins(ctx context.Context, appID string) ([]*entity.ApplicationAdmin, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := uc.repo.GetByID(ctx, appID); err != nil {
+		return nil, errors.New("application not found")
+	}
+
+	admins, err := uc.adminRepo.ListByApp(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admins: %w", err)
+	}
+	return admins, nil
+}
+
+func (uc *appUsecase) RemoveAdmin(ctx context.Context, appID, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := uc.adminRepo.Delete(ctx, appID, userID); err != nil {
+		return errors.New("admin not found")
+	}
+	uc.bumpVersion(ctx, userID)
+
+	uc.logger.Info("Application admin removed", service.Fields{
+		"app_id":  appID,
+		"user_id": userID,
+	})
+
+	return nil
+}
+
+// bumpVersion invalidates the user's cached authorization; a failure is
+// logged, the change itself is already saved
+func (uc *appUsecase) bumpVersion(ctx context.Context, userID string) {
+	if err := uc.permCache.BumpVersion(ctx, []string{userID}); err != nil {
+		uc.logger.Error("Failed to bump permissions version", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+func isValidTokenMode(mode string) bool {
+	return mode == entity.TokenModeFat || mode == entity.TokenModeThin
+}
+
+func isValidHookFailurePolicy(policy string) bool {
+	return policy == entity.HookFailOpen || policy == entity.HookFailClosed
+}
+
+// generateHookSecret generates a random secret used to sign hook requests
+func generateHookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
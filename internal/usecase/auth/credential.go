@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware/pwd"
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+)
+
+// localVerifier checks passwords against the bcrypt hashes stored with
+// local users
+type localVerifier struct {
+	userRepo repository.UserRepository
+}
+
+// NewLocalCredentialVerifier creates the verifier for locally stored
+// passwords. Users without a local password (e.g. provisioned from an
+// external identity) never match.
+func NewLocalCredentialVerifier(userRepo repository.UserRepository) service.CredentialVerifier {
+	return &localVerifier{userRepo: userRepo}
+}
+
+func (v *localVerifier) Name() string {
+	return entity.CredentialSourceLocal
+}
+
+func (v *localVerifier) Verify(ctx context.Context, login, password string) (*entity.VerifiedCredential, error) {
+	user, err := v.userRepo.GetByEmail(ctx, login)
+	if err != nil || user.Password == "" {
+		return nil, service.ErrInvalidCredentials
+	}
+
+	if !pwd.CheckHash(user.Password, password) {
+		return nil, service.ErrInvalidCredentials
+	}
+
+	return &entity.VerifiedCredential{
+		Source: entity.CredentialSourceLocal,
+		User:   user,
+	}, nil
+}
@@ -1 +1,13 @@
 package auth
+
+import "github.com/mafzaidi/authorizer/internal/domain/entity"
+
+type (
+	// ResolvedPermissions is the live authorization of a user, served to
+	// relying services that receive thin tokens.
+	ResolvedPermissions struct {
+		UserID        string
+		Version       int64
+		Authorization []entity.Authorization
+	}
+)
@@ -7,5 +7,10 @@ import (
 )
 
 type Usecase interface {
-	Login(ctx context.Context, application, email, password, validToken string, conf *config.Config) (*UserToken, error)
+	Login(ctx context.Context, application, organization, email, password, validToken string, conf *config.Config) (*UserToken, error)
+	IssueToken(ctx context.Context, userID, appCode string, conf *config.Config) (*UserToken, error)
+	// ExchangeToken issues a new token for an authenticated user, carrying
+	// the authorization of another organization or application
+	ExchangeToken(ctx context.Context, userID, appCode, orgCode string, conf *config.Config) (*UserToken, error)
+	ResolvePermissions(ctx context.Context, userID, appCode, orgCode string) (*ResolvedPermissions, error)
 }
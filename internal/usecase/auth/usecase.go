@@ -4,15 +4,15 @@ import (
 	"context"
 	"crypto/rsa"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware"
-	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
-	"github.com/mafzaidi/authorizer/internal/delivery/http/middleware/pwd"
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
 	"github.com/mafzaidi/authorizer/internal/domain/repository"
 	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
 )
 
 // JWTService defines the interface for JWT infrastructure service
@@ -22,6 +22,11 @@ type JWTService interface {
 	ValidateToken(ctx context.Context, tokenString string, publicKey *rsa.PublicKey) (*entity.Claims, error)
 }
 
+// TokenHookInvoker defines the interface for calling pre-token-issuance hooks
+type TokenHookInvoker interface {
+	Invoke(ctx context.Context, hook *entity.TokenHook, req *entity.TokenHookRequest) (*entity.TokenHookResult, error)
+}
+
 type UserToken struct {
 	User         *entity.User
 	Token        string
@@ -29,26 +34,48 @@ type UserToken struct {
 	Claims       *middleware.JWTClaims
 }
 
+// permissionsCacheTTL bounds how long a resolved authorization is cached
+// for a given permissions version
+const permissionsCacheTTL = 10 * time.Minute
+
 type authUsecase struct {
 	authRepo    repository.AuthRepository
 	userRepo    repository.UserRepository
+	appRepo     repository.AppRepository
+	hookRepo    repository.TokenHookRepository
+	permCache   repository.PermissionCacheRepository
 	authService service.AuthService
 	jwtService  JWTService
+	hookInvoker TokenHookInvoker
+	verifiers   []service.CredentialVerifier
+	identitySvc service.IdentityService
 	logger      service.Logger
 }
 
 func NewAuthUseCase(
 	authRepo repository.AuthRepository,
 	userRepo repository.UserRepository,
+	appRepo repository.AppRepository,
+	hookRepo repository.TokenHookRepository,
+	permCache repository.PermissionCacheRepository,
 	authService service.AuthService,
 	jwtService JWTService,
+	hookInvoker TokenHookInvoker,
+	verifiers []service.CredentialVerifier,
+	identitySvc service.IdentityService,
 	logger service.Logger,
 ) Usecase {
 	return &authUsecase{
 		authRepo:    authRepo,
 		userRepo:    userRepo,
+		appRepo:     appRepo,
+		hookRepo:    hookRepo,
+		permCache:   permCache,
 		authService: authService,
 		jwtService:  jwtService,
+		hookInvoker: hookInvoker,
+		verifiers:   verifiers,
+		identitySvc: identitySvc,
 		logger:      logger,
 	}
 }
@@ -56,6 +83,7 @@ func NewAuthUseCase(
 func (uc *authUsecase) Login(
 	ctx context.Context,
 	appCode,
+	orgCode,
 	email,
 	password,
 	validToken string,
@@ -70,29 +98,20 @@ func (uc *authUsecase) Login(
 		return nil, errors.New("email cannot be empty")
 	}
 
-	// Get user by email
-	user, err := uc.userRepo.GetByEmail(ctx, email)
+	user, err := uc.authenticate(ctx, email, password)
 	if err != nil {
-		uc.logger.Warn("Login failed: user not found", service.Fields{
-			"email": email,
-		})
-		return nil, errors.New("email or password is invalid")
-	}
-
-	// Verify password
-	if !pwd.CheckHash(user.Password, password) {
-		uc.logger.Warn("Login failed: invalid password", service.Fields{
-			"email":   email,
-			"user_id": user.ID,
-		})
-		return nil, errors.New("email or password is invalid")
+		return nil, err
 	}
 
-	// Check if we can reuse existing valid token
-	if validToken != "" {
-		existingClaims, err := uc.jwtService.ValidateToken(ctx, validToken, cfg.JWT.PublicKey)
-		if err == nil && existingClaims.Subject == user.ID {
-			// Token is still valid and belongs to this user, reuse it
+	// Check if we can reuse existing valid token. Hooks may deny the login
+	// or change its claims, so a token is never reused for an application
+	// with active hooks.
+	if validToken != "" && !uc.hasActiveHooks(ctx, appCode) {
+		publicKey, _ := cfg.JWT.VerificationKey()
+		existingClaims, err := uc.jwtService.ValidateToken(ctx, validToken, publicKey)
+		if err == nil && existingClaims.Subject == user.ID && existingClaims.Organization == orgCode {
+			// Token is still valid and belongs to this user and
+			// organization, reuse it
 			uc.logger.Info("Reusing valid token", service.Fields{
 				"user_id": user.ID,
 				"email":   email,
@@ -110,9 +129,130 @@ func (uc *authUsecase) Login(
 		}
 	}
 
+	token, err := uc.issueToken(ctx, user, appCode, orgCode, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("User logged in successfully", service.Fields{
+		"user_id":  user.ID,
+		"email":    email,
+		"app_code": appCode,
+		"org_code": orgCode,
+	})
+
+	return token, nil
+}
+
+// authenticate tries each credential verifier in order and returns the
+// local user for the first one that accepts the password. Every failure
+// is reported with the same message so logins cannot be enumerated.
+func (uc *authUsecase) authenticate(ctx context.Context, email, password string) (*entity.User, error) {
+	invalid := errors.New("email or password is invalid")
+
+	for _, v := range uc.verifiers {
+		cred, err := v.Verify(ctx, email, password)
+		if err != nil {
+			if !errors.Is(err, service.ErrInvalidCredentials) {
+				uc.logger.Error("Credential verifier failed", service.Fields{
+					"verifier": v.Name(),
+					"error":    err.Error(),
+				})
+			}
+			continue
+		}
+
+		user := cred.User
+		if user == nil {
+			user, err = uc.identitySvc.ResolveUser(ctx, cred.Identity)
+			if err != nil {
+				uc.logger.Warn("Login failed: external identity not resolved", service.Fields{
+					"verifier": v.Name(),
+					"error":    err.Error(),
+				})
+				return nil, invalid
+			}
+		}
+
+		if len(cred.GroupRoleMappings) > 0 {
+			if err := uc.identitySvc.SyncGroupRoles(ctx, user.ID, cred.Groups, cred.GroupRoleMappings); err != nil {
+				uc.logger.Error("Failed to apply group role mappings", service.Fields{
+					"verifier": v.Name(),
+					"user_id":  user.ID,
+					"error":    err.Error(),
+				})
+			}
+		}
+
+		return user, nil
+	}
+
+	uc.logger.Warn("Login failed: invalid credentials", service.Fields{
+		"email": email,
+	})
+	return nil, invalid
+}
+
+// IssueToken issues an access and refresh token for an already
+// authenticated user, as done after a successful password login
+func (uc *authUsecase) IssueToken(ctx context.Context, userID, appCode string, cfg *config.Config) (*UserToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("user is not active")
+	}
+
+	return uc.issueToken(ctx, user, appCode, "", cfg)
+}
+
+// ExchangeToken issues a token for the organization and application the
+// user switches to. The caller must already be authenticated as userID.
+func (uc *authUsecase) ExchangeToken(ctx context.Context, userID, appCode, orgCode string, cfg *config.Config) (*UserToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("user is not active")
+	}
+
+	token, err := uc.issueToken(ctx, user, appCode, orgCode, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Token exchanged successfully", service.Fields{
+		"user_id":  user.ID,
+		"app_code": appCode,
+		"org_code": orgCode,
+	})
+
+	return token, nil
+}
+
+// issueToken builds the user's claims, runs token hooks, applies the
+// application's token mode and signs the access token
+func (uc *authUsecase) issueToken(ctx context.Context, user *entity.User, appCode, orgCode string, cfg *config.Config) (*UserToken, error) {
 	// Build claims using domain service
-	claims, err := uc.authService.BuildClaims(ctx, user, appCode)
+	claims, err := uc.authService.BuildClaims(ctx, user, appCode, orgCode)
 	if err != nil {
+		if errors.Is(err, service.ErrNotOrganizationMember) {
+			uc.logger.Warn("Token denied: not an organization member", service.Fields{
+				"user_id":  user.ID,
+				"org_code": orgCode,
+			})
+			return nil, err
+		}
 		uc.logger.Error("Failed to build claims", service.Fields{
 			"user_id":  user.ID,
 			"app_code": appCode,
@@ -121,8 +261,19 @@ func (uc *authUsecase) Login(
 		return nil, errors.New("failed to build authorization claims")
 	}
 
+	// Let configured hooks enrich the claims or deny the login
+	if err := uc.runTokenHooks(ctx, user, appCode, claims); err != nil {
+		return nil, err
+	}
+
+	// Stamp the permissions version and strip the token down for thin-mode apps
+	if err := uc.applyTokenMode(ctx, user, appCode, claims); err != nil {
+		return nil, err
+	}
+
 	// Generate access token using infrastructure service
-	accessToken, err := uc.jwtService.GenerateToken(ctx, claims, cfg.JWT.PrivateKey, cfg.JWT.KeyID)
+	privateKey, keyID := cfg.JWT.SigningKey()
+	accessToken, err := uc.jwtService.GenerateToken(ctx, claims, privateKey, keyID)
 	if err != nil {
 		uc.logger.Error("Failed to generate access token", service.Fields{
 			"user_id": user.ID,
@@ -151,12 +302,6 @@ func (uc *authUsecase) Login(
 		return nil, errors.New("failed saving refresh token")
 	}
 
-	uc.logger.Info("User logged in successfully", service.Fields{
-		"user_id":  user.ID,
-		"email":    email,
-		"app_code": appCode,
-	})
-
 	// Convert entity.Claims to middleware.JWTClaims for backward compatibility
 	middlewareClaims := convertToMiddlewareClaims(claims)
 
@@ -191,6 +336,197 @@ func (uc *authUsecase) RefreshToken(
 	return "", "", nil
 }
 
+// hasActiveHooks reports whether issuing a token for the application runs
+// any token hook. Lookup failures count as hooks, so the caller goes through
+// the full issuance path.
+func (uc *authUsecase) hasActiveHooks(ctx context.Context, appCode string) bool {
+	if uc.hookRepo == nil || uc.hookInvoker == nil || appCode == "" {
+		return false
+	}
+
+	app, err := uc.appRepo.GetByCode(ctx, appCode)
+	if err != nil {
+		return true
+	}
+
+	hooks, err := uc.hookRepo.ListActiveByApp(ctx, app.ID)
+	return err != nil || len(hooks) > 0
+}
+
+// runTokenHooks invokes the active token hooks of the application the token
+// is issued for; a token for no application runs none. Hooks run in
+// creation order; claims they return are merged into claims.Extra. A hook
+// denial, or a hook failure when the application is FAIL_CLOSED, aborts
+// the login.
+func (uc *authUsecase) runTokenHooks(ctx context.Context, user *entity.User, appCode string, claims *entity.Claims) error {
+	if uc.hookRepo == nil || uc.hookInvoker == nil || appCode == "" {
+		return nil
+	}
+
+	app, err := uc.appRepo.GetByCode(ctx, appCode)
+	if err != nil {
+		return errors.New("failed to build authorization claims")
+	}
+
+	hooks, err := uc.hookRepo.ListActiveByApp(ctx, app.ID)
+	if err != nil {
+		uc.logger.Error("Failed to load token hooks", service.Fields{
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		return errors.New("failed to load token hooks")
+	}
+
+	for _, hook := range hooks {
+		req := &entity.TokenHookRequest{
+			Event:       "pre_token_issuance",
+			Application: appCode,
+			UserID:      user.ID,
+			Username:    user.Username,
+			Email:       user.Email,
+			Claims:      claims,
+		}
+
+		result, err := uc.hookInvoker.Invoke(ctx, hook, req)
+		if err != nil {
+			if app.HookFailurePolicy == entity.HookFailOpen {
+				uc.logger.Warn("Token hook failed, continuing (fail-open)", service.Fields{
+					"user_id": user.ID,
+					"hook_id": hook.ID,
+					"error":   err.Error(),
+				})
+				continue
+			}
+			uc.logger.Error("Token hook failed, denying login (fail-closed)", service.Fields{
+				"user_id": user.ID,
+				"hook_id": hook.ID,
+				"error":   err.Error(),
+			})
+			return errors.New("login denied: token hook unavailable")
+		}
+
+		if !result.Allow {
+			uc.logger.Warn("Login denied by token hook", service.Fields{
+				"user_id": user.ID,
+				"hook_id": hook.ID,
+				"reason":  result.Reason,
+			})
+			if result.Reason == "" {
+				return errors.New("login denied")
+			}
+			return fmt.Errorf("login denied: %s", result.Reason)
+		}
+
+		if len(result.Claims) > 0 {
+			if claims.Extra == nil {
+				claims.Extra = make(map[string]interface{})
+			}
+			for k, v := range result.Claims {
+				claims.Extra[k] = v
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyTokenMode stamps the user's permissions version on the claims and,
+// when the requested application uses thin tokens, removes everything but
+// the registered claims, the permissions version and hook-provided claims.
+func (uc *authUsecase) applyTokenMode(ctx context.Context, user *entity.User, appCode string, claims *entity.Claims) error {
+	version, err := uc.permCache.GetVersion(ctx, user.ID)
+	if err != nil {
+		uc.logger.Error("Failed to get permissions version", service.Fields{
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		return errors.New("failed to build authorization claims")
+	}
+	claims.PermissionsVersion = version
+
+	if appCode == "" {
+		return nil
+	}
+
+	app, err := uc.appRepo.GetByCode(ctx, appCode)
+	if err != nil {
+		return errors.New("failed to build authorization claims")
+	}
+
+	if app.TokenMode == entity.TokenModeThin {
+		claims.Username = ""
+		claims.Email = ""
+		claims.Authorization = nil
+		claims.AppAdmin = nil
+	}
+
+	return nil
+}
+
+// ResolvePermissions returns the live authorization of a user for an
+// application (or every application when appCode is empty), including the
+// tenant roles of orgCode when given. Results are cached per permissions
+// version, so a version bump invalidates them.
+func (uc *authUsecase) ResolvePermissions(ctx context.Context, userID, appCode, orgCode string) (*ResolvedPermissions, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if userID == "" {
+		return nil, errors.New("userID is required")
+	}
+
+	version, err := uc.permCache.GetVersion(ctx, userID)
+	if err != nil {
+		uc.logger.Error("Failed to get permissions version", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return nil, errors.New("failed to resolve permissions")
+	}
+
+	if auths, err := uc.permCache.GetAuthorization(ctx, userID, appCode, orgCode, version); err == nil {
+		return &ResolvedPermissions{
+			UserID:        userID,
+			Version:       version,
+			Authorization: auths,
+		}, nil
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		uc.logger.Warn("Resolve permissions failed: user not found", service.Fields{
+			"user_id": userID,
+		})
+		return nil, errors.New("user not found")
+	}
+
+	claims, err := uc.authService.BuildClaims(ctx, user, appCode, orgCode)
+	if err != nil {
+		if errors.Is(err, service.ErrNotOrganizationMember) {
+			return nil, err
+		}
+		uc.logger.Error("Failed to build claims", service.Fields{
+			"user_id":  userID,
+			"app_code": appCode,
+			"error":    err.Error(),
+		})
+		return nil, errors.New("failed to resolve permissions")
+	}
+
+	if err := uc.permCache.SetAuthorization(ctx, userID, appCode, orgCode, version, claims.Authorization, permissionsCacheTTL); err != nil {
+		uc.logger.Warn("Failed to cache resolved permissions", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+	}
+
+	return &ResolvedPermissions{
+		UserID:        userID,
+		Version:       version,
+		Authorization: claims.Authorization,
+	}, nil
+}
+
 // generateRefreshToken generates a random refresh token
 // This is a simple implementation that can be enhanced with more security
 func (uc *authUsecase) generateRefreshToken() (string, error) {
@@ -213,6 +549,7 @@ func convertToMiddlewareClaims(claims *entity.Claims) *middleware.JWTClaims {
 			App:         auth.App,
 			Roles:       auth.Roles,
 			Permissions: auth.Permissions,
+			Conditions:  auth.Conditions,
 		})
 	}
 
@@ -224,9 +561,14 @@ func convertToMiddlewareClaims(claims *entity.Claims) *middleware.JWTClaims {
 			ExpiresAt: jwt.NewNumericDate(time.Unix(claims.ExpiresAt, 0)),
 			IssuedAt:  jwt.NewNumericDate(time.Unix(claims.IssuedAt, 0)),
 		},
-		UserID:        claims.Subject,
-		Username:      claims.Username,
-		Email:         claims.Email,
-		Authorization: middlewareAuth,
+		UserID:             claims.Subject,
+		Username:           claims.Username,
+		Email:              claims.Email,
+		PrincipalType:      claims.PrincipalType,
+		Authorization:      middlewareAuth,
+		Organization:       claims.Organization,
+		AppAdmin:           claims.AppAdmin,
+		PermissionsVersion: claims.PermissionsVersion,
+		Extra:              claims.Extra,
 	}
 }
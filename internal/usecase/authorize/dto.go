@@ -0,0 +1,53 @@
+package authorize
+
+type (
+	// CheckInput asks whether a subject holds a permission in an
+	// application. The subject is identified by UserID or by a Token the
+	// subject was issued (an access token or personal access token).
+	// Organization adds the tenant roles the subject holds in that
+	// organization; a token issued for an organization implies it.
+	CheckInput struct {
+		UserID       string
+		Token        string
+		Organization string
+		AppCode      string
+		Permission   string
+		Resource     string
+		// Context holds the request attributes conditional grants are
+		// evaluated against, e.g. {"request": {"ip": "10.0.0.1"}}
+		Context map[string]any
+	}
+
+	// BatchCheckInput asks several checks for one subject at once. Context
+	// applies to every check without its own.
+	BatchCheckInput struct {
+		UserID       string
+		Token        string
+		Organization string
+		Checks       []CheckItem
+		Context      map[string]any
+	}
+
+	// CheckItem is one (application, permission, resource) tuple of a
+	// batch check
+	CheckItem struct {
+		AppCode    string
+		Permission string
+		Resource   string
+		Context    map[string]any
+	}
+
+	// Decision is the answer to a check
+	Decision struct {
+		UserID       string
+		Organization string
+		AppCode      string
+		Permission   string
+		Resource     string
+		Allowed      bool
+		Reason       string
+		// Version is the subject's permissions version the decision was
+		// computed at
+		Version int64
+	}
+)
@@ -0,0 +1,9 @@
+package authorize
+
+import "context"
+
+type Usecase interface {
+	Check(ctx context.Context, input *CheckInput) (*Decision, error)
+	// BatchCheck answers the checks in input order
+	BatchCheck(ctx context.Context, input *BatchCheckInput) ([]*Decision, error)
+}
@@ -0,0 +1,454 @@
+package authorize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/auth"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/pkg/condition"
+	"github.com/mafzaidi/authorizer/pkg/permission"
+)
+
+// decisionCacheTTL bounds how long a decision is cached. Assignment and
+// grant changes bump the permissions version, which invalidates it sooner,
+// and decisions never outlive the subject's earliest assignment end.
+const decisionCacheTTL = 10 * time.Minute
+
+// maxBatchChecks bounds the checks of one batch request
+const maxBatchChecks = 200
+
+// ErrEvaluationFailed is returned when a decision could not be computed, as
+// opposed to a deny
+var ErrEvaluationFailed = errors.New("failed to evaluate authorization")
+
+// TokenAuthenticator verifies personal access tokens used as subjects
+type TokenAuthenticator interface {
+	Authenticate(ctx context.Context, rawToken string) (*entity.Claims, error)
+}
+
+type authorizeUsecase struct {
+	userRepo     repository.UserRepository
+	userRoleRepo repository.UserRoleRepository
+	permCache    repository.PermissionCacheRepository
+	jwtService   auth.JWTService
+	patAuth      TokenAuthenticator
+	jwt          *config.JWT
+	logger       service.Logger
+}
+
+func NewAuthorizeUsecase(
+	userRepo repository.UserRepository,
+	userRoleRepo repository.UserRoleRepository,
+	permCache repository.PermissionCacheRepository,
+	jwtService auth.JWTService,
+	patAuth TokenAuthenticator,
+	jwt *config.JWT,
+	logger service.Logger,
+) Usecase {
+	return &authorizeUsecase{
+		userRepo:     userRepo,
+		userRoleRepo: userRoleRepo,
+		permCache:    permCache,
+		jwtService:   jwtService,
+		patAuth:      patAuth,
+		jwt:          jwt,
+		logger:       logger,
+	}
+}
+
+// Check decides whether the subject holds the permission in the
+// application, from the subject's live role assignments. Decisions are
+// cached per permissions version, so assignment changes take effect on the
+// next check.
+func (uc *authorizeUsecase) Check(ctx context.Context, in *CheckInput) (*Decision, error) {
+	decisions, err := uc.BatchCheck(ctx, &BatchCheckInput{
+		UserID:       in.UserID,
+		Token:        in.Token,
+		Organization: in.Organization,
+		Checks: []CheckItem{{
+			AppCode:    in.AppCode,
+			Permission: in.Permission,
+			Resource:   in.Resource,
+			Context:    in.Context,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decisions[0], nil
+}
+
+// BatchCheck answers many checks for one subject. Cached decisions are
+// read in one round trip and the rest are evaluated from a single grants
+// query covering every application involved.
+func (uc *authorizeUsecase) BatchCheck(ctx context.Context, in *BatchCheckInput) ([]*Decision, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if len(in.Checks) == 0 {
+		return nil, errors.New("checks are required")
+	}
+	if len(in.Checks) > maxBatchChecks {
+		return nil, fmt.Errorf("at most %d checks are allowed", maxBatchChecks)
+	}
+	for _, c := range in.Checks {
+		if c.AppCode == "" || c.Permission == "" {
+			return nil, errors.New("application and permission are required")
+		}
+	}
+
+	userID, orgCode, tokenPerms, err := uc.resolveSubject(ctx, in.UserID, in.Token, in.Organization)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]*Decision, len(in.Checks))
+	for i, c := range in.Checks {
+		decisions[i] = &Decision{
+			UserID:       userID,
+			Organization: orgCode,
+			AppCode:      c.AppCode,
+			Permission:   c.Permission,
+			Resource:     c.Resource,
+		}
+	}
+
+	// The subject is looked up on every check so deactivation applies
+	// immediately, even while role decisions are cached
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return denyAll(decisions, "subject not found"), nil
+	}
+	if !user.IsActive {
+		return denyAll(decisions, "subject is inactive"), nil
+	}
+
+	version, err := uc.permCache.GetVersion(ctx, userID)
+	if err != nil {
+		uc.logger.Error("Failed to get permissions version", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return nil, ErrEvaluationFailed
+	}
+
+	keys := make([]string, len(in.Checks))
+	for i, c := range in.Checks {
+		keys[i] = decisionKey(orgCode, c.AppCode, c.Permission, c.Resource)
+	}
+
+	cached, err := uc.permCache.GetDecisions(ctx, userID, keys, version)
+	if err != nil {
+		uc.logger.Warn("Failed to read cached authorization decisions", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+	}
+
+	var missing []int
+	appSet := make(map[string]struct{})
+	for i, d := range decisions {
+		d.Version = version
+		if hit, ok := cached[keys[i]]; ok {
+			d.Allowed = hit.Allowed
+			d.Reason = hit.Reason
+			continue
+		}
+		missing = append(missing, i)
+		appSet[d.AppCode] = struct{}{}
+	}
+	if len(missing) == 0 {
+		return restrictToToken(decisions, tokenPerms), nil
+	}
+
+	appCodes := make([]string, 0, len(appSet))
+	for code := range appSet {
+		appCodes = append(appCodes, code)
+	}
+
+	grants, err := uc.userRoleRepo.GetGrantsByUser(ctx, userID, orgCode, appCodes)
+	if err != nil {
+		uc.logger.Error("Failed to evaluate authorization", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return nil, ErrEvaluationFailed
+	}
+
+	// Decisions that depended on a condition hold for this request only
+	// and are not cached
+	now := time.Now()
+	computed := make(map[string]*entity.AccessDecision, len(missing))
+	for _, i := range missing {
+		d := decisions[i]
+		c := in.Checks[i]
+		if c.Context == nil {
+			c.Context = in.Context
+		}
+		result, cacheable := evaluate(grants, d.AppCode, d.Permission, conditionVars(userID, c, now))
+		d.Allowed = result.Allowed
+		d.Reason = result.Reason
+		if cacheable {
+			computed[keys[i]] = result
+		}
+	}
+
+	ttl, err := uc.decisionTTL(ctx, userID, now)
+	if err != nil {
+		uc.logger.Warn("Failed to get assignments for decision caching", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return restrictToToken(decisions, tokenPerms), nil
+	}
+	if ttl <= 0 {
+		return restrictToToken(decisions, tokenPerms), nil
+	}
+
+	if err := uc.permCache.SetDecisions(ctx, userID, computed, version, ttl); err != nil {
+		uc.logger.Warn("Failed to cache authorization decisions", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+	}
+
+	return restrictToToken(decisions, tokenPerms), nil
+}
+
+// decisionTTL returns how long the user's decisions may be cached: the
+// cache TTL, capped at the end of the earliest assignment in effect
+func (uc *authorizeUsecase) decisionTTL(ctx context.Context, userID string, now time.Time) (time.Duration, error) {
+	assignments, err := uc.userRoleRepo.GetAssignmentsByUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	ttl := decisionCacheTTL
+	for _, a := range assignments {
+		if a.ValidUntil != nil && a.ValidUntil.Sub(now) < ttl {
+			ttl = a.ValidUntil.Sub(now)
+		}
+	}
+	return ttl, nil
+}
+
+// restrictToToken denies the decisions a personal access token does not
+// cover. tokenPerms maps applications to the token's permissions and is
+// nil when the subject is not restricted. Decisions are cached per user,
+// so the restriction is applied after the cache.
+func restrictToToken(decisions []*Decision, tokenPerms map[string][]string) []*Decision {
+	if tokenPerms == nil {
+		return decisions
+	}
+	for _, d := range decisions {
+		if d.Allowed && !permission.Any(tokenPerms[d.AppCode], d.Permission) {
+			d.Allowed = false
+			d.Reason = "personal access token does not grant " + d.Permission
+		}
+	}
+	return decisions
+}
+
+// evaluate decides a check from the subject's role grants. Global roles
+// grant only the permissions explicitly given to them, except for the
+// reserved superadmin permission. Conditional grants are evaluated against
+// vars only when no unconditional grant applies; cacheable is false when
+// the decision depended on them.
+func evaluate(grants []*entity.RoleGrant, appCode, perm string, vars map[string]any) (decision *entity.AccessDecision, cacheable bool) {
+	for _, g := range grants {
+		if isSuperadmin(g) {
+			return &entity.AccessDecision{
+				Allowed: true,
+				Reason:  "granted by superadmin role " + g.RoleCode,
+			}, true
+		}
+	}
+
+	hasRole := false
+	var conditional []*entity.RoleGrant
+	for _, g := range grants {
+		if g.AppCode != appCode {
+			continue
+		}
+		hasRole = true
+		if g.PermissionCode == "" || !permission.Match(g.PermissionCode, perm) {
+			continue
+		}
+		if g.Condition != "" {
+			conditional = append(conditional, g)
+			continue
+		}
+		return &entity.AccessDecision{
+			Allowed: true,
+			Reason:  grantReason(g),
+		}, true
+	}
+
+	if !hasRole {
+		return &entity.AccessDecision{
+			Reason: "subject has no roles in application " + appCode,
+		}, true
+	}
+	if len(conditional) == 0 {
+		return &entity.AccessDecision{
+			Reason: "no role grants " + perm,
+		}, true
+	}
+
+	var failure string
+	for _, g := range conditional {
+		ok, err := condition.Eval(g.Condition, vars)
+		if err != nil {
+			// A condition that cannot be evaluated denies
+			failure = err.Error()
+			continue
+		}
+		if ok {
+			return &entity.AccessDecision{
+				Allowed: true,
+				Reason:  grantReason(g) + " when " + g.ConditionID,
+			}, false
+		}
+	}
+
+	reason := "conditions for " + perm + " are not met"
+	if failure != "" {
+		reason += ": " + failure
+	}
+	return &entity.AccessDecision{Reason: reason}, false
+}
+
+// grantReason describes the role grant a permission is held through
+func grantReason(g *entity.RoleGrant) string {
+	kind := "role "
+	switch g.Scope {
+	case entity.RoleScopeGlobal:
+		kind = "global role "
+	case entity.RoleScopeTenant:
+		kind = "tenant role "
+	}
+	reason := "granted by " + kind + g.RoleCode
+	if g.Organization != "" {
+		reason += " in organization " + g.Organization
+	}
+	if g.Group != "" {
+		reason += " via group " + g.Group
+	}
+	if g.InheritedFrom != "" {
+		reason += " (inherited from " + g.InheritedFrom + ")"
+	}
+	return reason + " through " + g.PermissionCode
+}
+
+// conditionVars builds the attributes conditions are evaluated against:
+// the caller's context, plus subject.id and the current UTC time, which
+// the caller cannot override. The checked resource is resource.id unless
+// the context sets it.
+func conditionVars(userID string, c CheckItem, now time.Time) map[string]any {
+	vars := make(map[string]any, len(c.Context)+3)
+	for k, v := range c.Context {
+		vars[k] = v
+	}
+
+	if c.Resource != "" {
+		resource := make(map[string]any)
+		if given, ok := vars["resource"].(map[string]any); ok {
+			for k, v := range given {
+				resource[k] = v
+			}
+		}
+		if _, ok := resource["id"]; !ok {
+			resource["id"] = c.Resource
+		}
+		vars["resource"] = resource
+	}
+
+	now = now.UTC()
+	vars["subject"] = map[string]any{"id": userID}
+	vars["time"] = map[string]any{
+		"hour":    now.Hour(),
+		"minute":  now.Minute(),
+		"weekday": int(now.Weekday()),
+		"unix":    now.Unix(),
+	}
+	return vars
+}
+
+// isSuperadmin reports whether the grant is the reserved superadmin
+// permission held through a global role
+func isSuperadmin(g *entity.RoleGrant) bool {
+	return g.Scope == entity.RoleScopeGlobal &&
+		g.AppCode == entity.AuthorizerAppCode &&
+		g.PermissionCode == entity.SuperadminPermission &&
+		g.Condition == ""
+}
+
+func denyAll(decisions []*Decision, reason string) []*Decision {
+	for _, d := range decisions {
+		d.Reason = reason
+	}
+	return decisions
+}
+
+// resolveSubject returns the user ID of the subject and the organization
+// the check is for, verifying the token when the subject is given as one.
+// A token issued for an organization is only checked within it. For a
+// personal access token it also returns the token's permissions by
+// application, which bound what its owner's grants allow.
+func (uc *authorizeUsecase) resolveSubject(ctx context.Context, userID, token, orgCode string) (string, string, map[string][]string, error) {
+	switch {
+	case userID != "" && token != "":
+		return "", "", nil, errors.New("subject must be either a user ID or a token")
+	case userID != "":
+		return userID, orgCode, nil, nil
+	case token == "":
+		return "", "", nil, errors.New("subject is required")
+	}
+
+	var (
+		claims *entity.Claims
+		err    error
+	)
+	isPAT := strings.HasPrefix(token, entity.PersonalAccessTokenPrefix) && uc.patAuth != nil
+	if isPAT {
+		claims, err = uc.patAuth.Authenticate(ctx, token)
+	} else {
+		publicKey, _ := uc.jwt.VerificationKey()
+		claims, err = uc.jwtService.ValidateToken(ctx, token, publicKey)
+	}
+	if err != nil {
+		return "", "", nil, errors.New("invalid subject token")
+	}
+	if claims.PrincipalType != "" && claims.PrincipalType != entity.PrincipalTypeUser {
+		return "", "", nil, errors.New("subject must be a user")
+	}
+	if claims.Organization != "" {
+		if orgCode != "" && orgCode != claims.Organization {
+			return "", "", nil, errors.New("organization does not match the subject token")
+		}
+		orgCode = claims.Organization
+	}
+
+	var tokenPerms map[string][]string
+	if isPAT {
+		tokenPerms = make(map[string][]string, len(claims.Authorization))
+		for _, a := range claims.Authorization {
+			tokenPerms[a.App] = append(tokenPerms[a.App], a.Permissions...)
+		}
+	}
+
+	return claims.Subject, orgCode, tokenPerms, nil
+}
+
+// decisionKey identifies a check within a user's cached decisions
+func decisionKey(orgCode, appCode, perm, resource string) string {
+	return url.PathEscape(orgCode) + ":" + url.PathEscape(appCode) + ":" + url.PathEscape(perm) + ":" + url.PathEscape(resource)
+}
@@ -0,0 +1,161 @@
+package authorize
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubUserRepo struct {
+	repository.UserRepository
+}
+
+func (stubUserRepo) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	return &entity.User{ID: id, IsActive: true}, nil
+}
+
+type stubUserRoleRepo struct {
+	repository.UserRoleRepository
+	grants      []*entity.RoleGrant
+	assignments []*entity.UserRole
+}
+
+func (r stubUserRoleRepo) GetGrantsByUser(ctx context.Context, userID, orgCode string, appCodes []string) ([]*entity.RoleGrant, error) {
+	return r.grants, nil
+}
+
+func (r stubUserRoleRepo) GetAssignmentsByUser(ctx context.Context, userID string) ([]*entity.UserRole, error) {
+	return r.assignments, nil
+}
+
+// stubPermCache caches nothing
+type stubPermCache struct {
+	repository.PermissionCacheRepository
+}
+
+func (stubPermCache) GetVersion(ctx context.Context, userID string) (int64, error) {
+	return 1, nil
+}
+
+func (stubPermCache) GetDecisions(ctx context.Context, userID string, keys []string, version int64) (map[string]*entity.AccessDecision, error) {
+	return nil, nil
+}
+
+func (stubPermCache) SetDecisions(ctx context.Context, userID string, decisions map[string]*entity.AccessDecision, version int64, ttl time.Duration) error {
+	return nil
+}
+
+// recordingPermCache records the TTL decisions are cached with
+type recordingPermCache struct {
+	stubPermCache
+	ttl *time.Duration
+}
+
+func (c recordingPermCache) SetDecisions(ctx context.Context, userID string, decisions map[string]*entity.AccessDecision, version int64, ttl time.Duration) error {
+	*c.ttl = ttl
+	return nil
+}
+
+type stubTokenAuthenticator struct {
+	claims *entity.Claims
+}
+
+func (a stubTokenAuthenticator) Authenticate(ctx context.Context, rawToken string) (*entity.Claims, error) {
+	return a.claims, nil
+}
+
+func TestBatchCheck_PersonalAccessTokenSubset(t *testing.T) {
+	grants := []*entity.RoleGrant{
+		{RoleCode: "editor", AppCode: "APP1", PermissionCode: "doc.read"},
+		{RoleCode: "editor", AppCode: "APP1", PermissionCode: "doc.write"},
+	}
+	pat := stubTokenAuthenticator{claims: &entity.Claims{
+		Subject:       "user-123",
+		PrincipalType: entity.PrincipalTypeUser,
+		Authorization: []entity.Authorization{{App: "APP1", Permissions: []string{"doc.read"}}},
+	}}
+	uc := NewAuthorizeUsecase(stubUserRepo{}, stubUserRoleRepo{grants: grants}, stubPermCache{}, nil, pat, nil, logger.New())
+
+	checks := []CheckItem{
+		{AppCode: "APP1", Permission: "doc.read"},
+		{AppCode: "APP1", Permission: "doc.write"},
+	}
+
+	// The token only covers doc.read, although its owner holds doc.write
+	decisions, err := uc.BatchCheck(context.Background(), &BatchCheckInput{
+		Token:  entity.PersonalAccessTokenPrefix + "token",
+		Checks: checks,
+	})
+	require.NoError(t, err)
+	assert.True(t, decisions[0].Allowed)
+	assert.False(t, decisions[1].Allowed)
+	assert.Contains(t, decisions[1].Reason, "personal access token")
+
+	// Checked as the owner, both are granted
+	decisions, err = uc.BatchCheck(context.Background(), &BatchCheckInput{
+		UserID: "user-123",
+		Checks: checks,
+	})
+	require.NoError(t, err)
+	assert.True(t, decisions[0].Allowed)
+	assert.True(t, decisions[1].Allowed)
+}
+
+func TestBatchCheck_DecisionTTLCappedAtAssignmentEnd(t *testing.T) {
+	grants := []*entity.RoleGrant{
+		{RoleCode: "editor", AppCode: "APP1", PermissionCode: "doc.read"},
+	}
+	soon := time.Now().Add(2 * time.Minute)
+	later := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		assignments []*entity.UserRole
+		wantMax     time.Duration
+		wantMin     time.Duration
+	}{
+		{
+			name:    "no assignments",
+			wantMax: decisionCacheTTL,
+			wantMin: decisionCacheTTL,
+		},
+		{
+			name:        "unbounded assignment",
+			assignments: []*entity.UserRole{{RoleID: "r1"}},
+			wantMax:     decisionCacheTTL,
+			wantMin:     decisionCacheTTL,
+		},
+		{
+			name: "earliest end wins",
+			assignments: []*entity.UserRole{
+				{RoleID: "r1", ValidUntil: &later},
+				{RoleID: "r2", ValidUntil: &soon},
+			},
+			wantMax: 2 * time.Minute,
+			wantMin: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ttl time.Duration
+			repo := stubUserRoleRepo{grants: grants, assignments: tt.assignments}
+			uc := NewAuthorizeUsecase(stubUserRepo{}, repo, recordingPermCache{ttl: &ttl}, nil, nil, nil, logger.New())
+
+			decisions, err := uc.BatchCheck(context.Background(), &BatchCheckInput{
+				UserID: "user-123",
+				Checks: []CheckItem{{AppCode: "APP1", Permission: "doc.read"}},
+			})
+			require.NoError(t, err)
+			assert.True(t, decisions[0].Allowed)
+			assert.LessOrEqual(t, ttl, tt.wantMax)
+			assert.GreaterOrEqual(t, ttl, tt.wantMin)
+		})
+	}
+}
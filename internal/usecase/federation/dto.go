@@ -0,0 +1,36 @@
+package federation
+
+import "github.com/mafzaidi/authorizer/internal/usecase/auth"
+
+type (
+	StartInput struct {
+		Provider string
+		AppCode  string
+		// Mode is the login mode ("bearer" or "cookie") the callback
+		// should use when delivering the token
+		Mode string
+	}
+
+	// LoginStart is where the browser is sent to log in. Binding must be
+	// kept by the browser that started the login and presented with the
+	// callback.
+	LoginStart struct {
+		RedirectURL string
+		Binding     string
+	}
+
+	CallbackInput struct {
+		Provider string
+		State    string
+		Code     string
+		// Binding is the value returned with LoginStart, read back from
+		// the browser
+		Binding string
+	}
+
+	// LoginResult is the outcome of a completed federated login
+	LoginResult struct {
+		Token *auth.UserToken
+		Mode  string
+	}
+)
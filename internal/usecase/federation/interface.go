@@ -0,0 +1,20 @@
+package federation
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+)
+
+// IdentityProvider is an upstream OIDC provider
+type IdentityProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Identity(ctx context.Context, code, codeVerifier, nonce string) (*entity.ExternalIdentity, error)
+}
+
+type Usecase interface {
+	StartLogin(ctx context.Context, input *StartInput) (*LoginStart, error)
+	Callback(ctx context.Context, input *CallbackInput, conf *config.Config) (*LoginResult, error)
+}
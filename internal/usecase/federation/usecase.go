@@ -0,0 +1,202 @@
+package federation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/auth"
+)
+
+const stateTTL = 10 * time.Minute
+
+type federationUsecase struct {
+	providers   map[string]IdentityProvider
+	stateRepo   repository.FederationStateRepository
+	identitySvc service.IdentityService
+	appRepo     repository.AppRepository
+	authUC      auth.Usecase
+	logger      service.Logger
+}
+
+func NewFederationUsecase(
+	providers []IdentityProvider,
+	stateRepo repository.FederationStateRepository,
+	identitySvc service.IdentityService,
+	appRepo repository.AppRepository,
+	authUC auth.Usecase,
+	logger service.Logger,
+) Usecase {
+	byName := make(map[string]IdentityProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+
+	return &federationUsecase{
+		providers:   byName,
+		stateRepo:   stateRepo,
+		identitySvc: identitySvc,
+		appRepo:     appRepo,
+		authUC:      authUC,
+		logger:      logger,
+	}
+}
+
+// StartLogin stores the login state and returns the provider URL the
+// browser is redirected to, with the binding that ties the state to that
+// browser
+func (uc *federationUsecase) StartLogin(ctx context.Context, in *StartInput) (*LoginStart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	p, ok := uc.providers[in.Provider]
+	if !ok {
+		return nil, errors.New("unknown identity provider")
+	}
+
+	if in.AppCode != "" {
+		if _, err := uc.appRepo.GetByCode(ctx, in.AppCode); err != nil {
+			return nil, errors.New("application not found")
+		}
+	}
+
+	state, err := randomString(32)
+	if err != nil {
+		return nil, errors.New("failed to start login")
+	}
+	nonce, err := randomString(32)
+	if err != nil {
+		return nil, errors.New("failed to start login")
+	}
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, errors.New("failed to start login")
+	}
+
+	st := &entity.FederationState{
+		Provider:     in.Provider,
+		AppCode:      in.AppCode,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		Mode:         in.Mode,
+		CreatedAt:    time.Now(),
+	}
+	if err := uc.stateRepo.Save(ctx, state, st, stateTTL); err != nil {
+		uc.logger.Error("Failed to store federation state", service.Fields{
+			"provider": in.Provider,
+			"error":    err.Error(),
+		})
+		return nil, errors.New("failed to start login")
+	}
+
+	redirectURL, err := p.AuthCodeURL(ctx, state, nonce, codeChallenge(verifier))
+	if err != nil {
+		uc.logger.Error("Failed to build provider authorization URL", service.Fields{
+			"provider": in.Provider,
+			"error":    err.Error(),
+		})
+		return nil, errors.New("identity provider is unavailable")
+	}
+
+	return &LoginStart{
+		RedirectURL: redirectURL,
+		Binding:     stateBinding(state),
+	}, nil
+}
+
+// Callback completes a federated login: it verifies the provider's ID
+// token, resolves or provisions the local user and issues a token through
+// the regular claims path
+func (uc *federationUsecase) Callback(ctx context.Context, in *CallbackInput, cfg *config.Config) (*LoginResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if in.State == "" || in.Code == "" {
+		return nil, errors.New("state and code are required")
+	}
+
+	// The state must come back to the browser that started the login;
+	// otherwise a victim could be logged in as whoever started it
+	if subtle.ConstantTimeCompare([]byte(in.Binding), []byte(stateBinding(in.State))) != 1 {
+		uc.logger.Warn("Federated login failed: state not bound to this browser", service.Fields{
+			"provider": in.Provider,
+		})
+		return nil, errors.New("invalid or expired login state")
+	}
+
+	st, err := uc.stateRepo.Take(ctx, in.State)
+	if err != nil || st.Provider != in.Provider {
+		uc.logger.Warn("Federated login failed: invalid state", service.Fields{
+			"provider": in.Provider,
+		})
+		return nil, errors.New("invalid or expired login state")
+	}
+
+	p, ok := uc.providers[st.Provider]
+	if !ok {
+		return nil, errors.New("unknown identity provider")
+	}
+
+	identity, err := p.Identity(ctx, in.Code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		uc.logger.Warn("Federated login failed: identity verification error", service.Fields{
+			"provider": st.Provider,
+			"error":    err.Error(),
+		})
+		return nil, errors.New("failed to verify identity")
+	}
+
+	user, err := uc.identitySvc.ResolveUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("user is not active")
+	}
+
+	token, err := uc.authUC.IssueToken(ctx, user.ID, st.AppCode, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Federated login succeeded", service.Fields{
+		"provider": st.Provider,
+		"user_id":  user.ID,
+		"app_code": st.AppCode,
+	})
+
+	return &LoginResult{
+		Token: token,
+		Mode:  st.Mode,
+	}, nil
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// stateBinding derives the value binding a login state to the browser
+// that started the login; the state itself is not stored in the browser
+func stateBinding(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// codeChallenge derives the S256 PKCE challenge from a verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
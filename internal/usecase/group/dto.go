@@ -0,0 +1,9 @@
+package group
+
+type (
+	CreateInput struct {
+		Code        string
+		Name        string
+		Description *string
+	}
+)
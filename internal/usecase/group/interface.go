@@ -0,0 +1,22 @@
+package group
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type Usecase interface {
+	Create(ctx context.Context, input *CreateInput) (*entity.Group, error)
+	Delete(ctx context.Context, id string) error
+	GetDetail(ctx context.Context, id string) (*entity.Group, error)
+	GetList(ctx context.Context, limit, offset int) ([]*entity.Group, error)
+	AddMember(ctx context.Context, groupID, userID string) error
+	RemoveMember(ctx context.Context, groupID, userID string) error
+	ListMembers(ctx context.Context, groupID string) ([]*entity.GroupMember, error)
+	AddSubgroup(ctx context.Context, groupID, subgroupID string) error
+	RemoveSubgroup(ctx context.Context, groupID, subgroupID string) error
+	ListSubgroups(ctx context.Context, groupID string) ([]*entity.Group, error)
+	AssignRoles(ctx context.Context, groupID, appID string, roles []string) error
+	GetRoles(ctx context.Context, groupID string) ([]*entity.Role, error)
+}
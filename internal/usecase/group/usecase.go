@@ -0,0 +1,498 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/pkg/idgen"
+)
+
+// ErrNestingCycle is returned when adding a subgroup would make a group
+// contain itself
+var ErrNestingCycle = errors.New("group nesting would contain a cycle")
+
+type groupUsecase struct {
+	groupRepo  repository.GroupRepository
+	userRepo   repository.UserRepository
+	roleRepo   repository.RoleRepository
+	permCache  repository.PermissionCacheRepository
+	transactor repository.Transactor
+	sodSvc     service.SoDService
+	logger     service.Logger
+}
+
+func NewGroupUsecase(
+	groupRepo repository.GroupRepository,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	permCache repository.PermissionCacheRepository,
+	transactor repository.Transactor,
+	sodSvc service.SoDService,
+	logger service.Logger,
+) Usecase {
+	return &groupUsecase{
+		groupRepo:  groupRepo,
+		userRepo:   userRepo,
+		roleRepo:   roleRepo,
+		permCache:  permCache,
+		transactor: transactor,
+		sodSvc:     sodSvc,
+		logger:     logger,
+	}
+}
+
+func (uc *groupUsecase) Create(ctx context.Context, in *CreateInput) (*entity.Group, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.Code == "" || in.Name == "" {
+		return nil, errors.New("code and name is required")
+	}
+
+	if existing, _ := uc.groupRepo.GetByCode(ctx, in.Code); existing != nil {
+		uc.logger.Warn("Group creation failed: group already exists", service.Fields{
+			"code": in.Code,
+		})
+		return nil, errors.New("group already exists")
+	}
+
+	group := &entity.Group{
+		ID:          idgen.NewUUIDv7(),
+		Code:        in.Code,
+		Name:        in.Name,
+		Description: in.Description,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := uc.groupRepo.Create(ctx, group); err != nil {
+		uc.logger.Error("Failed to create group", service.Fields{
+			"code":  in.Code,
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Group created successfully", service.Fields{
+		"group_id": group.ID,
+		"code":     group.Code,
+	})
+
+	return group, nil
+}
+
+// Delete removes the group. Its members lose the roles they held through
+// it, and subgroups are detached.
+func (uc *groupUsecase) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	group, err := uc.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return errors.New("group not found")
+	}
+
+	// Members are resolved before the nesting is gone
+	members := uc.effectiveMembers(ctx, group.ID)
+
+	if err := uc.groupRepo.Delete(ctx, group.ID); err != nil {
+		uc.logger.Error("Failed to delete group", service.Fields{
+			"group_id": group.ID,
+			"error":    err.Error(),
+		})
+		return err
+	}
+
+	uc.bumpVersion(ctx, members)
+
+	uc.logger.Info("Group deleted successfully", service.Fields{
+		"group_id": group.ID,
+		"code":     group.Code,
+	})
+
+	return nil
+}
+
+func (uc *groupUsecase) GetDetail(ctx context.Context, id string) (*entity.Group, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	group, err := uc.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+func (uc *groupUsecase) GetList(ctx context.Context, limit, offset int) ([]*entity.Group, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	groups, err := uc.groupRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (uc *groupUsecase) AddMember(ctx context.Context, groupID, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if userID == "" {
+		return errors.New("userID is required")
+	}
+
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return errors.New("group not found")
+	}
+
+	if _, err := uc.userRepo.GetByID(ctx, userID); err != nil {
+		return errors.New("user not found")
+	}
+
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.sodSvc.LockGroups(ctx); err != nil {
+			return err
+		}
+		if err := uc.checkConferredRoles(ctx, []string{userID}, group.ID); err != nil {
+			return err
+		}
+
+		err := uc.groupRepo.AddMember(ctx, group.ID, userID)
+		if err != nil {
+			uc.logger.Error("Failed to add group member", service.Fields{
+				"group_id": group.ID,
+				"user_id":  userID,
+				"error":    err.Error(),
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.bumpVersion(ctx, []string{userID})
+
+	uc.logger.Info("Group member added", service.Fields{
+		"group_id": group.ID,
+		"user_id":  userID,
+	})
+
+	return nil
+}
+
+func (uc *groupUsecase) RemoveMember(ctx context.Context, groupID, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return errors.New("group not found")
+	}
+
+	if err := uc.groupRepo.RemoveMember(ctx, group.ID, userID); err != nil {
+		uc.logger.Error("Failed to remove group member", service.Fields{
+			"group_id": group.ID,
+			"user_id":  userID,
+			"error":    err.Error(),
+		})
+		return err
+	}
+
+	uc.bumpVersion(ctx, []string{userID})
+
+	uc.logger.Info("Group member removed", service.Fields{
+		"group_id": group.ID,
+		"user_id":  userID,
+	})
+
+	return nil
+}
+
+func (uc *groupUsecase) ListMembers(ctx context.Context, groupID string) ([]*entity.GroupMember, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+
+	members, err := uc.groupRepo.ListMembers(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %w", err)
+	}
+	return members, nil
+}
+
+// AddSubgroup nests a group in another, making its members members of the
+// containing group as well
+func (uc *groupUsecase) AddSubgroup(ctx context.Context, groupID, subgroupID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if subgroupID == "" {
+		return errors.New("subgroupID is required")
+	}
+	if subgroupID == groupID {
+		return errors.New("a group cannot contain itself")
+	}
+
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return errors.New("group not found")
+	}
+	subgroup, err := uc.groupRepo.GetByID(ctx, subgroupID)
+	if err != nil {
+		return fmt.Errorf("subgroup %s not found", subgroupID)
+	}
+
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.sodSvc.LockGroups(ctx); err != nil {
+			return err
+		}
+
+		// The subgroup's members gain the roles the group confers
+		members, err := uc.groupRepo.GetEffectiveMemberIDs(ctx, subgroup.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch members: %w", err)
+		}
+		if err := uc.checkConferredRoles(ctx, members, group.ID); err != nil {
+			return err
+		}
+
+		err = uc.groupRepo.AddSubgroup(ctx, group.ID, subgroup.ID)
+		if errors.Is(err, repository.ErrGroupCycle) {
+			uc.logger.Warn("Add subgroup failed: cycle", service.Fields{
+				"group_id":    group.ID,
+				"subgroup_id": subgroup.ID,
+			})
+			return ErrNestingCycle
+		}
+		if err != nil {
+			uc.logger.Error("Failed to add subgroup", service.Fields{
+				"group_id":    group.ID,
+				"subgroup_id": subgroup.ID,
+				"error":       err.Error(),
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.bumpVersion(ctx, uc.effectiveMembers(ctx, subgroup.ID))
+
+	uc.logger.Info("Subgroup added", service.Fields{
+		"group_id":    group.ID,
+		"subgroup_id": subgroup.ID,
+	})
+
+	return nil
+}
+
+func (uc *groupUsecase) RemoveSubgroup(ctx context.Context, groupID, subgroupID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return errors.New("group not found")
+	}
+
+	if err := uc.groupRepo.RemoveSubgroup(ctx, group.ID, subgroupID); err != nil {
+		uc.logger.Error("Failed to remove subgroup", service.Fields{
+			"group_id":    group.ID,
+			"subgroup_id": subgroupID,
+			"error":       err.Error(),
+		})
+		return err
+	}
+
+	uc.bumpVersion(ctx, uc.effectiveMembers(ctx, subgroupID))
+
+	uc.logger.Info("Subgroup removed", service.Fields{
+		"group_id":    group.ID,
+		"subgroup_id": subgroupID,
+	})
+
+	return nil
+}
+
+func (uc *groupUsecase) ListSubgroups(ctx context.Context, groupID string) ([]*entity.Group, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+
+	groups, err := uc.groupRepo.ListSubgroups(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subgroups: %w", err)
+	}
+	return groups, nil
+}
+
+// AssignRoles replaces the group's roles in one application. An empty list
+// removes them. Every member of the group or of a subgroup holds them.
+func (uc *groupUsecase) AssignRoles(ctx context.Context, groupID, appID string, roles []string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if appID == "" {
+		return errors.New("appID is required")
+	}
+
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return errors.New("group not found")
+	}
+
+	var roleIDs []string
+	for _, code := range roles {
+		role, err := uc.roleRepo.GetByAppAndCode(ctx, appID, code)
+		if err != nil {
+			return fmt.Errorf("role %s not found", code)
+		}
+		if role.IsTenant() {
+			return fmt.Errorf("role %s is a tenant role and can only be assigned within an organization", role.Code)
+		}
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	var members []string
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.sodSvc.LockGroups(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		members, err = uc.groupRepo.GetEffectiveMemberIDs(ctx, group.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch members: %w", err)
+		}
+		if err := uc.sodSvc.CheckUsers(ctx, members, &service.RoleChange{
+			AppID:   appID,
+			Replace: &entity.RoleSource{Kind: entity.RoleSourceGroup, ID: group.ID},
+			RoleIDs: roleIDs,
+		}); err != nil {
+			return err
+		}
+
+		err = uc.groupRepo.ReplaceRoles(ctx, group.ID, appID, roleIDs)
+		if err != nil {
+			uc.logger.Error("Failed to assign group roles", service.Fields{
+				"group_id": group.ID,
+				"app_id":   appID,
+				"roles":    roles,
+				"error":    err.Error(),
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.bumpVersion(ctx, members)
+
+	uc.logger.Info("Group roles assigned successfully", service.Fields{
+		"group_id": group.ID,
+		"app_id":   appID,
+		"roles":    roles,
+	})
+
+	return nil
+}
+
+func (uc *groupUsecase) GetRoles(ctx context.Context, groupID string) ([]*entity.Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+
+	roles, err := uc.groupRepo.GetRoles(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	return roles, nil
+}
+
+// checkConferredRoles checks that the users may hold the roles the group
+// confers in addition to the roles they already hold
+func (uc *groupUsecase) checkConferredRoles(ctx context.Context, userIDs []string, groupID string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	roles, err := uc.groupRepo.GetConferredRoles(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch group roles: %w", err)
+	}
+
+	byApp := make(map[string][]string)
+	var appIDs []string
+	for _, r := range roles {
+		// Constraints only cover application roles
+		if r.ApplicationID == nil {
+			continue
+		}
+		if _, ok := byApp[*r.ApplicationID]; !ok {
+			appIDs = append(appIDs, *r.ApplicationID)
+		}
+		byApp[*r.ApplicationID] = append(byApp[*r.ApplicationID], r.ID)
+	}
+
+	// Applications are checked in order, as each check locks its own
+	sort.Strings(appIDs)
+	for _, appID := range appIDs {
+		if err := uc.sodSvc.CheckUsers(ctx, userIDs, &service.RoleChange{
+			AppID:   appID,
+			RoleIDs: byApp[appID],
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// effectiveMembers returns the users whose roles depend on the group,
+// its members and the members of its subgroups
+func (uc *groupUsecase) effectiveMembers(ctx context.Context, groupID string) []string {
+	userIDs, err := uc.groupRepo.GetEffectiveMemberIDs(ctx, groupID)
+	if err != nil {
+		uc.logger.Warn("Failed to get group members", service.Fields{
+			"group_id": groupID,
+			"error":    err.Error(),
+		})
+	}
+	return userIDs
+}
+
+// bumpVersion invalidates cached permissions so thin-token consumers and
+// the check API see the change
+func (uc *groupUsecase) bumpVersion(ctx context.Context, userIDs []string) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if err := uc.permCache.BumpVersion(ctx, userIDs); err != nil {
+		uc.logger.Warn("Failed to bump permissions version", service.Fields{
+			"user_ids": userIDs,
+			"error":    err.Error(),
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package magiclink
+
+type (
+	RequestInput struct {
+		Email   string
+		AppCode string
+	}
+
+	ConsumeInput struct {
+		Token   string
+		AppCode string
+	}
+)
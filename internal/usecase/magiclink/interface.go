@@ -0,0 +1,13 @@
+package magiclink
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/auth"
+)
+
+type Usecase interface {
+	RequestLink(ctx context.Context, input *RequestInput, conf *config.Config) error
+	Consume(ctx context.Context, input *ConsumeInput, conf *config.Config) (*auth.UserToken, error)
+}
@@ -0,0 +1,223 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/auth"
+)
+
+// mailTimeout bounds sending the link email, which happens after the
+// request has been answered
+const mailTimeout = 30 * time.Second
+
+type magicLinkUsecase struct {
+	linkRepo      repository.MagicLinkRepository
+	userRepo      repository.UserRepository
+	appRepo       repository.AppRepository
+	rateLimitRepo repository.RateLimitRepository
+	mailer        service.Mailer
+	authUC        auth.Usecase
+	logger        service.Logger
+}
+
+func NewMagicLinkUsecase(
+	linkRepo repository.MagicLinkRepository,
+	userRepo repository.UserRepository,
+	appRepo repository.AppRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	mailer service.Mailer,
+	authUC auth.Usecase,
+	logger service.Logger,
+) Usecase {
+	return &magicLinkUsecase{
+		linkRepo:      linkRepo,
+		userRepo:      userRepo,
+		appRepo:       appRepo,
+		rateLimitRepo: rateLimitRepo,
+		mailer:        mailer,
+		authUC:        authUC,
+		logger:        logger,
+	}
+}
+
+// RequestLink emails a single-use login link bound to the application.
+// Unknown, inactive and rate limited emails get the same nil result as a
+// sent link so accounts cannot be enumerated.
+func (uc *magicLinkUsecase) RequestLink(ctx context.Context, in *RequestInput, cfg *config.Config) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.Email == "" {
+		return errors.New("email cannot be empty")
+	}
+	if in.AppCode == "" {
+		return errors.New("application cannot be empty")
+	}
+
+	if _, err := uc.appRepo.GetByCode(ctx, in.AppCode); err != nil {
+		return errors.New("application not found")
+	}
+
+	// Bound the links sent to one address, independent of the client
+	rl := rateLimit(cfg)
+	count, err := uc.rateLimitRepo.Hit(ctx, "magiclink:"+strings.ToLower(in.Email), rl.LoginWindow)
+	if err != nil {
+		uc.logger.Error("Failed to check magic link rate limit", service.Fields{
+			"error": err.Error(),
+		})
+		return errors.New("failed to send login link")
+	}
+	if count > int64(rl.LoginAttempts) {
+		uc.logger.Warn("Magic link request rate limited", service.Fields{
+			"email": in.Email,
+		})
+		return nil
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, in.Email)
+	if err != nil || !user.IsActive {
+		uc.logger.Warn("Magic link requested for unknown or inactive user", service.Fields{
+			"email": in.Email,
+		})
+		return nil
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return errors.New("failed to send login link")
+	}
+
+	settings := magicLinkSettings(cfg)
+	link := &entity.MagicLink{
+		UserID:    user.ID,
+		AppCode:   in.AppCode,
+		CreatedAt: time.Now(),
+	}
+	if err := uc.linkRepo.Save(ctx, hashToken(token), link, settings.Expiry); err != nil {
+		uc.logger.Error("Failed to store magic link", service.Fields{
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		return errors.New("failed to send login link")
+	}
+
+	loginURL, err := buildLoginURL(settings.URL, token, in.AppCode)
+	if err != nil {
+		uc.logger.Error("Invalid magic link URL", service.Fields{
+			"error": err.Error(),
+		})
+		return errors.New("failed to send login link")
+	}
+
+	// Send after responding so the response time does not reveal whether
+	// the email belongs to a user
+	go uc.send(user, loginURL, settings.Expiry)
+
+	uc.logger.Info("Magic link issued", service.Fields{
+		"user_id":  user.ID,
+		"app_code": in.AppCode,
+	})
+
+	return nil
+}
+
+// Consume exchanges a magic link token for an access and refresh token.
+// The token is deleted on first use, whether or not the login succeeds.
+func (uc *magicLinkUsecase) Consume(ctx context.Context, in *ConsumeInput, cfg *config.Config) (*auth.UserToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.Token == "" || in.AppCode == "" {
+		return nil, errors.New("token and application are required")
+	}
+
+	link, err := uc.linkRepo.Take(ctx, hashToken(in.Token))
+	if err != nil || link.AppCode != in.AppCode {
+		uc.logger.Warn("Magic link login failed: invalid token", service.Fields{
+			"app_code": in.AppCode,
+		})
+		return nil, errors.New("invalid or expired login link")
+	}
+
+	token, err := uc.authUC.IssueToken(ctx, link.UserID, link.AppCode, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Magic link login succeeded", service.Fields{
+		"user_id":  link.UserID,
+		"app_code": link.AppCode,
+	})
+
+	return token, nil
+}
+
+func (uc *magicLinkUsecase) send(user *entity.User, loginURL string, expiry time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+
+	body := "Hello " + user.Username + ",\r\n\r\n" +
+		"Use the link below to sign in. It expires in " + expiry.String() + " and can be used once.\r\n\r\n" +
+		loginURL + "\r\n\r\n" +
+		"If you did not request this email you can ignore it.\r\n"
+
+	if err := uc.mailer.Send(ctx, user.Email, "Your sign-in link", body); err != nil {
+		uc.logger.Error("Failed to send magic link email", service.Fields{
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+func buildLoginURL(base, token, appCode string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("token", token)
+	q.Set("application", appCode)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the key a token is stored under, so a leaked store
+// does not reveal usable links
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func magicLinkSettings(cfg *config.Config) *config.MagicLink {
+	if cfg != nil && cfg.MagicLink != nil {
+		return cfg.MagicLink
+	}
+	return config.DefaultMagicLink()
+}
+
+func rateLimit(cfg *config.Config) *config.RateLimit {
+	if cfg != nil && cfg.RateLimit != nil {
+		return cfg.RateLimit
+	}
+	return config.DefaultRateLimit()
+}
@@ -0,0 +1,48 @@
+package oauth
+
+import "time"
+
+// Grant types accepted at the token endpoint
+const GrantTypeDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
+// Error codes returned by the token endpoint (RFC 6749 section 5.2 and
+// RFC 8628 section 3.5)
+const (
+	ErrCodeInvalidRequest       = "invalid_request"
+	ErrCodeInvalidClient        = "invalid_client"
+	ErrCodeInvalidGrant         = "invalid_grant"
+	ErrCodeUnsupportedGrantType = "unsupported_grant_type"
+	ErrCodeAuthorizationPending = "authorization_pending"
+	ErrCodeSlowDown             = "slow_down"
+	ErrCodeAccessDenied         = "access_denied"
+	ErrCodeExpiredToken         = "expired_token"
+)
+
+type (
+	// DeviceCode is the device authorization response handed to the device
+	DeviceCode struct {
+		DeviceCode              string
+		UserCode                string
+		VerificationURI         string
+		VerificationURIComplete string
+		ExpiresIn               time.Duration
+		Interval                time.Duration
+	}
+
+	// Error is an OAuth error carrying a protocol error code
+	Error struct {
+		Code        string
+		Description string
+	}
+)
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func newError(code, description string) *Error {
+	return &Error{Code: code, Description: description}
+}
@@ -0,0 +1,16 @@
+package oauth
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/auth"
+)
+
+type Usecase interface {
+	RequestDeviceCode(ctx context.Context, clientID string, conf *config.Config) (*DeviceCode, error)
+	GetDeviceAuthorization(ctx context.Context, userCode string) (*entity.DeviceAuthorization, error)
+	ApproveDevice(ctx context.Context, userCode, userID string, approve bool) error
+	PollDeviceToken(ctx context.Context, deviceCode, clientID string, conf *config.Config) (*auth.UserToken, error)
+}
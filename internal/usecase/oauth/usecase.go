@@ -0,0 +1,266 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/usecase/auth"
+)
+
+// userCodeAlphabet omits vowels and look-alike characters so user codes
+// are easy to type and cannot spell words (RFC 8628 section 6.1)
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+const (
+	userCodeLength   = 8
+	slowDownIncrease = 5 * time.Second
+)
+
+type oauthUsecase struct {
+	deviceRepo repository.DeviceAuthorizationRepository
+	appRepo    repository.AppRepository
+	authUC     auth.Usecase
+	logger     service.Logger
+}
+
+func NewOAuthUsecase(
+	deviceRepo repository.DeviceAuthorizationRepository,
+	appRepo repository.AppRepository,
+	authUC auth.Usecase,
+	logger service.Logger,
+) Usecase {
+	return &oauthUsecase{
+		deviceRepo: deviceRepo,
+		appRepo:    appRepo,
+		authUC:     authUC,
+		logger:     logger,
+	}
+}
+
+// RequestDeviceCode starts a device authorization for the application
+// identified by clientID
+func (uc *oauthUsecase) RequestDeviceCode(ctx context.Context, clientID string, cfg *config.Config) (*DeviceCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if clientID == "" {
+		return nil, newError(ErrCodeInvalidRequest, "client_id is required")
+	}
+
+	if _, err := uc.appRepo.GetByCode(ctx, clientID); err != nil {
+		return nil, newError(ErrCodeInvalidClient, "unknown client")
+	}
+
+	oauthCfg := cfg.OAuth
+	if oauthCfg == nil {
+		oauthCfg = config.DefaultOAuth()
+	}
+
+	deviceCode, err := generateDeviceCode()
+	if err != nil {
+		return nil, errors.New("failed to generate device code")
+	}
+	userCode, err := generateUserCode()
+	if err != nil {
+		return nil, errors.New("failed to generate user code")
+	}
+
+	now := time.Now()
+	da := &entity.DeviceAuthorization{
+		ID:        hashDeviceCode(deviceCode),
+		UserCode:  userCode,
+		AppCode:   clientID,
+		Status:    entity.DeviceAuthorizationPending,
+		Interval:  oauthCfg.PollInterval,
+		ExpiresAt: now.Add(oauthCfg.DeviceCodeExpiry),
+		CreatedAt: now,
+	}
+
+	if err := uc.deviceRepo.Save(ctx, da); err != nil {
+		uc.logger.Error("Failed to store device authorization", service.Fields{
+			"client_id": clientID,
+			"error":     err.Error(),
+		})
+		return nil, errors.New("failed to start device authorization")
+	}
+
+	uc.logger.Info("Device authorization started", service.Fields{
+		"client_id": clientID,
+	})
+
+	return &DeviceCode{
+		DeviceCode:              deviceCode,
+		UserCode:                formatUserCode(userCode),
+		VerificationURI:         oauthCfg.VerificationURI,
+		VerificationURIComplete: oauthCfg.VerificationURI + "?user_code=" + url.QueryEscape(formatUserCode(userCode)),
+		ExpiresIn:               oauthCfg.DeviceCodeExpiry,
+		Interval:                oauthCfg.PollInterval,
+	}, nil
+}
+
+// GetDeviceAuthorization returns the pending authorization for a user
+// code, so the verification page can show which application is asking
+func (uc *oauthUsecase) GetDeviceAuthorization(ctx context.Context, userCode string) (*entity.DeviceAuthorization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	da, err := uc.deviceRepo.GetByUserCode(ctx, normalizeUserCode(userCode))
+	if err != nil || da.Status != entity.DeviceAuthorizationPending {
+		return nil, errors.New("invalid or expired user code")
+	}
+
+	return da, nil
+}
+
+// ApproveDevice records the logged-in user's decision for a user code
+func (uc *oauthUsecase) ApproveDevice(ctx context.Context, userCode, userID string, approve bool) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if userID == "" {
+		return errors.New("userID is required")
+	}
+
+	da, err := uc.deviceRepo.GetByUserCode(ctx, normalizeUserCode(userCode))
+	if err != nil || da.Status != entity.DeviceAuthorizationPending {
+		uc.logger.Warn("Device approval failed: invalid user code", service.Fields{
+			"user_id": userID,
+		})
+		return errors.New("invalid or expired user code")
+	}
+
+	da.UserID = userID
+	da.Status = entity.DeviceAuthorizationDenied
+	if approve {
+		da.Status = entity.DeviceAuthorizationApproved
+	}
+
+	if err := uc.deviceRepo.Save(ctx, da); err != nil {
+		return errors.New("invalid or expired user code")
+	}
+
+	uc.logger.Info("Device authorization decided", service.Fields{
+		"user_id":   userID,
+		"client_id": da.AppCode,
+		"status":    da.Status,
+	})
+
+	return nil
+}
+
+// PollDeviceToken is called by the device at the token endpoint. It
+// answers authorization_pending until the user decides, slow_down when the
+// device polls faster than its interval, and issues a token exactly once
+// after approval.
+func (uc *oauthUsecase) PollDeviceToken(ctx context.Context, deviceCode, clientID string, cfg *config.Config) (*auth.UserToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if deviceCode == "" || clientID == "" {
+		return nil, newError(ErrCodeInvalidRequest, "device_code and client_id are required")
+	}
+
+	da, err := uc.deviceRepo.GetByID(ctx, hashDeviceCode(deviceCode))
+	if err != nil {
+		return nil, newError(ErrCodeExpiredToken, "device code is invalid or has expired")
+	}
+
+	if da.AppCode != clientID {
+		return nil, newError(ErrCodeInvalidGrant, "device code was issued to another client")
+	}
+
+	// Polls only update the poll fields, so an approval saved between the
+	// read above and these writes is not overwritten
+	now := time.Now()
+	if da.LastPolledAt != nil && now.Sub(*da.LastPolledAt) < da.Interval {
+		interval := da.Interval + slowDownIncrease
+		_ = uc.deviceRepo.UpdatePoll(ctx, da.ID, now, interval)
+		return nil, newError(ErrCodeSlowDown, fmt.Sprintf("poll at most every %d seconds", int(interval.Seconds())))
+	}
+
+	switch da.Status {
+	case entity.DeviceAuthorizationPending:
+		if err := uc.deviceRepo.UpdatePoll(ctx, da.ID, now, da.Interval); err != nil {
+			return nil, newError(ErrCodeExpiredToken, "device code has expired")
+		}
+		return nil, newError(ErrCodeAuthorizationPending, "")
+
+	case entity.DeviceAuthorizationDenied:
+		_ = uc.deviceRepo.Delete(ctx, da)
+		return nil, newError(ErrCodeAccessDenied, "the user denied the request")
+	}
+
+	// Approved: the device code is single use. Delete fails for all but
+	// one of concurrent polls, and only that one is issued a token.
+	if err := uc.deviceRepo.Delete(ctx, da); err != nil {
+		return nil, newError(ErrCodeInvalidGrant, "device code has already been used")
+	}
+
+	token, err := uc.authUC.IssueToken(ctx, da.UserID, da.AppCode, cfg)
+	if err != nil {
+		uc.logger.Warn("Device token issuance failed", service.Fields{
+			"user_id":   da.UserID,
+			"client_id": da.AppCode,
+			"error":     err.Error(),
+		})
+		return nil, newError(ErrCodeAccessDenied, err.Error())
+	}
+
+	uc.logger.Info("Device token issued", service.Fields{
+		"user_id":   da.UserID,
+		"client_id": da.AppCode,
+	})
+
+	return token, nil
+}
+
+func generateDeviceCode() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func generateUserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// formatUserCode inserts a dash in the middle of the code for readability
+func formatUserCode(code string) string {
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// normalizeUserCode accepts user input in any case, with or without the
+// dash and surrounding whitespace
+func normalizeUserCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashDeviceCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
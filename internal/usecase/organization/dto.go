@@ -0,0 +1,8 @@
+package organization
+
+type (
+	CreateInput struct {
+		Code string
+		Name string
+	}
+)
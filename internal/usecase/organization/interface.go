@@ -0,0 +1,18 @@
+package organization
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type Usecase interface {
+	Create(ctx context.Context, input *CreateInput) (*entity.Organization, error)
+	GetDetail(ctx context.Context, id string) (*entity.Organization, error)
+	GetList(ctx context.Context, limit, offset int) ([]*entity.Organization, error)
+	ListByUser(ctx context.Context, userID string) ([]*entity.Organization, error)
+	AddMember(ctx context.Context, orgID, userID string) error
+	RemoveMember(ctx context.Context, orgID, userID string) error
+	ListMembers(ctx context.Context, orgID string) ([]*entity.OrganizationMember, error)
+	AssignRoles(ctx context.Context, orgID, userID, appID string, roles []string) error
+}
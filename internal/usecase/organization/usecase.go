@@ -0,0 +1,279 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/pkg/idgen"
+)
+
+type organizationUsecase struct {
+	orgRepo    repository.OrganizationRepository
+	userRepo   repository.UserRepository
+	roleRepo   repository.RoleRepository
+	permCache  repository.PermissionCacheRepository
+	transactor repository.Transactor
+	sodSvc     service.SoDService
+	logger     service.Logger
+}
+
+func NewOrganizationUsecase(
+	orgRepo repository.OrganizationRepository,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	permCache repository.PermissionCacheRepository,
+	transactor repository.Transactor,
+	sodSvc service.SoDService,
+	logger service.Logger,
+) Usecase {
+	return &organizationUsecase{
+		orgRepo:    orgRepo,
+		userRepo:   userRepo,
+		roleRepo:   roleRepo,
+		permCache:  permCache,
+		transactor: transactor,
+		sodSvc:     sodSvc,
+		logger:     logger,
+	}
+}
+
+func (uc *organizationUsecase) Create(ctx context.Context, in *CreateInput) (*entity.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.Code == "" || in.Name == "" {
+		return nil, errors.New("code and name is required")
+	}
+
+	if existing, _ := uc.orgRepo.GetByCode(ctx, in.Code); existing != nil {
+		uc.logger.Warn("Organization creation failed: organization already exists", service.Fields{
+			"code": in.Code,
+		})
+		return nil, errors.New("organization already exists")
+	}
+
+	org := &entity.Organization{
+		ID:        idgen.NewUUIDv7(),
+		Code:      in.Code,
+		Name:      in.Name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := uc.orgRepo.Create(ctx, org); err != nil {
+		uc.logger.Error("Failed to create organization", service.Fields{
+			"code":  in.Code,
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Organization created successfully", service.Fields{
+		"org_id": org.ID,
+		"code":   org.Code,
+	})
+
+	return org, nil
+}
+
+func (uc *organizationUsecase) GetDetail(ctx context.Context, id string) (*entity.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	org, err := uc.orgRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("organization not found")
+	}
+	return org, nil
+}
+
+func (uc *organizationUsecase) GetList(ctx context.Context, limit, offset int) ([]*entity.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orgs, err := uc.orgRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+// ListByUser returns the organizations the user is a member of, the ones
+// a token can be issued for
+func (uc *organizationUsecase) ListByUser(ctx context.Context, userID string) ([]*entity.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orgs, err := uc.orgRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+func (uc *organizationUsecase) AddMember(ctx context.Context, orgID, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if userID == "" {
+		return errors.New("userID is required")
+	}
+
+	org, err := uc.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return errors.New("organization not found")
+	}
+
+	if _, err := uc.userRepo.GetByID(ctx, userID); err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := uc.orgRepo.AddMember(ctx, org.ID, userID); err != nil {
+		uc.logger.Error("Failed to add organization member", service.Fields{
+			"org_id":  org.ID,
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Organization member added", service.Fields{
+		"org_id":  org.ID,
+		"user_id": userID,
+	})
+
+	return nil
+}
+
+// RemoveMember removes the member and every role they hold in the
+// organization
+func (uc *organizationUsecase) RemoveMember(ctx context.Context, orgID, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	org, err := uc.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return errors.New("organization not found")
+	}
+
+	if err := uc.orgRepo.RemoveMember(ctx, org.ID, userID); err != nil {
+		uc.logger.Error("Failed to remove organization member", service.Fields{
+			"org_id":  org.ID,
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return err
+	}
+
+	uc.bumpVersion(ctx, userID)
+
+	uc.logger.Info("Organization member removed", service.Fields{
+		"org_id":  org.ID,
+		"user_id": userID,
+	})
+
+	return nil
+}
+
+func (uc *organizationUsecase) ListMembers(ctx context.Context, orgID string) ([]*entity.OrganizationMember, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	org, err := uc.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, errors.New("organization not found")
+	}
+
+	members, err := uc.orgRepo.ListMembers(ctx, org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %w", err)
+	}
+	return members, nil
+}
+
+// AssignRoles replaces the member's tenant roles in one application of the
+// organization. An empty list removes them.
+func (uc *organizationUsecase) AssignRoles(ctx context.Context, orgID, userID, appID string, roles []string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if userID == "" || appID == "" {
+		return errors.New("userID and appID are required")
+	}
+
+	org, err := uc.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return errors.New("organization not found")
+	}
+
+	member, err := uc.orgRepo.IsMember(ctx, org.ID, userID)
+	if err != nil {
+		return fmt.Errorf("failed: %w", err)
+	}
+	if !member {
+		return errors.New("user is not a member of the organization")
+	}
+
+	var roleIDs []string
+	for _, code := range roles {
+		role, err := uc.roleRepo.GetByAppAndCode(ctx, appID, code)
+		if err != nil {
+			return fmt.Errorf("role %s not found", code)
+		}
+		if !role.IsTenant() {
+			return fmt.Errorf("role %s is not a tenant role", code)
+		}
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.sodSvc.CheckUsers(ctx, []string{userID}, &service.RoleChange{
+			AppID:   appID,
+			Replace: &entity.RoleSource{Kind: entity.RoleSourceOrganization, ID: org.ID},
+			RoleIDs: roleIDs,
+		}); err != nil {
+			return err
+		}
+
+		err := uc.orgRepo.ReplaceRoles(ctx, org.ID, userID, appID, roleIDs)
+		if err != nil {
+			uc.logger.Error("Failed to assign organization roles", service.Fields{
+				"org_id":  org.ID,
+				"user_id": userID,
+				"app_id":  appID,
+				"roles":   roles,
+				"error":   err.Error(),
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.bumpVersion(ctx, userID)
+
+	uc.logger.Info("Organization roles assigned successfully", service.Fields{
+		"org_id":  org.ID,
+		"user_id": userID,
+		"app_id":  appID,
+		"roles":   roles,
+	})
+
+	return nil
+}
+
+// bumpVersion invalidates cached permissions so thin-token consumers and
+// the check API see the change
+func (uc *organizationUsecase) bumpVersion(ctx context.Context, userID string) {
+	if err := uc.permCache.BumpVersion(ctx, []string{userID}); err != nil {
+		uc.logger.Warn("Failed to bump permissions version", service.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/mafzaidi/authorizer/internal/domain/repository"
 	"github.com/mafzaidi/authorizer/internal/domain/service"
 	"github.com/mafzaidi/authorizer/pkg/idgen"
+	"github.com/mafzaidi/authorizer/pkg/permission"
 )
 
 type permUsecase struct {
@@ -55,6 +56,15 @@ func (uc *permUsecase) Create(ctx context.Context, in *CreateInput) error {
 		return fmt.Errorf("failed: %w", err)
 	}
 
+	if err := uc.validateCatalog(ctx, app, []*PermissionsInput{{Code: in.Code}}); err != nil {
+		uc.logger.Warn("Create permission failed: invalid permission", service.Fields{
+			"app_id": app.ID,
+			"code":   in.Code,
+			"error":  err.Error(),
+		})
+		return err
+	}
+
 	existingPerm, _ := uc.permRepo.GetByAppAndCode(ctx, app.ID, in.Code)
 	if existingPerm != nil {
 		uc.logger.Warn("Create permission failed: permission already exists", service.Fields{
@@ -111,6 +121,14 @@ func (uc *permUsecase) SyncPermissions(ctx context.Context, in *SyncInput) error
 		return fmt.Errorf("failed: %w", err)
 	}
 
+	if err := uc.validateCatalog(ctx, app, in.Permissions); err != nil {
+		uc.logger.Warn("Sync permissions failed: invalid permission", service.Fields{
+			"app_code": in.AppCode,
+			"error":    err.Error(),
+		})
+		return err
+	}
+
 	var perms []*entity.Permission
 	for _, v := range in.Permissions {
 		perm := &entity.Permission{
@@ -144,3 +162,47 @@ func (uc *permUsecase) SyncPermissions(ctx context.Context, in *SyncInput) error
 
 	return nil
 }
+
+// validateCatalog checks the syntax of synced permission codes and that
+// every wildcard matches at least one concrete permission of the
+// application, counting both existing and synced permissions
+func (uc *permUsecase) validateCatalog(ctx context.Context, app *entity.Application, in []*PermissionsInput) error {
+	existing, err := uc.permRepo.ListByApp(ctx, app.ID)
+	if err != nil {
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	var concrete, wildcards []string
+	for _, p := range existing {
+		if !permission.IsWildcard(p.Code) {
+			concrete = append(concrete, p.Code)
+		}
+	}
+	for _, v := range in {
+		if err := permission.Validate(v.Code); err != nil {
+			return err
+		}
+		if v.Code == entity.SuperadminPermission && app.Code != entity.AuthorizerAppCode {
+			return fmt.Errorf("permission %q is reserved", v.Code)
+		}
+		if permission.IsWildcard(v.Code) {
+			wildcards = append(wildcards, v.Code)
+		} else {
+			concrete = append(concrete, v.Code)
+		}
+	}
+
+	for _, w := range wildcards {
+		matched := false
+		for _, c := range concrete {
+			if permission.Match(w, c) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("wildcard permission %q matches no permission of %s", w, app.Code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package relation
+
+import "encoding/json"
+
+type (
+	SchemaOutput struct {
+		AppCode    string
+		Definition json.RawMessage
+		Version    int
+	}
+
+	// TuplesInput carries tuples as object#relation@subject strings
+	TuplesInput struct {
+		AppCode string
+		Tuples  []string
+	}
+
+	// CheckInput asks whether Subject (namespace:id or
+	// namespace:id#relation) has Relation to Object (namespace:id)
+	CheckInput struct {
+		AppCode  string
+		Object   string
+		Relation string
+		Subject  string
+	}
+
+	ExpandInput struct {
+		AppCode  string
+		Object   string
+		Relation string
+	}
+
+	// ListObjectsInput asks for the objects of Namespace Subject has
+	// Relation to
+	ListObjectsInput struct {
+		AppCode   string
+		Namespace string
+		Relation  string
+		Subject   string
+	}
+)
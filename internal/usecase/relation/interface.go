@@ -0,0 +1,18 @@
+package relation
+
+import (
+	"context"
+	"encoding/json"
+
+	rebac "github.com/mafzaidi/authorizer/pkg/relation"
+)
+
+type Usecase interface {
+	SetSchema(ctx context.Context, appCode string, definition json.RawMessage) (*SchemaOutput, error)
+	GetSchema(ctx context.Context, appCode string) (*SchemaOutput, error)
+	WriteTuples(ctx context.Context, input *TuplesInput) error
+	DeleteTuples(ctx context.Context, input *TuplesInput) error
+	Check(ctx context.Context, input *CheckInput) (bool, error)
+	Expand(ctx context.Context, input *ExpandInput) (*rebac.Tree, error)
+	ListObjects(ctx context.Context, input *ListObjectsInput) ([]string, error)
+}
@@ -0,0 +1,391 @@
+package relation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	rebac "github.com/mafzaidi/authorizer/pkg/relation"
+)
+
+// maxTuplesPerWrite bounds the tuples of one write or delete request
+const maxTuplesPerWrite = 100
+
+// ErrEvaluationFailed is returned when a query could not be answered, as
+// opposed to answered negatively
+var ErrEvaluationFailed = errors.New("failed to evaluate relation query")
+
+type relationUsecase struct {
+	appRepo      repository.AppRepository
+	relationRepo repository.RelationRepository
+	logger       service.Logger
+}
+
+func NewRelationUsecase(
+	appRepo repository.AppRepository,
+	relationRepo repository.RelationRepository,
+	logger service.Logger,
+) Usecase {
+	return &relationUsecase{
+		appRepo:      appRepo,
+		relationRepo: relationRepo,
+		logger:       logger,
+	}
+}
+
+// SetSchema replaces the application's namespace schema. A schema that no
+// longer declares a relation stored tuples use is rejected.
+func (uc *relationUsecase) SetSchema(ctx context.Context, appCode string, definition json.RawMessage) (*SchemaOutput, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	app, err := uc.resolveApp(ctx, appCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema rebac.Schema
+	if err := json.Unmarshal(definition, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	used, err := uc.relationRepo.ListUsedRelations(ctx, app.ID)
+	if err != nil {
+		uc.logger.Error("Failed to list relations in use", service.Fields{
+			"app_code": app.Code,
+			"error":    err.Error(),
+		})
+		return nil, err
+	}
+	for _, ref := range used {
+		if ref.Relation == "" && !schema.HasNamespace(ref.Namespace) {
+			return nil, fmt.Errorf("namespace %s is used by stored tuples", ref.Namespace)
+		}
+		if ref.Relation != "" && !schema.HasRelation(ref.Namespace, ref.Relation) {
+			return nil, fmt.Errorf("relation %s#%s is used by stored tuples", ref.Namespace, ref.Relation)
+		}
+	}
+
+	// Store the normalized document
+	normalized, err := json.Marshal(&schema)
+	if err != nil {
+		return nil, err
+	}
+	stored := &entity.RelationSchema{
+		ApplicationID: app.ID,
+		Definition:    normalized,
+	}
+	if err := uc.relationRepo.SaveSchema(ctx, stored); err != nil {
+		uc.logger.Error("Failed to save relation schema", service.Fields{
+			"app_code": app.Code,
+			"error":    err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Relation schema saved successfully", service.Fields{
+		"app_code": app.Code,
+		"version":  stored.Version,
+	})
+
+	return &SchemaOutput{
+		AppCode:    app.Code,
+		Definition: stored.Definition,
+		Version:    stored.Version,
+	}, nil
+}
+
+func (uc *relationUsecase) GetSchema(ctx context.Context, appCode string) (*SchemaOutput, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	app, err := uc.resolveApp(ctx, appCode)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := uc.relationRepo.GetSchema(ctx, app.ID)
+	if err != nil {
+		return nil, errors.New("application has no relation schema")
+	}
+
+	return &SchemaOutput{
+		AppCode:    app.Code,
+		Definition: stored.Definition,
+		Version:    stored.Version,
+	}, nil
+}
+
+// WriteTuples stores tuples after checking them against the schema
+func (uc *relationUsecase) WriteTuples(ctx context.Context, in *TuplesInput) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	app, schema, err := uc.loadSchema(ctx, in.AppCode)
+	if err != nil {
+		return err
+	}
+
+	tuples, err := parseTuples(app.ID, in.Tuples, schema)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.relationRepo.WriteTuples(ctx, tuples); err != nil {
+		uc.logger.Error("Failed to write relation tuples", service.Fields{
+			"app_code": app.Code,
+			"error":    err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Relation tuples written successfully", service.Fields{
+		"app_code": app.Code,
+		"count":    len(tuples),
+	})
+
+	return nil
+}
+
+// DeleteTuples removes tuples. Tuples that are not stored are ignored.
+func (uc *relationUsecase) DeleteTuples(ctx context.Context, in *TuplesInput) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	app, err := uc.resolveApp(ctx, in.AppCode)
+	if err != nil {
+		return err
+	}
+
+	tuples, err := parseTuples(app.ID, in.Tuples, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.relationRepo.DeleteTuples(ctx, tuples); err != nil {
+		uc.logger.Error("Failed to delete relation tuples", service.Fields{
+			"app_code": app.Code,
+			"error":    err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Relation tuples deleted successfully", service.Fields{
+		"app_code": app.Code,
+		"count":    len(tuples),
+	})
+
+	return nil
+}
+
+func (uc *relationUsecase) Check(ctx context.Context, in *CheckInput) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	app, schema, err := uc.loadSchema(ctx, in.AppCode)
+	if err != nil {
+		return false, err
+	}
+
+	ns, id, err := rebac.ParseObject(in.Object)
+	if err != nil {
+		return false, err
+	}
+	subject, err := rebac.ParseSubject(in.Subject)
+	if err != nil {
+		return false, err
+	}
+
+	engine := rebac.NewEngine(schema, &tupleStore{appID: app.ID, repo: uc.relationRepo})
+	ok, err := engine.Check(ctx, ns, id, in.Relation, subject)
+	return ok, uc.queryError(app, "check", err)
+}
+
+func (uc *relationUsecase) Expand(ctx context.Context, in *ExpandInput) (*rebac.Tree, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	app, schema, err := uc.loadSchema(ctx, in.AppCode)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, id, err := rebac.ParseObject(in.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := rebac.NewEngine(schema, &tupleStore{appID: app.ID, repo: uc.relationRepo})
+	tree, err := engine.Expand(ctx, ns, id, in.Relation)
+	if err != nil {
+		return nil, uc.queryError(app, "expand", err)
+	}
+	return tree, nil
+}
+
+func (uc *relationUsecase) ListObjects(ctx context.Context, in *ListObjectsInput) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	app, schema, err := uc.loadSchema(ctx, in.AppCode)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := rebac.ParseSubject(in.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := rebac.NewEngine(schema, &tupleStore{appID: app.ID, repo: uc.relationRepo})
+	ids, err := engine.ListObjects(ctx, in.Namespace, in.Relation, subject)
+	if err != nil {
+		return nil, uc.queryError(app, "list objects", err)
+	}
+	return ids, nil
+}
+
+// queryError separates failures to read tuples from answers the engine
+// refused to give, such as an undefined relation or an exceeded limit
+func (uc *relationUsecase) queryError(app *entity.Application, query string, err error) error {
+	var storeErr *storeError
+	if err == nil || !errors.As(err, &storeErr) {
+		return err
+	}
+
+	uc.logger.Error("Failed to evaluate relation query", service.Fields{
+		"app_code": app.Code,
+		"query":    query,
+		"error":    err.Error(),
+	})
+	return ErrEvaluationFailed
+}
+
+func (uc *relationUsecase) resolveApp(ctx context.Context, appCode string) (*entity.Application, error) {
+	if appCode == "" {
+		return nil, errors.New("application is required")
+	}
+
+	app, err := uc.appRepo.GetByCode(ctx, appCode)
+	if err != nil {
+		return nil, errors.New("application not found")
+	}
+	return app, nil
+}
+
+// loadSchema resolves the application and its parsed schema
+func (uc *relationUsecase) loadSchema(ctx context.Context, appCode string) (*entity.Application, *rebac.Schema, error) {
+	app, err := uc.resolveApp(ctx, appCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored, err := uc.relationRepo.GetSchema(ctx, app.ID)
+	if err != nil {
+		return nil, nil, errors.New("application has no relation schema")
+	}
+
+	var schema rebac.Schema
+	if err := json.Unmarshal(stored.Definition, &schema); err != nil {
+		uc.logger.Error("Failed to decode relation schema", service.Fields{
+			"app_code": app.Code,
+			"error":    err.Error(),
+		})
+		return nil, nil, ErrEvaluationFailed
+	}
+	return app, &schema, nil
+}
+
+// parseTuples parses tuple strings, validating them against schema when
+// given
+func parseTuples(appID string, raw []string, schema *rebac.Schema) ([]*entity.RelationTuple, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("tuples are required")
+	}
+	if len(raw) > maxTuplesPerWrite {
+		return nil, fmt.Errorf("at most %d tuples are allowed", maxTuplesPerWrite)
+	}
+
+	tuples := make([]*entity.RelationTuple, 0, len(raw))
+	for _, s := range raw {
+		t, err := rebac.ParseTuple(s)
+		if err != nil {
+			return nil, err
+		}
+		if schema != nil {
+			if err := schema.ValidateTuple(t); err != nil {
+				return nil, err
+			}
+		}
+		tuples = append(tuples, &entity.RelationTuple{
+			ApplicationID:    appID,
+			Namespace:        t.Namespace,
+			ObjectID:         t.ObjectID,
+			Relation:         t.Relation,
+			SubjectNamespace: t.Subject.Namespace,
+			SubjectID:        t.Subject.ID,
+			SubjectRelation:  t.Subject.Relation,
+		})
+	}
+	return tuples, nil
+}
+
+// tupleStore reads one application's tuples for the engine
+type tupleStore struct {
+	appID string
+	repo  repository.RelationRepository
+}
+
+// storeError marks errors reading tuples
+type storeError struct {
+	err error
+}
+
+func (e *storeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *storeError) Unwrap() error {
+	return e.err
+}
+
+func (s *tupleStore) ObjectTuples(ctx context.Context, namespace, objectID, relation string) ([]rebac.Tuple, error) {
+	stored, err := s.repo.ListObjectTuples(ctx, s.appID, namespace, objectID, relation)
+	if err != nil {
+		return nil, &storeError{err: err}
+	}
+	return toTuples(stored), nil
+}
+
+func (s *tupleStore) SubjectTuples(ctx context.Context, subject rebac.Subject) ([]rebac.Tuple, error) {
+	stored, err := s.repo.ListSubjectTuples(ctx, s.appID, subject.Namespace, subject.ID, subject.Relation)
+	if err != nil {
+		return nil, &storeError{err: err}
+	}
+	return toTuples(stored), nil
+}
+
+func toTuples(stored []*entity.RelationTuple) []rebac.Tuple {
+	tuples := make([]rebac.Tuple, 0, len(stored))
+	for _, t := range stored {
+		tuples = append(tuples, rebac.Tuple{
+			Namespace: t.Namespace,
+			ObjectID:  t.ObjectID,
+			Relation:  t.Relation,
+			Subject: rebac.Subject{
+				Namespace: t.SubjectNamespace,
+				ID:        t.SubjectID,
+				Relation:  t.SubjectRelation,
+			},
+		})
+	}
+	return tuples
+}
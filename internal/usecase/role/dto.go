@@ -6,6 +6,38 @@ type (
 		Code        string
 		Name        string
 		Description string
+		// Scope is APPLICATION (the default), GLOBAL or TENANT; global
+		// roles have no AppID, tenant roles are assigned within an
+		// organization
+		Scope string
+	}
+
+	// EffectivePermsOutput lists the permissions a role holds: its own
+	// grants and those inherited from its ancestors
+	EffectivePermsOutput struct {
+		RoleID    string
+		RoleCode  string
+		Parents   []*ParentRole
+		Direct    []string
+		Inherited []*InheritedPerm
+		// Conditions maps direct grants that are conditional to their
+		// expression
+		Conditions map[string]string
+	}
+
+	ParentRole struct {
+		ID   string
+		Code string
+	}
+
+	// InheritedPerm is a permission granted to an ancestor role. Depth is
+	// the number of parent links between the role and that ancestor.
+	InheritedPerm struct {
+		Code         string
+		FromRoleID   string
+		FromRoleCode string
+		Depth        int
+		Condition    string
 	}
 
 	UpdateInput struct {
@@ -1,8 +1,15 @@
 package role
 
-import "context"
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
 
 type Usecase interface {
 	Create(ctx context.Context, input *CreateInput) error
-	GrantPerms(ctx context.Context, roleID string, perms []string) error
+	GrantPerms(ctx context.Context, roleID string, perms []string, conditions map[string]string) error
+	SetParents(ctx context.Context, roleID string, parentIDs []string) error
+	GetEffectivePerms(ctx context.Context, roleID string) (*EffectivePermsOutput, error)
+	GetCondition(ctx context.Context, id string) (*entity.GrantCondition, error)
 }
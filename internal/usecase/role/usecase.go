@@ -4,19 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mafzaidi/authorizer/internal/domain/entity"
 	"github.com/mafzaidi/authorizer/internal/domain/repository"
 	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/pkg/condition"
 	"github.com/mafzaidi/authorizer/pkg/idgen"
 )
 
+// ErrHierarchyCycle is returned when new parents would make a role its
+// own ancestor
+var ErrHierarchyCycle = errors.New("role hierarchy would contain a cycle")
+
 type roleUsecase struct {
 	roleRepo     repository.RoleRepository
 	appRepo      repository.AppRepository
 	permRepo     repository.PermRepository
 	rolePermRepo repository.RolePermRepository
+	userRoleRepo repository.UserRoleRepository
+	permCache    repository.PermissionCacheRepository
+	transactor   repository.Transactor
+	sodSvc       service.SoDService
 	logger       service.Logger
 }
 
@@ -25,6 +35,10 @@ func NewRoleUsecase(
 	appRepo repository.AppRepository,
 	permRepo repository.PermRepository,
 	rolePermRepo repository.RolePermRepository,
+	userRoleRepo repository.UserRoleRepository,
+	permCache repository.PermissionCacheRepository,
+	transactor repository.Transactor,
+	sodSvc service.SoDService,
 	logger service.Logger,
 ) Usecase {
 	return &roleUsecase{
@@ -32,6 +46,10 @@ func NewRoleUsecase(
 		appRepo:      appRepo,
 		permRepo:     permRepo,
 		rolePermRepo: rolePermRepo,
+		userRoleRepo: userRoleRepo,
+		permCache:    permCache,
+		transactor:   transactor,
+		sodSvc:       sodSvc,
 		logger:       logger,
 	}
 }
@@ -40,25 +58,47 @@ func (uc *roleUsecase) Create(ctx context.Context, in *CreateInput) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	if in.AppID == "" {
-		return errors.New("app ID is required")
+	scope := in.Scope
+	if scope == "" {
+		scope = entity.RoleScopeApplication
+	}
+
+	var appID *string
+	switch scope {
+	case entity.RoleScopeApplication, entity.RoleScopeTenant:
+		if in.AppID == "" {
+			return errors.New("app ID is required")
+		}
+		appID = &in.AppID
+	case entity.RoleScopeGlobal:
+		if in.AppID != "" {
+			return errors.New("global roles cannot belong to an application")
+		}
+	default:
+		return errors.New("scope must be APPLICATION, GLOBAL or TENANT")
 	}
 
 	if in.Code == "" || in.Name == "" {
 		return errors.New("code and name is required")
 	}
 
-	existingRole, _ := uc.roleRepo.GetByAppAndCode(ctx, in.AppID, in.Code)
+	var existingRole *entity.Role
+	if appID != nil {
+		existingRole, _ = uc.roleRepo.GetByAppAndCode(ctx, *appID, in.Code)
+	} else {
+		existingRole, _ = uc.roleRepo.GetGlobalByCode(ctx, in.Code)
+	}
 	if existingRole != nil {
 		return errors.New("role already exists")
 	}
 
 	role := &entity.Role{
 		ID:            idgen.NewUUIDv7(),
-		ApplicationID: &in.AppID,
+		ApplicationID: appID,
 		Code:          in.Code,
 		Name:          in.Name,
 		Description:   &in.Description,
+		Scope:         &scope,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
@@ -66,7 +106,7 @@ func (uc *roleUsecase) Create(ctx context.Context, in *CreateInput) error {
 	return uc.roleRepo.Create(ctx, role)
 }
 
-func (uc *roleUsecase) GrantPerms(ctx context.Context, roleID string, perms []string) error {
+func (uc *roleUsecase) GrantPerms(ctx context.Context, roleID string, perms []string, conditions map[string]string) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -83,19 +123,308 @@ func (uc *roleUsecase) GrantPerms(ctx context.Context, roleID string, perms []st
 		return fmt.Errorf("failed: %w", err)
 	}
 
-	app, err := uc.appRepo.GetByID(ctx, *role.ApplicationID)
+	var permIDs []string
+	if role.IsGlobal() {
+		permIDs, err = uc.resolveGlobalPerms(ctx, perms)
+	} else {
+		permIDs, err = uc.resolveAppPerms(ctx, role, perms)
+	}
+	if err != nil {
+		return err
+	}
+
+	grants, conds, err := buildGrants(perms, permIDs, conditions)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.rolePermRepo.Replace(ctx, role.ID, grants, conds); err != nil {
+		return err
+	}
+
+	uc.invalidateRoleHolders(ctx, role.ID)
+
+	return nil
+}
+
+func (uc *roleUsecase) SetParents(ctx context.Context, roleID string, parentIDs []string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if roleID == "" {
+		return errors.New("roleID is required")
+	}
+
+	role, err := uc.roleRepo.GetByID(ctx, roleID)
 	if err != nil {
 		return fmt.Errorf("failed: %w", err)
 	}
+	if role.IsGlobal() || role.ApplicationID == nil {
+		return errors.New("global roles cannot have parents")
+	}
+
+	seen := make(map[string]struct{}, len(parentIDs))
+	var ids []string
+	for _, id := range parentIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if id == role.ID {
+			return errors.New("a role cannot be its own parent")
+		}
+
+		parent, err := uc.roleRepo.GetByID(ctx, id)
+		if err != nil || parent.DeletedAt != nil {
+			return fmt.Errorf("parent role %s not found", id)
+		}
+		if parent.ApplicationID == nil || *parent.ApplicationID != *role.ApplicationID {
+			return fmt.Errorf("parent role %s belongs to another application", parent.Code)
+		}
+		ids = append(ids, parent.ID)
+	}
+
+	// Holders of the role and of roles inheriting from it gain the new
+	// parents, so they must still satisfy the application's constraints
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.sodSvc.CheckRoleParents(ctx, *role.ApplicationID, role.ID, ids); err != nil {
+			return err
+		}
+
+		err := uc.roleRepo.SetParents(ctx, role.ID, ids)
+		if errors.Is(err, repository.ErrRoleCycle) {
+			uc.logger.Warn("Set role parents failed: cycle", service.Fields{
+				"role_id":    role.ID,
+				"parent_ids": ids,
+			})
+			return ErrHierarchyCycle
+		}
+		if err != nil {
+			uc.logger.Error("Failed to set role parents", service.Fields{
+				"role_id": role.ID,
+				"error":   err.Error(),
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.invalidateRoleHolders(ctx, role.ID)
+
+	uc.logger.Info("Role parents set successfully", service.Fields{
+		"role_id":    role.ID,
+		"parent_ids": ids,
+	})
+
+	return nil
+}
+
+func (uc *roleUsecase) GetEffectivePerms(ctx context.Context, roleID string) (*EffectivePermsOutput, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if roleID == "" {
+		return nil, errors.New("roleID is required")
+	}
+
+	role, err := uc.roleRepo.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	parents, err := uc.roleRepo.GetParents(ctx, role.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	perms, err := uc.rolePermRepo.GetEffectivePermsByRole(ctx, role.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	out := &EffectivePermsOutput{
+		RoleID:     role.ID,
+		RoleCode:   role.Code,
+		Parents:    make([]*ParentRole, 0, len(parents)),
+		Direct:     []string{},
+		Inherited:  []*InheritedPerm{},
+		Conditions: make(map[string]string),
+	}
+	for _, p := range parents {
+		out.Parents = append(out.Parents, &ParentRole{ID: p.ID, Code: p.Code})
+	}
+	for _, p := range perms {
+		if !p.Inherited() {
+			out.Direct = append(out.Direct, p.PermissionCode)
+			if p.Condition != "" {
+				out.Conditions[p.PermissionCode] = p.Condition
+			}
+			continue
+		}
+		out.Inherited = append(out.Inherited, &InheritedPerm{
+			Code:         p.PermissionCode,
+			FromRoleID:   p.SourceRoleID,
+			FromRoleCode: p.SourceRoleCode,
+			Depth:        p.Depth,
+			Condition:    p.Condition,
+		})
+	}
+
+	return out, nil
+}
+
+// GetCondition returns a grant condition by the reference tokens carry
+func (uc *roleUsecase) GetCondition(ctx context.Context, id string) (*entity.GrantCondition, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if id == "" {
+		return nil, errors.New("condition ID is required")
+	}
+
+	return uc.rolePermRepo.GetCondition(ctx, id)
+}
+
+// buildGrants pairs the resolved permission IDs with the conditions given
+// for their codes. Conditions are compiled here so a malformed expression
+// is rejected before it can deny every check.
+func buildGrants(perms, permIDs []string, conditions map[string]string) ([]*entity.RolePermission, []*entity.GrantCondition, error) {
+	granted := make(map[string]struct{}, len(perms))
+	for _, code := range perms {
+		granted[code] = struct{}{}
+	}
+	for code := range conditions {
+		if _, ok := granted[code]; !ok {
+			return nil, nil, fmt.Errorf("condition given for %q, which is not granted", code)
+		}
+	}
+
+	var (
+		grants []*entity.RolePermission
+		conds  []*entity.GrantCondition
+	)
+	for i, code := range perms {
+		grant := &entity.RolePermission{PermissionID: permIDs[i]}
+
+		if expr, ok := conditions[code]; ok && strings.TrimSpace(expr) != "" {
+			if isSuperadminCode(code) {
+				return nil, nil, errors.New("superadmin cannot be granted conditionally")
+			}
+			if err := condition.Validate(expr); err != nil {
+				return nil, nil, fmt.Errorf("invalid condition for %q: %w", code, err)
+			}
+			ref := condition.Ref(expr)
+			grant.ConditionID = &ref
+			conds = append(conds, &entity.GrantCondition{
+				ID:         ref,
+				Expression: strings.TrimSpace(expr),
+			})
+		}
+		grants = append(grants, grant)
+	}
+	return grants, conds, nil
+}
+
+// isSuperadminCode reports whether a global role's qualified permission
+// code is the reserved superadmin permission
+func isSuperadminCode(code string) bool {
+	return code == entity.AuthorizerAppCode+":"+entity.SuperadminPermission
+}
+
+// resolveAppPerms looks up permissions in the catalog of the role's
+// application. The reserved superadmin permission is only for global roles.
+func (uc *roleUsecase) resolveAppPerms(ctx context.Context, role *entity.Role, perms []string) ([]string, error) {
+	if role.ApplicationID == nil {
+		return nil, errors.New("role has no application")
+	}
+
+	app, err := uc.appRepo.GetByID(ctx, *role.ApplicationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
 
 	var permIDs []string
 	for _, v := range perms {
+		if app.Code == entity.AuthorizerAppCode && v == entity.SuperadminPermission {
+			return nil, errors.New("superadmin can only be granted to global roles")
+		}
 		perm, err := uc.permRepo.GetByAppAndCode(ctx, app.ID, v)
 		if err != nil {
-			return fmt.Errorf("failed: %w", err)
+			return nil, fmt.Errorf("failed: %w", err)
+		}
+		permIDs = append(permIDs, perm.ID)
+	}
+	return permIDs, nil
+}
+
+// resolveGlobalPerms looks up permissions for a global role. Global roles
+// may hold permissions of any application, so each is qualified with its
+// application code as APP_CODE:permission.code.
+func (uc *roleUsecase) resolveGlobalPerms(ctx context.Context, perms []string) ([]string, error) {
+	apps := make(map[string]*entity.Application)
+
+	var permIDs []string
+	for _, v := range perms {
+		appCode, code, ok := strings.Cut(v, ":")
+		if !ok || appCode == "" || code == "" {
+			return nil, fmt.Errorf("permission %q of a global role must be written as APP_CODE:permission", v)
+		}
+
+		app, ok := apps[appCode]
+		if !ok {
+			var err error
+			app, err = uc.appRepo.GetByCode(ctx, appCode)
+			if err != nil {
+				return nil, fmt.Errorf("failed: %w", err)
+			}
+			apps[appCode] = app
+		}
+
+		perm, err := uc.permRepo.GetByAppAndCode(ctx, app.ID, code)
+		if err != nil {
+			return nil, fmt.Errorf("failed: %w", err)
 		}
 		permIDs = append(permIDs, perm.ID)
 	}
+	return permIDs, nil
+}
 
-	return uc.rolePermRepo.Replace(ctx, role.ID, permIDs)
+// invalidateRoleHolders bumps the permissions version of every user holding
+// the role, or a role inheriting from it, so cached authorizations are
+// re-resolved
+func (uc *roleUsecase) invalidateRoleHolders(ctx context.Context, roleID string) {
+	roleIDs := []string{roleID}
+	descendants, err := uc.roleRepo.GetDescendantIDs(ctx, roleID)
+	if err != nil {
+		uc.logger.Warn("Failed to get descendant roles", service.Fields{
+			"role_id": roleID,
+			"error":   err.Error(),
+		})
+	}
+	roleIDs = append(roleIDs, descendants...)
+
+	var userIDs []string
+	for _, id := range roleIDs {
+		users, err := uc.userRoleRepo.GetUsersByRole(ctx, id)
+		if err != nil {
+			uc.logger.Warn("Failed to get role holders", service.Fields{
+				"role_id": id,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		for _, u := range users {
+			userIDs = append(userIDs, u.ID)
+		}
+	}
+
+	if err := uc.permCache.BumpVersion(ctx, userIDs); err != nil {
+		uc.logger.Warn("Failed to bump permissions version", service.Fields{
+			"role_id": roleID,
+			"error":   err.Error(),
+		})
+	}
 }
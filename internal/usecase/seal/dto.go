@@ -0,0 +1,18 @@
+package seal
+
+type (
+	// Status reports whether the service is sealed and, for Shamir
+	// unsealing, how many of the required shares have been submitted
+	Status struct {
+		Sealed    bool
+		Threshold int
+		Progress  int
+	}
+
+	// UnsealInput carries either the unseal password or one Shamir share
+	// (base64 encoded)
+	UnsealInput struct {
+		Password string
+		Share    string
+	}
+)
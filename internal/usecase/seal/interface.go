@@ -0,0 +1,15 @@
+package seal
+
+import "context"
+
+type Usecase interface {
+	Status(ctx context.Context) *Status
+	Unseal(ctx context.Context, input *UnsealInput) (*Status, error)
+	Seal(ctx context.Context) error
+
+	// Acquire admits a request while the service is unsealed; admitted
+	// requests must call Release when done. Sealing waits for admitted
+	// requests to finish before the keys are wiped.
+	Acquire() bool
+	Release()
+}
@@ -0,0 +1,184 @@
+package seal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"sync"
+
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/keystore"
+)
+
+type sealUsecase struct {
+	keyFile *keystore.File
+	jwt     *config.JWT
+	logger  service.Logger
+
+	// gate is held for reading by admitted requests and for writing while
+	// the sealed state changes
+	gate   sync.RWMutex
+	sealed bool
+
+	// mu guards the submitted shares
+	mu     sync.Mutex
+	shares [][]byte
+}
+
+// NewSealUsecase creates the seal manager for the signing key in keyFile.
+// A nil keyFile means the key is not envelope-encrypted; the service then
+// can never be sealed.
+func NewSealUsecase(keyFile *keystore.File, jwt *config.JWT, sealed bool, logger service.Logger) Usecase {
+	return &sealUsecase{
+		keyFile: keyFile,
+		jwt:     jwt,
+		logger:  logger,
+		sealed:  sealed && keyFile != nil,
+	}
+}
+
+func (uc *sealUsecase) Acquire() bool {
+	uc.gate.RLock()
+	if uc.sealed {
+		uc.gate.RUnlock()
+		return false
+	}
+	return true
+}
+
+func (uc *sealUsecase) Release() {
+	uc.gate.RUnlock()
+}
+
+func (uc *sealUsecase) Status(ctx context.Context) *Status {
+	uc.gate.RLock()
+	sealed := uc.sealed
+	uc.gate.RUnlock()
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	return uc.status(sealed)
+}
+
+// Unseal decrypts the signing key with the password, or collects one
+// Shamir share and unseals once the threshold is reached. A failed
+// attempt discards the collected shares.
+func (uc *sealUsecase) Unseal(ctx context.Context, in *UnsealInput) (*Status, error) {
+	if uc.keyFile == nil {
+		return nil, errors.New("signing key is not encrypted")
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	uc.gate.RLock()
+	sealed := uc.sealed
+	uc.gate.RUnlock()
+	if !sealed {
+		return uc.status(false), nil
+	}
+
+	password := in.Password
+	if password == "" {
+		if in.Share == "" {
+			return nil, errors.New("password or share is required")
+		}
+		if uc.keyFile.Threshold == 0 {
+			return nil, errors.New("signing key is not split into shares")
+		}
+
+		share, err := base64.StdEncoding.DecodeString(in.Share)
+		if err != nil || len(share) < 2 {
+			return nil, errors.New("invalid share")
+		}
+		for _, s := range uc.shares {
+			if bytes.Equal(s, share) {
+				return nil, errors.New("share already submitted")
+			}
+		}
+		uc.shares = append(uc.shares, share)
+
+		if len(uc.shares) < uc.keyFile.Threshold {
+			uc.logger.Info("Unseal share accepted", service.Fields{
+				"progress":  len(uc.shares),
+				"threshold": uc.keyFile.Threshold,
+			})
+			return uc.status(true), nil
+		}
+
+		secret, err := keystore.Combine(uc.shares)
+		uc.resetShares()
+		if err != nil {
+			uc.logger.Warn("Unseal failed: invalid shares", service.Fields{})
+			return nil, errors.New("unseal failed")
+		}
+		password = string(secret)
+		for i := range secret {
+			secret[i] = 0
+		}
+	}
+
+	key, err := keystore.Unlock(uc.keyFile, password)
+	if err != nil {
+		uc.resetShares()
+		uc.logger.Warn("Unseal failed", service.Fields{
+			"error": err.Error(),
+		})
+		return nil, errors.New("unseal failed")
+	}
+
+	uc.gate.Lock()
+	uc.jwt.SetKeys(key.PrivateKey)
+	uc.jwt.SetMasterKey(key.MasterKey)
+	uc.sealed = false
+	uc.gate.Unlock()
+
+	uc.logger.Info("Service unsealed", service.Fields{})
+
+	return uc.status(false), nil
+}
+
+// Seal wipes the signing key from memory once in-flight requests finish.
+// Until unsealed again only health and unseal endpoints are served.
+func (uc *sealUsecase) Seal(ctx context.Context) error {
+	if uc.keyFile == nil {
+		return errors.New("signing key is not encrypted and could not be unsealed again")
+	}
+
+	uc.gate.Lock()
+	defer uc.gate.Unlock()
+
+	if uc.sealed {
+		return nil
+	}
+	uc.jwt.ClearKeys()
+	uc.sealed = true
+
+	uc.logger.Info("Service sealed", service.Fields{})
+
+	return nil
+}
+
+func (uc *sealUsecase) status(sealed bool) *Status {
+	st := &Status{Sealed: sealed}
+	if uc.keyFile != nil {
+		st.Threshold = uc.keyFile.Threshold
+	}
+	if sealed {
+		st.Progress = len(uc.shares)
+	}
+	return st
+}
+
+// resetShares discards and wipes the collected shares
+func (uc *sealUsecase) resetShares() {
+	for _, s := range uc.shares {
+		for i := range s {
+			s[i] = 0
+		}
+	}
+	uc.shares = nil
+}
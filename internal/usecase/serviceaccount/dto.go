@@ -0,0 +1,31 @@
+package serviceaccount
+
+import "time"
+
+type (
+	CreateInput struct {
+		ApplicationID string
+		Name          string
+		Description   string
+	}
+
+	ListInput struct {
+		ApplicationID string
+		Limit         int
+		Offset        int
+	}
+
+	// CreatedKey carries the plaintext key, which is only available at
+	// creation time
+	CreatedKey struct {
+		ID        string
+		Key       string
+		ExpiresAt *time.Time
+	}
+
+	// IssuedToken is an access token minted for a service account key
+	IssuedToken struct {
+		Token     string
+		ExpiresAt time.Time
+	}
+)
@@ -0,0 +1,22 @@
+package serviceaccount
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+)
+
+type Usecase interface {
+	Create(ctx context.Context, input *CreateInput) (*entity.ServiceAccount, error)
+	Get(ctx context.Context, id string) (*entity.ServiceAccount, error)
+	List(ctx context.Context, input *ListInput) ([]*entity.ServiceAccount, error)
+	SetActive(ctx context.Context, id string, active bool) error
+	Delete(ctx context.Context, id string) error
+	AssignRoles(ctx context.Context, id string, roleIDs []string) error
+	GetRoles(ctx context.Context, id string) ([]*entity.Role, error)
+	CreateKey(ctx context.Context, id string, expiresInDays int) (*CreatedKey, error)
+	ListKeys(ctx context.Context, id string) ([]*entity.ServiceAccountKey, error)
+	RevokeKey(ctx context.Context, id, keyID string) error
+	IssueToken(ctx context.Context, rawKey string, conf *config.Config) (*IssuedToken, error)
+}
@@ -0,0 +1,515 @@
+package serviceaccount
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/internal/infrastructure/config"
+	"github.com/mafzaidi/authorizer/pkg/idgen"
+)
+
+const (
+	tokenLifetime   = time.Hour
+	maxKeyLifetime  = 365 * 24 * time.Hour
+	lookupPrefixLen = 12
+)
+
+// JWTService signs access tokens for service accounts
+type JWTService interface {
+	GenerateToken(ctx context.Context, claims *entity.Claims, privateKey *rsa.PrivateKey, keyID string) (string, error)
+}
+
+type serviceAccountUsecase struct {
+	saRepo       repository.ServiceAccountRepository
+	saRoleRepo   repository.ServiceAccountRoleRepository
+	keyRepo      repository.ServiceAccountKeyRepository
+	appRepo      repository.AppRepository
+	roleRepo     repository.RoleRepository
+	rolePermRepo repository.RolePermRepository
+	transactor   repository.Transactor
+	sodSvc       service.SoDService
+	jwtService   JWTService
+	logger       service.Logger
+}
+
+func NewServiceAccountUsecase(
+	saRepo repository.ServiceAccountRepository,
+	saRoleRepo repository.ServiceAccountRoleRepository,
+	keyRepo repository.ServiceAccountKeyRepository,
+	appRepo repository.AppRepository,
+	roleRepo repository.RoleRepository,
+	rolePermRepo repository.RolePermRepository,
+	transactor repository.Transactor,
+	sodSvc service.SoDService,
+	jwtService JWTService,
+	logger service.Logger,
+) Usecase {
+	return &serviceAccountUsecase{
+		saRepo:       saRepo,
+		saRoleRepo:   saRoleRepo,
+		keyRepo:      keyRepo,
+		appRepo:      appRepo,
+		roleRepo:     roleRepo,
+		rolePermRepo: rolePermRepo,
+		transactor:   transactor,
+		sodSvc:       sodSvc,
+		jwtService:   jwtService,
+		logger:       logger,
+	}
+}
+
+func (uc *serviceAccountUsecase) Create(ctx context.Context, in *CreateInput) (*entity.ServiceAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.ApplicationID == "" || in.Name == "" {
+		uc.logger.Warn("Service account creation failed: application and name required", service.Fields{})
+		return nil, errors.New("application and name is required")
+	}
+
+	if _, err := uc.appRepo.GetByID(ctx, in.ApplicationID); err != nil {
+		return nil, errors.New("application not found")
+	}
+
+	sa := &entity.ServiceAccount{
+		ID:            idgen.NewUUIDv7(),
+		ApplicationID: in.ApplicationID,
+		Name:          in.Name,
+		Description:   in.Description,
+		IsActive:      true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	if err := uc.saRepo.Create(ctx, sa); err != nil {
+		uc.logger.Error("Failed to create service account", service.Fields{
+			"app_id": in.ApplicationID,
+			"name":   in.Name,
+			"error":  err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Service account created successfully", service.Fields{
+		"service_account_id": sa.ID,
+		"app_id":             sa.ApplicationID,
+	})
+
+	return sa, nil
+}
+
+func (uc *serviceAccountUsecase) Get(ctx context.Context, id string) (*entity.ServiceAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sa, err := uc.saRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	return sa, nil
+}
+
+func (uc *serviceAccountUsecase) List(ctx context.Context, in *ListInput) ([]*entity.ServiceAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	accounts, err := uc.saRepo.List(ctx, repository.ServiceAccountFilter{
+		ApplicationID: in.ApplicationID,
+		Limit:         in.Limit,
+		Offset:        in.Offset,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to list service accounts", service.Fields{
+			"app_id": in.ApplicationID,
+			"error":  err.Error(),
+		})
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+func (uc *serviceAccountUsecase) SetActive(ctx context.Context, id string, active bool) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sa, err := uc.saRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	sa.IsActive = active
+	if err := uc.saRepo.Update(ctx, sa); err != nil {
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	uc.logger.Info("Service account updated", service.Fields{
+		"service_account_id": id,
+		"is_active":          active,
+	})
+
+	return nil
+}
+
+func (uc *serviceAccountUsecase) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := uc.saRepo.Delete(ctx, id); err != nil {
+		uc.logger.Error("Failed to delete service account", service.Fields{
+			"service_account_id": id,
+			"error":              err.Error(),
+		})
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	uc.logger.Info("Service account deleted", service.Fields{
+		"service_account_id": id,
+	})
+
+	return nil
+}
+
+// AssignRoles replaces the roles of a service account. Only roles of the
+// owning application may be assigned; global and tenant roles are reserved
+// for users. The application's separation-of-duties constraints apply as
+// they do to users.
+func (uc *serviceAccountUsecase) AssignRoles(ctx context.Context, id string, roleIDs []string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sa, err := uc.saRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	for _, roleID := range roleIDs {
+		role, err := uc.roleRepo.GetByID(ctx, roleID)
+		if err != nil {
+			return fmt.Errorf("role %s not found", roleID)
+		}
+		if role.ApplicationID == nil || *role.ApplicationID != sa.ApplicationID {
+			uc.logger.Warn("Assign service account roles failed: role outside owning application", service.Fields{
+				"service_account_id": id,
+				"role_id":            roleID,
+			})
+			return fmt.Errorf("role %s does not belong to the service account's application", role.Code)
+		}
+		if role.IsTenant() {
+			return fmt.Errorf("role %s is a tenant role and cannot be assigned to a service account", role.Code)
+		}
+	}
+
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.sodSvc.CheckUsers(ctx, []string{sa.ID}, &service.RoleChange{
+			AppID:   sa.ApplicationID,
+			Replace: &entity.RoleSource{Kind: entity.RoleSourceDirect},
+			RoleIDs: roleIDs,
+		}); err != nil {
+			return err
+		}
+
+		err := uc.saRoleRepo.Replace(ctx, id, roleIDs)
+		if err != nil {
+			uc.logger.Error("Failed to assign service account roles", service.Fields{
+				"service_account_id": id,
+				"error":              err.Error(),
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.logger.Info("Service account roles assigned", service.Fields{
+		"service_account_id": id,
+		"roles":              roleIDs,
+	})
+
+	return nil
+}
+
+func (uc *serviceAccountUsecase) GetRoles(ctx context.Context, id string) ([]*entity.Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := uc.saRepo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	return uc.saRoleRepo.GetRolesByServiceAccount(ctx, id)
+}
+
+func (uc *serviceAccountUsecase) CreateKey(ctx context.Context, id string, expiresInDays int) (*CreatedKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sa, err := uc.saRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+
+	var expiresAt *time.Time
+	if expiresInDays != 0 {
+		lifetime := time.Duration(expiresInDays) * 24 * time.Hour
+		if lifetime < 0 || lifetime > maxKeyLifetime {
+			return nil, fmt.Errorf("expiry must be between 1 and %d days", int(maxKeyLifetime.Hours()/24))
+		}
+		t := time.Now().Add(lifetime)
+		expiresAt = &t
+	}
+
+	prefix, secret, err := generateKey()
+	if err != nil {
+		return nil, errors.New("failed to generate key")
+	}
+	raw := entity.ServiceAccountKeyPrefix + prefix + "_" + secret
+
+	key := &entity.ServiceAccountKey{
+		ID:               idgen.NewUUIDv7(),
+		ServiceAccountID: sa.ID,
+		Prefix:           prefix,
+		KeyHash:          hashKey(raw),
+		ExpiresAt:        expiresAt,
+		CreatedAt:        time.Now(),
+	}
+
+	if err := uc.keyRepo.Create(ctx, key); err != nil {
+		uc.logger.Error("Failed to create service account key", service.Fields{
+			"service_account_id": sa.ID,
+			"error":              err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Service account key created", service.Fields{
+		"service_account_id": sa.ID,
+		"key_id":             key.ID,
+	})
+
+	return &CreatedKey{
+		ID:        key.ID,
+		Key:       raw,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (uc *serviceAccountUsecase) ListKeys(ctx context.Context, id string) ([]*entity.ServiceAccountKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	keys, err := uc.keyRepo.ListByServiceAccount(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (uc *serviceAccountUsecase) RevokeKey(ctx context.Context, id, keyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := uc.keyRepo.Revoke(ctx, id, keyID); err != nil {
+		return fmt.Errorf("failed: %w", err)
+	}
+
+	uc.logger.Info("Service account key revoked", service.Fields{
+		"service_account_id": id,
+		"key_id":             keyID,
+	})
+
+	return nil
+}
+
+// IssueToken exchanges a service account key for a short-lived access
+// token. The token carries principal_type "service_account" and the
+// account's roles and permissions in its owning application.
+func (uc *serviceAccountUsecase) IssueToken(ctx context.Context, rawKey string, cfg *config.Config) (*IssuedToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	prefix, ok := parsePrefix(rawKey)
+	if !ok {
+		return nil, errors.New("invalid key")
+	}
+
+	key, err := uc.keyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, errors.New("invalid key")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashKey(rawKey))) != 1 {
+		return nil, errors.New("invalid key")
+	}
+
+	if key.RevokedAt != nil {
+		return nil, errors.New("key has been revoked")
+	}
+
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, errors.New("key has expired")
+	}
+
+	sa, err := uc.saRepo.GetByID(ctx, key.ServiceAccountID)
+	if err != nil || !sa.IsActive {
+		uc.logger.Warn("Service token denied: account not active", service.Fields{
+			"service_account_id": key.ServiceAccountID,
+		})
+		return nil, errors.New("service account is not active")
+	}
+
+	claims, err := uc.buildClaims(ctx, sa)
+	if err != nil {
+		uc.logger.Error("Failed to build service account claims", service.Fields{
+			"service_account_id": sa.ID,
+			"error":              err.Error(),
+		})
+		return nil, errors.New("failed to build authorization claims")
+	}
+
+	privateKey, keyID := cfg.JWT.SigningKey()
+	token, err := uc.jwtService.GenerateToken(ctx, claims, privateKey, keyID)
+	if err != nil {
+		uc.logger.Error("Failed to generate service account token", service.Fields{
+			"service_account_id": sa.ID,
+			"error":              err.Error(),
+		})
+		return nil, errors.New("failed to generate access token")
+	}
+
+	if err := uc.keyRepo.TouchLastUsed(ctx, key.ID); err != nil {
+		uc.logger.Warn("Failed to update service account key last used", service.Fields{
+			"key_id": key.ID,
+			"error":  err.Error(),
+		})
+	}
+
+	uc.logger.Info("Service account token issued", service.Fields{
+		"service_account_id": sa.ID,
+		"key_id":             key.ID,
+	})
+
+	return &IssuedToken{
+		Token:     token,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+// buildClaims constructs claims for a service account from its roles in
+// the owning application
+func (uc *serviceAccountUsecase) buildClaims(ctx context.Context, sa *entity.ServiceAccount) (*entity.Claims, error) {
+	app, err := uc.appRepo.GetByID(ctx, sa.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := uc.saRoleRepo.GetRolesByServiceAccount(ctx, sa.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleSet := make(map[string]struct{})
+	permSet := make(map[string]struct{})
+	condSet := make(map[string]map[string]struct{})
+	for _, r := range roles {
+		roleSet[r.Code] = struct{}{}
+
+		perms, _ := uc.rolePermRepo.GetEffectivePermsByRole(ctx, r.ID)
+		for _, p := range perms {
+			if p.ConditionID == "" {
+				permSet[p.PermissionCode] = struct{}{}
+				continue
+			}
+			if condSet[p.PermissionCode] == nil {
+				condSet[p.PermissionCode] = make(map[string]struct{})
+			}
+			condSet[p.PermissionCode][p.ConditionID] = struct{}{}
+		}
+	}
+
+	// Conditional permissions are listed apart, unless also granted
+	// unconditionally
+	var conditions map[string][]string
+	for code, refs := range condSet {
+		if _, ok := permSet[code]; ok {
+			continue
+		}
+		if conditions == nil {
+			conditions = make(map[string][]string)
+		}
+		conditions[code] = mapKeys(refs)
+	}
+
+	now := time.Now()
+	return &entity.Claims{
+		Issuer:        "authorizer",
+		Subject:       sa.ID,
+		Audience:      []string{app.Code},
+		IssuedAt:      now.Unix(),
+		ExpiresAt:     now.Add(tokenLifetime).Unix(),
+		Username:      sa.Name,
+		PrincipalType: entity.PrincipalTypeServiceAccount,
+		Authorization: []entity.Authorization{
+			{
+				App:         app.Code,
+				Roles:       mapKeys(roleSet),
+				Permissions: mapKeys(permSet),
+				Conditions:  conditions,
+			},
+		},
+	}, nil
+}
+
+// generateKey returns a random lookup prefix and secret
+func generateKey() (string, string, error) {
+	p := make([]byte, lookupPrefixLen/2)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", err
+	}
+	s := make([]byte, 32)
+	if _, err := rand.Read(s); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(p), base64.RawURLEncoding.EncodeToString(s), nil
+}
+
+func parsePrefix(raw string) (string, bool) {
+	if !strings.HasPrefix(raw, entity.ServiceAccountKeyPrefix) {
+		return "", false
+	}
+	rest := strings.TrimPrefix(raw, entity.ServiceAccountKeyPrefix)
+	if len(rest) <= lookupPrefixLen || rest[lookupPrefixLen] != '_' {
+		return "", false
+	}
+	return rest[:lookupPrefixLen], true
+}
+
+// hashKey hashes a key for storage. Keys carry 256 bits of entropy, so a
+// fast hash is sufficient.
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func mapKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
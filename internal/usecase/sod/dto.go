@@ -0,0 +1,13 @@
+package sod
+
+type (
+	// CreateInput is a constraint allowing a user at most MaxRoles of
+	// Roles, role codes of the application
+	CreateInput struct {
+		Code        string
+		Name        string
+		Description *string
+		MaxRoles    int
+		Roles       []string
+	}
+)
@@ -0,0 +1,14 @@
+package sod
+
+import (
+	"context"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+)
+
+type Usecase interface {
+	Create(ctx context.Context, appID string, input *CreateInput) (*entity.SoDConstraint, error)
+	Delete(ctx context.Context, appID, id string) error
+	List(ctx context.Context, appID string) ([]*entity.SoDConstraint, error)
+	GetViolations(ctx context.Context, appID string) ([]*entity.SoDViolation, error)
+}
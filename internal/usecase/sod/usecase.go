@@ -0,0 +1,162 @@
+package sod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mafzaidi/authorizer/internal/domain/entity"
+	"github.com/mafzaidi/authorizer/internal/domain/repository"
+	"github.com/mafzaidi/authorizer/internal/domain/service"
+	"github.com/mafzaidi/authorizer/pkg/idgen"
+)
+
+type sodUsecase struct {
+	sodRepo  repository.SoDConstraintRepository
+	appRepo  repository.AppRepository
+	roleRepo repository.RoleRepository
+	logger   service.Logger
+}
+
+func NewSoDUsecase(
+	sodRepo repository.SoDConstraintRepository,
+	appRepo repository.AppRepository,
+	roleRepo repository.RoleRepository,
+	logger service.Logger,
+) Usecase {
+	return &sodUsecase{
+		sodRepo:  sodRepo,
+		appRepo:  appRepo,
+		roleRepo: roleRepo,
+		logger:   logger,
+	}
+}
+
+// Create adds a constraint to the application. It applies to assignments
+// made from now on; users already holding too many of its roles are listed
+// by GetViolations.
+func (uc *sodUsecase) Create(ctx context.Context, appID string, in *CreateInput) (*entity.SoDConstraint, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if in.Code == "" || in.Name == "" {
+		return nil, errors.New("code and name is required")
+	}
+	if in.MaxRoles < 1 {
+		return nil, errors.New("max_roles must be at least 1")
+	}
+
+	app, err := uc.appRepo.GetByID(ctx, appID)
+	if err != nil {
+		return nil, errors.New("application not found")
+	}
+
+	if existing, _ := uc.sodRepo.GetByAppAndCode(ctx, app.ID, in.Code); existing != nil {
+		return nil, errors.New("constraint already exists")
+	}
+
+	seen := make(map[string]bool, len(in.Roles))
+	var roleIDs, roleCodes []string
+	for _, code := range in.Roles {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		role, err := uc.roleRepo.GetByAppAndCode(ctx, app.ID, code)
+		if err != nil {
+			return nil, fmt.Errorf("role %s not found", code)
+		}
+		roleIDs = append(roleIDs, role.ID)
+		roleCodes = append(roleCodes, role.Code)
+	}
+	if len(roleIDs) <= in.MaxRoles {
+		return nil, errors.New("roles must list more roles than max_roles")
+	}
+
+	constraint := &entity.SoDConstraint{
+		ID:            idgen.NewUUIDv7(),
+		ApplicationID: app.ID,
+		Code:          in.Code,
+		Name:          in.Name,
+		Description:   in.Description,
+		MaxRoles:      in.MaxRoles,
+		RoleIDs:       roleIDs,
+		RoleCodes:     roleCodes,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := uc.sodRepo.Create(ctx, constraint); err != nil {
+		uc.logger.Error("Failed to create separation of duties constraint", service.Fields{
+			"app_id": app.ID,
+			"code":   in.Code,
+			"error":  err.Error(),
+		})
+		return nil, err
+	}
+
+	uc.logger.Info("Separation of duties constraint created", service.Fields{
+		"constraint_id": constraint.ID,
+		"app_id":        app.ID,
+		"code":          constraint.Code,
+		"roles":         roleCodes,
+		"max_roles":     constraint.MaxRoles,
+	})
+
+	return constraint, nil
+}
+
+func (uc *sodUsecase) Delete(ctx context.Context, appID, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	constraint, err := uc.sodRepo.GetByID(ctx, id)
+	if err != nil || constraint.ApplicationID != appID {
+		return errors.New("constraint not found")
+	}
+
+	if err := uc.sodRepo.Delete(ctx, constraint.ID); err != nil {
+		uc.logger.Error("Failed to delete separation of duties constraint", service.Fields{
+			"constraint_id": constraint.ID,
+			"error":         err.Error(),
+		})
+		return err
+	}
+
+	uc.logger.Info("Separation of duties constraint deleted", service.Fields{
+		"constraint_id": constraint.ID,
+		"app_id":        appID,
+		"code":          constraint.Code,
+	})
+
+	return nil
+}
+
+func (uc *sodUsecase) List(ctx context.Context, appID string) ([]*entity.SoDConstraint, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := uc.appRepo.GetByID(ctx, appID); err != nil {
+		return nil, errors.New("application not found")
+	}
+
+	constraints, err := uc.sodRepo.ListByApp(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch constraints: %w", err)
+	}
+	return constraints, nil
+}
+
+// GetViolations lists the users holding more roles of a constraint than it
+// allows, in one application or, with an empty appID, in all of them
+func (uc *sodUsecase) GetViolations(ctx context.Context, appID string) ([]*entity.SoDViolation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	violations, err := uc.sodRepo.GetViolations(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch violations: %w", err)
+	}
+	return violations, nil
+}
@@ -1,5 +1,7 @@
 package user
 
+import "time"
+
 type (
 	RegisterInput struct {
 		Username string
@@ -13,4 +15,12 @@ type (
 		FullName string
 		Phone    string
 	}
+
+	// AssignRolesInput lists the roles to assign. When set, the roles are
+	// in effect from ValidFrom until ValidUntil only.
+	AssignRolesInput struct {
+		Roles      []string
+		ValidFrom  *time.Time
+		ValidUntil *time.Time
+	}
 )
@@ -11,5 +11,5 @@ type Usecase interface {
 	GetDetail(ctx context.Context, userID string) (*entity.User, error)
 	UpdateData(ctx context.Context, userID string, input *UpdateInput) error
 	GetList(ctx context.Context, limit, offset int) ([]*entity.User, error)
-	AssignRoles(ctx context.Context, userID, appID string, roles []string) error
+	AssignRoles(ctx context.Context, userID, appID string, input *AssignRolesInput) error
 }
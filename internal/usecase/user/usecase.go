@@ -17,6 +17,9 @@ type userUsecase struct {
 	repo         repository.UserRepository
 	roleRepo     repository.RoleRepository
 	userRoleRepo repository.UserRoleRepository
+	permCache    repository.PermissionCacheRepository
+	transactor   repository.Transactor
+	sodSvc       service.SoDService
 	logger       service.Logger
 }
 
@@ -24,12 +27,18 @@ func NewUserUsecase(
 	repo repository.UserRepository,
 	roleRepo repository.RoleRepository,
 	userRoleRepo repository.UserRoleRepository,
+	permCache repository.PermissionCacheRepository,
+	transactor repository.Transactor,
+	sodSvc service.SoDService,
 	logger service.Logger,
 ) Usecase {
 	return &userUsecase{
 		repo:         repo,
 		roleRepo:     roleRepo,
 		userRoleRepo: userRoleRepo,
+		permCache:    permCache,
+		transactor:   transactor,
+		sodSvc:       sodSvc,
 		logger:       logger,
 	}
 }
@@ -180,7 +189,7 @@ func (uc *userUsecase) GetList(ctx context.Context, limit, offset int) ([]*entit
 	return users, nil
 }
 
-func (uc *userUsecase) AssignRoles(ctx context.Context, userID, appID string, roles []string) error {
+func (uc *userUsecase) AssignRoles(ctx context.Context, userID, appID string, in *AssignRolesInput) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -196,13 +205,23 @@ func (uc *userUsecase) AssignRoles(ctx context.Context, userID, appID string, ro
 		return errors.New("appID is required")
 	}
 
-	if len(roles) == 0 {
+	if len(in.Roles) == 0 {
 		uc.logger.Warn("AssignRoles failed: roles is required", service.Fields{
 			"user_id": userID,
 			"app_id":  appID,
 		})
 		return errors.New("roles is required")
 	}
+	roles := in.Roles
+
+	if in.ValidUntil != nil {
+		if !in.ValidUntil.After(time.Now()) {
+			return errors.New("valid_until must be in the future")
+		}
+		if in.ValidFrom != nil && !in.ValidUntil.After(*in.ValidFrom) {
+			return errors.New("valid_until must be after valid_from")
+		}
+	}
 
 	user, err := uc.repo.GetByID(ctx, userID)
 	if err != nil {
@@ -214,6 +233,7 @@ func (uc *userUsecase) AssignRoles(ctx context.Context, userID, appID string, ro
 	}
 
 	var roleIDs []string
+	var assignments []*entity.UserRole
 	for _, v := range roles {
 		role, err := uc.roleRepo.GetByAppAndCode(ctx, appID, v)
 		if err != nil {
@@ -225,24 +245,58 @@ func (uc *userUsecase) AssignRoles(ctx context.Context, userID, appID string, ro
 			})
 			return fmt.Errorf("failed: %w", err)
 		}
+		if role.IsTenant() {
+			return fmt.Errorf("role %s is a tenant role and can only be assigned within an organization", role.Code)
+		}
 		roleIDs = append(roleIDs, role.ID)
+		assignments = append(assignments, &entity.UserRole{
+			UserID:     user.ID,
+			RoleID:     role.ID,
+			ValidFrom:  in.ValidFrom,
+			ValidUntil: in.ValidUntil,
+		})
 	}
 
-	err = uc.userRoleRepo.Replace(ctx, user.ID, roleIDs)
+	// The user's direct roles in this application are replaced; roles in
+	// other applications and global roles are kept
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.sodSvc.CheckUsers(ctx, []string{user.ID}, &service.RoleChange{
+			AppID:   appID,
+			Replace: &entity.RoleSource{Kind: entity.RoleSourceDirect},
+			RoleIDs: roleIDs,
+		}); err != nil {
+			return err
+		}
+
+		err := uc.userRoleRepo.Replace(ctx, user.ID, appID, assignments)
+		if err != nil {
+			uc.logger.Error("Failed to assign roles", service.Fields{
+				"user_id": userID,
+				"app_id":  appID,
+				"roles":   roles,
+				"error":   err.Error(),
+			})
+		}
+		return err
+	})
 	if err != nil {
-		uc.logger.Error("Failed to assign roles", service.Fields{
+		return err
+	}
+
+	// Invalidate cached permissions so thin-token consumers see the change
+	if err := uc.permCache.BumpVersion(ctx, []string{user.ID}); err != nil {
+		uc.logger.Warn("Failed to bump permissions version", service.Fields{
 			"user_id": userID,
-			"app_id":  appID,
-			"roles":   roles,
 			"error":   err.Error(),
 		})
-		return err
 	}
 
 	uc.logger.Info("Roles assigned successfully", service.Fields{
-		"user_id": userID,
-		"app_id":  appID,
-		"roles":   roles,
+		"user_id":     userID,
+		"app_id":      appID,
+		"roles":       roles,
+		"valid_from":  in.ValidFrom,
+		"valid_until": in.ValidUntil,
 	})
 
 	return nil
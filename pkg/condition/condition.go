@@ -0,0 +1,713 @@
+// Package condition implements the expression language of conditional
+// permission grants. An expression is evaluated against request attributes
+// and must yield a boolean:
+//
+//	time.hour >= 9 && time.hour < 17 && time.weekday in [1, 2, 3, 4, 5]
+//	in_cidr(request.ip, "10.0.0.0/8") || in_cidr(request.ip, "192.168.0.0/16")
+//	resource.owner == subject.id
+//
+// Operands are string ('...' or "..."), number and boolean literals, lists
+// ([a, b]), dotted attribute paths and function calls. Operators are
+// ||, &&, !, ==, !=, <, <=, >, >= and in, with parentheses for grouping.
+// Functions are in_cidr(ip, cidr), starts_with(s, prefix) and
+// ends_with(s, suffix).
+//
+// Evaluation fails, rather than yielding false, when an attribute is
+// missing or operand types do not match; callers treat a failure as a
+// deny. The check API and consumers evaluating tokens locally use this
+// package, so a condition means the same everywhere.
+package condition
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// MaxLength bounds the length of an expression
+const MaxLength = 1024
+
+// refPrefix marks condition references carried in tokens
+const refPrefix = "cond_"
+
+// Expr is a compiled expression
+type Expr struct {
+	source string
+	root   node
+}
+
+// Compile parses an expression
+func Compile(expr string) (*Expr, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("condition is empty")
+	}
+	if len(expr) > MaxLength {
+		return nil, fmt.Errorf("condition is longer than %d characters", MaxLength)
+	}
+
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.peek().text, p.peek().pos)
+	}
+	return &Expr{source: expr, root: root}, nil
+}
+
+// Validate reports whether expr is a well-formed expression
+func Validate(expr string) error {
+	_, err := Compile(expr)
+	return err
+}
+
+// Ref returns the stable reference of an expression. Equal expressions
+// share a reference, so consumers can cache expressions by reference.
+func Ref(expr string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(expr)))
+	return refPrefix + hex.EncodeToString(sum[:12])
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against vars. Nested maps are reached
+// with dotted paths; numbers may be any Go integer or float type.
+func (e *Expr) Eval(vars map[string]any) (bool, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition yields %s, not a boolean", typeName(v))
+	}
+	return b, nil
+}
+
+// Eval compiles and evaluates expr against vars
+func Eval(expr string, vars map[string]any) (bool, error) {
+	e, err := Compile(expr)
+	if err != nil {
+		return false, err
+	}
+	return e.Eval(vars)
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{tokLBracket, "[", i})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{tokRBracket, "]", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			str, n, err := lexString(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+			tokens = append(tokens, token{tokString, str, i})
+			i += n
+		case isDigit(c) || (c == '-' && i+1 < len(s) && isDigit(s[i+1])):
+			start := i
+			i++
+			for i < len(s) && (isDigit(s[i]) || s[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, s[start:i], start})
+		case isIdentStart(c):
+			start := i
+			for i < len(s) && (isIdentStart(s[i]) || isDigit(s[i]) || s[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, s[start:i], start})
+		default:
+			op := ""
+			for _, o := range []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!"} {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokEOF, "end of condition", len(s)}), nil
+}
+
+// lexString reads a quoted string, returning its value and the number of
+// bytes consumed
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, errors.New("unterminated string")
+			}
+			i++
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// Parser
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	t := p.next()
+	if t.kind != kind {
+		return fmt.Errorf("expected %s, got %q at offset %d", what, t.text, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().kind == tokOp && p.peek().text == "!" {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	op := ""
+	switch {
+	case t.kind == tokOp && t.text != "||" && t.text != "&&" && t.text != "!":
+		op = t.text
+	case t.kind == tokIdent && t.text == "in":
+		op = "in"
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRParen, ")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokString:
+		return &literalNode{value: t.text}, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return &literalNode{value: f}, nil
+	case tokLBracket:
+		return p.parseList()
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+		}
+		if p.peek().kind == tokLParen {
+			return p.parseCall(t)
+		}
+		if strings.HasSuffix(t.text, ".") || strings.Contains(t.text, "..") {
+			return nil, fmt.Errorf("invalid attribute %q at offset %d", t.text, t.pos)
+		}
+		return &attrNode{path: strings.Split(t.text, ".")}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+func (p *parser) parseList() (node, error) {
+	list := &listNode{}
+	if p.peek().kind == tokRBracket {
+		p.next()
+		return list, nil
+	}
+	for {
+		item, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		list.items = append(list.items, item)
+
+		t := p.next()
+		if t.kind == tokRBracket {
+			return list, nil
+		}
+		if t.kind != tokComma {
+			return nil, fmt.Errorf("expected , or ], got %q at offset %d", t.text, t.pos)
+		}
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at offset %d", name.text, name.pos)
+	}
+	p.next() // (
+
+	call := &callNode{name: name.text, fn: fn.call}
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect(tokRParen, ")"); err != nil {
+		return nil, err
+	}
+	if len(call.args) != fn.arity {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", name.text, fn.arity, len(call.args))
+	}
+	return call, nil
+}
+
+// Evaluation
+
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n *literalNode) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(vars map[string]any) (any, error) {
+	out := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+type attrNode struct {
+	path []string
+}
+
+func (n *attrNode) eval(vars map[string]any) (any, error) {
+	var cur any = vars
+	for _, seg := range n.path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("undefined attribute %s", strings.Join(n.path, "."))
+		}
+		cur, ok = m[seg]
+		if !ok {
+			return nil, fmt.Errorf("undefined attribute %s", strings.Join(n.path, "."))
+		}
+	}
+	return normalize(cur), nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(vars map[string]any) (any, error) {
+	v, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("! needs a boolean, got %s", typeName(v))
+	}
+	return !b, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicalNode) eval(vars map[string]any) (any, error) {
+	l, err := evalBool(n.left, vars, n.op)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit like the usual operators
+	if (n.op == "||" && l) || (n.op == "&&" && !l) {
+		return l, nil
+	}
+	return evalBool(n.right, vars, n.op)
+}
+
+func evalBool(n node, vars map[string]any, op string) (bool, error) {
+	v, err := n.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s needs booleans, got %s", op, typeName(v))
+	}
+	return b, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(vars map[string]any) (any, error) {
+	l, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		eq, err := equal(l, r)
+		if err != nil {
+			return nil, err
+		}
+		return eq == (n.op == "=="), nil
+	case "in":
+		list, ok := r.([]any)
+		if !ok {
+			return nil, fmt.Errorf("in needs a list, got %s", typeName(r))
+		}
+		for _, item := range list {
+			if eq, err := equal(l, normalize(item)); err == nil && eq {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	var c int
+	switch lv := l.(type) {
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare number with %s", typeName(r))
+		}
+		c = compareFloat(lv, rv)
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare string with %s", typeName(r))
+		}
+		c = strings.Compare(lv, rv)
+	default:
+		return nil, fmt.Errorf("%s needs numbers or strings, got %s", n.op, typeName(l))
+	}
+
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func equal(l, r any) (bool, error) {
+	switch lv := l.(type) {
+	case string:
+		if rv, ok := r.(string); ok {
+			return lv == rv, nil
+		}
+	case float64:
+		if rv, ok := r.(float64); ok {
+			return lv == rv, nil
+		}
+	case bool:
+		if rv, ok := r.(bool); ok {
+			return lv == rv, nil
+		}
+	}
+	return false, fmt.Errorf("cannot compare %s with %s", typeName(l), typeName(r))
+}
+
+type callNode struct {
+	name string
+	fn   func(args []any) (any, error)
+	args []node
+}
+
+func (n *callNode) eval(vars map[string]any) (any, error) {
+	args := make([]any, 0, len(n.args))
+	for _, a := range n.args {
+		v, err := a.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	v, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+type function struct {
+	arity int
+	call  func(args []any) (any, error)
+}
+
+var functions = map[string]function{
+	"in_cidr": {arity: 2, call: func(args []any) (any, error) {
+		ip, cidr, err := twoStrings(args)
+		if err != nil {
+			return nil, err
+		}
+		addr := net.ParseIP(ip)
+		if addr == nil {
+			return nil, fmt.Errorf("invalid IP address %q", ip)
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", cidr)
+		}
+		return network.Contains(addr), nil
+	}},
+	"starts_with": {arity: 2, call: func(args []any) (any, error) {
+		s, prefix, err := twoStrings(args)
+		if err != nil {
+			return nil, err
+		}
+		return strings.HasPrefix(s, prefix), nil
+	}},
+	"ends_with": {arity: 2, call: func(args []any) (any, error) {
+		s, suffix, err := twoStrings(args)
+		if err != nil {
+			return nil, err
+		}
+		return strings.HasSuffix(s, suffix), nil
+	}},
+}
+
+func twoStrings(args []any) (string, string, error) {
+	a, ok1 := args[0].(string)
+	b, ok2 := args[1].(string)
+	if !ok1 || !ok2 {
+		return "", "", fmt.Errorf("needs strings, got %s and %s", typeName(args[0]), typeName(args[1]))
+	}
+	return a, b, nil
+}
+
+// normalize converts Go numeric types to float64 and slices of any
+// element type to []any
+func normalize(v any) any {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case []string:
+		out := make([]any, len(n))
+		for i, s := range n {
+			out[i] = s
+		}
+		return out
+	}
+	return v
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,117 @@
+package condition
+
+import (
+	"strings"
+	"testing"
+)
+
+func testVars() map[string]any {
+	return map[string]any{
+		"subject": map[string]any{"id": "user-1"},
+		"time":    map[string]any{"hour": 10, "weekday": 3},
+		"request": map[string]any{"ip": "10.1.2.3"},
+		"resource": map[string]any{
+			"owner": "user-1",
+			"tags":  []string{"public", "draft"},
+			"size":  42.5,
+		},
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`resource.owner == subject.id`, true},
+		{`resource.owner != subject.id`, false},
+		{`time.hour >= 9 && time.hour < 17`, true},
+		{`time.hour >= 11 || time.weekday == 3`, true},
+		{`time.weekday in [1, 2, 3, 4, 5]`, true},
+		{`time.weekday in [0, 6]`, false},
+		{`"draft" in resource.tags`, true},
+		{`in_cidr(request.ip, "10.0.0.0/8")`, true},
+		{`in_cidr(request.ip, '192.168.0.0/16')`, false},
+		{`!in_cidr(request.ip, "192.168.0.0/16")`, true},
+		{`starts_with(subject.id, "user-") && ends_with(subject.id, "-1")`, true},
+		{`resource.size > 40 && resource.size <= 42.5`, true},
+		{`(time.hour < 9 || time.hour > 17) && true`, false},
+		{`"b" > "a"`, true},
+		{`time.hour == -1`, false},
+		// Short-circuit skips the missing attribute
+		{`true || resource.missing == 1`, true},
+		{`false && resource.missing == 1`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Eval(tt.expr, testVars())
+			if err != nil {
+				t.Fatalf("Eval(%q) failed: %v", tt.expr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`resource.missing == 1`, "undefined attribute resource.missing"},
+		{`resource.owner == 1`, "cannot compare string with number"},
+		{`time.hour`, "not a boolean"},
+		{`time.hour && true`, "needs booleans"},
+		{`in_cidr(request.ip, "nonsense")`, "invalid CIDR"},
+		{`time.hour in resource.owner`, "in needs a list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Eval(tt.expr, testVars())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Eval(%q) error = %v, want %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []string{
+		``,
+		`time.hour >=`,
+		`(time.hour > 1`,
+		`time.hour > 1)`,
+		`"unterminated`,
+		`unknown(time.hour)`,
+		`in_cidr(request.ip)`,
+		`[1, 2`,
+		`time. == 1`,
+		`time.hour # 1`,
+		strings.Repeat("a", MaxLength+1),
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if err := Validate(expr); err == nil {
+				t.Errorf("Validate(%q) succeeded, want error", expr)
+			}
+		})
+	}
+}
+
+func TestRef(t *testing.T) {
+	a := Ref("time.hour > 9")
+	if a != Ref("  time.hour > 9 ") {
+		t.Errorf("Ref should ignore surrounding whitespace")
+	}
+	if a == Ref("time.hour > 10") {
+		t.Errorf("Ref should differ for different expressions")
+	}
+	if !strings.HasPrefix(a, "cond_") || len(a) != len("cond_")+24 {
+		t.Errorf("Unexpected ref %q", a)
+	}
+}
@@ -0,0 +1,87 @@
+// Package permission implements permission code matching. Codes are dotted
+// segments such as "user.assign_roles". A grant may use "*" as a whole
+// segment: a trailing "*" matches one or more remaining segments ("user.*"
+// matches "user.read" and "user.profile.read"), any other "*" matches
+// exactly one segment ("*.read" matches "user.read" but not
+// "user.profile.read").
+//
+// The authorizer middleware, the check API and consumers verifying tokens
+// locally all use these functions, so a grant means the same everywhere.
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Wildcard is the segment that matches any segment
+const Wildcard = "*"
+
+// Match reports whether grant covers the required permission. The required
+// permission is compared literally, so a wildcard in it only matches a
+// wildcard in the same position of the grant.
+func Match(grant, required string) bool {
+	if grant == required {
+		return true
+	}
+	if !strings.Contains(grant, Wildcard) {
+		return false
+	}
+
+	g := strings.Split(grant, ".")
+	r := strings.Split(required, ".")
+	for i, seg := range g {
+		if i >= len(r) {
+			return false
+		}
+		if seg == Wildcard {
+			if i == len(g)-1 {
+				// Trailing wildcard covers the rest of the code
+				return true
+			}
+			continue
+		}
+		if seg != r[i] {
+			return false
+		}
+	}
+	return len(g) == len(r)
+}
+
+// Any reports whether any of the grants covers the required permission
+func Any(grants []string, required string) bool {
+	for _, g := range grants {
+		if Match(g, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWildcard reports whether the code contains a wildcard segment
+func IsWildcard(code string) bool {
+	for _, seg := range strings.Split(code, ".") {
+		if seg == Wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that code is well formed: non-empty segments, with "*"
+// only used as a whole segment
+func Validate(code string) error {
+	if code == "" {
+		return errors.New("permission code cannot be empty")
+	}
+	for _, seg := range strings.Split(code, ".") {
+		if seg == "" {
+			return fmt.Errorf("permission %q has an empty segment", code)
+		}
+		if seg != Wildcard && strings.Contains(seg, Wildcard) {
+			return fmt.Errorf("permission %q may only use %q as a whole segment", code, Wildcard)
+		}
+	}
+	return nil
+}
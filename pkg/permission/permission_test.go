@@ -0,0 +1,75 @@
+package permission
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		grant    string
+		required string
+		expected bool
+	}{
+		{"user.read", "user.read", true},
+		{"user.read", "user.write", false},
+		{"user.*", "user.read", true},
+		{"user.*", "user.profile.read", true},
+		{"user.*", "user", false},
+		{"user.*", "role.read", false},
+		{"*.read", "user.read", true},
+		{"*.read", "user.write", false},
+		{"*.read", "user.profile.read", false},
+		{"user.*.read", "user.profile.read", true},
+		{"user.*.read", "user.profile.write", false},
+		{"*", "user.read", true},
+		{"*", "seal", true},
+		{"user.read", "user.*", false},
+		{"user.*", "user.*", true},
+		{"read", "user.read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.grant+" "+tt.required, func(t *testing.T) {
+			if got := Match(tt.grant, tt.required); got != tt.expected {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.grant, tt.required, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAny(t *testing.T) {
+	grants := []string{"role.read", "user.*"}
+
+	if !Any(grants, "user.assign_roles") {
+		t.Error("Any() should match through the wildcard grant")
+	}
+	if Any(grants, "role.create") {
+		t.Error("Any() matched a permission no grant covers")
+	}
+	if Any(nil, "role.read") {
+		t.Error("Any() matched with no grants")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{"user.read", "user.*", "*.read", "*"}
+	for _, code := range valid {
+		if err := Validate(code); err != nil {
+			t.Errorf("Validate(%q) error = %v", code, err)
+		}
+	}
+
+	invalid := []string{"", "user.", ".read", "user..read", "user.re*", "us*.read"}
+	for _, code := range invalid {
+		if err := Validate(code); err == nil {
+			t.Errorf("Validate(%q) accepted an invalid code", code)
+		}
+	}
+}
+
+func TestIsWildcard(t *testing.T) {
+	if !IsWildcard("user.*") || !IsWildcard("*.read") {
+		t.Error("IsWildcard() missed a wildcard code")
+	}
+	if IsWildcard("user.read") {
+		t.Error("IsWildcard() reported a concrete code")
+	}
+}
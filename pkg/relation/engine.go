@@ -0,0 +1,342 @@
+package relation
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// MaxDepth bounds how many usersets a check or expand follows from the
+// object it starts at
+const MaxDepth = 25
+
+// MaxListNodes bounds the usersets visited by one list-objects query
+const MaxListNodes = 10000
+
+// ErrLimitExceeded is returned when a query reaches MaxDepth or
+// MaxListNodes
+var ErrLimitExceeded = errors.New("relation graph is too deep or too large")
+
+// Store reads the tuples of one application
+type Store interface {
+	// ObjectTuples returns the tuples of namespace:objectID#relation
+	ObjectTuples(ctx context.Context, namespace, objectID, relation string) ([]Tuple, error)
+	// SubjectTuples returns the tuples whose subject is exactly subject
+	SubjectTuples(ctx context.Context, subject Subject) ([]Tuple, error)
+}
+
+// Tree is the expansion of a userset: the subjects of its own tuples, and
+// the usersets it also includes
+type Tree struct {
+	Userset  string   `json:"userset"`
+	Subjects []string `json:"subjects,omitempty"`
+	Children []*Tree  `json:"children,omitempty"`
+}
+
+// Engine evaluates queries against a schema and a store
+type Engine struct {
+	schema *Schema
+	store  Store
+}
+
+func NewEngine(schema *Schema, store Store) *Engine {
+	return &Engine{schema: schema, store: store}
+}
+
+// Check reports whether subject has the relation to namespace:objectID,
+// directly, through a userset or through a computed relation
+func (e *Engine) Check(ctx context.Context, namespace, objectID, relation string, subject Subject) (bool, error) {
+	if !e.schema.HasRelation(namespace, relation) {
+		return false, errors.New("relation " + namespace + "#" + relation + " is not defined")
+	}
+	c := &checker{engine: e, subject: subject, visited: make(map[Subject]bool)}
+	return c.check(ctx, Subject{Namespace: namespace, ID: objectID, Relation: relation}, 0)
+}
+
+type checker struct {
+	engine  *Engine
+	subject Subject
+	// visited holds usersets already evaluated or being evaluated; a
+	// userset reached again through a cycle adds nothing
+	visited map[Subject]bool
+}
+
+func (c *checker) check(ctx context.Context, us Subject, depth int) (bool, error) {
+	if depth > MaxDepth {
+		return false, ErrLimitExceeded
+	}
+	if us == c.subject {
+		return true, nil
+	}
+	if result, ok := c.visited[us]; ok {
+		return result, nil
+	}
+	c.visited[us] = false
+
+	ok, err := c.evaluate(ctx, us, depth)
+	if err != nil {
+		return false, err
+	}
+	c.visited[us] = ok
+	return ok, nil
+}
+
+func (c *checker) evaluate(ctx context.Context, us Subject, depth int) (bool, error) {
+	rel := c.engine.schema.relation(us.Namespace, us.Relation)
+	if rel == nil {
+		return false, nil
+	}
+
+	tuples, err := c.engine.store.ObjectTuples(ctx, us.Namespace, us.ID, us.Relation)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tuples {
+		if t.Subject == c.subject {
+			return true, nil
+		}
+	}
+	for _, t := range tuples {
+		if !t.Subject.IsUserset() {
+			continue
+		}
+		if ok, err := c.check(ctx, t.Subject, depth+1); ok || err != nil {
+			return ok, err
+		}
+	}
+
+	for _, computed := range rel.Computed {
+		next := Subject{Namespace: us.Namespace, ID: us.ID, Relation: computed}
+		if ok, err := c.check(ctx, next, depth+1); ok || err != nil {
+			return ok, err
+		}
+	}
+
+	for _, f := range rel.From {
+		targets, err := c.engine.store.ObjectTuples(ctx, us.Namespace, us.ID, f.Tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, t := range targets {
+			if !c.engine.schema.HasRelation(t.Subject.Namespace, f.Relation) {
+				continue
+			}
+			next := Subject{Namespace: t.Subject.Namespace, ID: t.Subject.ID, Relation: f.Relation}
+			if ok, err := c.check(ctx, next, depth+1); ok || err != nil {
+				return ok, err
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Expand returns the tree of subjects and usersets that make up
+// namespace:objectID#relation
+func (e *Engine) Expand(ctx context.Context, namespace, objectID, relation string) (*Tree, error) {
+	if !e.schema.HasRelation(namespace, relation) {
+		return nil, errors.New("relation " + namespace + "#" + relation + " is not defined")
+	}
+	return e.expand(ctx, Subject{Namespace: namespace, ID: objectID, Relation: relation}, 0, make(map[Subject]bool))
+}
+
+func (e *Engine) expand(ctx context.Context, us Subject, depth int, path map[Subject]bool) (*Tree, error) {
+	tree := &Tree{Userset: us.String()}
+	// A userset already on the path is shown without repeating its
+	// expansion
+	if path[us] {
+		return tree, nil
+	}
+	if depth > MaxDepth {
+		return nil, ErrLimitExceeded
+	}
+	path[us] = true
+	defer delete(path, us)
+
+	rel := e.schema.relation(us.Namespace, us.Relation)
+	if rel == nil {
+		return tree, nil
+	}
+
+	tuples, err := e.store.ObjectTuples(ctx, us.Namespace, us.ID, us.Relation)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tuples {
+		if !t.Subject.IsUserset() {
+			tree.Subjects = append(tree.Subjects, t.Subject.String())
+			continue
+		}
+		child, err := e.expand(ctx, t.Subject, depth+1, path)
+		if err != nil {
+			return nil, err
+		}
+		tree.Children = append(tree.Children, child)
+	}
+
+	for _, computed := range rel.Computed {
+		child, err := e.expand(ctx, Subject{Namespace: us.Namespace, ID: us.ID, Relation: computed}, depth+1, path)
+		if err != nil {
+			return nil, err
+		}
+		tree.Children = append(tree.Children, child)
+	}
+
+	for _, f := range rel.From {
+		targets, err := e.store.ObjectTuples(ctx, us.Namespace, us.ID, f.Tupleset)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range targets {
+			if !e.schema.HasRelation(t.Subject.Namespace, f.Relation) {
+				continue
+			}
+			next := Subject{Namespace: t.Subject.Namespace, ID: t.Subject.ID, Relation: f.Relation}
+			child, err := e.expand(ctx, next, depth+1, path)
+			if err != nil {
+				return nil, err
+			}
+			tree.Children = append(tree.Children, child)
+		}
+	}
+
+	sort.Strings(tree.Subjects)
+	return tree, nil
+}
+
+// ListObjects returns the IDs of the objects in namespace the subject has
+// the relation to. It walks the graph backwards from the subject: the
+// tuples naming it, the usersets those make it a member of, the relations
+// computed from those, and so on.
+func (e *Engine) ListObjects(ctx context.Context, namespace, relation string, subject Subject) ([]string, error) {
+	if !e.schema.HasRelation(namespace, relation) {
+		return nil, errors.New("relation " + namespace + "#" + relation + " is not defined")
+	}
+
+	l := &lister{engine: e, reached: make(map[Subject]bool), objects: make(map[string]struct{})}
+	l.target = Subject{Namespace: namespace, Relation: relation}
+
+	if subject.IsUserset() {
+		if err := l.reach(subject); err != nil {
+			return nil, err
+		}
+	} else {
+		l.queue = append(l.queue, subject)
+	}
+
+	for len(l.queue) > 0 {
+		next := l.queue[0]
+		l.queue = l.queue[1:]
+		if err := l.follow(ctx, next); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]string, 0, len(l.objects))
+	for id := range l.objects {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+type lister struct {
+	engine  *Engine
+	target  Subject
+	reached map[Subject]bool
+	queue   []Subject
+	objects map[string]struct{}
+}
+
+// reach records that the subject holds the userset us and queues it to
+// find what membership of us leads to
+func (l *lister) reach(us Subject) error {
+	if l.reached[us] {
+		return nil
+	}
+	if len(l.reached) >= MaxListNodes {
+		return ErrLimitExceeded
+	}
+	l.reached[us] = true
+	l.queue = append(l.queue, us)
+
+	if us.Namespace == l.target.Namespace && us.Relation == l.target.Relation {
+		l.objects[us.ID] = struct{}{}
+	}
+
+	// Relations of the same object computed from this one
+	ns := l.engine.schema.Namespaces[us.Namespace]
+	if ns == nil {
+		return nil
+	}
+	for _, name := range sortedKeys(ns.Relations) {
+		rel := ns.Relations[name]
+		if rel == nil {
+			continue
+		}
+		for _, computed := range rel.Computed {
+			if computed == us.Relation {
+				if err := l.reach(Subject{Namespace: us.Namespace, ID: us.ID, Relation: name}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// follow finds the usersets that s, as a tuple subject, is a member of
+func (l *lister) follow(ctx context.Context, s Subject) error {
+	tuples, err := l.engine.store.SubjectTuples(ctx, s)
+	if err != nil {
+		return err
+	}
+	for _, t := range tuples {
+		if err := l.reach(Subject{Namespace: t.Namespace, ID: t.ObjectID, Relation: t.Relation}); err != nil {
+			return err
+		}
+	}
+
+	if !s.IsUserset() {
+		return nil
+	}
+
+	// Relations taken from this object by objects pointing at it through
+	// a tupleset, e.g. documents whose parent is this folder
+	var pointers []Tuple
+	loaded := false
+	schema := l.engine.schema
+	for _, nsName := range sortedKeys(schema.Namespaces) {
+		ns := schema.Namespaces[nsName]
+		if ns == nil {
+			continue
+		}
+		for _, name := range sortedKeys(ns.Relations) {
+			rel := ns.Relations[name]
+			if rel == nil {
+				continue
+			}
+			for _, f := range rel.From {
+				if f.Relation != s.Relation {
+					continue
+				}
+				if !loaded {
+					object := Subject{Namespace: s.Namespace, ID: s.ID}
+					if pointers, err = l.engine.store.SubjectTuples(ctx, object); err != nil {
+						return err
+					}
+					loaded = true
+				}
+				for _, t := range pointers {
+					if t.Namespace != nsName || t.Relation != f.Tupleset {
+						continue
+					}
+					if err := l.reach(Subject{Namespace: nsName, ID: t.ObjectID, Relation: name}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package relation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// memStore is an in-memory Store
+type memStore []Tuple
+
+func (m memStore) ObjectTuples(ctx context.Context, namespace, objectID, relation string) ([]Tuple, error) {
+	var out []Tuple
+	for _, t := range m {
+		if t.Namespace == namespace && t.ObjectID == objectID && t.Relation == relation {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (m memStore) SubjectTuples(ctx context.Context, subject Subject) ([]Tuple, error) {
+	var out []Tuple
+	for _, t := range m {
+		if t.Subject == subject {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+const testSchema = `{"namespaces": {
+	"user": {},
+	"group": {"relations": {"member": {}}},
+	"folder": {"relations": {"viewer": {}}},
+	"document": {"relations": {
+		"parent": {},
+		"owner":  {},
+		"editor": {"computed": ["owner"]},
+		"viewer": {"computed": ["editor"], "from": [{"tupleset": "parent", "relation": "viewer"}]}
+	}}
+}}`
+
+func newTestEngine(t *testing.T, tuples ...string) *Engine {
+	t.Helper()
+
+	var schema Schema
+	if err := json.Unmarshal([]byte(testSchema), &schema); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+	if err := schema.Validate(); err != nil {
+		t.Fatalf("Invalid schema: %v", err)
+	}
+
+	var store memStore
+	for _, s := range tuples {
+		tuple, err := ParseTuple(s)
+		if err != nil {
+			t.Fatalf("ParseTuple(%q) failed: %v", s, err)
+		}
+		if err := schema.ValidateTuple(tuple); err != nil {
+			t.Fatalf("ValidateTuple(%q) failed: %v", s, err)
+		}
+		store = append(store, tuple)
+	}
+	return NewEngine(&schema, store)
+}
+
+var testTuples = []string{
+	"document:1#owner@user:alice",
+	"document:1#editor@group:eng#member",
+	"group:eng#member@user:bob",
+	"group:eng#member@group:leads#member",
+	"group:leads#member@user:carol",
+	"document:2#parent@folder:a",
+	"folder:a#viewer@user:dave",
+	"document:3#viewer@user:bob",
+	// A membership cycle must not loop
+	"group:leads#member@group:eng#member",
+}
+
+func TestParseTuple(t *testing.T) {
+	tuple, err := ParseTuple("document:42#viewer@group:eng#member")
+	if err != nil {
+		t.Fatalf("ParseTuple failed: %v", err)
+	}
+	want := Tuple{
+		Namespace: "document",
+		ObjectID:  "42",
+		Relation:  "viewer",
+		Subject:   Subject{Namespace: "group", ID: "eng", Relation: "member"},
+	}
+	if tuple != want {
+		t.Errorf("ParseTuple = %+v, want %+v", tuple, want)
+	}
+	if tuple.String() != "document:42#viewer@group:eng#member" {
+		t.Errorf("String() = %q", tuple.String())
+	}
+
+	for _, bad := range []string{
+		"document:42#viewer",
+		"document#viewer@user:1",
+		"document:42@user:1",
+		"Document:42#viewer@user:1",
+		"document:42#viewer@user",
+		"document:#viewer@user:1",
+		"document:42#view-er@user:1",
+	} {
+		if _, err := ParseTuple(bad); err == nil {
+			t.Errorf("ParseTuple(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{`{"namespaces": {}}`, "no namespaces"},
+		{`{"namespaces": {"doc": {"relations": {"viewer": {"computed": ["editor"]}}}}}`, `computed relation "editor"`},
+		{`{"namespaces": {"doc": {"relations": {"viewer": {"from": [{"tupleset": "parent", "relation": "viewer"}]}}}}}`, `tupleset "parent"`},
+		{`{"namespaces": {"Doc": {}}}`, "lowercase"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			var s Schema
+			if err := json.Unmarshal([]byte(tt.schema), &s); err != nil {
+				t.Fatalf("Failed to decode schema: %v", err)
+			}
+			err := s.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema_ValidateTuple(t *testing.T) {
+	e := newTestEngine(t)
+	for _, bad := range []string{
+		"document:1#admin@user:alice",
+		"document:1#viewer@robot:r2",
+		"document:1#viewer@group:eng#owner",
+	} {
+		tuple, err := ParseTuple(bad)
+		if err != nil {
+			t.Fatalf("ParseTuple(%q) failed: %v", bad, err)
+		}
+		if err := e.schema.ValidateTuple(tuple); err == nil {
+			t.Errorf("ValidateTuple(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestEngine_Check(t *testing.T) {
+	e := newTestEngine(t, testTuples...)
+
+	tests := []struct {
+		object   string
+		relation string
+		subject  string
+		expected bool
+	}{
+		{"document:1", "owner", "user:alice", true},
+		{"document:1", "editor", "user:alice", true},
+		{"document:1", "viewer", "user:alice", true},
+		{"document:1", "editor", "user:bob", true},
+		{"document:1", "owner", "user:bob", false},
+		{"document:1", "viewer", "user:carol", true},
+		{"document:1", "viewer", "user:dave", false},
+		{"document:2", "viewer", "user:dave", true},
+		{"document:2", "editor", "user:dave", false},
+		{"document:3", "viewer", "user:bob", true},
+		{"document:3", "viewer", "user:alice", false},
+		{"document:1", "editor", "group:eng#member", true},
+		{"document:1", "editor", "group:leads#member", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.object+"#"+tt.relation+"@"+tt.subject, func(t *testing.T) {
+			ns, id, err := ParseObject(tt.object)
+			if err != nil {
+				t.Fatalf("ParseObject failed: %v", err)
+			}
+			subject, err := ParseSubject(tt.subject)
+			if err != nil {
+				t.Fatalf("ParseSubject failed: %v", err)
+			}
+			got, err := e.Check(context.Background(), ns, id, tt.relation, subject)
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Check = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := e.Check(context.Background(), "document", "1", "admin", Subject{Namespace: "user", ID: "alice"}); err == nil {
+		t.Errorf("Check of an undefined relation succeeded, want error")
+	}
+}
+
+func TestEngine_Expand(t *testing.T) {
+	e := newTestEngine(t, testTuples...)
+
+	tree, err := e.Expand(context.Background(), "document", "1", "editor")
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if tree.Userset != "document:1#editor" || len(tree.Subjects) != 0 || len(tree.Children) != 2 {
+		t.Fatalf("Unexpected tree: %+v", tree)
+	}
+
+	eng := tree.Children[0]
+	if eng.Userset != "group:eng#member" || !reflect.DeepEqual(eng.Subjects, []string{"user:bob"}) {
+		t.Errorf("Unexpected group expansion: %+v", eng)
+	}
+	// The membership cycle ends at the repeated userset
+	if len(eng.Children) != 1 || eng.Children[0].Userset != "group:leads#member" ||
+		len(eng.Children[0].Children) != 1 || len(eng.Children[0].Children[0].Children) != 0 {
+		t.Errorf("Unexpected nested expansion: %+v", eng.Children)
+	}
+
+	owner := tree.Children[1]
+	if owner.Userset != "document:1#owner" || !reflect.DeepEqual(owner.Subjects, []string{"user:alice"}) {
+		t.Errorf("Unexpected computed expansion: %+v", owner)
+	}
+}
+
+func TestEngine_ListObjects(t *testing.T) {
+	e := newTestEngine(t, testTuples...)
+
+	tests := []struct {
+		relation string
+		subject  string
+		expected []string
+	}{
+		{"viewer", "user:alice", []string{"1"}},
+		{"viewer", "user:bob", []string{"1", "3"}},
+		{"viewer", "user:carol", []string{"1"}},
+		{"viewer", "user:dave", []string{"2"}},
+		{"editor", "user:dave", []string{}},
+		{"editor", "group:leads#member", []string{"1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.relation+"@"+tt.subject, func(t *testing.T) {
+			subject, err := ParseSubject(tt.subject)
+			if err != nil {
+				t.Fatalf("ParseSubject failed: %v", err)
+			}
+			got, err := e.ListObjects(context.Background(), "document", tt.relation, subject)
+			if err != nil {
+				t.Fatalf("ListObjects failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ListObjects = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEngine_DepthLimit(t *testing.T) {
+	tuples := []string{"group:g0#member@user:alice"}
+	for i := 1; i <= MaxDepth+2; i++ {
+		tuples = append(tuples, "group:g"+strconv.Itoa(i)+"#member@group:g"+strconv.Itoa(i-1)+"#member")
+	}
+	e := newTestEngine(t, tuples...)
+
+	_, err := e.Check(context.Background(), "group", "g"+strconv.Itoa(MaxDepth+2), "member", Subject{Namespace: "user", ID: "alice"})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Check error = %v, want ErrLimitExceeded", err)
+	}
+}
@@ -0,0 +1,137 @@
+package relation
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Schema declares the namespaces of an application and their relations:
+//
+//	{"namespaces": {
+//	  "user": {},
+//	  "group": {"relations": {"member": {}}},
+//	  "folder": {"relations": {"viewer": {}}},
+//	  "document": {"relations": {
+//	    "parent": {},
+//	    "owner":  {},
+//	    "editor": {"computed": ["owner"]},
+//	    "viewer": {"computed": ["editor"], "from": [{"tupleset": "parent", "relation": "viewer"}]}
+//	  }}
+//	}}
+//
+// A relation always includes the subjects of its own tuples. Computed
+// adds everyone holding another relation of the same object; From adds
+// everyone holding Relation on the objects the Tupleset relation points
+// to, e.g. viewers of a document's parent folder.
+type Schema struct {
+	Namespaces map[string]*Namespace `json:"namespaces"`
+}
+
+// Namespace is an object type
+type Namespace struct {
+	Relations map[string]*Relation `json:"relations,omitempty"`
+}
+
+// Relation defines the usersets a relation is the union of
+type Relation struct {
+	Computed []string         `json:"computed,omitempty"`
+	From     []TupleToUserset `json:"from,omitempty"`
+}
+
+// TupleToUserset follows the Tupleset relation of an object to other
+// objects and takes their Relation
+type TupleToUserset struct {
+	Tupleset string `json:"tupleset"`
+	Relation string `json:"relation"`
+}
+
+// Validate checks names and that computed relations and tuplesets refer
+// to relations of the same namespace
+func (s *Schema) Validate() error {
+	if s == nil || len(s.Namespaces) == 0 {
+		return fmt.Errorf("schema declares no namespaces")
+	}
+
+	for _, nsName := range sortedKeys(s.Namespaces) {
+		if err := validName(nsName); err != nil {
+			return err
+		}
+		ns := s.Namespaces[nsName]
+		if ns == nil {
+			continue
+		}
+		for _, relName := range sortedKeys(ns.Relations) {
+			if err := validName(relName); err != nil {
+				return err
+			}
+			rel := ns.Relations[relName]
+			if rel == nil {
+				continue
+			}
+			for _, c := range rel.Computed {
+				if !s.HasRelation(nsName, c) {
+					return fmt.Errorf("%s#%s: computed relation %q is not defined", nsName, relName, c)
+				}
+			}
+			for _, f := range rel.From {
+				if !s.HasRelation(nsName, f.Tupleset) {
+					return fmt.Errorf("%s#%s: tupleset %q is not defined", nsName, relName, f.Tupleset)
+				}
+				if err := validName(f.Relation); err != nil {
+					return fmt.Errorf("%s#%s: %w", nsName, relName, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateTuple checks that the tuple's object relation and its subject
+// are declared by the schema
+func (s *Schema) ValidateTuple(t Tuple) error {
+	if !s.HasRelation(t.Namespace, t.Relation) {
+		return fmt.Errorf("%s: relation %s#%s is not defined", t, t.Namespace, t.Relation)
+	}
+	if !s.HasNamespace(t.Subject.Namespace) {
+		return fmt.Errorf("%s: namespace %s is not defined", t, t.Subject.Namespace)
+	}
+	if t.Subject.IsUserset() && !s.HasRelation(t.Subject.Namespace, t.Subject.Relation) {
+		return fmt.Errorf("%s: relation %s#%s is not defined", t, t.Subject.Namespace, t.Subject.Relation)
+	}
+	return nil
+}
+
+// HasNamespace reports whether the namespace is declared
+func (s *Schema) HasNamespace(namespace string) bool {
+	_, ok := s.Namespaces[namespace]
+	return ok
+}
+
+// HasRelation reports whether the namespace declares the relation
+func (s *Schema) HasRelation(namespace, relation string) bool {
+	return s.relation(namespace, relation) != nil
+}
+
+func (s *Schema) relation(namespace, relation string) *Relation {
+	ns := s.Namespaces[namespace]
+	if ns == nil {
+		return nil
+	}
+	rel, ok := ns.Relations[relation]
+	if !ok {
+		return nil
+	}
+	if rel == nil {
+		return &Relation{}
+	}
+	return rel
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
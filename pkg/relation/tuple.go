@@ -0,0 +1,128 @@
+// Package relation implements relationship-based access control in the
+// style of Zanzibar. Relation tuples of the form
+//
+//	document:42#editor@user:alice
+//	document:42#viewer@group:eng#member
+//	document:42#parent@folder:7
+//
+// state that a subject, or every member of a userset, has a relation to an
+// object. A Schema declares each namespace's relations and how they are
+// computed from others, and an Engine answers check, expand and
+// list-objects queries over tuples read from a Store.
+package relation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Subject is who a tuple grants the relation to: an object
+// (namespace:id), or with Relation set, the userset of everyone holding
+// that relation to the object (namespace:id#relation).
+type Subject struct {
+	Namespace string
+	ID        string
+	Relation  string
+}
+
+// IsUserset reports whether the subject is a userset rather than a single
+// object
+func (s Subject) IsUserset() bool {
+	return s.Relation != ""
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Namespace + ":" + s.ID
+	}
+	return s.Namespace + ":" + s.ID + "#" + s.Relation
+}
+
+// Tuple states that Subject has Relation to the object Namespace:ObjectID
+type Tuple struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+	Subject   Subject
+}
+
+func (t Tuple) String() string {
+	return t.Namespace + ":" + t.ObjectID + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// ParseTuple parses object#relation@subject
+func ParseTuple(s string) (Tuple, error) {
+	object, subject, ok := strings.Cut(s, "@")
+	if !ok {
+		return Tuple{}, fmt.Errorf("tuple %q must be object#relation@subject", s)
+	}
+	object, rel, ok := strings.Cut(object, "#")
+	if !ok {
+		return Tuple{}, fmt.Errorf("tuple %q must be object#relation@subject", s)
+	}
+
+	ns, id, err := ParseObject(object)
+	if err != nil {
+		return Tuple{}, err
+	}
+	if err := validName(rel); err != nil {
+		return Tuple{}, err
+	}
+	sub, err := ParseSubject(subject)
+	if err != nil {
+		return Tuple{}, err
+	}
+
+	return Tuple{Namespace: ns, ObjectID: id, Relation: rel, Subject: sub}, nil
+}
+
+// ParseSubject parses namespace:id or namespace:id#relation
+func ParseSubject(s string) (Subject, error) {
+	object, rel, hasRel := strings.Cut(s, "#")
+	ns, id, err := ParseObject(object)
+	if err != nil {
+		return Subject{}, err
+	}
+	if hasRel {
+		if err := validName(rel); err != nil {
+			return Subject{}, err
+		}
+	}
+	return Subject{Namespace: ns, ID: id, Relation: rel}, nil
+}
+
+// ParseObject parses namespace:id
+func ParseObject(s string) (namespace, id string, err error) {
+	namespace, id, ok := strings.Cut(s, ":")
+	if !ok || id == "" {
+		return "", "", fmt.Errorf("object %q must be namespace:id", s)
+	}
+	if err := validName(namespace); err != nil {
+		return "", "", err
+	}
+	if strings.ContainsAny(id, "#@ ") {
+		return "", "", fmt.Errorf("object ID %q contains a reserved character", id)
+	}
+	return namespace, id, nil
+}
+
+// validName checks namespace and relation names: lowercase letters,
+// digits and underscores, starting with a letter
+func validName(name string) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z':
+		case (c >= '0' && c <= '9') || c == '_':
+			if i == 0 {
+				return fmt.Errorf("name %q must start with a letter", name)
+			}
+		default:
+			return fmt.Errorf("name %q may only contain lowercase letters, digits and underscores", name)
+		}
+	}
+	return nil
+}